@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+	"warehouse-management-system/src/config"
+)
+
+const backupUsage = `usage: wms backup <command> [file]
+
+commands:
+  create         take an online backup into BACKUP_DIR
+  list           show backups in BACKUP_DIR
+  verify <file>  check backup integrity and schema version
+  integrity      check integrity and foreign keys of the database
+  restore <file> replace the database file with a backup (stop the server first)`
+
+// backupCommandTimeout ограничивает одну операцию подкоманды backup.
+const backupCommandTimeout = 30 * time.Minute
+
+// runBackupCommand выполняет подкоманду backup. Поддерживается только SQLite.
+// restore работает с файлом БД напрямую, поэтому БД открывается только для остальных команд.
+func runBackupCommand(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing backup command\n%s", backupUsage)
+	}
+	if cfg.DBDriver != config.DriverSQLite {
+		return config.ErrBackupUnsupported
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), backupCommandTimeout)
+	defer cancel()
+
+	switch args[0] {
+	case "verify", "restore":
+		if len(args) < 2 {
+			return fmt.Errorf("missing backup file\n%s", backupUsage)
+		}
+		if args[0] == "restore" {
+			info, err := config.RestoreSQLite(ctx, args[1], cfg.DBPath)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Restored %s (schema version %d) into %s\n", args[1], info.SchemaVersion, cfg.DBPath)
+			return nil
+		}
+		info, err := config.VerifyBackup(ctx, args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Backup %s is OK (schema version %d, %d bytes)\n", args[1], info.SchemaVersion, info.Size)
+		return nil
+	case "create", "list", "integrity":
+	default:
+		return fmt.Errorf("unknown backup command %q\n%s", args[0], backupUsage)
+	}
+
+	db, err := config.OpenDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	m, err := config.NewBackupManager(db, cfg.BackupDir, cfg.BackupRetention)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "create":
+		info, err := m.Create(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Backup %s created (schema version %d, %d bytes)\n", info.Name, info.SchemaVersion, info.Size)
+	case "list":
+		return printBackups(m)
+	case "integrity":
+		problems, err := m.IntegrityCheck(ctx)
+		if err != nil {
+			return err
+		}
+		if len(problems) == 0 {
+			fmt.Println("Database integrity: ok")
+			return nil
+		}
+		for _, p := range problems {
+			fmt.Println(p)
+		}
+		return fmt.Errorf("database integrity check found %d problem(s)", len(problems))
+	}
+	return nil
+}
+
+func printBackups(m *config.BackupManager) error {
+	backups, err := m.List()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSIZE\tCREATED AT")
+	for _, b := range backups {
+		fmt.Fprintf(w, "%s\t%d\t%s\n", b.Name, b.Size, b.CreatedAt.Format("2006-01-02 15:04:05"))
+	}
+	return w.Flush()
+}
@@ -0,0 +1,349 @@
+package config
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"warehouse-management-system/src/models"
+)
+
+// Резервные копии делаются только для SQLite: файл БД копируется целиком командой VACUUM INTO,
+// которая работает на живой базе и даёт согласованный снимок на момент начала копирования.
+// Копирование читает БД в отдельном подключении, поэтому в режиме WAL не задерживает запись.
+// Для PostgreSQL используются штатные pg_dump/pg_basebackup.
+
+var (
+	ErrBackupUnsupported  = errors.New("backups are supported only for the sqlite driver")
+	ErrBackupCorrupted    = errors.New("backup failed integrity check")
+	ErrBackupNotWMS       = errors.New("file is not a warehouse database backup")
+	ErrBackupSchemaTooNew = errors.New("backup schema is newer than this build supports")
+)
+
+const (
+	backupFilePrefix = "wms-"
+	backupFileSuffix = ".db"
+	// backupTimeLayout входит в имя файла, поэтому сортировка по имени совпадает с хронологической.
+	backupTimeLayout = "20060102T150405Z"
+)
+
+// BackupManager создаёт резервные копии SQLite в каталоге dir и хранит не больше retention
+// последних копий (retention <= 0 — без ограничения).
+type BackupManager struct {
+	db        *DB
+	dir       string
+	retention int
+}
+
+// NewBackupManager создаёт менеджер резервных копий. Для PostgreSQL возвращает ErrBackupUnsupported.
+func NewBackupManager(db *DB, dir string, retention int) (*BackupManager, error) {
+	if db.Dialect().Name() != DriverSQLite {
+		return nil, ErrBackupUnsupported
+	}
+	if dir == "" {
+		return nil, errors.New("backup directory is not set")
+	}
+	return &BackupManager{db: db, dir: dir, retention: retention}, nil
+}
+
+// Create снимает резервную копию, проверяет её целостность и удаляет копии сверх retention.
+// Копия пишется во временный файл и переименовывается только после проверки, поэтому
+// в каталоге не бывает недописанных копий.
+func (m *BackupManager) Create(ctx context.Context) (*models.BackupInfo, error) {
+	if err := os.MkdirAll(m.dir, 0o750); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	name := backupFilePrefix + now.Format(backupTimeLayout) + backupFileSuffix
+	final := filepath.Join(m.dir, name)
+	if _, err := os.Stat(final); err == nil {
+		return nil, fmt.Errorf("backup %s already exists", name)
+	}
+	partial := final + ".partial"
+	_ = os.Remove(partial)
+
+	// VACUUM INTO идёт через подключение обслуживания: на подключении записи копия большой БД
+	// задерживала бы все приёмки и заказы до конца копирования.
+	if _, err := m.db.Maintenance().ExecContext(ctx, `VACUUM INTO ?;`, partial); err != nil {
+		_ = os.Remove(partial)
+		return nil, fmt.Errorf("vacuum into: %w", err)
+	}
+
+	info, err := VerifyBackup(ctx, partial)
+	if err != nil {
+		_ = os.Remove(partial)
+		return nil, err
+	}
+	if err := os.Rename(partial, final); err != nil {
+		_ = os.Remove(partial)
+		return nil, err
+	}
+	info.Name = name
+	info.CreatedAt = now
+	log.Printf("Backup created: %s (%d bytes, schema %d)", final, info.Size, info.SchemaVersion)
+
+	if err := m.prune(); err != nil {
+		log.Printf("backup: retention cleanup failed: %v", err)
+	}
+	return info, nil
+}
+
+// List возвращает резервные копии из каталога, новые первыми.
+func (m *BackupManager) List() ([]models.BackupInfo, error) {
+	entries, err := os.ReadDir(m.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return []models.BackupInfo{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := []models.BackupInfo{}
+	for _, e := range entries {
+		createdAt, ok := parseBackupName(e.Name())
+		if e.IsDir() || !ok {
+			continue
+		}
+		fi, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, models.BackupInfo{Name: e.Name(), Size: fi.Size(), CreatedAt: createdAt})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name > result[j].Name })
+	return result, nil
+}
+
+// RunSchedule создаёт резервные копии каждые interval до отмены ctx. Ошибки только логируются:
+// неудачная копия не должна останавливать сервер.
+func (m *BackupManager) RunSchedule(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := m.Create(ctx); err != nil {
+				log.Printf("backup: scheduled backup failed: %v", err)
+			}
+		}
+	}
+}
+
+// IntegrityCheck проверяет целостность рабочей БД и внешние ключи.
+// Возвращает список найденных проблем; пустой список означает, что БД в порядке.
+func (m *BackupManager) IntegrityCheck(ctx context.Context) ([]string, error) {
+	problems, err := integrityProblems(ctx, m.db.Maintenance())
+	if err != nil {
+		return nil, err
+	}
+	fkProblems, err := foreignKeyProblems(ctx, m.db.Maintenance())
+	if err != nil {
+		return nil, err
+	}
+	return append(problems, fkProblems...), nil
+}
+
+// prune удаляет самые старые копии сверх retention.
+func (m *BackupManager) prune() error {
+	if m.retention <= 0 {
+		return nil
+	}
+	backups, err := m.List()
+	if err != nil {
+		return err
+	}
+	for i := m.retention; i < len(backups); i++ {
+		if err := os.Remove(filepath.Join(m.dir, backups[i].Name)); err != nil {
+			return err
+		}
+		log.Printf("Backup removed by retention policy: %s", backups[i].Name)
+	}
+	return nil
+}
+
+// VerifyBackup открывает файл копии только на чтение, проверяет целостность
+// и возвращает версию схемы, записанную в schema_migrations.
+func VerifyBackup(ctx context.Context, path string) (*models.BackupInfo, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite", "file:"+path+"?mode=ro")
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	// Нарушения внешних ключей копию не портят (они есть и в исходной БД), поэтому здесь
+	// проверяется только физическая целостность файла.
+	problems, err := integrityProblems(ctx, db)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBackupCorrupted, err)
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrBackupCorrupted, strings.Join(problems, "; "))
+	}
+
+	exists, err := tableExists(ctx, NewDB(db, sqliteDialect{}), "schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrBackupNotWMS
+	}
+	var version int
+	if err := db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations;`).Scan(&version); err != nil {
+		return nil, err
+	}
+
+	return &models.BackupInfo{
+		Name:          filepath.Base(path),
+		Size:          fi.Size(),
+		CreatedAt:     fi.ModTime().UTC(),
+		SchemaVersion: version,
+	}, nil
+}
+
+// RestoreSQLite заменяет файл БД dbPath копией backupPath. Сервер в это время должен быть остановлен.
+// Копия предварительно проверяется: целостность и версия схемы не новее, чем знает сборка
+// (более старая схема доводится миграциями при следующем запуске). Прежний файл БД
+// не удаляется, а сохраняется рядом с суффиксом .before-restore-<время>.
+func RestoreSQLite(ctx context.Context, backupPath, dbPath string) (*models.BackupInfo, error) {
+	info, err := VerifyBackup(ctx, backupPath)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations, err := LoadMigrations(migrationFiles, "migrations/"+DriverSQLite)
+	if err != nil {
+		return nil, err
+	}
+	latest := 0
+	if len(migrations) > 0 {
+		latest = migrations[len(migrations)-1].Version
+	}
+	if info.SchemaVersion > latest {
+		return nil, fmt.Errorf("%w: backup has version %d, build knows up to %d", ErrBackupSchemaTooNew, info.SchemaVersion, latest)
+	}
+
+	// Копируем рядом с целевым файлом, чтобы финальное переименование было атомарным.
+	restoring := dbPath + ".restoring"
+	if err := copyFileSync(backupPath, restoring); err != nil {
+		_ = os.Remove(restoring)
+		return nil, err
+	}
+
+	if _, err := os.Stat(dbPath); err == nil {
+		saved := dbPath + ".before-restore-" + time.Now().UTC().Format(backupTimeLayout)
+		if err := os.Rename(dbPath, saved); err != nil {
+			_ = os.Remove(restoring)
+			return nil, err
+		}
+		// Журналы WAL/rollback относятся к прежнему файлу и не должны примениться к восстановленному.
+		for _, suffix := range []string{"-wal", "-shm", "-journal"} {
+			if _, err := os.Stat(dbPath + suffix); err == nil {
+				if err := os.Rename(dbPath+suffix, saved+suffix); err != nil {
+					return nil, err
+				}
+			}
+		}
+		log.Printf("Previous database saved as %s", saved)
+	}
+
+	if err := os.Rename(restoring, dbPath); err != nil {
+		return nil, err
+	}
+	log.Printf("Database restored from %s (schema %d)", backupPath, info.SchemaVersion)
+	return info, nil
+}
+
+// integrityProblems выполняет PRAGMA integrity_check.
+func integrityProblems(ctx context.Context, db *sql.DB) ([]string, error) {
+	problems := []string{}
+
+	rows, err := db.QueryContext(ctx, `PRAGMA integrity_check;`)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var msg string
+		if err := rows.Scan(&msg); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if msg != "ok" {
+			problems = append(problems, msg)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return problems, nil
+}
+
+// foreignKeyProblems выполняет PRAGMA foreign_key_check.
+func foreignKeyProblems(ctx context.Context, db *sql.DB) ([]string, error) {
+	problems := []string{}
+
+	fkRows, err := db.QueryContext(ctx, `PRAGMA foreign_key_check;`)
+	if err != nil {
+		return nil, err
+	}
+	defer fkRows.Close()
+	for fkRows.Next() {
+		var (
+			table, parent string
+			rowID         sql.NullInt64
+			fkIndex       int
+		)
+		if err := fkRows.Scan(&table, &rowID, &parent, &fkIndex); err != nil {
+			return nil, err
+		}
+		problems = append(problems, fmt.Sprintf("foreign key violation: %s row %d references missing %s", table, rowID.Int64, parent))
+	}
+	return problems, fkRows.Err()
+}
+
+func parseBackupName(name string) (time.Time, bool) {
+	if !strings.HasPrefix(name, backupFilePrefix) || !strings.HasSuffix(name, backupFileSuffix) {
+		return time.Time{}, false
+	}
+	stamp := strings.TrimSuffix(strings.TrimPrefix(name, backupFilePrefix), backupFileSuffix)
+	t, err := time.Parse(backupTimeLayout, stamp)
+	return t, err == nil
+}
+
+func copyFileSync(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o640)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBackupDoesNotWaitForWriter(t *testing.T) {
+	db, err := OpenSQLite(filepath.Join(t.TempDir(), "warehouse.db"), SQLiteConfig{JournalMode: "WAL", Synchronous: "NORMAL", ReadConns: 2})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := Migrate(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	m, err := NewBackupManager(db, filepath.Join(t.TempDir(), "backups"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Открытая транзакция занимает единственное подключение записи: на нём копия ждала бы её конца.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, `INSERT INTO categories (id, name, created_at, updated_at, version) VALUES ('c-1', 'Open', ?, ?, 1);`,
+		time.Now().UTC(), time.Now().UTC()); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := m.Create(ctx)
+	if err != nil {
+		t.Fatalf("backup while the writer is busy: %v", err)
+	}
+	if info.SchemaVersion == 0 {
+		t.Fatalf("backup has no schema version: %+v", info)
+	}
+
+	problems, err := m.IntegrityCheck(ctx)
+	if err != nil || len(problems) > 0 {
+		t.Fatalf("IntegrityCheck while the writer is busy = %v, %v", problems, err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,213 @@
+package config
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+)
+
+// Поддерживаемые драйверы БД (DB_DRIVER).
+const (
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+)
+
+// Dialect скрывает различия SQL между драйверами. Репозитории пишут запросы
+// с плейсхолдерами ? и общим подмножеством SQL, а диалект приводит их к синтаксису драйвера.
+type Dialect interface {
+	// Name возвращает имя драйвера (DriverSQLite или DriverPostgres).
+	Name() string
+	// Rebind переписывает плейсхолдеры ? в синтаксис драйвера.
+	Rebind(query string) string
+	// Upsert возвращает окончание INSERT, которое при конфликте по conflictColumns обновляет
+	// updateColumns значениями из вставляемой строки; без updateColumns вставка пропускается.
+	Upsert(conflictColumns []string, updateColumns ...string) string
+	// Lower возвращает выражение, понижающее регистр expr с учётом Unicode
+	// (встроенный LOWER в SQLite понижает регистр только латиницы).
+	Lower(expr string) string
+	// LockTable возвращает оператор, который до конца транзакции не пускает в table других писателей,
+	// или пустую строку, если транзакции записи драйвера и так выполняются по одной.
+	LockTable(table string) string
+}
+
+// DialectFor возвращает диалект по имени драйвера.
+func DialectFor(driver string) (Dialect, bool) {
+	switch driver {
+	case DriverSQLite:
+		return sqliteDialect{}, true
+	case DriverPostgres:
+		return postgresDialect{}, true
+	}
+	return nil, false
+}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() string               { return DriverSQLite }
+func (sqliteDialect) Rebind(query string) string { return query }
+func (sqliteDialect) Upsert(conflictColumns []string, updateColumns ...string) string {
+	return onConflict(conflictColumns, updateColumns)
+}
+func (sqliteDialect) Lower(expr string) string { return sqliteLowerFunc + "(" + expr + ")" }
+
+// LockTable не нужен: транзакции записи идут через единственное подключение с _txlock=immediate.
+func (sqliteDialect) LockTable(string) string { return "" }
+
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string { return DriverPostgres }
+
+// Rebind заменяет ? на $1, $2, ... Вопросительные знаки внутри строковых литералов не трогаются.
+func (postgresDialect) Rebind(query string) string {
+	if !strings.Contains(query, "?") {
+		return query
+	}
+
+	var (
+		b       strings.Builder
+		n       int
+		inQuote bool
+	)
+	b.Grow(len(query) + 8)
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '\'':
+			inQuote = !inQuote
+			b.WriteByte(c)
+		case c == '?' && !inQuote:
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+func (postgresDialect) Upsert(conflictColumns []string, updateColumns ...string) string {
+	return onConflict(conflictColumns, updateColumns)
+}
+
+// Lower использует LOWER: в PostgreSQL он учитывает Unicode по правилам локали БД.
+func (postgresDialect) Lower(expr string) string { return "LOWER(" + expr + ")" }
+
+// LockTable берёт SHARE ROW EXCLUSIVE: режим конфликтует сам с собой и с записью в таблицу,
+// но не с чтением. Транзакции READ COMMITTED после блокировки видят всё, что зафиксировали предыдущие.
+func (postgresDialect) LockTable(table string) string {
+	return "LOCK TABLE " + table + " IN SHARE ROW EXCLUSIVE MODE"
+}
+
+// onConflict формирует ON CONFLICT-выражение: синтаксис SQLite (3.24+) и PostgreSQL здесь совпадает.
+func onConflict(conflictColumns, updateColumns []string) string {
+	clause := "ON CONFLICT (" + strings.Join(conflictColumns, ", ") + ")"
+	if len(updateColumns) == 0 {
+		return clause + " DO NOTHING"
+	}
+	sets := make([]string, len(updateColumns))
+	for i, col := range updateColumns {
+		sets[i] = col + " = excluded." + col
+	}
+	return clause + " DO UPDATE SET " + strings.Join(sets, ", ")
+}
+
+// DB — пул подключений к БД вместе с диалектом. Все запросы проходят через Dialect.Rebind,
+// поэтому репозитории работают одинаково с SQLite и PostgreSQL.
+// Запросы SELECT вне транзакций могут идти в отдельный пул чтения (см. NewSplitDB),
+// всё остальное — в основной пул.
+type DB struct {
+	sql         *sql.DB
+	read        *sql.DB
+	maintenance *sql.DB
+	dialect     Dialect
+}
+
+// NewDB оборачивает открытый пул подключений.
+func NewDB(db *sql.DB, dialect Dialect) *DB {
+	return &DB{sql: db, read: db, maintenance: db, dialect: dialect}
+}
+
+// NewSplitDB оборачивает раздельные пулы: write — для изменений и транзакций, read — для SELECT.
+func NewSplitDB(write, read *sql.DB, dialect Dialect) *DB {
+	return &DB{sql: write, read: read, maintenance: write, dialect: dialect}
+}
+
+// Dialect возвращает диалект подключения.
+func (db *DB) Dialect() Dialect {
+	return db.dialect
+}
+
+// SQL возвращает основной пул (пул записи) для операций, специфичных для драйвера
+// (плейсхолдеры не переписываются).
+func (db *DB) SQL() *sql.DB {
+	return db.sql
+}
+
+// Maintenance возвращает пул для долгих служебных операций (резервное копирование, проверка целостности).
+// В SQLite это отдельное подключение, чтобы такие операции не занимали единственное подключение записи;
+// для остальных драйверов — основной пул.
+func (db *DB) Maintenance() *sql.DB {
+	return db.maintenance
+}
+
+// Close закрывает пулы подключений.
+func (db *DB) Close() error {
+	if db.read != db.sql {
+		_ = db.read.Close()
+	}
+	if db.maintenance != db.sql {
+		_ = db.maintenance.Close()
+	}
+	return db.sql.Close()
+}
+
+func (db *DB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return db.sql.ExecContext(ctx, db.dialect.Rebind(query), args...)
+}
+
+func (db *DB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return db.poolFor(query).QueryContext(ctx, db.dialect.Rebind(query), args...)
+}
+
+func (db *DB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return db.poolFor(query).QueryRowContext(ctx, db.dialect.Rebind(query), args...)
+}
+
+// poolFor выбирает пул для запроса: в пул чтения уходят только запросы, начинающиеся с SELECT.
+// INSERT ... RETURNING и прочие изменения через QueryContext остаются в основном пуле.
+func (db *DB) poolFor(query string) *sql.DB {
+	q := strings.TrimSpace(query)
+	if len(q) >= 6 && strings.EqualFold(q[:6], "SELECT") {
+		return db.read
+	}
+	return db.sql
+}
+
+// BeginTx начинает транзакцию; запросы в ней переписываются тем же диалектом.
+func (db *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
+	tx, err := db.sql.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Tx{Tx: tx, dialect: db.dialect}, nil
+}
+
+// Tx — транзакция с переписыванием плейсхолдеров.
+type Tx struct {
+	*sql.Tx
+	dialect Dialect
+}
+
+func (tx *Tx) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return tx.Tx.ExecContext(ctx, tx.dialect.Rebind(query), args...)
+}
+
+func (tx *Tx) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return tx.Tx.QueryContext(ctx, tx.dialect.Rebind(query), args...)
+}
+
+func (tx *Tx) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return tx.Tx.QueryRowContext(ctx, tx.dialect.Rebind(query), args...)
+}
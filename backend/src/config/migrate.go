@@ -0,0 +1,494 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Миграции схемы лежат в migrations/<драйвер>/ и вшиваются в бинарник.
+// Имя файла: <версия>_<название>.up.sql и (необязательно) <версия>_<название>.down.sql,
+// например 0002_add_barcodes.up.sql. Версии применяются по возрастанию; у каждого
+// драйвера свой набор файлов с одинаковыми номерами версий.
+//
+//go:embed migrations/sqlite/*.sql migrations/postgres/*.sql
+var migrationFiles embed.FS
+
+// Migration — одна версия схемы БД.
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string // SHA-256 up-скрипта; защищает от правки уже применённых миграций
+}
+
+// MigrationStatus — состояние миграции в конкретной БД.
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+}
+
+var (
+	ErrMigrationChecksum = errors.New("migration checksum mismatch")
+	ErrUnknownMigration  = errors.New("database contains migrations unknown to this build")
+	ErrNoDownMigration   = errors.New("migration has no down script")
+)
+
+// migrationTimeout ограничивает время применения одной миграции.
+const migrationTimeout = 5 * time.Minute
+
+var migrationFileRe = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// LoadMigrations читает миграции из каталога dir файловой системы fsys.
+func LoadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, e := range entries {
+		match := migrationFileRe.FindStringSubmatch(e.Name())
+		if e.IsDir() || match == nil {
+			return nil, fmt.Errorf("unexpected file in migrations: %s", e.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		body, err := fs.ReadFile(fsys, path.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has different names: %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(body)
+			sum := sha256.Sum256(body)
+			m.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	result := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %04d_%s has no up script", m.Version, m.Name)
+		}
+		result = append(result, *m)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Version < result[j].Version })
+	return result, nil
+}
+
+// Migrator применяет и откатывает миграции, ведя учёт в таблице schema_migrations.
+// Каждая миграция выполняется в отдельной транзакции вместе с записью о ней.
+type Migrator struct {
+	db         *DB
+	migrations []Migration
+}
+
+// NewMigrator создаёт мигратор со встроенными миграциями для драйвера БД.
+func NewMigrator(db *DB) (*Migrator, error) {
+	migrations, err := LoadMigrations(migrationFiles, "migrations/"+db.Dialect().Name())
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Latest возвращает номер последней известной сборке версии схемы.
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version возвращает текущую версию схемы БД (0 — миграции не применялись).
+func (m *Migrator) Version() (int, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+	version := 0
+	for v := range applied {
+		if v > version {
+			version = v
+		}
+	}
+	return version, nil
+}
+
+// Status возвращает все известные миграции с отметкой о применении.
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]MigrationStatus, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		st := MigrationStatus{Version: mig.Version, Name: mig.Name}
+		if rec, ok := applied[mig.Version]; ok {
+			at := rec.appliedAt
+			st.AppliedAt = &at
+		}
+		result = append(result, st)
+	}
+	return result, nil
+}
+
+// Up применяет до steps неприменённых миграций (steps <= 0 — все) и возвращает применённые.
+func (m *Migrator) Up(steps int) ([]Migration, error) {
+	applied, err := m.verified()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, mig := range m.migrations {
+		if steps > 0 && len(done) == steps {
+			break
+		}
+		if _, ok := applied[mig.Version]; ok {
+			continue
+		}
+		err := m.inTx(func(tx *Tx, ctx context.Context) error {
+			if _, err := tx.ExecContext(ctx, mig.Up); err != nil {
+				return err
+			}
+			_, err := tx.ExecContext(ctx,
+				`INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES (?, ?, ?, ?);`,
+				mig.Version, mig.Name, mig.Checksum, time.Now().UTC(),
+			)
+			return err
+		})
+		if err != nil {
+			return done, fmt.Errorf("migration %04d_%s up: %w", mig.Version, mig.Name, err)
+		}
+		log.Printf("Migration applied: %04d_%s", mig.Version, mig.Name)
+		done = append(done, mig)
+	}
+	return done, nil
+}
+
+// Down откатывает steps последних применённых миграций (по умолчанию одну) и возвращает откаченные.
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	applied, err := m.verified()
+	if err != nil {
+		return nil, err
+	}
+	if steps <= 0 {
+		steps = 1
+	}
+
+	var done []Migration
+	for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		mig := m.migrations[i]
+		if _, ok := applied[mig.Version]; !ok {
+			continue
+		}
+		if mig.Down == "" {
+			return done, fmt.Errorf("%w: %04d_%s", ErrNoDownMigration, mig.Version, mig.Name)
+		}
+		err := m.inTx(func(tx *Tx, ctx context.Context) error {
+			if _, err := tx.ExecContext(ctx, mig.Down); err != nil {
+				return err
+			}
+			_, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = ?;`, mig.Version)
+			return err
+		})
+		if err != nil {
+			return done, fmt.Errorf("migration %04d_%s down: %w", mig.Version, mig.Name, err)
+		}
+		log.Printf("Migration rolled back: %04d_%s", mig.Version, mig.Name)
+		done = append(done, mig)
+	}
+	return done, nil
+}
+
+// appliedMigration — запись из schema_migrations.
+type appliedMigration struct {
+	checksum  string
+	appliedAt time.Time
+}
+
+// verified возвращает применённые миграции, убедившись, что они совпадают со встроенными:
+// неизвестная версия означает, что БД обновлена более новой сборкой, а другой checksum — что
+// уже применённый скрипт был изменён.
+func (m *Migrator) verified() (map[int]appliedMigration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[int]Migration, len(m.migrations))
+	for _, mig := range m.migrations {
+		known[mig.Version] = mig
+	}
+	for version, rec := range applied {
+		mig, ok := known[version]
+		if !ok {
+			return nil, fmt.Errorf("%w: version %d", ErrUnknownMigration, version)
+		}
+		if mig.Checksum != rec.checksum {
+			return nil, fmt.Errorf("%w: %04d_%s", ErrMigrationChecksum, mig.Version, mig.Name)
+		}
+	}
+	return applied, nil
+}
+
+// applied читает schema_migrations, при необходимости создавая её.
+func (m *Migrator) applied() (map[int]appliedMigration, error) {
+	if err := m.ensureTable(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.db.QueryContext(ctx, `SELECT version, checksum, applied_at FROM schema_migrations;`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := map[int]appliedMigration{}
+	for rows.Next() {
+		var (
+			version int
+			rec     appliedMigration
+		)
+		if err := rows.Scan(&version, &rec.checksum, &rec.appliedAt); err != nil {
+			return nil, err
+		}
+		result[version] = rec
+	}
+	return result, rows.Err()
+}
+
+// ensureTable создаёт schema_migrations. Если таблицы ещё нет, а данные уже есть, значит
+// БД создана до перехода на миграции — её схема сначала доводится до состояния 0001_init.
+func (m *Migrator) ensureTable() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	exists, err := tableExists(ctx, m.db, "schema_migrations")
+	if err != nil || exists {
+		return err
+	}
+	legacy, err := tableExists(ctx, m.db, "users")
+	if err != nil {
+		return err
+	}
+	if legacy && m.db.Dialect().Name() == DriverSQLite {
+		if err := adoptLegacySchema(m.db.SQL()); err != nil {
+			return fmt.Errorf("adopt legacy schema: %w", err)
+		}
+	}
+
+	_, err = m.db.ExecContext(ctx, `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version    INTEGER PRIMARY KEY,
+    name       TEXT NOT NULL,
+    checksum   TEXT NOT NULL,
+    applied_at TIMESTAMP NOT NULL
+);`)
+	if err != nil || !legacy || m.db.Dialect().Name() != DriverSQLite {
+		return err
+	}
+	return m.markLegacyApplied()
+}
+
+// legacyMigrations — миграции, изменения которых уже есть в части БД, созданных прежним
+// MigrateSQLite (например, в warehouse.db из репозитория categories уже содержит parent_id;
+// adoptLegacySchema приводит такую таблицу к виду после миграции). Признак — колонка column в таблице table.
+var legacyMigrations = []struct {
+	version       int
+	table, column string
+}{
+	{7, "categories", "parent_id"},
+}
+
+// markLegacyApplied отмечает применёнными миграции из legacyMigrations, изменения которых
+// уже есть в схеме: повторный ADD COLUMN завершился бы ошибкой duplicate column name.
+func (m *Migrator) markLegacyApplied() error {
+	byVersion := make(map[int]Migration, len(m.migrations))
+	for _, mig := range m.migrations {
+		byVersion[mig.Version] = mig
+	}
+
+	// Колонки проверяются до транзакции: у SQLite одно подключение на запись, и PRAGMA
+	// через пул внутри транзакции ждал бы его бесконечно.
+	var found []Migration
+	for _, l := range legacyMigrations {
+		mig, ok := byVersion[l.version]
+		if !ok {
+			continue
+		}
+		exists, err := columnExists(m.db.SQL(), l.table, l.column)
+		if err != nil {
+			return err
+		}
+		if exists {
+			found = append(found, mig)
+		}
+	}
+	if len(found) == 0 {
+		return nil
+	}
+
+	return m.inTx(func(tx *Tx, ctx context.Context) error {
+		for _, mig := range found {
+			if _, err := tx.ExecContext(ctx,
+				`INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES (?, ?, ?, ?);`,
+				mig.Version, mig.Name, mig.Checksum, time.Now().UTC(),
+			); err != nil {
+				return err
+			}
+			log.Printf("Migration %04d_%s is already present in the legacy schema, marked as applied", mig.Version, mig.Name)
+		}
+		return nil
+	})
+}
+
+func (m *Migrator) inTx(fn func(tx *Tx, ctx context.Context) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), migrationTimeout)
+	defer cancel()
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx, ctx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func tableExists(ctx context.Context, db *DB, table string) (bool, error) {
+	query := `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?;`
+	if db.Dialect().Name() == DriverPostgres {
+		query = `SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = ?;`
+	}
+
+	var n int
+	err := db.QueryRowContext(ctx, query, table).Scan(&n)
+	return n > 0, err
+}
+
+// adoptLegacySchema добавляет в БД, созданную прежним MigrateSQLite, колонки, которые
+// появились в схеме после её создания (CREATE TABLE IF NOT EXISTS их не добавлял).
+// Недостающие таблицы создаст сама 0001_init. Новые изменения схемы сюда не добавляются —
+// для них пишутся обычные миграции.
+func adoptLegacySchema(db *sql.DB) error {
+	columns := []struct{ table, column, definition string }{
+		{"stock_movements", "created_by", "TEXT NULL"},
+		{"order_status_history", "changed_by", "TEXT NULL"},
+	}
+	for _, c := range columns {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		exists, err := tableExists(ctx, NewDB(db, sqliteDialect{}), c.table)
+		cancel()
+		if err != nil {
+			return err
+		}
+		if !exists {
+			continue
+		}
+		if err := addColumnIfMissing(db, c.table, c.column, c.definition); err != nil {
+			return err
+		}
+	}
+	if err := rebuildLegacyCategories(db); err != nil {
+		return err
+	}
+	log.Println("Legacy database schema adopted by versioned migrations")
+	return nil
+}
+
+// rebuildLegacyCategories приводит categories прежней схемы к виду после 0007_category_tree.
+// В прежней схеме parent_id уже есть, но внешний ключ объявлен на уровне таблицы, и откат
+// 0007 (DROP COLUMN) на такой таблице невозможен. Таблица пересоздаётся с теми же данными;
+// внешние ключи на время пересоздания отключаются на том же подключении (внутри транзакции
+// PRAGMA foreign_keys не действует), иначе DROP TABLE удалил бы ссылки из products.
+func rebuildLegacyCategories(db *sql.DB) (err error) {
+	exists, err := columnExists(db, "categories", "parent_id")
+	if err != nil || !exists {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), migrationTimeout)
+	defer cancel()
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `PRAGMA foreign_keys = OFF;`); err != nil {
+		return err
+	}
+	defer func() {
+		if _, fkErr := conn.ExecContext(context.Background(), `PRAGMA foreign_keys = ON;`); err == nil {
+			err = fkErr
+		}
+	}()
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if _, err = tx.ExecContext(ctx, `
+CREATE TABLE categories_rebuild (
+    id         TEXT PRIMARY KEY,
+    name       TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL,
+    parent_id  TEXT NULL REFERENCES categories(id)
+);
+INSERT INTO categories_rebuild (id, name, created_at, updated_at, parent_id)
+SELECT id, name, created_at, updated_at, parent_id FROM categories;
+DROP TABLE categories;
+ALTER TABLE categories_rebuild RENAME TO categories;
+CREATE INDEX IF NOT EXISTS idx_categories_parent_id ON categories(parent_id);`); err != nil {
+		return err
+	}
+
+	// Проверка, которую отключённые внешние ключи пропустили: parent_id ссылается на существующие категории.
+	var orphan sql.NullString
+	if err = tx.QueryRowContext(ctx, `PRAGMA foreign_key_check(categories);`).Scan(&orphan, new(any), new(any), new(any)); err != sql.ErrNoRows {
+		if err == nil {
+			err = errors.New("categories.parent_id references missing categories")
+		}
+		return err
+	}
+	return tx.Commit()
+}
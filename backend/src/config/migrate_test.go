@@ -0,0 +1,121 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// legacyDBPath — БД из репозитория, созданная прежним MigrateSQLite, до перехода на миграции.
+const legacyDBPath = "../../warehouse.db"
+
+// copyLegacyDB копирует legacyDBPath во временный каталог и открывает копию.
+func copyLegacyDB(t *testing.T) *DB {
+	t.Helper()
+	data, err := os.ReadFile(legacyDBPath)
+	if err != nil {
+		t.Fatalf("read legacy db: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "warehouse.db")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	db, err := OpenSQLite(path, SQLiteConfig{JournalMode: "WAL", Synchronous: "NORMAL", ReadConns: 2})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestMigrateLegacyDatabase(t *testing.T) {
+	db := copyLegacyDB(t)
+	ctx := context.Background()
+
+	// В прежней схеме parent_id уже был: иерархия должна пережить перестройку таблицы.
+	var child, parent string
+	err := db.QueryRowContext(ctx, `SELECT MIN(id), MAX(id) FROM categories;`).Scan(&child, &parent)
+	if err != nil || child == parent {
+		t.Fatalf("legacy db needs two categories: %v", err)
+	}
+	if _, err := db.SQL().Exec(`UPDATE categories SET parent_id = ? WHERE id = ?;`, parent, child); err != nil {
+		t.Fatal(err)
+	}
+	var products int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM products;`).Scan(&products); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(0); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	if v, _ := m.Version(); v != m.Latest() {
+		t.Fatalf("version = %d, want %d", v, m.Latest())
+	}
+
+	var got string
+	if err := db.QueryRowContext(ctx, `SELECT parent_id FROM categories WHERE id = ?;`, child).Scan(&got); err != nil || got != parent {
+		t.Fatalf("parent_id = %q, %v; want %q", got, err, parent)
+	}
+	var after int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM products;`).Scan(&after); err != nil || after != products {
+		t.Fatalf("products = %d, %v; want %d", after, err, products)
+	}
+
+	// Откат до нуля и повторное применение работают и на перестроенной таблице.
+	if _, err := m.Down(m.Latest()); err != nil {
+		t.Fatalf("down: %v", err)
+	}
+	if _, err := m.Up(0); err != nil {
+		t.Fatalf("up after down: %v", err)
+	}
+}
+
+func TestMigrateRebuildsStockBalancesFromLedger(t *testing.T) {
+	db, err := OpenSQLite(filepath.Join(t.TempDir(), "warehouse.db"), SQLiteConfig{JournalMode: "WAL", Synchronous: "NORMAL", ReadConns: 2})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	ctx := context.Background()
+
+	m, err := NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(2); err != nil {
+		t.Fatalf("up to 2: %v", err)
+	}
+	// До 0003 количества — REAL. Остаток 0.30005 отличается от суммы движений 0.1 + 0.2 на половину
+	// десятитысячной доли и округлением переводится в 0.3001, а по журналу — 0.3.
+	for _, stmt := range []string{
+		`INSERT INTO categories (id, name, created_at, updated_at) VALUES ('c-1', 'C', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP);`,
+		`INSERT INTO products (id, sku, name, category_id, unit, created_at, updated_at) VALUES ('p-1', 'P-1', 'P', 'c-1', 'kg', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP);`,
+		`INSERT INTO stock_movements (id, type, product_id, quantity, created_at) VALUES ('m-1', 'receipt', 'p-1', 0.1, CURRENT_TIMESTAMP);`,
+		`INSERT INTO stock_movements (id, type, product_id, quantity, created_at) VALUES ('m-2', 'receipt', 'p-1', 0.2, CURRENT_TIMESTAMP);`,
+		`INSERT INTO stock_balances (product_id, quantity, updated_at) VALUES ('p-1', 0.30005, CURRENT_TIMESTAMP);`,
+	} {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := m.Up(0); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	var balance, ledger int64
+	if err := db.QueryRowContext(ctx, `SELECT quantity FROM stock_balances WHERE product_id = 'p-1';`).Scan(&balance); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.QueryRowContext(ctx, `SELECT SUM(quantity) FROM stock_movements WHERE product_id = 'p-1';`).Scan(&ledger); err != nil {
+		t.Fatal(err)
+	}
+	if balance != ledger || ledger != 3000 {
+		t.Fatalf("balance = %d, ledger = %d; want both 3000 (0.3)", balance, ledger)
+	}
+}
@@ -0,0 +1,17 @@
+-- Возврат к DOUBLE PRECISION: количества и цены делятся обратно, валюта отбрасывается.
+
+ALTER TABLE stock_balances
+    ALTER COLUMN quantity TYPE DOUBLE PRECISION USING quantity / 10000.0;
+
+ALTER TABLE order_items
+    DROP COLUMN currency,
+    ALTER COLUMN quantity TYPE DOUBLE PRECISION USING quantity / 10000.0,
+    ALTER COLUMN price TYPE DOUBLE PRECISION USING price / 10000.0;
+
+ALTER TABLE stock_movements
+    ALTER COLUMN price DROP NOT NULL,
+    ALTER COLUMN price DROP DEFAULT;
+ALTER TABLE stock_movements
+    DROP COLUMN currency,
+    ALTER COLUMN quantity TYPE DOUBLE PRECISION USING quantity / 10000.0,
+    ALTER COLUMN price TYPE DOUBLE PRECISION USING price / 10000.0;
@@ -0,0 +1,19 @@
+-- Количества и цены переводятся из DOUBLE PRECISION в целые десятитысячные доли
+-- (models.Decimal), у цен появляется валюта.
+
+ALTER TABLE stock_movements
+    ALTER COLUMN quantity TYPE BIGINT USING ROUND(quantity * 10000)::BIGINT,
+    ALTER COLUMN price TYPE BIGINT USING ROUND(COALESCE(price, 0) * 10000)::BIGINT,
+    ADD COLUMN currency TEXT NULL;
+ALTER TABLE stock_movements
+    ALTER COLUMN price SET DEFAULT 0,
+    ALTER COLUMN price SET NOT NULL;
+UPDATE stock_movements SET currency = 'RUB' WHERE price <> 0;
+
+ALTER TABLE order_items
+    ALTER COLUMN quantity TYPE BIGINT USING ROUND(quantity * 10000)::BIGINT,
+    ALTER COLUMN price TYPE BIGINT USING ROUND(price * 10000)::BIGINT,
+    ADD COLUMN currency TEXT NOT NULL DEFAULT 'RUB';
+
+ALTER TABLE stock_balances
+    ALTER COLUMN quantity TYPE BIGINT USING ROUND(quantity * 10000)::BIGINT;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_products_name;
+DROP INDEX IF EXISTS idx_products_updated_at;
+DROP INDEX IF EXISTS idx_products_created_at;
@@ -0,0 +1,5 @@
+-- Индексы для сортировки и постраничной выборки списка товаров (ORDER BY <поле>, id).
+-- Сортировка по SKU и фильтры по категории и поставщику используют индексы из 0001_init.
+CREATE INDEX IF NOT EXISTS idx_products_created_at ON products(created_at, id);
+CREATE INDEX IF NOT EXISTS idx_products_updated_at ON products(updated_at, id);
+CREATE INDEX IF NOT EXISTS idx_products_name ON products(name, id);
@@ -0,0 +1,4 @@
+-- Иерархия категорий: parent_id ссылается на родительскую категорию, NULL — категория верхнего уровня.
+ALTER TABLE categories ADD COLUMN parent_id TEXT NULL REFERENCES categories(id);
+
+CREATE INDEX IF NOT EXISTS idx_categories_parent_id ON categories(parent_id);
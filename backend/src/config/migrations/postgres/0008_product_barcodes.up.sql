@@ -0,0 +1,13 @@
+-- Штрихкоды товаров: у товара может быть несколько штрихкодов, по одному на уровень упаковки
+-- (штука, коробка, паллета). quantity — сколько единиц товара в упаковке (models.Decimal).
+CREATE TABLE IF NOT EXISTS product_barcodes (
+    code       TEXT PRIMARY KEY,
+    product_id TEXT NOT NULL,
+    type       TEXT NOT NULL,
+    packaging  TEXT NOT NULL,
+    quantity   BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_barcodes_product_id ON product_barcodes(product_id);
@@ -0,0 +1,16 @@
+-- Дополнительные единицы измерения товаров: 1 unit = quantity of_unit (models.Decimal),
+-- of_unit — базовая единица товара или другая его дополнительная единица.
+CREATE TABLE IF NOT EXISTS product_units (
+    product_id TEXT NOT NULL,
+    unit       TEXT NOT NULL,
+    quantity   BIGINT NOT NULL,
+    of_unit    TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (product_id, unit),
+    FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
+);
+
+-- Единица, в которой заказана позиция (NULL — базовая единица товара).
+-- Количество и цена позиции указаны в этой единице, резерв на складе — в базовой.
+ALTER TABLE order_items ADD COLUMN unit TEXT NULL;
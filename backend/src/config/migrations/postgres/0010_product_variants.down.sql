@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS product_attributes;
+DROP INDEX IF EXISTS idx_products_parent_id;
+ALTER TABLE products DROP COLUMN parent_id;
@@ -0,0 +1,21 @@
+-- Вложения товаров и поставщиков: фото, спецификации, сертификаты. Содержимое хранится
+-- вне БД в хранилище файлов под именем, равным SHA-256 содержимого (одинаковые файлы хранятся
+-- один раз); thumbnail_sha256 — уменьшенная копия изображения в PNG (NULL — не строилась).
+-- owner_type/owner_id ссылаются на products или suppliers, поэтому внешнего ключа нет.
+CREATE TABLE IF NOT EXISTS attachments (
+    id               TEXT PRIMARY KEY,
+    owner_type       TEXT NOT NULL,
+    owner_id         TEXT NOT NULL,
+    kind             TEXT NOT NULL,
+    file_name        TEXT NOT NULL,
+    content_type     TEXT NOT NULL,
+    size             BIGINT NOT NULL,
+    sha256           TEXT NOT NULL,
+    thumbnail_sha256 TEXT NULL,
+    created_by       TEXT NULL,
+    created_at       TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_attachments_owner ON attachments(owner_type, owner_id);
+CREATE INDEX IF NOT EXISTS idx_attachments_sha256 ON attachments(sha256);
+CREATE INDEX IF NOT EXISTS idx_attachments_thumbnail_sha256 ON attachments(thumbnail_sha256);
@@ -0,0 +1,3 @@
+-- Полнотекстовый индекс товаров есть только в SQLite (FTS5); в PostgreSQL поиск идёт по подстроке.
+-- Миграция оставлена пустой, чтобы номера версий у драйверов совпадали.
+SELECT 1;
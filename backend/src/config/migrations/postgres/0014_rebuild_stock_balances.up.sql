@@ -0,0 +1,20 @@
+-- Миграция 0003 перевела stock_balances округлением прежней суммы REAL, и остаток мог разойтись
+-- с переведёнными движениями на десятитысячную долю. Остатки пересчитываются по журналу движений
+-- так же, как это делает WarehouseRepositorySQL.RebuildBalances.
+
+DELETE FROM stock_balances;
+
+INSERT INTO stock_balances (product_id, quantity, updated_at)
+SELECT
+    product_id,
+    CAST(SUM(
+        CASE type
+            WHEN 'receipt'   THEN quantity
+            WHEN 'write_off' THEN -quantity
+            WHEN 'reserve'   THEN -quantity
+            ELSE 0
+        END
+    ) AS BIGINT),
+    CURRENT_TIMESTAMP
+FROM stock_movements
+GROUP BY product_id;
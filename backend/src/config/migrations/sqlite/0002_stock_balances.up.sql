@@ -0,0 +1,25 @@
+-- Материализованные остатки: stock_balances обновляется в одной транзакции с записью
+-- в stock_movements, поэтому чтение остатков не агрегирует весь журнал движений.
+
+CREATE TABLE stock_balances (
+    product_id TEXT PRIMARY KEY,
+    quantity   REAL NOT NULL,
+    updated_at DATETIME NOT NULL,
+    FOREIGN KEY (product_id) REFERENCES products(id)
+);
+
+-- Начальные остатки считаются по уже накопленным движениям.
+INSERT INTO stock_balances (product_id, quantity, updated_at)
+SELECT
+    product_id,
+    SUM(
+        CASE type
+            WHEN 'receipt'   THEN quantity
+            WHEN 'write_off' THEN -quantity
+            WHEN 'reserve'   THEN -quantity
+            ELSE 0
+        END
+    ),
+    CURRENT_TIMESTAMP
+FROM stock_movements
+GROUP BY product_id;
@@ -0,0 +1,62 @@
+-- Возврат к REAL: количества и цены делятся обратно, валюта отбрасывается.
+
+CREATE TABLE stock_movements_old (
+    id          TEXT PRIMARY KEY,
+    type        TEXT NOT NULL,
+    product_id  TEXT NOT NULL,
+    supplier_id TEXT NULL,
+    order_id    TEXT NULL,
+    quantity    REAL NOT NULL,
+    price       REAL,
+    expiry_date DATETIME,
+    created_by  TEXT NULL,
+    created_at  DATETIME NOT NULL,
+    FOREIGN KEY (product_id)  REFERENCES products(id),
+    FOREIGN KEY (supplier_id) REFERENCES suppliers(id),
+    FOREIGN KEY (order_id)    REFERENCES orders(id)
+);
+
+INSERT INTO stock_movements_old (id, type, product_id, supplier_id, order_id, quantity, price, expiry_date, created_by, created_at)
+SELECT id, type, product_id, supplier_id, order_id, quantity / 10000.0, price / 10000.0, expiry_date, created_by, created_at
+FROM stock_movements;
+
+DROP TABLE stock_movements;
+ALTER TABLE stock_movements_old RENAME TO stock_movements;
+
+CREATE INDEX idx_stock_movements_product_id ON stock_movements(product_id);
+CREATE INDEX idx_stock_movements_type ON stock_movements(type);
+CREATE INDEX idx_stock_movements_order_id ON stock_movements(order_id);
+
+CREATE TABLE order_items_old (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    order_id   TEXT NOT NULL,
+    product_id TEXT NOT NULL,
+    quantity   REAL NOT NULL,
+    price      REAL NOT NULL,
+    FOREIGN KEY (order_id) REFERENCES orders(id),
+    FOREIGN KEY (product_id) REFERENCES products(id)
+);
+
+INSERT INTO order_items_old (id, order_id, product_id, quantity, price)
+SELECT id, order_id, product_id, quantity / 10000.0, price / 10000.0
+FROM order_items;
+
+DROP TABLE order_items;
+ALTER TABLE order_items_old RENAME TO order_items;
+
+CREATE INDEX idx_order_items_order_id ON order_items(order_id);
+CREATE INDEX idx_order_items_product_id ON order_items(product_id);
+
+CREATE TABLE stock_balances_old (
+    product_id TEXT PRIMARY KEY,
+    quantity   REAL NOT NULL,
+    updated_at DATETIME NOT NULL,
+    FOREIGN KEY (product_id) REFERENCES products(id)
+);
+
+INSERT INTO stock_balances_old (product_id, quantity, updated_at)
+SELECT product_id, quantity / 10000.0, updated_at
+FROM stock_balances;
+
+DROP TABLE stock_balances;
+ALTER TABLE stock_balances_old RENAME TO stock_balances;
@@ -0,0 +1,71 @@
+-- Количества и цены переводятся из REAL в целые десятитысячные доли (models.Decimal),
+-- у цен появляется валюта. SQLite не меняет тип колонки через ALTER TABLE,
+-- поэтому таблицы пересоздаются с переносом данных.
+
+CREATE TABLE stock_movements_new (
+    id          TEXT PRIMARY KEY,
+    type        TEXT NOT NULL,
+    product_id  TEXT NOT NULL,
+    supplier_id TEXT NULL,
+    order_id    TEXT NULL,
+    quantity    INTEGER NOT NULL,
+    price       INTEGER NOT NULL DEFAULT 0,
+    currency    TEXT NULL,
+    expiry_date DATETIME,
+    created_by  TEXT NULL,
+    created_at  DATETIME NOT NULL,
+    FOREIGN KEY (product_id)  REFERENCES products(id),
+    FOREIGN KEY (supplier_id) REFERENCES suppliers(id),
+    FOREIGN KEY (order_id)    REFERENCES orders(id)
+);
+
+INSERT INTO stock_movements_new (id, type, product_id, supplier_id, order_id, quantity, price, currency, expiry_date, created_by, created_at)
+SELECT
+    id, type, product_id, supplier_id, order_id,
+    CAST(ROUND(quantity * 10000) AS INTEGER),
+    CAST(ROUND(COALESCE(price, 0) * 10000) AS INTEGER),
+    CASE WHEN COALESCE(price, 0) <> 0 THEN 'RUB' END,
+    expiry_date, created_by, created_at
+FROM stock_movements;
+
+DROP TABLE stock_movements;
+ALTER TABLE stock_movements_new RENAME TO stock_movements;
+
+CREATE INDEX idx_stock_movements_product_id ON stock_movements(product_id);
+CREATE INDEX idx_stock_movements_type ON stock_movements(type);
+CREATE INDEX idx_stock_movements_order_id ON stock_movements(order_id);
+
+CREATE TABLE order_items_new (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    order_id   TEXT NOT NULL,
+    product_id TEXT NOT NULL,
+    quantity   INTEGER NOT NULL,
+    price      INTEGER NOT NULL,
+    currency   TEXT NOT NULL DEFAULT 'RUB',
+    FOREIGN KEY (order_id) REFERENCES orders(id),
+    FOREIGN KEY (product_id) REFERENCES products(id)
+);
+
+INSERT INTO order_items_new (id, order_id, product_id, quantity, price, currency)
+SELECT id, order_id, product_id, CAST(ROUND(quantity * 10000) AS INTEGER), CAST(ROUND(price * 10000) AS INTEGER), 'RUB'
+FROM order_items;
+
+DROP TABLE order_items;
+ALTER TABLE order_items_new RENAME TO order_items;
+
+CREATE INDEX idx_order_items_order_id ON order_items(order_id);
+CREATE INDEX idx_order_items_product_id ON order_items(product_id);
+
+CREATE TABLE stock_balances_new (
+    product_id TEXT PRIMARY KEY,
+    quantity   INTEGER NOT NULL,
+    updated_at DATETIME NOT NULL,
+    FOREIGN KEY (product_id) REFERENCES products(id)
+);
+
+INSERT INTO stock_balances_new (product_id, quantity, updated_at)
+SELECT product_id, CAST(ROUND(quantity * 10000) AS INTEGER), updated_at
+FROM stock_balances;
+
+DROP TABLE stock_balances;
+ALTER TABLE stock_balances_new RENAME TO stock_balances;
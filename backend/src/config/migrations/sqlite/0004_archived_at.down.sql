@@ -0,0 +1,3 @@
+ALTER TABLE suppliers DROP COLUMN archived_at;
+ALTER TABLE categories DROP COLUMN archived_at;
+ALTER TABLE products DROP COLUMN archived_at;
@@ -0,0 +1,5 @@
+-- Мягкое удаление справочников: заархивированные записи скрыты из списков,
+-- но остаются в БД, чтобы на них могли ссылаться движения и заказы.
+ALTER TABLE products ADD COLUMN archived_at DATETIME NULL;
+ALTER TABLE categories ADD COLUMN archived_at DATETIME NULL;
+ALTER TABLE suppliers ADD COLUMN archived_at DATETIME NULL;
@@ -0,0 +1,6 @@
+-- Версии записей для оптимистичных блокировок: каждое изменение увеличивает version,
+-- клиент передаёт прочитанную версию в If-Match, и устаревшее изменение отклоняется.
+ALTER TABLE products ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE categories ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE suppliers ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE orders ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
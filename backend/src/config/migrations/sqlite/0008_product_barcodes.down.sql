@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_product_barcodes_product_id;
+DROP TABLE IF EXISTS product_barcodes;
@@ -0,0 +1,14 @@
+-- Варианты товара: parent_id ссылается на шаблон (модель товара), NULL — самостоятельный товар или шаблон.
+ALTER TABLE products ADD COLUMN parent_id TEXT NULL REFERENCES products(id);
+
+CREATE INDEX IF NOT EXISTS idx_products_parent_id ON products(parent_id);
+
+-- Атрибуты товара: размер, цвет и произвольные пары ключ/значение (type = custom).
+CREATE TABLE IF NOT EXISTS product_attributes (
+    product_id TEXT NOT NULL,
+    name       TEXT NOT NULL,
+    type       TEXT NOT NULL,
+    value      TEXT NOT NULL,
+    PRIMARY KEY (product_id, name),
+    FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
+);
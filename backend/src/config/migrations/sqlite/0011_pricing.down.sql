@@ -0,0 +1,4 @@
+ALTER TABLE order_items DROP COLUMN price_list_id;
+DROP TABLE IF EXISTS price_list_items;
+DROP TABLE IF EXISTS price_lists;
+DROP TABLE IF EXISTS supplier_products;
@@ -0,0 +1,47 @@
+-- Закупочные цены: условия поставщика на товар (цена, срок поставки в днях, минимальная партия).
+-- cost и min_order_quantity — models.Decimal.
+CREATE TABLE IF NOT EXISTS supplier_products (
+    supplier_id        TEXT NOT NULL,
+    product_id         TEXT NOT NULL,
+    supplier_sku       TEXT NULL,
+    cost               INTEGER NOT NULL,
+    currency           TEXT NOT NULL,
+    lead_time_days     INTEGER NOT NULL DEFAULT 0,
+    min_order_quantity INTEGER NOT NULL DEFAULT 0,
+    created_at         DATETIME NOT NULL,
+    updated_at         DATETIME NOT NULL,
+    PRIMARY KEY (supplier_id, product_id),
+    FOREIGN KEY (supplier_id) REFERENCES suppliers(id) ON DELETE CASCADE,
+    FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_supplier_products_product_id ON supplier_products(product_id);
+
+-- Прайс-листы продаж: валюта, срок действия (valid_to NULL — бессрочно)
+-- и покупатель (customer NULL — общий прайс-лист).
+CREATE TABLE IF NOT EXISTS price_lists (
+    id         TEXT PRIMARY KEY,
+    name       TEXT NOT NULL,
+    currency   TEXT NOT NULL,
+    customer   TEXT NULL,
+    valid_from DATETIME NOT NULL,
+    valid_to   DATETIME NULL,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL,
+    version    INTEGER NOT NULL DEFAULT 1
+);
+
+-- Цены товаров в прайс-листе за базовую единицу товара (models.Decimal).
+CREATE TABLE IF NOT EXISTS price_list_items (
+    price_list_id TEXT NOT NULL,
+    product_id    TEXT NOT NULL,
+    price         INTEGER NOT NULL,
+    PRIMARY KEY (price_list_id, product_id),
+    FOREIGN KEY (price_list_id) REFERENCES price_lists(id) ON DELETE CASCADE,
+    FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_list_items_product_id ON price_list_items(product_id);
+
+-- Прайс-лист, из которого подставлена цена позиции заказа (NULL — цену указал клиент).
+ALTER TABLE order_items ADD COLUMN price_list_id TEXT NULL;
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS products_fts_insert;
+DROP TRIGGER IF EXISTS products_fts_update;
+DROP TRIGGER IF EXISTS products_fts_delete;
+DROP TABLE IF EXISTS products_fts;
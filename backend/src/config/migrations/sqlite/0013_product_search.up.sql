@@ -0,0 +1,36 @@
+-- Полнотекстовый индекс товаров (FTS5) для GET /api/products/search. Раньше таблица и триггеры
+-- создавались при старте сервера; удаляем их, чтобы заменить индексом с внешним содержимым.
+-- Индекс хранит только токены, а текст берёт из products по rowid, поэтому его можно
+-- перестроить командой 'rebuild', если он разошёлся с products.
+DROP TRIGGER IF EXISTS products_fts_insert;
+DROP TRIGGER IF EXISTS products_fts_update;
+DROP TRIGGER IF EXISTS products_fts_delete;
+DROP TABLE IF EXISTS products_fts;
+
+CREATE VIRTUAL TABLE products_fts USING fts5(
+    sku,
+    name,
+    description,
+    content = 'products',
+    content_rowid = 'rowid',
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+CREATE TRIGGER products_fts_insert AFTER INSERT ON products BEGIN
+    INSERT INTO products_fts (rowid, sku, name, description)
+    VALUES (new.rowid, new.sku, new.name, new.description);
+END;
+
+CREATE TRIGGER products_fts_update AFTER UPDATE OF sku, name, description ON products BEGIN
+    INSERT INTO products_fts (products_fts, rowid, sku, name, description)
+    VALUES ('delete', old.rowid, old.sku, old.name, old.description);
+    INSERT INTO products_fts (rowid, sku, name, description)
+    VALUES (new.rowid, new.sku, new.name, new.description);
+END;
+
+CREATE TRIGGER products_fts_delete AFTER DELETE ON products BEGIN
+    INSERT INTO products_fts (products_fts, rowid, sku, name, description)
+    VALUES ('delete', old.rowid, old.sku, old.name, old.description);
+END;
+
+INSERT INTO products_fts (products_fts) VALUES ('rebuild');
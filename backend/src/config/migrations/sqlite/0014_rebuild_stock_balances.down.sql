@@ -0,0 +1,2 @@
+-- Пересчёт остатков не меняет схему; откатывать нечего.
+SELECT 1;
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"database/sql/driver"
+	"strings"
+
+	"modernc.org/sqlite"
+)
+
+// sqliteLowerFunc — имя функции SQLite, понижающей регистр строки по правилам Unicode (strings.ToLower).
+// Встроенный LOWER без расширения ICU понижает регистр только латиницы, и поиск «МОЛОКО» не находил «молоко».
+// Функция регистрируется в драйвере и доступна во всех подключениях, которые он открывает.
+const sqliteLowerFunc = "unicode_lower"
+
+func init() {
+	sqlite.MustRegisterDeterministicScalarFunction(sqliteLowerFunc, 1, unicodeLower)
+}
+
+// unicodeLower реализует unicode_lower(x): NULL остаётся NULL, прочие нестроковые значения не меняются.
+func unicodeLower(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+	switch v := args[0].(type) {
+	case string:
+		return strings.ToLower(v), nil
+	case []byte:
+		return strings.ToLower(string(v)), nil
+	default:
+		return v, nil
+	}
+}
@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+	"warehouse-management-system/src/models"
+	"warehouse-management-system/src/services"
+
+	"github.com/gorilla/mux"
+)
+
+// APIKeyController обрабатывает HTTP-запросы управления API-ключами интеграций.
+type APIKeyController struct {
+	apiKeyService *services.APIKeyService
+}
+
+// NewAPIKeyController — конструктор контроллера API-ключей.
+func NewAPIKeyController(apiKeyService *services.APIKeyService) *APIKeyController {
+	return &APIKeyController{apiKeyService: apiKeyService}
+}
+
+// createAPIKeyRequest описывает тело запроса на выпуск API-ключа.
+type createAPIKeyRequest struct {
+	Name      string      `json:"name"`
+	UserID    string      `json:"user_id"` // опционально, по умолчанию — текущий пользователь
+	Role      models.Role `json:"role"`
+	Scopes    []string    `json:"scopes"`
+	ExpiresAt string      `json:"expires_at"` // ISO8601, опционально
+}
+
+// createAPIKeyResponse — ответ на выпуск ключа. Поле key показывается только один раз.
+type createAPIKeyResponse struct {
+	Key    string         `json:"key"`
+	APIKey *models.APIKey `json:"api_key"`
+}
+
+// GetAPIKeys — получение списка API-ключей.
+func (c *APIKeyController) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	keys, err := c.apiKeyService.ListKeys(r.Context())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(keys)
+}
+
+// CreateAPIKey — выпуск нового API-ключа.
+func (c *APIKeyController) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var req createAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: "invalid request body"})
+		return
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresAt != "" {
+		t, err := time.Parse(time.RFC3339, req.ExpiresAt)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(ErrorResponse{Error: "invalid expires_at format, expected RFC3339"})
+			return
+		}
+		t = t.UTC()
+		expiresAt = &t
+	}
+
+	userID := strings.TrimSpace(req.UserID)
+	if userID == "" {
+		userID, _ = r.Context().Value("userID").(string)
+	}
+
+	key, rawKey, err := c.apiKeyService.CreateKey(r.Context(), req.Name, userID, req.Role, req.Scopes, expiresAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidAPIKey), errors.Is(err, services.ErrAPIKeyRole):
+			w.WriteHeader(http.StatusBadRequest)
+		case errors.Is(err, services.ErrUserNotFound):
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(createAPIKeyResponse{
+		Key:    rawKey,
+		APIKey: key,
+	})
+}
+
+// RevokeAPIKey — отзыв API-ключа по ID.
+func (c *APIKeyController) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	vars := mux.Vars(r)
+	id := strings.TrimSpace(vars["id"])
+	if id == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: "id is required"})
+		return
+	}
+
+	if err := c.apiKeyService.RevokeKey(r.Context(), id); err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidAPIKey), errors.Is(err, services.ErrAPIKeyRole):
+			w.WriteHeader(http.StatusBadRequest)
+		case errors.Is(err, services.ErrAPIKeyNotFound):
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
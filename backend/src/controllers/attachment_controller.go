@@ -0,0 +1,225 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+	"warehouse-management-system/src/models"
+	"warehouse-management-system/src/services"
+
+	"github.com/gorilla/mux"
+)
+
+// AttachmentController обрабатывает HTTP-запросы, связанные с вложениями товаров и поставщиков.
+type AttachmentController struct {
+	attachmentService *services.AttachmentService
+}
+
+// NewAttachmentController — конструктор контроллера вложений.
+func NewAttachmentController(attachmentService *services.AttachmentService) *AttachmentController {
+	return &AttachmentController{attachmentService: attachmentService}
+}
+
+// multipartOverhead — запас к наибольшему размеру файла на заголовки частей multipart-запроса.
+const multipartOverhead = 64 << 10
+
+// GetProductAttachments — список вложений товара.
+func (c *AttachmentController) GetProductAttachments(w http.ResponseWriter, r *http.Request) {
+	c.list(w, r, models.AttachmentOwnerProduct)
+}
+
+// GetSupplierAttachments — список вложений поставщика.
+func (c *AttachmentController) GetSupplierAttachments(w http.ResponseWriter, r *http.Request) {
+	c.list(w, r, models.AttachmentOwnerSupplier)
+}
+
+// UploadProductAttachment — загрузка вложения товара.
+func (c *AttachmentController) UploadProductAttachment(w http.ResponseWriter, r *http.Request) {
+	c.upload(w, r, models.AttachmentOwnerProduct)
+}
+
+// UploadSupplierAttachment — загрузка вложения поставщика.
+func (c *AttachmentController) UploadSupplierAttachment(w http.ResponseWriter, r *http.Request) {
+	c.upload(w, r, models.AttachmentOwnerSupplier)
+}
+
+// GetAttachment — сведения о вложении.
+func (c *AttachmentController) GetAttachment(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	a, err := c.attachmentService.GetAttachment(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeAttachmentError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(a)
+}
+
+// DownloadAttachment — содержимое вложения (файл для сохранения, поддерживаются Range и If-None-Match).
+func (c *AttachmentController) DownloadAttachment(w http.ResponseWriter, r *http.Request) {
+	c.serve(w, r, false)
+}
+
+// GetThumbnail — уменьшенная копия изображения в PNG.
+func (c *AttachmentController) GetThumbnail(w http.ResponseWriter, r *http.Request) {
+	c.serve(w, r, true)
+}
+
+// DeleteAttachment — удаление вложения.
+func (c *AttachmentController) DeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if err := c.attachmentService.DeleteAttachment(r.Context(), mux.Vars(r)["id"]); err != nil {
+		writeAttachmentError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *AttachmentController) list(w http.ResponseWriter, r *http.Request, owner models.AttachmentOwner) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	attachments, err := c.attachmentService.ListAttachments(r.Context(), owner, mux.Vars(r)["id"])
+	if err != nil {
+		writeAttachmentError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(attachments)
+}
+
+// upload принимает multipart/form-data с файлом в поле file; назначение задаётся параметром ?kind=.
+// Файл читается потоком, без сохранения запроса во временные файлы.
+func (c *AttachmentController) upload(w http.ResponseWriter, r *http.Request, owner models.AttachmentOwner) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, c.attachmentService.MaxSize()+multipartOverhead)
+	mr, err := r.MultipartReader()
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: "expected multipart/form-data request with a file field"})
+		return
+	}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(ErrorResponse{Error: "file field is required"})
+			return
+		}
+		if err != nil {
+			writeAttachmentError(w, uploadError(err))
+			return
+		}
+		if part.FormName() != "file" {
+			continue
+		}
+
+		kind := models.AttachmentKind(r.URL.Query().Get("kind"))
+		a, err := c.attachmentService.Upload(r.Context(), owner, mux.Vars(r)["id"], kind, part.FileName(), part)
+		if err != nil {
+			writeAttachmentError(w, uploadError(err))
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(a)
+		return
+	}
+}
+
+// serve отдаёт содержимое вложения или его уменьшенную копию. Содержимое по ID никогда не меняется,
+// поэтому ETag — хеш содержимого, а ответ можно кэшировать.
+func (c *AttachmentController) serve(w http.ResponseWriter, r *http.Request, thumbnail bool) {
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	a, content, err := c.attachmentService.Open(r.Context(), mux.Vars(r)["id"], thumbnail)
+	if err != nil {
+		writeAttachmentError(w, err)
+		return
+	}
+	defer content.Close()
+
+	h := w.Header()
+	h.Set("X-Content-Type-Options", "nosniff")
+	h.Set("Cache-Control", "private, max-age=86400")
+	if thumbnail {
+		h.Set("Content-Type", "image/png")
+		h.Set("ETag", strconv.Quote(a.ThumbnailSHA256))
+	} else {
+		h.Set("Content-Type", a.ContentType)
+		h.Set("ETag", strconv.Quote(a.SHA256))
+		h.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": a.FileName}))
+	}
+
+	if rs, ok := content.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, "", time.Time{}, rs)
+		return
+	}
+	if !thumbnail {
+		h.Set("Content-Length", strconv.FormatInt(a.Size, 10))
+	}
+	w.WriteHeader(http.StatusOK)
+	_, _ = io.Copy(w, content)
+}
+
+// uploadError приводит превышение лимита тела запроса к ErrAttachmentTooLarge.
+func uploadError(err error) error {
+	var maxBytes *http.MaxBytesError
+	if errors.As(err, &maxBytes) {
+		return services.ErrAttachmentTooLarge
+	}
+	return err
+}
+
+// writeAttachmentError отвечает на ошибку операции с вложениями подходящим HTTP-статусом.
+func writeAttachmentError(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	switch err {
+	case services.ErrInvalidAttachment, services.ErrInvalidProduct, services.ErrInvalidSupplier:
+		w.WriteHeader(http.StatusBadRequest)
+	case services.ErrAttachmentNotFound, services.ErrNoThumbnail,
+		services.ErrProductNotFound, services.ErrSupplierNotFound:
+		w.WriteHeader(http.StatusNotFound)
+	case services.ErrProductArchived, services.ErrSupplierArchived:
+		w.WriteHeader(http.StatusConflict)
+	case services.ErrAttachmentTooLarge:
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+	case services.ErrAttachmentType:
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	_ = json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+}
@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+	"warehouse-management-system/src/models"
+	"warehouse-management-system/src/services"
+)
+
+// AuditController отдаёт журнал аудита изменений.
+type AuditController struct {
+	auditService *services.AuditService
+}
+
+// NewAuditController — конструктор контроллера журнала аудита.
+func NewAuditController(auditService *services.AuditService) *AuditController {
+	return &AuditController{auditService: auditService}
+}
+
+// GetAuditLog — выборка из журнала аудита.
+// Query-параметры: actor_id, action, entity_type, entity_id, request_id, from, to (RFC3339), limit, offset.
+func (c *AuditController) GetAuditLog(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	q := r.URL.Query()
+	filter := models.AuditFilter{
+		ActorID:    q.Get("actor_id"),
+		Action:     q.Get("action"),
+		EntityType: q.Get("entity_type"),
+		EntityID:   q.Get("entity_id"),
+		RequestID:  q.Get("request_id"),
+	}
+
+	var err error
+	if filter.From, err = parseTimeParam(q.Get("from")); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: "invalid from format, expected RFC3339"})
+		return
+	}
+	if filter.To, err = parseTimeParam(q.Get("to")); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: "invalid to format, expected RFC3339"})
+		return
+	}
+	if filter.Limit, err = parseIntParam(q.Get("limit")); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: "limit must be an integer"})
+		return
+	}
+	if filter.Offset, err = parseIntParam(q.Get("offset")); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: "offset must be an integer"})
+		return
+	}
+
+	entries, err := c.auditService.ListEntries(r.Context(), filter)
+	if err != nil {
+		if err == services.ErrInvalidAuditFilter {
+			w.WriteHeader(http.StatusBadRequest)
+		} else {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(entries)
+}
+
+// parseTimeParam разбирает необязательный query-параметр времени в формате RFC3339.
+func parseTimeParam(raw string) (*time.Time, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// parseIntParam разбирает необязательный целочисленный query-параметр (пусто — 0).
+func parseIntParam(raw string) (int, error) {
+	if raw == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(raw)
+}
+
+// parseBoolParam разбирает необязательный логический query-параметр (пусто — false).
+func parseBoolParam(raw string) (bool, error) {
+	if raw == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(raw)
+}
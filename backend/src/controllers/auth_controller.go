@@ -1,12 +1,19 @@
 package controllers
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"net"
 	"net/http"
+	"net/url"
 	"strings"
 	"warehouse-management-system/src/models"
 	"warehouse-management-system/src/services"
+
+	"github.com/gorilla/mux"
 )
 
 // AuthController обрабатывает HTTP-запросы, связанные с аутентификацией.
@@ -28,11 +35,16 @@ type registerRequest struct {
 }
 
 // loginRequest описывает тело запроса на вход.
+// Provider — имя провайдера с логином/паролем (local по умолчанию, ldap).
 type loginRequest struct {
+	Provider string `json:"provider"`
 	Email    string `json:"email"`
 	Password string `json:"password"`
 }
 
+// redirectStateCookie хранит state и nonce между переходом к внешнему провайдеру и callback.
+const redirectStateCookie = "wms_auth_state"
+
 // authResponse — стандартный ответ при успешной аутентификации/регистрации.
 type authResponse struct {
 	Token string       `json:"token"`
@@ -62,7 +74,7 @@ func (c *AuthController) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := c.authService.Register(req.Email, req.Password, req.Role)
+	user, err := c.authService.Register(r.Context(), req.Email, req.Password, req.Role)
 	if err != nil {
 		switch {
 		case errors.Is(err, services.ErrEmailAlreadyInUse):
@@ -101,11 +113,16 @@ func (c *AuthController) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, token, err := c.authService.Login(req.Email, req.Password)
+	user, token, err := c.authService.Login(r.Context(), strings.TrimSpace(req.Provider), req.Email, req.Password, clientInfo(r))
 	if err != nil {
-		if err == services.ErrInvalidCredentials {
+		switch {
+		case errors.Is(err, services.ErrInvalidCredentials):
 			w.WriteHeader(http.StatusUnauthorized)
-		} else {
+		case errors.Is(err, services.ErrUnknownProvider):
+			w.WriteHeader(http.StatusBadRequest)
+		case errors.Is(err, services.ErrNoRoleMapping), errors.Is(err, services.ErrIdentityNotLinked):
+			w.WriteHeader(http.StatusForbidden)
+		default:
 			w.WriteHeader(http.StatusInternalServerError)
 		}
 		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
@@ -119,6 +136,180 @@ func (c *AuthController) Login(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// GetProviders — список доступных способов входа (для отображения на странице логина).
+func (c *AuthController) GetProviders(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(c.authService.Providers())
+}
+
+// StartRedirectLogin перенаправляет браузер на страницу входа внешнего провайдера (OIDC).
+func (c *AuthController) StartRedirectLogin(w http.ResponseWriter, r *http.Request) {
+	provider := mux.Vars(r)["provider"]
+
+	state, err := randomToken()
+	if err != nil {
+		writeAuthError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	nonce, err := randomToken()
+	if err != nil {
+		writeAuthError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	target, err := c.authService.LoginRedirectURL(r.Context(), provider, state, nonce)
+	if err != nil {
+		if errors.Is(err, services.ErrUnknownProvider) {
+			writeAuthError(w, http.StatusNotFound, err.Error())
+		} else {
+			writeAuthError(w, http.StatusBadGateway, err.Error())
+		}
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     redirectStateCookie,
+		Value:    state + "." + nonce,
+		Path:     "/api/auth/" + provider,
+		MaxAge:   600,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+// RedirectCallback принимает код авторизации от внешнего провайдера, выдаёт JWT
+// и перенаправляет на страницу логина, которая сохраняет токен (передаётся во fragment URL).
+func (c *AuthController) RedirectCallback(w http.ResponseWriter, r *http.Request) {
+	provider := mux.Vars(r)["provider"]
+
+	if errParam := r.URL.Query().Get("error"); errParam != "" {
+		writeAuthError(w, http.StatusUnauthorized, "provider returned error: "+errParam)
+		return
+	}
+
+	cookie, err := r.Cookie(redirectStateCookie)
+	if err != nil {
+		writeAuthError(w, http.StatusBadRequest, "missing login state")
+		return
+	}
+	state, nonce, ok := strings.Cut(cookie.Value, ".")
+	if !ok || state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(r.URL.Query().Get("state"))) != 1 {
+		writeAuthError(w, http.StatusBadRequest, "invalid login state")
+		return
+	}
+
+	// state одноразовый — удаляем cookie сразу.
+	http.SetCookie(w, &http.Cookie{
+		Name:     redirectStateCookie,
+		Value:    "",
+		Path:     "/api/auth/" + provider,
+		MaxAge:   -1,
+		HttpOnly: true,
+	})
+
+	code := r.URL.Query().Get("code")
+	if code == "" {
+		writeAuthError(w, http.StatusBadRequest, "code is required")
+		return
+	}
+
+	_, token, err := c.authService.CompleteRedirectLogin(r.Context(), provider, code, nonce, clientInfo(r))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrUnknownProvider):
+			writeAuthError(w, http.StatusNotFound, err.Error())
+		case errors.Is(err, services.ErrNoRoleMapping), errors.Is(err, services.ErrIdentityNotLinked):
+			writeAuthError(w, http.StatusForbidden, err.Error())
+		default:
+			writeAuthError(w, http.StatusUnauthorized, err.Error())
+		}
+		return
+	}
+
+	http.Redirect(w, r, "/#token="+url.QueryEscape(token), http.StatusFound)
+}
+
+// Logout завершает текущую сессию: выданный для неё токен перестаёт приниматься.
+func (c *AuthController) Logout(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	userID, _ := r.Context().Value("userID").(string)
+	sessionID, _ := r.Context().Value("sessionID").(string)
+	if sessionID == "" {
+		// Запрос аутентифицирован API-ключом — завершать нечего.
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: "request is not bound to a session"})
+		return
+	}
+
+	if err := c.authService.Logout(r.Context(), userID, sessionID); err != nil {
+		if errors.Is(err, services.ErrSessionNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+		} else {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// clientInfo извлекает из запроса сведения об устройстве для записи сессии.
+func clientInfo(r *http.Request) services.ClientInfo {
+	ip := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		ip = host
+	}
+	return services.ClientInfo{
+		UserAgent: r.UserAgent(),
+		IP:        ip,
+	}
+}
+
+func writeAuthError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(ErrorResponse{Error: msg})
+}
+
+func randomToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// GetJWKS — публикация открытых ключей подписи JWT (/.well-known/jwks.json).
+func (c *AuthController) GetJWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	// Ключи меняются только при перезапуске, поэтому ответ можно кэшировать.
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(c.authService.JWKS())
+}
+
 // GetMe — HTTP-обработчик, возвращающий текущего пользователя по информации из контекста.
 // Ожидается, что middleware аутентификации положит userID в контекст запроса.
 func (c *AuthController) GetMe(w http.ResponseWriter, r *http.Request) {
@@ -140,7 +331,7 @@ func (c *AuthController) GetMe(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := c.authService.GetUserByID(userID)
+	user, err := c.authService.GetUserByID(r.Context(), userID)
 	if err != nil {
 		if errors.Is(err, services.ErrUserNotFound) {
 			w.WriteHeader(http.StatusNotFound)
@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"warehouse-management-system/src/services"
+)
+
+// BackupController обрабатывает HTTP-запросы резервного копирования и проверки целостности БД.
+type BackupController struct {
+	backupService *services.BackupService
+}
+
+// NewBackupController — конструктор контроллера резервных копий.
+func NewBackupController(backupService *services.BackupService) *BackupController {
+	return &BackupController{backupService: backupService}
+}
+
+// CreateBackup — создание резервной копии работающей БД.
+func (c *BackupController) CreateBackup(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	info, err := c.backupService.CreateBackup(r.Context())
+	if err != nil {
+		writeBackupError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(info)
+}
+
+// GetBackups — список резервных копий.
+func (c *BackupController) GetBackups(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	backups, err := c.backupService.ListBackups(r.Context())
+	if err != nil {
+		writeBackupError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(backups)
+}
+
+// CheckIntegrity — проверка целостности БД. Найденные проблемы возвращаются
+// в теле ответа со статусом 200: сама проверка выполнена успешно.
+func (c *BackupController) CheckIntegrity(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	report, err := c.backupService.CheckIntegrity(r.Context())
+	if err != nil {
+		writeBackupError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(report)
+}
+
+func writeBackupError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrBackupUnsupported):
+		w.WriteHeader(http.StatusNotImplemented)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	_ = json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+}
@@ -0,0 +1,179 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"warehouse-management-system/src/models"
+	"warehouse-management-system/src/services"
+
+	"github.com/gorilla/mux"
+)
+
+// BarcodeController обрабатывает HTTP-запросы, связанные со штрихкодами товаров.
+type BarcodeController struct {
+	barcodeService *services.BarcodeService
+}
+
+// NewBarcodeController — конструктор контроллера штрихкодов.
+func NewBarcodeController(barcodeService *services.BarcodeService) *BarcodeController {
+	return &BarcodeController{barcodeService: barcodeService}
+}
+
+// barcodeRequest описывает тело запроса на добавление штрихкода.
+// Без code сервер генерирует внутренний EAN-13; без type символика определяется по коду.
+type barcodeRequest struct {
+	Code      string             `json:"code"`
+	Type      models.BarcodeType `json:"type"`
+	Packaging string             `json:"packaging"`
+	Quantity  models.Decimal     `json:"quantity"`
+}
+
+// barcodeLookupResponse — товар, найденный по штрихкоду, и сам штрихкод (с упаковкой и количеством).
+type barcodeLookupResponse struct {
+	Product *models.Product `json:"product"`
+	Barcode *models.Barcode `json:"barcode"`
+}
+
+// GetBarcodes — список штрихкодов товара.
+func (c *BarcodeController) GetBarcodes(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	barcodes, err := c.barcodeService.ListBarcodes(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeBarcodeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(barcodes)
+}
+
+// CreateBarcode — добавление штрихкода товару.
+func (c *BarcodeController) CreateBarcode(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var req barcodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: "invalid request body"})
+		return
+	}
+
+	barcode, err := c.barcodeService.AddBarcode(r.Context(), mux.Vars(r)["id"], req.Code, req.Type, req.Packaging, req.Quantity)
+	if err != nil {
+		writeBarcodeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(barcode)
+}
+
+// DeleteBarcode — удаление штрихкода товара.
+func (c *BarcodeController) DeleteBarcode(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	vars := mux.Vars(r)
+	if err := c.barcodeService.RemoveBarcode(r.Context(), vars["id"], vars["code"]); err != nil {
+		writeBarcodeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetBarcodeImage — изображение штрихкода для печати.
+// Query-параметры: format (svg или png, по умолчанию svg), scale (ширина модуля в пикселях, 1–10),
+// height (высота полос в модулях), label=true — этикетка с названием товара и SKU.
+func (c *BarcodeController) GetBarcodeImage(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	q := r.URL.Query()
+	opts := services.BarcodeImageOptions{Format: services.BarcodeImageFormat(strings.ToLower(q.Get("format")))}
+	var err error
+	if opts.Scale, err = parseIntParam(q.Get("scale")); err != nil {
+		writeBarcodeError(w, services.ErrInvalidBarcodeImage)
+		return
+	}
+	if opts.Height, err = parseIntParam(q.Get("height")); err != nil {
+		writeBarcodeError(w, services.ErrInvalidBarcodeImage)
+		return
+	}
+	if opts.Label, err = parseBoolParam(q.Get("label")); err != nil {
+		writeBarcodeError(w, services.ErrInvalidBarcodeImage)
+		return
+	}
+
+	vars := mux.Vars(r)
+	img, err := c.barcodeService.BarcodeImage(r.Context(), vars["id"], vars["code"], opts)
+	if err != nil {
+		writeBarcodeError(w, err)
+		return
+	}
+
+	if opts.Format == "" {
+		opts.Format = services.BarcodeImageSVG
+	}
+	w.Header().Set("Content-Type", opts.Format.ContentType())
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(img)
+}
+
+// GetProductByBarcode — поиск товара по отсканированному штрихкоду.
+func (c *BarcodeController) GetProductByBarcode(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	product, barcode, err := c.barcodeService.FindByBarcode(r.Context(), mux.Vars(r)["code"])
+	if err != nil {
+		writeBarcodeError(w, err)
+		return
+	}
+
+	setETag(w, product.Version)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(barcodeLookupResponse{Product: product, Barcode: barcode})
+}
+
+// writeBarcodeError отвечает на ошибку операции со штрихкодом подходящим HTTP-статусом.
+func writeBarcodeError(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case errors.Is(err, models.ErrInvalidBarcode), errors.Is(err, models.ErrBarcodeChecksum),
+		err == services.ErrInvalidPackaging, err == services.ErrPackagingMismatch, err == models.ErrUnitNotDefined,
+		err == services.ErrQuantityPrecision,
+		err == services.ErrInvalidBarcodeImage, err == services.ErrInvalidProduct:
+		w.WriteHeader(http.StatusBadRequest)
+	case err == services.ErrProductNotFound, err == services.ErrBarcodeNotFound:
+		w.WriteHeader(http.StatusNotFound)
+	case err == services.ErrBarcodeAlreadyUsed, err == services.ErrProductArchived:
+		w.WriteHeader(http.StatusConflict)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	_ = json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+}
@@ -20,8 +20,15 @@ func NewCategoryController(categoryService *services.CategoryService) *CategoryC
 }
 
 // categoryRequest описывает тело запроса для создания/обновления категории.
+// Если parent_id не передан при обновлении, категория остаётся под прежним родителем.
 type categoryRequest struct {
-	Name string `json:"name"`
+	Name     string  `json:"name"`
+	ParentID *string `json:"parent_id"`
+}
+
+// moveCategoryRequest описывает тело запроса на перенос категории. Пустой parent_id — на верхний уровень.
+type moveCategoryRequest struct {
+	ParentID string `json:"parent_id"`
 }
 
 // GetCategories — получение списка категорий.
@@ -33,7 +40,14 @@ func (c *CategoryController) GetCategories(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	categories, err := c.categoryService.ListCategories()
+	includeArchived, err := parseBoolParam(r.URL.Query().Get("include_archived"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: "include_archived must be a boolean"})
+		return
+	}
+
+	categories, err := c.categoryService.ListCategories(r.Context(), includeArchived)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
@@ -44,6 +58,33 @@ func (c *CategoryController) GetCategories(w http.ResponseWriter, r *http.Reques
 	_ = json.NewEncoder(w).Encode(categories)
 }
 
+// GetCategoryTree — получение категорий в виде дерева.
+func (c *CategoryController) GetCategoryTree(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	includeArchived, err := parseBoolParam(r.URL.Query().Get("include_archived"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: "include_archived must be a boolean"})
+		return
+	}
+
+	tree, err := c.categoryService.CategoryTree(r.Context(), includeArchived)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(tree)
+}
+
 // GetCategory — получение категории по ID.
 func (c *CategoryController) GetCategory(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
@@ -61,7 +102,7 @@ func (c *CategoryController) GetCategory(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	category, err := c.categoryService.GetCategory(id)
+	category, err := c.categoryService.GetCategory(r.Context(), id)
 	if err != nil {
 		if err == services.ErrCategoryNotFound {
 			w.WriteHeader(http.StatusNotFound)
@@ -74,6 +115,7 @@ func (c *CategoryController) GetCategory(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	setETag(w, category.Version)
 	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(category)
 }
@@ -94,22 +136,23 @@ func (c *CategoryController) CreateCategory(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	category, err := c.categoryService.CreateCategory(req.Name)
+	var parentID string
+	if req.ParentID != nil {
+		parentID = *req.ParentID
+	}
+
+	category, err := c.categoryService.CreateCategory(r.Context(), req.Name, parentID)
 	if err != nil {
-		if err == services.ErrInvalidCategory {
-			w.WriteHeader(http.StatusBadRequest)
-		} else {
-			w.WriteHeader(http.StatusInternalServerError)
-		}
-		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		writeCategoryUpdateError(w, err)
 		return
 	}
 
+	setETag(w, category.Version)
 	w.WriteHeader(http.StatusCreated)
 	_ = json.NewEncoder(w).Encode(category)
 }
 
-// UpdateCategory — обновление категории по ID.
+// UpdateCategory — обновление категории по ID. Требует If-Match с ETag текущей версии категории.
 func (c *CategoryController) UpdateCategory(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
@@ -126,6 +169,11 @@ func (c *CategoryController) UpdateCategory(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	version, ok := requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
 	var req categoryRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
@@ -133,24 +181,60 @@ func (c *CategoryController) UpdateCategory(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	category, err := c.categoryService.UpdateCategory(id, req.Name)
+	category, err := c.categoryService.UpdateCategory(r.Context(), id, version, req.Name, req.ParentID)
 	if err != nil {
-		if err == services.ErrInvalidCategory {
-			w.WriteHeader(http.StatusBadRequest)
-		} else if err == services.ErrCategoryNotFound {
-			w.WriteHeader(http.StatusNotFound)
-		} else {
-			w.WriteHeader(http.StatusInternalServerError)
-		}
-		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		writeCategoryUpdateError(w, err)
+		return
+	}
+
+	setETag(w, category.Version)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(category)
+}
+
+// MoveCategory — перенос категории вместе с подкатегориями под другого родителя.
+// Требует If-Match с ETag текущей версии категории.
+func (c *CategoryController) MoveCategory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	vars := mux.Vars(r)
+	id := strings.TrimSpace(vars["id"])
+	if id == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: "id is required"})
 		return
 	}
 
+	version, ok := requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	var req moveCategoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: "invalid request body"})
+		return
+	}
+
+	category, err := c.categoryService.MoveCategory(r.Context(), id, version, req.ParentID)
+	if err != nil {
+		writeCategoryUpdateError(w, err)
+		return
+	}
+
+	setETag(w, category.Version)
 	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(category)
 }
 
-// DeleteCategory — удаление категории по ID.
+// DeleteCategory — удаление категории по ID: по умолчанию архивирует, с ?hard=true удаляет физически.
+// Требует If-Match с ETag текущей версии категории.
 func (c *CategoryController) DeleteCategory(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
@@ -167,15 +251,94 @@ func (c *CategoryController) DeleteCategory(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	if err := c.categoryService.DeleteCategory(id); err != nil {
-		if err == services.ErrInvalidCategory {
-			w.WriteHeader(http.StatusBadRequest)
-		} else {
-			w.WriteHeader(http.StatusInternalServerError)
-		}
-		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+	hard, err := parseBoolParam(r.URL.Query().Get("hard"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: "hard must be a boolean"})
+		return
+	}
+
+	version, ok := requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	if hard {
+		err = c.categoryService.DeleteCategory(r.Context(), id, version)
+	} else {
+		err = c.categoryService.ArchiveCategory(r.Context(), id, version)
+	}
+	if err != nil {
+		writeCategoryDeleteError(w, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// RestoreCategory — восстановление заархивированной категории. If-Match необязателен.
+func (c *CategoryController) RestoreCategory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	vars := mux.Vars(r)
+	id := strings.TrimSpace(vars["id"])
+	if id == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: "id is required"})
+		return
+	}
+
+	version, ok := optionalIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	restored, err := c.categoryService.RestoreCategory(r.Context(), id, version)
+	if err != nil {
+		writeCategoryDeleteError(w, err)
+		return
+	}
+
+	setETag(w, restored.Version)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(restored)
+}
+
+// writeCategoryUpdateError отвечает на ошибку создания, изменения или переноса категории.
+func writeCategoryUpdateError(w http.ResponseWriter, err error) {
+	switch err {
+	case services.ErrInvalidCategory, services.ErrParentCategoryNotFound:
+		w.WriteHeader(http.StatusBadRequest)
+	case services.ErrCategoryNotFound:
+		w.WriteHeader(http.StatusNotFound)
+	case services.ErrCategoryCycle:
+		w.WriteHeader(http.StatusConflict)
+	case services.ErrVersionMismatch:
+		w.WriteHeader(http.StatusPreconditionFailed)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	_ = json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+}
+
+// writeCategoryDeleteError отвечает на ошибку архивации, восстановления или удаления категории.
+func writeCategoryDeleteError(w http.ResponseWriter, err error) {
+	switch err {
+	case services.ErrInvalidCategory:
+		w.WriteHeader(http.StatusBadRequest)
+	case services.ErrCategoryNotFound:
+		w.WriteHeader(http.StatusNotFound)
+	case services.ErrCategoryInUse:
+		w.WriteHeader(http.StatusConflict)
+	case services.ErrVersionMismatch:
+		w.WriteHeader(http.StatusPreconditionFailed)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	_ = json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+}
@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"warehouse-management-system/src/services"
+)
+
+// ETag записи — её версия в кавычках, например "3". Клиент возвращает его в If-Match
+// при изменении записи, и сервис отклоняет изменение, если запись успели изменить.
+
+// setETag выставляет заголовок ETag по версии записи. Вызывается до WriteHeader.
+func setETag(w http.ResponseWriter, version int64) {
+	w.Header().Set("ETag", strconv.Quote(strconv.FormatInt(version, 10)))
+}
+
+// requireIfMatch извлекает ожидаемую версию из обязательного заголовка If-Match.
+// Без заголовка отвечает 428 Precondition Required, при некорректном значении — 400.
+// "If-Match: *" означает любую версию (services.AnyVersion).
+func requireIfMatch(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	if strings.TrimSpace(r.Header.Get("If-Match")) == "" {
+		w.WriteHeader(http.StatusPreconditionRequired)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: "If-Match header with the resource ETag is required"})
+		return 0, false
+	}
+	return optionalIfMatch(w, r)
+}
+
+// optionalIfMatch извлекает ожидаемую версию из If-Match, если заголовок передан (иначе services.AnyVersion).
+func optionalIfMatch(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	raw := strings.TrimSpace(r.Header.Get("If-Match"))
+	if raw == "" || raw == "*" {
+		return services.AnyVersion, true
+	}
+
+	// Слабые ETag (W/"...") для If-Match не годятся: сравнение должно быть строгим.
+	unquoted, err := strconv.Unquote(raw)
+	if err == nil {
+		if version, err := strconv.ParseInt(unquoted, 10, 64); err == nil && version > 0 {
+			return version, true
+		}
+	}
+	w.WriteHeader(http.StatusBadRequest)
+	_ = json.NewEncoder(w).Encode(ErrorResponse{Error: "If-Match must contain a single ETag, e.g. \"3\""})
+	return 0, false
+}
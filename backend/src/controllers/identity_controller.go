@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"warehouse-management-system/src/services"
+
+	"github.com/gorilla/mux"
+)
+
+// IdentityController обрабатывает HTTP-запросы администрирования привязок
+// пользователей к учётным записям внешних провайдеров (LDAP, OIDC).
+type IdentityController struct {
+	authService *services.AuthService
+}
+
+// NewIdentityController — конструктор контроллера привязок.
+func NewIdentityController(authService *services.AuthService) *IdentityController {
+	return &IdentityController{authService: authService}
+}
+
+// identityRequest — тело запроса на привязку внешней учётной записи.
+// Subject — sub из id_token для OIDC или DN записи для LDAP.
+type identityRequest struct {
+	Provider string `json:"provider"`
+	Subject  string `json:"subject"`
+}
+
+// GetUserIdentities — список внешних учётных записей пользователя.
+func (c *IdentityController) GetUserIdentities(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	identities, err := c.authService.ListIdentities(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeIdentityError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(identities)
+}
+
+// LinkUserIdentity — явная привязка внешней учётной записи к существующему пользователю.
+func (c *IdentityController) LinkUserIdentity(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var req identityRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: "invalid request body"})
+		return
+	}
+
+	identity, err := c.authService.LinkIdentity(r.Context(), mux.Vars(r)["id"],
+		strings.TrimSpace(req.Provider), strings.TrimSpace(req.Subject))
+	if err != nil {
+		writeIdentityError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(identity)
+}
+
+// UnlinkUserIdentity — удаление привязки. Provider и subject передаются query-параметрами:
+// DN записи LDAP может содержать символы, неудобные для сегмента пути.
+func (c *IdentityController) UnlinkUserIdentity(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	q := r.URL.Query()
+	if err := c.authService.UnlinkIdentity(r.Context(), mux.Vars(r)["id"], q.Get("provider"), q.Get("subject")); err != nil {
+		writeIdentityError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeIdentityError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrUserNotFound), errors.Is(err, services.ErrIdentityNotFound):
+		w.WriteHeader(http.StatusNotFound)
+	case errors.Is(err, services.ErrInvalidIdentity), errors.Is(err, services.ErrUnknownProvider):
+		w.WriteHeader(http.StatusBadRequest)
+	case errors.Is(err, services.ErrIdentityLinked):
+		w.WriteHeader(http.StatusConflict)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	_ = json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+}
@@ -22,9 +22,11 @@ func NewOrderController(orderService *services.OrderService) *OrderController {
 
 // orderItemRequest описывает одну позицию в заказе.
 type orderItemRequest struct {
-	ProductID string  `json:"product_id"`
-	Quantity  float64 `json:"quantity"`
-	Price     float64 `json:"price"`
+	ProductID string               `json:"product_id"`
+	Quantity  models.Decimal       `json:"quantity"`
+	Unit      models.UnitOfMeasure `json:"unit"`
+	Price     models.Decimal       `json:"price"`
+	Currency  string               `json:"currency"`
 }
 
 // createOrderRequest — тело запроса на создание заказа.
@@ -47,7 +49,7 @@ func (c *OrderController) GetOrders(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	orders, err := c.orderService.ListOrders()
+	orders, err := c.orderService.ListOrders(r.Context())
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
@@ -75,7 +77,7 @@ func (c *OrderController) GetOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	order, err := c.orderService.GetOrder(id)
+	order, err := c.orderService.GetOrder(r.Context(), id)
 	if err != nil {
 		if err == services.ErrOrderNotFound {
 			w.WriteHeader(http.StatusNotFound)
@@ -88,6 +90,7 @@ func (c *OrderController) GetOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	setETag(w, order.Version)
 	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(order)
 }
@@ -113,15 +116,20 @@ func (c *OrderController) CreateOrder(w http.ResponseWriter, r *http.Request) {
 		items = append(items, models.OrderItem{
 			ProductID: it.ProductID,
 			Quantity:  it.Quantity,
+			Unit:      it.Unit,
 			Price:     it.Price,
+			Currency:  it.Currency,
 		})
 	}
 
-	order, err := c.orderService.CreateOrder(req.Customer, items)
+	order, err := c.orderService.CreateOrder(r.Context(), req.Customer, items)
 	if err != nil {
-		if err == services.ErrInvalidOrder {
+		if err == services.ErrInvalidOrder || err == services.ErrQuantityPrecision || err == services.ErrInvalidPrice ||
+			err == services.ErrInvalidUnit || err == models.ErrUnitNotDefined {
 			w.WriteHeader(http.StatusBadRequest)
-		} else if err == services.ErrInsufficientStock {
+		} else if err == services.ErrProductNotFound {
+			w.WriteHeader(http.StatusNotFound)
+		} else if err == services.ErrInsufficientStock || err == services.ErrProductArchived {
 			w.WriteHeader(http.StatusConflict)
 		} else {
 			w.WriteHeader(http.StatusInternalServerError)
@@ -130,11 +138,12 @@ func (c *OrderController) CreateOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	setETag(w, order.Version)
 	w.WriteHeader(http.StatusCreated)
 	_ = json.NewEncoder(w).Encode(order)
 }
 
-// UpdateOrderStatus — обновление статуса заказа.
+// UpdateOrderStatus — обновление статуса заказа. Требует If-Match с ETag текущей версии заказа.
 func (c *OrderController) UpdateOrderStatus(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
@@ -151,6 +160,11 @@ func (c *OrderController) UpdateOrderStatus(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	version, ok := requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
 	var req updateStatusRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
@@ -158,7 +172,7 @@ func (c *OrderController) UpdateOrderStatus(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	order, err := c.orderService.UpdateOrderStatus(id, req.Status)
+	order, err := c.orderService.UpdateOrderStatus(r.Context(), id, version, req.Status)
 	if err != nil {
 		if err == services.ErrInvalidOrder {
 			w.WriteHeader(http.StatusBadRequest)
@@ -166,6 +180,8 @@ func (c *OrderController) UpdateOrderStatus(w http.ResponseWriter, r *http.Reque
 			w.WriteHeader(http.StatusNotFound)
 		} else if err == services.ErrOrderBadStatus {
 			w.WriteHeader(http.StatusConflict)
+		} else if err == services.ErrVersionMismatch {
+			w.WriteHeader(http.StatusPreconditionFailed)
 		} else {
 			w.WriteHeader(http.StatusInternalServerError)
 		}
@@ -173,6 +189,7 @@ func (c *OrderController) UpdateOrderStatus(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	setETag(w, order.Version)
 	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(order)
 }
@@ -0,0 +1,277 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+	"warehouse-management-system/src/models"
+	"warehouse-management-system/src/services"
+
+	"github.com/gorilla/mux"
+)
+
+// PriceListController обрабатывает HTTP-запросы, связанные с прайс-листами и ценами продажи.
+type PriceListController struct {
+	priceListService *services.PriceListService
+}
+
+// NewPriceListController — конструктор контроллера прайс-листов.
+func NewPriceListController(priceListService *services.PriceListService) *PriceListController {
+	return &PriceListController{priceListService: priceListService}
+}
+
+// priceListRequest описывает тело запроса на создание или изменение прайс-листа.
+// Даты — в формате RFC3339; без valid_from прайс-лист действует с момента создания,
+// без valid_to — бессрочно.
+type priceListRequest struct {
+	Name      string `json:"name"`
+	Currency  string `json:"currency"`
+	Customer  string `json:"customer"`
+	ValidFrom string `json:"valid_from"`
+	ValidTo   string `json:"valid_to"`
+}
+
+// priceItemRequest описывает тело запроса на задание цены товара в прайс-листе.
+type priceItemRequest struct {
+	Price models.Decimal `json:"price"`
+}
+
+// GetPriceLists — список прайс-листов без позиций.
+func (c *PriceListController) GetPriceLists(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	lists, err := c.priceListService.ListPriceLists(r.Context())
+	if err != nil {
+		writePriceListError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(lists)
+}
+
+// GetPriceList — прайс-лист вместе с позициями.
+func (c *PriceListController) GetPriceList(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	list, err := c.priceListService.GetPriceList(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writePriceListError(w, err)
+		return
+	}
+
+	setETag(w, list.Version)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(list)
+}
+
+// CreatePriceList — создание прайс-листа.
+func (c *PriceListController) CreatePriceList(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	req, validFrom, validTo, ok := decodePriceListRequest(w, r)
+	if !ok {
+		return
+	}
+
+	list, err := c.priceListService.CreatePriceList(r.Context(), req.Name, req.Currency, req.Customer, validFrom, validTo)
+	if err != nil {
+		writePriceListError(w, err)
+		return
+	}
+
+	setETag(w, list.Version)
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(list)
+}
+
+// UpdatePriceList — изменение реквизитов прайс-листа (заголовок If-Match обязателен).
+func (c *PriceListController) UpdatePriceList(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	version, ok := requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	req, validFrom, validTo, ok := decodePriceListRequest(w, r)
+	if !ok {
+		return
+	}
+
+	list, err := c.priceListService.UpdatePriceList(r.Context(), mux.Vars(r)["id"], version, req.Name, req.Currency, req.Customer, validFrom, validTo)
+	if err != nil {
+		writePriceListError(w, err)
+		return
+	}
+
+	setETag(w, list.Version)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(list)
+}
+
+// DeletePriceList — удаление прайс-листа вместе с позициями (заголовок If-Match обязателен).
+func (c *PriceListController) DeletePriceList(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	version, ok := requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	if err := c.priceListService.DeletePriceList(r.Context(), mux.Vars(r)["id"], version); err != nil {
+		writePriceListError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SetItem — задание цены товара в прайс-листе.
+func (c *PriceListController) SetItem(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var req priceItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: "invalid request body"})
+		return
+	}
+
+	vars := mux.Vars(r)
+	item, err := c.priceListService.SetItem(r.Context(), vars["id"], vars["productId"], req.Price)
+	if err != nil {
+		writePriceListError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(item)
+}
+
+// DeleteItem — удаление цены товара из прайс-листа.
+func (c *PriceListController) DeleteItem(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	vars := mux.Vars(r)
+	if err := c.priceListService.DeleteItem(r.Context(), vars["id"], vars["productId"]); err != nil {
+		writePriceListError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetProductPrice — действующая цена продажи товара: ?customer= выбирает прайс-лист покупателя,
+// ?currency= ограничивает валюту, ?at= (RFC3339) задаёт момент вместо текущего.
+func (c *PriceListController) GetProductPrice(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	q := r.URL.Query()
+	at, err := parseTimeParam(q.Get("at"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: "invalid at format, expected RFC3339"})
+		return
+	}
+	moment := time.Now()
+	if at != nil {
+		moment = *at
+	}
+
+	price, err := c.priceListService.ResolvePrice(r.Context(), mux.Vars(r)["id"], q.Get("customer"), q.Get("currency"), moment)
+	if err != nil {
+		writePriceListError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(price)
+}
+
+// decodePriceListRequest читает тело запроса прайс-листа и разбирает даты; при ошибке отвечает 400.
+func decodePriceListRequest(w http.ResponseWriter, r *http.Request) (priceListRequest, time.Time, *time.Time, bool) {
+	var req priceListRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: "invalid request body"})
+		return req, time.Time{}, nil, false
+	}
+
+	validFrom, err := parseTimeParam(req.ValidFrom)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: "invalid valid_from format, expected RFC3339"})
+		return req, time.Time{}, nil, false
+	}
+	validTo, err := parseTimeParam(req.ValidTo)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: "invalid valid_to format, expected RFC3339"})
+		return req, time.Time{}, nil, false
+	}
+
+	var from time.Time
+	if validFrom != nil {
+		from = *validFrom
+	}
+	return req, from, validTo, true
+}
+
+// writePriceListError отвечает на ошибку операции с прайс-листами подходящим HTTP-статусом.
+func writePriceListError(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	switch err {
+	case services.ErrInvalidPriceList, services.ErrInvalidPrice, services.ErrInvalidProduct:
+		w.WriteHeader(http.StatusBadRequest)
+	case services.ErrPriceListNotFound, services.ErrPriceNotFound, services.ErrProductNotFound:
+		w.WriteHeader(http.StatusNotFound)
+	case services.ErrProductArchived:
+		w.WriteHeader(http.StatusConflict)
+	case services.ErrVersionMismatch:
+		w.WriteHeader(http.StatusPreconditionFailed)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	_ = json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+}
@@ -1,9 +1,13 @@
 package controllers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
 	"strings"
+	"warehouse-management-system/src/models"
 	"warehouse-management-system/src/services"
 
 	"github.com/gorilla/mux"
@@ -31,7 +35,11 @@ type productRequest struct {
 }
 
 // GetProducts — обработчик получения списка товаров.
-// В будущем сюда можно добавить поддержку пагинации, фильтрации и сортировки через query-параметры.
+// Query-параметры: q (поиск по SKU, названию и описанию), category_id, supplier_id,
+// parent_id (варианты шаблона), include_archived,
+// sort (created_at, updated_at, name, sku; с минусом — по убыванию, по умолчанию -created_at),
+// limit, offset или cursor (из заголовка X-Next-Cursor предыдущего ответа).
+// Тело ответа — массив товаров; общее число подходящих товаров — в заголовке X-Total-Count.
 func (c *ProductController) GetProducts(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
@@ -40,15 +48,97 @@ func (c *ProductController) GetProducts(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	products, err := c.productService.ListProducts()
+	q := r.URL.Query()
+	filter := models.ProductFilter{
+		Query:      q.Get("q"),
+		CategoryID: q.Get("category_id"),
+		SupplierID: q.Get("supplier_id"),
+		ParentID:   q.Get("parent_id"),
+	}
+
+	var err error
+	if filter.IncludeArchived, err = parseBoolParam(q.Get("include_archived")); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: "include_archived must be a boolean"})
+		return
+	}
+	if sort := q.Get("sort"); sort != "" {
+		filter.Desc = strings.HasPrefix(sort, "-")
+		filter.Sort = models.ProductSortField(strings.TrimPrefix(sort, "-"))
+	}
+	if filter.Limit, err = parseIntParam(q.Get("limit")); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: "limit must be an integer"})
+		return
+	}
+	if filter.Offset, err = parseIntParam(q.Get("offset")); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: "offset must be an integer"})
+		return
+	}
+	if cursor := q.Get("cursor"); cursor != "" {
+		if filter.After, err = models.DecodeProductCursor(cursor); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+
+	page, err := c.productService.ListProducts(r.Context(), filter)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		if err == services.ErrInvalidProductFilter || err == models.ErrInvalidCursor {
+			w.WriteHeader(http.StatusBadRequest)
+		} else {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
 		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
 		return
 	}
 
+	w.Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+	if page.NextCursor != "" {
+		w.Header().Set("X-Next-Cursor", page.NextCursor)
+	}
 	w.WriteHeader(http.StatusOK)
-	_ = json.NewEncoder(w).Encode(products)
+	_ = json.NewEncoder(w).Encode(page.Items)
+}
+
+// SearchProducts — обработчик поиска товаров: GET /products/search?q=...&limit=...
+// Возвращает найденные товары по убыванию релевантности с подсветкой совпадений.
+func (c *ProductController) SearchProducts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	q := r.URL.Query()
+	if strings.TrimSpace(q.Get("q")) == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: "q is required"})
+		return
+	}
+	limit, err := parseIntParam(q.Get("limit"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: "limit must be an integer"})
+		return
+	}
+
+	hits, err := c.productService.SearchProducts(r.Context(), q.Get("q"), limit)
+	if err != nil {
+		if err == services.ErrInvalidProductFilter {
+			w.WriteHeader(http.StatusBadRequest)
+		} else {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(hits)
 }
 
 // GetProduct — обработчик получения товара по ID.
@@ -68,7 +158,7 @@ func (c *ProductController) GetProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	product, err := c.productService.GetProduct(id)
+	product, err := c.productService.GetProduct(r.Context(), id)
 	if err != nil {
 		if err == services.ErrProductNotFound {
 			w.WriteHeader(http.StatusNotFound)
@@ -79,6 +169,7 @@ func (c *ProductController) GetProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	setETag(w, product.Version)
 	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(product)
 }
@@ -100,6 +191,7 @@ func (c *ProductController) CreateProduct(w http.ResponseWriter, r *http.Request
 	}
 
 	product, err := c.productService.CreateProduct(
+		r.Context(),
 		req.SKU,
 		req.Name,
 		req.Description,
@@ -108,8 +200,13 @@ func (c *ProductController) CreateProduct(w http.ResponseWriter, r *http.Request
 		req.Unit,
 	)
 	if err != nil {
+		var refErr *services.ReferenceError
+		if errors.As(err, &refErr) {
+			writeReferenceError(w, refErr)
+			return
+		}
 		switch err {
-		case services.ErrInvalidProduct:
+		case services.ErrInvalidProduct, services.ErrInvalidUnit:
 			w.WriteHeader(http.StatusBadRequest)
 		case services.ErrSKUAlreadyUsed:
 			w.WriteHeader(http.StatusConflict)
@@ -120,11 +217,12 @@ func (c *ProductController) CreateProduct(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	setETag(w, product.Version)
 	w.WriteHeader(http.StatusCreated)
 	_ = json.NewEncoder(w).Encode(product)
 }
 
-// UpdateProduct — обработчик обновления товара по ID.
+// UpdateProduct — обработчик обновления товара по ID. Требует If-Match с ETag текущей версии товара.
 func (c *ProductController) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
@@ -141,6 +239,11 @@ func (c *ProductController) UpdateProduct(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	version, ok := requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
 	var req productRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
@@ -149,7 +252,9 @@ func (c *ProductController) UpdateProduct(w http.ResponseWriter, r *http.Request
 	}
 
 	product, err := c.productService.UpdateProduct(
+		r.Context(),
 		id,
+		version,
 		req.SKU,
 		req.Name,
 		req.Description,
@@ -158,13 +263,20 @@ func (c *ProductController) UpdateProduct(w http.ResponseWriter, r *http.Request
 		req.Unit,
 	)
 	if err != nil {
+		var refErr *services.ReferenceError
+		if errors.As(err, &refErr) {
+			writeReferenceError(w, refErr)
+			return
+		}
 		switch err {
-		case services.ErrInvalidProduct:
+		case services.ErrInvalidProduct, services.ErrInvalidUnit:
 			w.WriteHeader(http.StatusBadRequest)
 		case services.ErrProductNotFound:
 			w.WriteHeader(http.StatusNotFound)
-		case services.ErrSKUAlreadyUsed:
+		case services.ErrSKUAlreadyUsed, services.ErrUnitInUse, services.ErrUnitHasStock, services.ErrVariantUnit:
 			w.WriteHeader(http.StatusConflict)
+		case services.ErrVersionMismatch:
+			w.WriteHeader(http.StatusPreconditionFailed)
 		default:
 			w.WriteHeader(http.StatusInternalServerError)
 		}
@@ -172,11 +284,13 @@ func (c *ProductController) UpdateProduct(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	setETag(w, product.Version)
 	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(product)
 }
 
-// DeleteProduct — обработчик удаления товара по ID.
+// DeleteProduct — обработчик удаления товара по ID: по умолчанию архивирует, с ?hard=true удаляет физически.
+// Требует If-Match с ETag текущей версии товара.
 func (c *ProductController) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
@@ -193,9 +307,192 @@ func (c *ProductController) DeleteProduct(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	err := c.productService.DeleteProduct(id)
+	hard, err := parseBoolParam(r.URL.Query().Get("hard"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: "hard must be a boolean"})
+		return
+	}
+
+	version, ok := requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	if hard {
+		err = c.productService.DeleteProduct(r.Context(), id, version)
+	} else {
+		err = c.productService.ArchiveProduct(r.Context(), id, version)
+	}
+	if err != nil {
+		writeProductDeleteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RestoreProduct — обработчик восстановления заархивированного товара. If-Match необязателен.
+func (c *ProductController) RestoreProduct(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	vars := mux.Vars(r)
+	id := strings.TrimSpace(vars["id"])
+	if id == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: "id is required"})
+		return
+	}
+
+	version, ok := optionalIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	restored, err := c.productService.RestoreProduct(r.Context(), id, version)
 	if err != nil {
-		if err == services.ErrInvalidProduct {
+		writeProductDeleteError(w, err)
+		return
+	}
+
+	setETag(w, restored.Version)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(restored)
+}
+
+// writeReferenceError отвечает 422 Unprocessable Entity с перечнем полей, ссылающихся
+// на несуществующие или архивные записи.
+func writeReferenceError(w http.ResponseWriter, err *services.ReferenceError) {
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	_ = json.NewEncoder(w).Encode(ValidationErrorResponse{
+		Error:  services.ErrInvalidReference.Error(),
+		Fields: fieldErrors(err),
+	})
+}
+
+// fieldErrors переводит ошибки полей сервиса в формат ответа.
+func fieldErrors(err *services.ReferenceError) []FieldErrorResponse {
+	fields := make([]FieldErrorResponse, len(err.Fields))
+	for i, f := range err.Fields {
+		fields[i] = FieldErrorResponse{Field: f.Field, Message: f.Message}
+	}
+	return fields
+}
+
+// writeProductDeleteError отвечает на ошибку архивации, восстановления или удаления товара.
+func writeProductDeleteError(w http.ResponseWriter, err error) {
+	switch err {
+	case services.ErrInvalidProduct:
+		w.WriteHeader(http.StatusBadRequest)
+	case services.ErrProductNotFound:
+		w.WriteHeader(http.StatusNotFound)
+	case services.ErrProductInUse:
+		w.WriteHeader(http.StatusConflict)
+	case services.ErrVersionMismatch:
+		w.WriteHeader(http.StatusPreconditionFailed)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	_ = json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+}
+
+// productBatchRequest — тело пакетного запроса: строки пакета в поле items.
+// Для изменения и удаления в строке нужны id и version (версия товара, как в If-Match).
+type productBatchRequest struct {
+	Items []struct {
+		ID      string `json:"id"`
+		Version int64  `json:"version"`
+		productRequest
+	} `json:"items"`
+}
+
+// productBatchRowResponse — результат строки пакета: HTTP-статус, который получил бы такой же
+// одиночный запрос, и товар или ошибка.
+type productBatchRowResponse struct {
+	Index   int                  `json:"index"`
+	Status  int                  `json:"status"`
+	Product *models.Product      `json:"product,omitempty"`
+	Error   string               `json:"error,omitempty"`
+	Fields  []FieldErrorResponse `json:"fields,omitempty"`
+}
+
+// productBatchResponse — ответ на пакетный запрос. Applied — сохранены ли изменения (все вместе).
+type productBatchResponse struct {
+	Applied bool                      `json:"applied"`
+	Error   string                    `json:"error,omitempty"`
+	Results []productBatchRowResponse `json:"results"`
+}
+
+// BatchCreateProducts — пакетное создание товаров: POST /products/batch.
+func (c *ProductController) BatchCreateProducts(w http.ResponseWriter, r *http.Request) {
+	c.batch(w, r, http.StatusCreated, c.productService.BatchCreateProducts)
+}
+
+// BatchUpdateProducts — пакетное изменение товаров: PUT /products/batch.
+func (c *ProductController) BatchUpdateProducts(w http.ResponseWriter, r *http.Request) {
+	c.batch(w, r, http.StatusOK, c.productService.BatchUpdateProducts)
+}
+
+// BatchDeleteProducts — пакетное удаление товаров: POST /products/batch/delete.
+// По умолчанию товары архивируются (строка — 200 с товаром), с ?hard=true удаляются физически (204).
+func (c *ProductController) BatchDeleteProducts(w http.ResponseWriter, r *http.Request) {
+	hard, err := parseBoolParam(r.URL.Query().Get("hard"))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: "hard must be a boolean"})
+		return
+	}
+	okStatus := http.StatusOK
+	if hard {
+		okStatus = http.StatusNoContent
+	}
+	c.batch(w, r, okStatus, func(ctx context.Context, items []services.ProductBatchItem) ([]services.ProductBatchResult, error) {
+		return c.productService.BatchDeleteProducts(ctx, items, hard)
+	})
+}
+
+// batch разбирает пакетный запрос, выполняет его через apply и отвечает результатами строк.
+// Если пакет отклонён, ответ — 422 Unprocessable Entity, и ни одно изменение не сохранено.
+// okStatus — статус успешной строки.
+func (c *ProductController) batch(w http.ResponseWriter, r *http.Request, okStatus int,
+	apply func(context.Context, []services.ProductBatchItem) ([]services.ProductBatchResult, error)) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var req productBatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: "invalid request body"})
+		return
+	}
+
+	items := make([]services.ProductBatchItem, len(req.Items))
+	for i, item := range req.Items {
+		items[i] = services.ProductBatchItem{
+			ID:          item.ID,
+			Version:     item.Version,
+			SKU:         item.SKU,
+			Name:        item.Name,
+			Description: item.Description,
+			CategoryID:  item.CategoryID,
+			SupplierID:  item.SupplierID,
+			Unit:        item.Unit,
+		}
+	}
+
+	results, err := apply(r.Context(), items)
+	if err != nil && err != services.ErrProductBatchRejected {
+		if err == services.ErrInvalidProductBatch {
 			w.WriteHeader(http.StatusBadRequest)
 		} else {
 			w.WriteHeader(http.StatusInternalServerError)
@@ -204,5 +501,46 @@ func (c *ProductController) DeleteProduct(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	w.WriteHeader(http.StatusNoContent)
+	resp := productBatchResponse{Applied: err == nil, Results: make([]productBatchRowResponse, len(results))}
+	for i, res := range results {
+		row := productBatchRowResponse{Index: res.Index, Status: okStatus, Product: res.Product}
+		var refErr *services.ReferenceError
+		if errors.As(res.Err, &refErr) {
+			row.Status = http.StatusUnprocessableEntity
+			row.Error = services.ErrInvalidReference.Error()
+			row.Fields = fieldErrors(refErr)
+		} else if res.Err != nil {
+			row.Status = productBatchRowStatus(res.Err)
+			row.Error = res.Err.Error()
+		}
+		resp.Results[i] = row
+	}
+	if err != nil {
+		resp.Error = err.Error()
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// productBatchRowStatus сопоставляет ошибку строки пакета HTTP-статусу.
+func productBatchRowStatus(err error) int {
+	switch err {
+	case services.ErrInvalidProduct, services.ErrInvalidUnit:
+		return http.StatusBadRequest
+	case services.ErrProductNotFound:
+		return http.StatusNotFound
+	case services.ErrSKUAlreadyUsed, services.ErrUnitInUse, services.ErrUnitHasStock, services.ErrVariantUnit,
+		services.ErrProductInUse, services.ErrBatchDuplicateRow:
+		return http.StatusConflict
+	case services.ErrVersionMismatch:
+		return http.StatusPreconditionFailed
+	case services.ErrBatchVersionRequired:
+		return http.StatusPreconditionRequired
+	case services.ErrBatchRowNotApplied:
+		return http.StatusFailedDependency
+	default:
+		return http.StatusInternalServerError
+	}
 }
@@ -5,4 +5,16 @@ type ErrorResponse struct {
 	Error string `json:"error"`
 }
 
+// ValidationErrorResponse — ошибка с перечнем неверных полей запроса (422 Unprocessable Entity).
+type ValidationErrorResponse struct {
+	Error  string               `json:"error"`
+	Fields []FieldErrorResponse `json:"fields"`
+}
+
+// FieldErrorResponse — ошибка значения одного поля запроса.
+type FieldErrorResponse struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
 
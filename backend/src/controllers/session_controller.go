@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"warehouse-management-system/src/services"
+
+	"github.com/gorilla/mux"
+)
+
+// SessionController обрабатывает HTTP-запросы администрирования сессий пользователей.
+type SessionController struct {
+	sessionService *services.SessionService
+}
+
+// NewSessionController — конструктор контроллера сессий.
+func NewSessionController(sessionService *services.SessionService) *SessionController {
+	return &SessionController{sessionService: sessionService}
+}
+
+// revokeSessionsResponse — ответ на принудительный выход пользователя со всех устройств.
+type revokeSessionsResponse struct {
+	Revoked int `json:"revoked"`
+}
+
+// GetUserSessions — список активных сессий пользователя.
+func (c *SessionController) GetUserSessions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	sessions, err := c.sessionService.ListUserSessions(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeSessionError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(sessions)
+}
+
+// RevokeUserSessions — завершение всех сессий пользователя.
+func (c *SessionController) RevokeUserSessions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	revoked, err := c.sessionService.RevokeUserSessions(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeSessionError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(revokeSessionsResponse{Revoked: revoked})
+}
+
+// RevokeUserSession — завершение одной сессии пользователя.
+func (c *SessionController) RevokeUserSession(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	vars := mux.Vars(r)
+	if err := c.sessionService.RevokeSession(r.Context(), vars["id"], vars["sessionId"]); err != nil {
+		writeSessionError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeSessionError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrUserNotFound), errors.Is(err, services.ErrSessionNotFound):
+		w.WriteHeader(http.StatusNotFound)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	_ = json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+}
@@ -36,7 +36,14 @@ func (c *SupplierController) GetSuppliers(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	suppliers, err := c.supplierService.ListSuppliers()
+	includeArchived, err := parseBoolParam(r.URL.Query().Get("include_archived"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: "include_archived must be a boolean"})
+		return
+	}
+
+	suppliers, err := c.supplierService.ListSuppliers(r.Context(), includeArchived)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
@@ -64,7 +71,7 @@ func (c *SupplierController) GetSupplier(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	supplier, err := c.supplierService.GetSupplier(id)
+	supplier, err := c.supplierService.GetSupplier(r.Context(), id)
 	if err != nil {
 		if err == services.ErrSupplierNotFound {
 			w.WriteHeader(http.StatusNotFound)
@@ -77,6 +84,7 @@ func (c *SupplierController) GetSupplier(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	setETag(w, supplier.Version)
 	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(supplier)
 }
@@ -98,6 +106,7 @@ func (c *SupplierController) CreateSupplier(w http.ResponseWriter, r *http.Reque
 	}
 
 	supplier, err := c.supplierService.CreateSupplier(
+		r.Context(),
 		req.Name,
 		req.Address,
 		req.Phone,
@@ -113,11 +122,12 @@ func (c *SupplierController) CreateSupplier(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	setETag(w, supplier.Version)
 	w.WriteHeader(http.StatusCreated)
 	_ = json.NewEncoder(w).Encode(supplier)
 }
 
-// UpdateSupplier — обновление данных поставщика.
+// UpdateSupplier — обновление данных поставщика. Требует If-Match с ETag текущей версии поставщика.
 func (c *SupplierController) UpdateSupplier(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
@@ -134,6 +144,11 @@ func (c *SupplierController) UpdateSupplier(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	version, ok := requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
 	var req supplierRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
@@ -142,7 +157,9 @@ func (c *SupplierController) UpdateSupplier(w http.ResponseWriter, r *http.Reque
 	}
 
 	supplier, err := c.supplierService.UpdateSupplier(
+		r.Context(),
 		id,
+		version,
 		req.Name,
 		req.Address,
 		req.Phone,
@@ -153,6 +170,8 @@ func (c *SupplierController) UpdateSupplier(w http.ResponseWriter, r *http.Reque
 			w.WriteHeader(http.StatusBadRequest)
 		} else if err == services.ErrSupplierNotFound {
 			w.WriteHeader(http.StatusNotFound)
+		} else if err == services.ErrVersionMismatch {
+			w.WriteHeader(http.StatusPreconditionFailed)
 		} else {
 			w.WriteHeader(http.StatusInternalServerError)
 		}
@@ -160,11 +179,13 @@ func (c *SupplierController) UpdateSupplier(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	setETag(w, supplier.Version)
 	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(supplier)
 }
 
-// DeleteSupplier — удаление поставщика по ID.
+// DeleteSupplier — удаление поставщика по ID: по умолчанию архивирует, с ?hard=true удаляет физически.
+// Требует If-Match с ETag текущей версии поставщика.
 func (c *SupplierController) DeleteSupplier(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
@@ -181,17 +202,79 @@ func (c *SupplierController) DeleteSupplier(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	if err := c.supplierService.DeleteSupplier(id); err != nil {
-		if err == services.ErrInvalidSupplier {
-			w.WriteHeader(http.StatusBadRequest)
-		} else {
-			w.WriteHeader(http.StatusInternalServerError)
-		}
-		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+	hard, err := parseBoolParam(r.URL.Query().Get("hard"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: "hard must be a boolean"})
+		return
+	}
+
+	version, ok := requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	if hard {
+		err = c.supplierService.DeleteSupplier(r.Context(), id, version)
+	} else {
+		err = c.supplierService.ArchiveSupplier(r.Context(), id, version)
+	}
+	if err != nil {
+		writeSupplierDeleteError(w, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// RestoreSupplier — восстановление заархивированного поставщика. If-Match необязателен.
+func (c *SupplierController) RestoreSupplier(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	vars := mux.Vars(r)
+	id := strings.TrimSpace(vars["id"])
+	if id == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: "id is required"})
+		return
+	}
+
+	version, ok := optionalIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	restored, err := c.supplierService.RestoreSupplier(r.Context(), id, version)
+	if err != nil {
+		writeSupplierDeleteError(w, err)
+		return
+	}
+
+	setETag(w, restored.Version)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(restored)
+}
+
+// writeSupplierDeleteError отвечает на ошибку архивации, восстановления или удаления поставщика.
+func writeSupplierDeleteError(w http.ResponseWriter, err error) {
+	switch err {
+	case services.ErrInvalidSupplier:
+		w.WriteHeader(http.StatusBadRequest)
+	case services.ErrSupplierNotFound:
+		w.WriteHeader(http.StatusNotFound)
+	case services.ErrSupplierInUse:
+		w.WriteHeader(http.StatusConflict)
+	case services.ErrVersionMismatch:
+		w.WriteHeader(http.StatusPreconditionFailed)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	_ = json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+}
+
 
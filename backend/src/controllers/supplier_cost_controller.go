@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"warehouse-management-system/src/models"
+	"warehouse-management-system/src/services"
+
+	"github.com/gorilla/mux"
+)
+
+// SupplierCostController обрабатывает HTTP-запросы, связанные с закупочными условиями поставщиков.
+type SupplierCostController struct {
+	supplierCostService *services.SupplierCostService
+}
+
+// NewSupplierCostController — конструктор контроллера закупочных условий.
+func NewSupplierCostController(supplierCostService *services.SupplierCostService) *SupplierCostController {
+	return &SupplierCostController{supplierCostService: supplierCostService}
+}
+
+// supplierCostRequest описывает тело запроса на задание условий поставщика на товар.
+// cost — за базовую единицу товара, min_order_quantity — в базовых единицах.
+type supplierCostRequest struct {
+	SupplierSKU      string         `json:"supplier_sku"`
+	Cost             models.Decimal `json:"cost"`
+	Currency         string         `json:"currency"`
+	LeadTimeDays     int            `json:"lead_time_days"`
+	MinOrderQuantity models.Decimal `json:"min_order_quantity"`
+}
+
+// GetProductSuppliers — условия всех поставщиков товара.
+func (c *SupplierCostController) GetProductSuppliers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	costs, err := c.supplierCostService.ListProductSuppliers(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeSupplierCostError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(costs)
+}
+
+// SetSupplierCost — задание или изменение условий поставщика на товар.
+func (c *SupplierCostController) SetSupplierCost(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var req supplierCostRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: "invalid request body"})
+		return
+	}
+
+	vars := mux.Vars(r)
+	cost, err := c.supplierCostService.SetSupplierCost(r.Context(), vars["id"], vars["supplierId"],
+		req.SupplierSKU, req.Cost, req.Currency, req.LeadTimeDays, req.MinOrderQuantity)
+	if err != nil {
+		writeSupplierCostError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(cost)
+}
+
+// DeleteSupplierCost — удаление условий поставщика на товар.
+func (c *SupplierCostController) DeleteSupplierCost(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	vars := mux.Vars(r)
+	if err := c.supplierCostService.DeleteSupplierCost(r.Context(), vars["id"], vars["supplierId"]); err != nil {
+		writeSupplierCostError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeSupplierCostError отвечает на ошибку операции с закупочными условиями подходящим HTTP-статусом.
+func writeSupplierCostError(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	switch err {
+	case services.ErrInvalidSupplierCost, services.ErrInvalidPrice, services.ErrQuantityPrecision,
+		services.ErrInvalidProduct, services.ErrInvalidSupplier:
+		w.WriteHeader(http.StatusBadRequest)
+	case services.ErrProductNotFound, services.ErrSupplierNotFound, services.ErrSupplierCostNotFound:
+		w.WriteHeader(http.StatusNotFound)
+	case services.ErrProductArchived, services.ErrSupplierArchived:
+		w.WriteHeader(http.StatusConflict)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	_ = json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+}
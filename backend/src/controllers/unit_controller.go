@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"warehouse-management-system/src/models"
+	"warehouse-management-system/src/services"
+
+	"github.com/gorilla/mux"
+)
+
+// UnitController обрабатывает HTTP-запросы, связанные с единицами измерения товаров.
+type UnitController struct {
+	unitService *services.UnitService
+}
+
+// NewUnitController — конструктор контроллера единиц измерения.
+func NewUnitController(unitService *services.UnitService) *UnitController {
+	return &UnitController{unitService: unitService}
+}
+
+// unitRequest описывает тело запроса на задание единицы: 1 {unit} = quantity of_unit.
+// Без of_unit единица задаётся через базовую единицу товара.
+type unitRequest struct {
+	Quantity models.Decimal       `json:"quantity"`
+	OfUnit   models.UnitOfMeasure `json:"of_unit"`
+}
+
+// GetUnits — список дополнительных единиц товара с коэффициентами пересчёта в базовую.
+func (c *UnitController) GetUnits(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	units, err := c.unitService.ListUnits(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeUnitError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(units)
+}
+
+// SetUnit — задание или изменение дополнительной единицы товара.
+func (c *UnitController) SetUnit(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var req unitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: "invalid request body"})
+		return
+	}
+
+	vars := mux.Vars(r)
+	unit, err := c.unitService.SetUnit(r.Context(), vars["id"], models.UnitOfMeasure(vars["unit"]), req.Quantity, req.OfUnit)
+	if err == models.ErrUnitNotDefined {
+		// of_unit не задана для товара — ошибка в теле запроса, а не отсутствующий ресурс.
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err != nil {
+		writeUnitError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(unit)
+}
+
+// DeleteUnit — удаление дополнительной единицы товара.
+func (c *UnitController) DeleteUnit(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	vars := mux.Vars(r)
+	if err := c.unitService.DeleteUnit(r.Context(), vars["id"], models.UnitOfMeasure(vars["unit"])); err != nil {
+		writeUnitError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeUnitError отвечает на ошибку операции с единицами измерения подходящим HTTP-статусом.
+func writeUnitError(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	switch err {
+	case services.ErrInvalidUnit, services.ErrInvalidUnitQuantity, services.ErrQuantityPrecision,
+		services.ErrInvalidProduct:
+		w.WriteHeader(http.StatusBadRequest)
+	case services.ErrProductNotFound, models.ErrUnitNotDefined:
+		w.WriteHeader(http.StatusNotFound)
+	case models.ErrUnitCycle, services.ErrUnitInUse, services.ErrUnitHasBarcodes, services.ErrProductArchived:
+		w.WriteHeader(http.StatusConflict)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	_ = json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+}
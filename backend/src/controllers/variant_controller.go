@@ -0,0 +1,131 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"warehouse-management-system/src/models"
+	"warehouse-management-system/src/services"
+
+	"github.com/gorilla/mux"
+)
+
+// VariantController обрабатывает HTTP-запросы, связанные с вариантами и атрибутами товаров.
+type VariantController struct {
+	variantService *services.VariantService
+}
+
+// NewVariantController — конструктор контроллера вариантов товара.
+func NewVariantController(variantService *services.VariantService) *VariantController {
+	return &VariantController{variantService: variantService}
+}
+
+// variantRequest описывает тело запроса на создание варианта. Без sku и name они формируются
+// из шаблона и значений атрибутов.
+type variantRequest struct {
+	SKU        string                    `json:"sku"`
+	Name       string                    `json:"name"`
+	Attributes []models.ProductAttribute `json:"attributes"`
+}
+
+// attributesRequest описывает тело запроса на замену атрибутов товара.
+type attributesRequest struct {
+	Attributes []models.ProductAttribute `json:"attributes"`
+}
+
+// GetVariants — список вариантов шаблона.
+func (c *VariantController) GetVariants(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	variants, err := c.variantService.ListVariants(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeVariantError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(variants)
+}
+
+// CreateVariant — создание варианта шаблона.
+func (c *VariantController) CreateVariant(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var req variantRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: "invalid request body"})
+		return
+	}
+
+	variant, err := c.variantService.CreateVariant(r.Context(), mux.Vars(r)["id"], req.SKU, req.Name, req.Attributes)
+	if err != nil {
+		writeVariantError(w, err)
+		return
+	}
+
+	setETag(w, variant.Version)
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(variant)
+}
+
+// SetAttributes — замена атрибутов товара (заголовок If-Match обязателен).
+func (c *VariantController) SetAttributes(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	version, ok := requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	var req attributesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: "invalid request body"})
+		return
+	}
+
+	product, err := c.variantService.SetAttributes(r.Context(), mux.Vars(r)["id"], version, req.Attributes)
+	if err != nil {
+		writeVariantError(w, err)
+		return
+	}
+
+	setETag(w, product.Version)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(product)
+}
+
+// writeVariantError отвечает на ошибку операции с вариантами подходящим HTTP-статусом.
+func writeVariantError(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case errors.Is(err, models.ErrInvalidAttribute), err == services.ErrInvalidProduct:
+		w.WriteHeader(http.StatusBadRequest)
+	case err == services.ErrProductNotFound:
+		w.WriteHeader(http.StatusNotFound)
+	case err == services.ErrVariantExists, err == services.ErrNestedVariant,
+		err == services.ErrSKUAlreadyUsed, err == services.ErrProductArchived:
+		w.WriteHeader(http.StatusConflict)
+	case err == services.ErrVersionMismatch:
+		w.WriteHeader(http.StatusPreconditionFailed)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	_ = json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+}
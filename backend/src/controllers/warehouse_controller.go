@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"time"
+	"warehouse-management-system/src/models"
 	"warehouse-management-system/src/services"
 )
 
@@ -19,24 +20,28 @@ func NewWarehouseController(warehouseService *services.WarehouseService) *Wareho
 
 // receiptRequest описывает тело запроса для приёмки товара.
 type receiptRequest struct {
-	ProductID  string  `json:"product_id"`
-	SupplierID string  `json:"supplier_id"`
-	Quantity   float64 `json:"quantity"`
-	Price      float64 `json:"price"`
-	ExpiryDate string  `json:"expiry_date"` // ISO8601, опционально
+	ProductID  string               `json:"product_id"`
+	SupplierID string               `json:"supplier_id"`
+	Quantity   models.Decimal       `json:"quantity"`
+	Unit       models.UnitOfMeasure `json:"unit"`        // единица количества и цены, по умолчанию базовая
+	Price      models.Decimal       `json:"price"`       // цена за единицу unit
+	Currency   string               `json:"currency"`    // ISO 4217, по умолчанию RUB
+	ExpiryDate string               `json:"expiry_date"` // ISO8601, опционально
 }
 
 // writeOffRequest описывает тело запроса для списания товара.
 type writeOffRequest struct {
-	ProductID string  `json:"product_id"`
-	Quantity  float64 `json:"quantity"`
+	ProductID string               `json:"product_id"`
+	Quantity  models.Decimal       `json:"quantity"`
+	Unit      models.UnitOfMeasure `json:"unit"`
 }
 
 // reserveRequest описывает тело запроса для резервирования товара под заказ.
 type reserveRequest struct {
-	ProductID string  `json:"product_id"`
-	OrderID   string  `json:"order_id"`
-	Quantity  float64 `json:"quantity"`
+	ProductID string               `json:"product_id"`
+	OrderID   string               `json:"order_id"`
+	Quantity  models.Decimal       `json:"quantity"`
+	Unit      models.UnitOfMeasure `json:"unit"`
 }
 
 // Receipt — приёмка товара на склад.
@@ -66,13 +71,8 @@ func (c *WarehouseController) Receipt(w http.ResponseWriter, r *http.Request) {
 		expiry = &t
 	}
 
-	if err := c.warehouseService.Receipt(req.ProductID, req.SupplierID, req.Quantity, req.Price, expiry); err != nil {
-		if err == services.ErrInvalidOperation {
-			w.WriteHeader(http.StatusBadRequest)
-		} else {
-			w.WriteHeader(http.StatusInternalServerError)
-		}
-		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+	if err := c.warehouseService.Receipt(r.Context(), req.ProductID, req.SupplierID, req.Quantity, req.Unit, req.Price, req.Currency, expiry); err != nil {
+		writeOperationError(w, err)
 		return
 	}
 
@@ -95,15 +95,8 @@ func (c *WarehouseController) WriteOff(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := c.warehouseService.WriteOff(req.ProductID, req.Quantity); err != nil {
-		if err == services.ErrInvalidOperation {
-			w.WriteHeader(http.StatusBadRequest)
-		} else if err == services.ErrInsufficientStock {
-			w.WriteHeader(http.StatusConflict)
-		} else {
-			w.WriteHeader(http.StatusInternalServerError)
-		}
-		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+	if err := c.warehouseService.WriteOff(r.Context(), req.ProductID, req.Quantity, req.Unit); err != nil {
+		writeOperationError(w, err)
 		return
 	}
 
@@ -126,22 +119,17 @@ func (c *WarehouseController) Reserve(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := c.warehouseService.Reserve(req.ProductID, req.OrderID, req.Quantity); err != nil {
-		if err == services.ErrInvalidOperation {
-			w.WriteHeader(http.StatusBadRequest)
-		} else if err == services.ErrInsufficientStock {
-			w.WriteHeader(http.StatusConflict)
-		} else {
-			w.WriteHeader(http.StatusInternalServerError)
-		}
-		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+	if err := c.warehouseService.Reserve(r.Context(), req.ProductID, req.OrderID, req.Quantity, req.Unit); err != nil {
+		writeOperationError(w, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusCreated)
 }
 
-// GetInventory — получение текущих остатков.
+// GetInventory — получение текущих остатков. Query-параметры: unit — единица, в которой показать
+// остатки (товары без такой единицы остаются в базовой); rollup=true — сводка по шаблонам товаров
+// с остатками вариантов.
 func (c *WarehouseController) GetInventory(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
@@ -150,7 +138,20 @@ func (c *WarehouseController) GetInventory(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	items, err := c.warehouseService.GetInventory()
+	q := r.URL.Query()
+	rollup, err := parseBoolParam(q.Get("rollup"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: "rollup must be a boolean"})
+		return
+	}
+
+	items, err := c.warehouseService.GetInventory(r.Context(), models.UnitOfMeasure(q.Get("unit")), rollup)
+	if err == services.ErrInvalidUnit {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
@@ -161,4 +162,71 @@ func (c *WarehouseController) GetInventory(w http.ResponseWriter, r *http.Reques
 	_ = json.NewEncoder(w).Encode(items)
 }
 
+// writeOperationError отвечает на ошибку складской операции подходящим HTTP-статусом.
+func writeOperationError(w http.ResponseWriter, err error) {
+	switch err {
+	case services.ErrInvalidOperation, services.ErrQuantityPrecision, services.ErrInvalidPrice,
+		services.ErrInvalidUnit, models.ErrUnitNotDefined:
+		w.WriteHeader(http.StatusBadRequest)
+	case services.ErrProductNotFound:
+		w.WriteHeader(http.StatusNotFound)
+	case services.ErrInsufficientStock, services.ErrProductArchived:
+		w.WriteHeader(http.StatusConflict)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	_ = json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+}
+
+// balanceCheckResponse — результат сверки остатков с журналом движений.
+type balanceCheckResponse struct {
+	Consistent bool                 `json:"consistent"`
+	Drift      []*models.StockDrift `json:"drift"`
+}
+
+// CheckBalances — сверка материализованных остатков с журналом движений (только отчёт).
+func (c *WarehouseController) CheckBalances(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	drift, err := c.warehouseService.CheckBalances(r.Context())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(newBalanceCheckResponse(drift))
+}
+
+// RebuildBalances — пересчёт остатков по журналу движений. В ответе — исправленные расхождения.
+func (c *WarehouseController) RebuildBalances(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
 
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	drift, err := c.warehouseService.RebuildBalances(r.Context())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(newBalanceCheckResponse(drift))
+}
+
+func newBalanceCheckResponse(drift []*models.StockDrift) balanceCheckResponse {
+	if drift == nil {
+		drift = []*models.StockDrift{}
+	}
+	return balanceCheckResponse{Consistent: len(drift) == 0, Drift: drift}
+}
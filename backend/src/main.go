@@ -1,13 +1,19 @@
 package main
 
 import (
-	"database/sql"
+	"context"
+	"errors"
 	"log"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 	"warehouse-management-system/src/config"
 	"warehouse-management-system/src/controllers"
 	"warehouse-management-system/src/middleware"
+	"warehouse-management-system/src/models"
 	"warehouse-management-system/src/repositories"
 	"warehouse-management-system/src/services"
 
@@ -18,52 +24,131 @@ func main() {
 	// Загрузка конфигурации
 	cfg := config.LoadConfig()
 
-	dsn := "file:" + cfg.DBPath + "?_pragma=foreign_keys(ON)"
+	// Подкоманда backup открывает БД сама: restore подменяет файл БД и не должен держать его открытым.
+	if len(os.Args) > 1 && os.Args[1] == "backup" {
+		if err := runBackupCommand(cfg, os.Args[2:]); err != nil {
+			log.Fatalf("backup: %v", err)
+		}
+		return
+	}
 
-	log.Println("SQLite DSN:", dsn)
-	db, err := config.OpenSQLite(dsn)
+	db, err := config.OpenDatabase(cfg)
 	if err != nil {
-		log.Fatalf("failed to open SQLite database: %v", err)
+		log.Fatalf("failed to open database: %v", err)
 	}
-	defer func(db *sql.DB) {
+	defer func(db *config.DB) {
 		_ = db.Close()
 	}(db)
 
-	// Выполняем миграцию схемы (idempotent).
-	if err := config.MigrateSQLite(db); err != nil {
-		log.Fatalf("failed to migrate SQLite schema: %v", err)
+	// Подкоманда migrate управляет схемой БД и не запускает сервер.
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrateCommand(db, os.Args[2:]); err != nil {
+			log.Fatalf("migrate: %v", err)
+		}
+		return
+	}
+
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("invalid configuration: %v", err)
+	}
+
+	tokenKeys, err := loadTokenKeys(cfg)
+	if err != nil {
+		log.Fatalf("failed to load JWT signing keys: %v", err)
+	}
+
+	// Применяем неприменённые миграции схемы.
+	if err := config.Migrate(db); err != nil {
+		log.Fatalf("failed to migrate database schema: %v", err)
+	}
+
+	// Резервные копии встроены только для SQLite; для PostgreSQL store остаётся nil.
+	var backupStore services.BackupStore
+	backupManager, err := config.NewBackupManager(db, cfg.BackupDir, cfg.BackupRetention)
+	switch {
+	case err == nil:
+		backupStore = backupManager
+	case errors.Is(err, config.ErrBackupUnsupported):
+		log.Printf("Built-in backups are disabled for %s, use pg_dump", cfg.DBDriver)
+	default:
+		log.Fatalf("failed to initialize backups: %v", err)
 	}
 
 	// Инициализация репозиториев (слой хранения данных)
-	userRepo := repositories.NewUserRepository(db)
-	productRepo := repositories.NewProductRepository(db)
-	categoryRepo := repositories.NewCategoryRepository(db)
-	supplierRepo := repositories.NewSupplierRepository(db)
-	warehouseRepo := repositories.NewWarehouseRepository(db)
-	orderRepo := repositories.NewOrderRepository(db)
+	ids := repositories.NewUUIDv7Generator()
+	userRepo := repositories.NewUserRepository(db, ids)
+	productRepo := repositories.NewProductRepository(db, ids)
+	categoryRepo := repositories.NewCategoryRepository(db, ids)
+	supplierRepo := repositories.NewSupplierRepository(db, ids)
+	warehouseRepo := repositories.NewWarehouseRepository(db, ids)
+	orderRepo := repositories.NewOrderRepository(db, ids)
+	apiKeyRepo := repositories.NewAPIKeyRepository(db, ids)
+	auditRepo := repositories.NewAuditRepository(db)
+	sessionRepo := repositories.NewSessionRepository(db, ids)
+	barcodeRepo := repositories.NewBarcodeRepository(db)
+	unitRepo := repositories.NewProductUnitRepository(db)
+	priceListRepo := repositories.NewPriceListRepository(db, ids)
+	supplierCostRepo := repositories.NewSupplierCostRepository(db)
+	attachmentRepo := repositories.NewAttachmentRepository(db, ids)
+	attachmentStore, err := repositories.NewFileBlobStore(cfg.AttachmentDir)
+	if err != nil {
+		log.Fatalf("failed to initialize attachment store: %v", err)
+	}
+	productSearchRepo, err := repositories.NewProductSearchRepository(context.Background(), db)
+	if err != nil {
+		log.Fatalf("failed to set up product search index: %v", err)
+	}
 
 	// Инициализация сервисов
-	authService := services.NewAuthService(userRepo, cfg.JWTSecret)
-	productService := services.NewProductService(productRepo)
-	categoryService := services.NewCategoryService(categoryRepo)
-	supplierService := services.NewSupplierService(supplierRepo)
-	warehouseService := services.NewWarehouseService(warehouseRepo, productRepo)
-	orderService := services.NewOrderService(orderRepo, warehouseRepo, productRepo)
+	auditService := services.NewAuditService(auditRepo)
+	sessionService := services.NewSessionService(sessionRepo, userRepo, auditService)
+	authService := services.NewAuthService(userRepo, tokenKeys, sessionService, auditService, roleMapping(cfg), authProviders(cfg)...)
+	productService := services.NewProductService(productRepo, productSearchRepo, unitRepo, categoryRepo, supplierRepo, auditService)
+	barcodeService := services.NewBarcodeService(barcodeRepo, productRepo, unitRepo, auditService)
+	unitService := services.NewUnitService(unitRepo, productRepo, barcodeRepo, auditService)
+	variantService := services.NewVariantService(productRepo, auditService)
+	categoryService := services.NewCategoryService(categoryRepo, auditService)
+	supplierService := services.NewSupplierService(supplierRepo, auditService)
+	warehouseService := services.NewWarehouseService(warehouseRepo, productRepo, unitService, auditService)
+	priceListService := services.NewPriceListService(priceListRepo, productRepo, auditService)
+	supplierCostService := services.NewSupplierCostService(supplierCostRepo, supplierRepo, productRepo, auditService)
+	attachmentService := services.NewAttachmentService(attachmentRepo, attachmentStore, productRepo, supplierRepo, int64(cfg.AttachmentMaxSize), auditService)
+	orderService := services.NewOrderService(orderRepo, productRepo, unitService, priceListService, auditService)
+	apiKeyService := services.NewAPIKeyService(apiKeyRepo, userRepo, auditService)
+	backupService := services.NewBackupService(backupStore, auditService)
 
 	// Инициализация контроллеров
 	authController := controllers.NewAuthController(authService)
 	productController := controllers.NewProductController(productService)
+	barcodeController := controllers.NewBarcodeController(barcodeService)
+	unitController := controllers.NewUnitController(unitService)
+	variantController := controllers.NewVariantController(variantService)
 	categoryController := controllers.NewCategoryController(categoryService)
 	supplierController := controllers.NewSupplierController(supplierService)
+	supplierCostController := controllers.NewSupplierCostController(supplierCostService)
+	priceListController := controllers.NewPriceListController(priceListService)
+	attachmentController := controllers.NewAttachmentController(attachmentService)
 	warehouseController := controllers.NewWarehouseController(warehouseService)
 	orderController := controllers.NewOrderController(orderService)
+	apiKeyController := controllers.NewAPIKeyController(apiKeyService)
+	auditController := controllers.NewAuditController(auditService)
+	sessionController := controllers.NewSessionController(sessionService)
+	identityController := controllers.NewIdentityController(authService)
+	backupController := controllers.NewBackupController(backupService)
 
 	// Инициализация роутера
 	router := mux.NewRouter()
 
 	// Middleware
 	router.Use(middleware.CORS)
+	router.Use(middleware.RequestID)
 	router.Use(middleware.LoggingMiddleware)
+	// Общий REQUEST_TIMEOUT; копии БД и передача файлов получают LONG_REQUEST_TIMEOUT через timeouts.Route.
+	timeouts := middleware.NewTimeouts(cfg.RequestTimeout)
+	router.Use(timeouts.Middleware)
+
+	// Открытые ключи для проверки наших JWT другими сервисами.
+	router.HandleFunc("/.well-known/jwks.json", authController.GetJWKS).Methods("GET", "OPTIONS")
 
 	// API routes
 	api := router.PathPrefix("/api").Subrouter()
@@ -71,40 +156,112 @@ func main() {
 	// Auth routes
 	api.HandleFunc("/auth/register", authController.Register).Methods("POST", "OPTIONS")
 	api.HandleFunc("/auth/login", authController.Login).Methods("POST", "OPTIONS")
-	api.HandleFunc("/auth/me", middleware.AuthMiddleware(authController.GetMe, cfg.JWTSecret)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/auth/providers", authController.GetProviders).Methods("GET", "OPTIONS")
+	api.HandleFunc("/auth/{provider}/start", authController.StartRedirectLogin).Methods("GET")
+	api.HandleFunc("/auth/{provider}/callback", authController.RedirectCallback).Methods("GET")
+	api.HandleFunc("/auth/logout", middleware.AuthMiddleware(authController.Logout, tokenKeys, apiKeyService, sessionService)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/auth/me", middleware.AuthMiddleware(authController.GetMe, tokenKeys, apiKeyService, sessionService)).Methods("GET", "OPTIONS")
 
 	// Products routes
-	api.HandleFunc("/products", middleware.AuthMiddleware(productController.GetProducts, cfg.JWTSecret)).Methods("GET", "OPTIONS")
-	api.HandleFunc("/products", middleware.AuthMiddleware(middleware.RoleMiddleware(productController.CreateProduct, "admin", "manager"), cfg.JWTSecret)).Methods("POST", "OPTIONS")
-	api.HandleFunc("/products/{id}", middleware.AuthMiddleware(productController.GetProduct, cfg.JWTSecret)).Methods("GET", "OPTIONS")
-	api.HandleFunc("/products/{id}", middleware.AuthMiddleware(middleware.RoleMiddleware(productController.UpdateProduct, "admin", "manager"), cfg.JWTSecret)).Methods("PUT", "OPTIONS")
-	api.HandleFunc("/products/{id}", middleware.AuthMiddleware(middleware.RoleMiddleware(productController.DeleteProduct, "admin"), cfg.JWTSecret)).Methods("DELETE", "OPTIONS")
+	api.HandleFunc("/products", middleware.AuthMiddleware(productController.GetProducts, tokenKeys, apiKeyService, sessionService)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/products", middleware.AuthMiddleware(middleware.RoleMiddleware(productController.CreateProduct, "admin", "manager"), tokenKeys, apiKeyService, sessionService)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/products/batch", middleware.AuthMiddleware(middleware.RoleMiddleware(productController.BatchCreateProducts, "admin", "manager"), tokenKeys, apiKeyService, sessionService)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/products/batch", middleware.AuthMiddleware(middleware.RoleMiddleware(productController.BatchUpdateProducts, "admin", "manager"), tokenKeys, apiKeyService, sessionService)).Methods("PUT", "OPTIONS")
+	api.HandleFunc("/products/batch/delete", middleware.AuthMiddleware(middleware.RoleMiddleware(productController.BatchDeleteProducts, "admin"), tokenKeys, apiKeyService, sessionService)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/products/search", middleware.AuthMiddleware(productController.SearchProducts, tokenKeys, apiKeyService, sessionService)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/products/by-barcode/{code:.+}", middleware.AuthMiddleware(barcodeController.GetProductByBarcode, tokenKeys, apiKeyService, sessionService)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/products/{id}", middleware.AuthMiddleware(productController.GetProduct, tokenKeys, apiKeyService, sessionService)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/products/{id}", middleware.AuthMiddleware(middleware.RoleMiddleware(productController.UpdateProduct, "admin", "manager"), tokenKeys, apiKeyService, sessionService)).Methods("PUT", "OPTIONS")
+	api.HandleFunc("/products/{id}", middleware.AuthMiddleware(middleware.RoleMiddleware(productController.DeleteProduct, "admin"), tokenKeys, apiKeyService, sessionService)).Methods("DELETE", "OPTIONS")
+	api.HandleFunc("/products/{id}/restore", middleware.AuthMiddleware(middleware.RoleMiddleware(productController.RestoreProduct, "admin"), tokenKeys, apiKeyService, sessionService)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/products/{id}/barcodes", middleware.AuthMiddleware(barcodeController.GetBarcodes, tokenKeys, apiKeyService, sessionService)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/products/{id}/barcodes", middleware.AuthMiddleware(middleware.RoleMiddleware(barcodeController.CreateBarcode, "admin", "manager"), tokenKeys, apiKeyService, sessionService)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/products/{id}/barcodes/{code}", middleware.AuthMiddleware(middleware.RoleMiddleware(barcodeController.DeleteBarcode, "admin", "manager"), tokenKeys, apiKeyService, sessionService)).Methods("DELETE", "OPTIONS")
+	api.HandleFunc("/products/{id}/barcodes/{code}/image", middleware.AuthMiddleware(barcodeController.GetBarcodeImage, tokenKeys, apiKeyService, sessionService)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/products/{id}/variants", middleware.AuthMiddleware(variantController.GetVariants, tokenKeys, apiKeyService, sessionService)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/products/{id}/variants", middleware.AuthMiddleware(middleware.RoleMiddleware(variantController.CreateVariant, "admin", "manager"), tokenKeys, apiKeyService, sessionService)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/products/{id}/attributes", middleware.AuthMiddleware(middleware.RoleMiddleware(variantController.SetAttributes, "admin", "manager"), tokenKeys, apiKeyService, sessionService)).Methods("PUT", "OPTIONS")
+	api.HandleFunc("/products/{id}/units", middleware.AuthMiddleware(unitController.GetUnits, tokenKeys, apiKeyService, sessionService)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/products/{id}/units/{unit}", middleware.AuthMiddleware(middleware.RoleMiddleware(unitController.SetUnit, "admin", "manager"), tokenKeys, apiKeyService, sessionService)).Methods("PUT", "OPTIONS")
+	api.HandleFunc("/products/{id}/units/{unit}", middleware.AuthMiddleware(middleware.RoleMiddleware(unitController.DeleteUnit, "admin", "manager"), tokenKeys, apiKeyService, sessionService)).Methods("DELETE", "OPTIONS")
+	api.HandleFunc("/products/{id}/attachments", middleware.AuthMiddleware(attachmentController.GetProductAttachments, tokenKeys, apiKeyService, sessionService)).Methods("GET", "OPTIONS")
+	timeouts.Route(api.HandleFunc("/products/{id}/attachments", middleware.AuthMiddleware(middleware.RoleMiddleware(attachmentController.UploadProductAttachment, "admin", "manager"), tokenKeys, apiKeyService, sessionService)).Methods("POST", "OPTIONS"), cfg.LongRequestTimeout)
+	api.HandleFunc("/products/{id}/price", middleware.AuthMiddleware(priceListController.GetProductPrice, tokenKeys, apiKeyService, sessionService)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/products/{id}/suppliers", middleware.AuthMiddleware(middleware.RoleMiddleware(supplierCostController.GetProductSuppliers, "admin", "manager"), tokenKeys, apiKeyService, sessionService)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/products/{id}/suppliers/{supplierId}", middleware.AuthMiddleware(middleware.RoleMiddleware(supplierCostController.SetSupplierCost, "admin", "manager"), tokenKeys, apiKeyService, sessionService)).Methods("PUT", "OPTIONS")
+	api.HandleFunc("/products/{id}/suppliers/{supplierId}", middleware.AuthMiddleware(middleware.RoleMiddleware(supplierCostController.DeleteSupplierCost, "admin", "manager"), tokenKeys, apiKeyService, sessionService)).Methods("DELETE", "OPTIONS")
 
 	// Categories routes
-	api.HandleFunc("/categories", middleware.AuthMiddleware(categoryController.GetCategories, cfg.JWTSecret)).Methods("GET", "OPTIONS")
-	api.HandleFunc("/categories", middleware.AuthMiddleware(middleware.RoleMiddleware(categoryController.CreateCategory, "admin", "manager"), cfg.JWTSecret)).Methods("POST", "OPTIONS")
-	api.HandleFunc("/categories/{id}", middleware.AuthMiddleware(categoryController.GetCategory, cfg.JWTSecret)).Methods("GET", "OPTIONS")
-	api.HandleFunc("/categories/{id}", middleware.AuthMiddleware(middleware.RoleMiddleware(categoryController.UpdateCategory, "admin", "manager"), cfg.JWTSecret)).Methods("PUT", "OPTIONS")
-	api.HandleFunc("/categories/{id}", middleware.AuthMiddleware(middleware.RoleMiddleware(categoryController.DeleteCategory, "admin"), cfg.JWTSecret)).Methods("DELETE", "OPTIONS")
+	api.HandleFunc("/categories", middleware.AuthMiddleware(categoryController.GetCategories, tokenKeys, apiKeyService, sessionService)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/categories", middleware.AuthMiddleware(middleware.RoleMiddleware(categoryController.CreateCategory, "admin", "manager"), tokenKeys, apiKeyService, sessionService)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/categories/tree", middleware.AuthMiddleware(categoryController.GetCategoryTree, tokenKeys, apiKeyService, sessionService)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/categories/{id}", middleware.AuthMiddleware(categoryController.GetCategory, tokenKeys, apiKeyService, sessionService)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/categories/{id}", middleware.AuthMiddleware(middleware.RoleMiddleware(categoryController.UpdateCategory, "admin", "manager"), tokenKeys, apiKeyService, sessionService)).Methods("PUT", "OPTIONS")
+	api.HandleFunc("/categories/{id}", middleware.AuthMiddleware(middleware.RoleMiddleware(categoryController.DeleteCategory, "admin"), tokenKeys, apiKeyService, sessionService)).Methods("DELETE", "OPTIONS")
+	api.HandleFunc("/categories/{id}/move", middleware.AuthMiddleware(middleware.RoleMiddleware(categoryController.MoveCategory, "admin", "manager"), tokenKeys, apiKeyService, sessionService)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/categories/{id}/restore", middleware.AuthMiddleware(middleware.RoleMiddleware(categoryController.RestoreCategory, "admin"), tokenKeys, apiKeyService, sessionService)).Methods("POST", "OPTIONS")
 
 	// Suppliers routes
-	api.HandleFunc("/suppliers", middleware.AuthMiddleware(supplierController.GetSuppliers, cfg.JWTSecret)).Methods("GET", "OPTIONS")
-	api.HandleFunc("/suppliers", middleware.AuthMiddleware(middleware.RoleMiddleware(supplierController.CreateSupplier, "admin", "manager"), cfg.JWTSecret)).Methods("POST", "OPTIONS")
-	api.HandleFunc("/suppliers/{id}", middleware.AuthMiddleware(supplierController.GetSupplier, cfg.JWTSecret)).Methods("GET", "OPTIONS")
-	api.HandleFunc("/suppliers/{id}", middleware.AuthMiddleware(middleware.RoleMiddleware(supplierController.UpdateSupplier, "admin", "manager"), cfg.JWTSecret)).Methods("PUT", "OPTIONS")
-	api.HandleFunc("/suppliers/{id}", middleware.AuthMiddleware(middleware.RoleMiddleware(supplierController.DeleteSupplier, "admin"), cfg.JWTSecret)).Methods("DELETE", "OPTIONS")
+	api.HandleFunc("/suppliers", middleware.AuthMiddleware(supplierController.GetSuppliers, tokenKeys, apiKeyService, sessionService)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/suppliers", middleware.AuthMiddleware(middleware.RoleMiddleware(supplierController.CreateSupplier, "admin", "manager"), tokenKeys, apiKeyService, sessionService)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/suppliers/{id}", middleware.AuthMiddleware(supplierController.GetSupplier, tokenKeys, apiKeyService, sessionService)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/suppliers/{id}", middleware.AuthMiddleware(middleware.RoleMiddleware(supplierController.UpdateSupplier, "admin", "manager"), tokenKeys, apiKeyService, sessionService)).Methods("PUT", "OPTIONS")
+	api.HandleFunc("/suppliers/{id}", middleware.AuthMiddleware(middleware.RoleMiddleware(supplierController.DeleteSupplier, "admin"), tokenKeys, apiKeyService, sessionService)).Methods("DELETE", "OPTIONS")
+	api.HandleFunc("/suppliers/{id}/restore", middleware.AuthMiddleware(middleware.RoleMiddleware(supplierController.RestoreSupplier, "admin"), tokenKeys, apiKeyService, sessionService)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/suppliers/{id}/attachments", middleware.AuthMiddleware(attachmentController.GetSupplierAttachments, tokenKeys, apiKeyService, sessionService)).Methods("GET", "OPTIONS")
+	timeouts.Route(api.HandleFunc("/suppliers/{id}/attachments", middleware.AuthMiddleware(middleware.RoleMiddleware(attachmentController.UploadSupplierAttachment, "admin", "manager"), tokenKeys, apiKeyService, sessionService)).Methods("POST", "OPTIONS"), cfg.LongRequestTimeout)
+
+	// Attachments routes (содержимое вложений товаров и поставщиков)
+	api.HandleFunc("/attachments/{id}", middleware.AuthMiddleware(attachmentController.GetAttachment, tokenKeys, apiKeyService, sessionService)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/attachments/{id}", middleware.AuthMiddleware(middleware.RoleMiddleware(attachmentController.DeleteAttachment, "admin", "manager"), tokenKeys, apiKeyService, sessionService)).Methods("DELETE", "OPTIONS")
+	timeouts.Route(api.HandleFunc("/attachments/{id}/content", middleware.AuthMiddleware(attachmentController.DownloadAttachment, tokenKeys, apiKeyService, sessionService)).Methods("GET", "OPTIONS"), cfg.LongRequestTimeout)
+	api.HandleFunc("/attachments/{id}/thumbnail", middleware.AuthMiddleware(attachmentController.GetThumbnail, tokenKeys, apiKeyService, sessionService)).Methods("GET", "OPTIONS")
 
 	// Warehouse operations routes
-	api.HandleFunc("/warehouse/receipt", middleware.AuthMiddleware(middleware.RoleMiddleware(warehouseController.Receipt, "admin", "manager", "storekeeper"), cfg.JWTSecret)).Methods("POST", "OPTIONS")
-	api.HandleFunc("/warehouse/write-off", middleware.AuthMiddleware(middleware.RoleMiddleware(warehouseController.WriteOff, "admin", "manager", "storekeeper"), cfg.JWTSecret)).Methods("POST", "OPTIONS")
-	api.HandleFunc("/warehouse/reserve", middleware.AuthMiddleware(middleware.RoleMiddleware(warehouseController.Reserve, "admin", "manager"), cfg.JWTSecret)).Methods("POST", "OPTIONS")
-	api.HandleFunc("/warehouse/inventory", middleware.AuthMiddleware(warehouseController.GetInventory, cfg.JWTSecret)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/warehouse/receipt", middleware.AuthMiddleware(middleware.RoleMiddleware(warehouseController.Receipt, "admin", "manager", "storekeeper"), tokenKeys, apiKeyService, sessionService)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/warehouse/write-off", middleware.AuthMiddleware(middleware.RoleMiddleware(warehouseController.WriteOff, "admin", "manager", "storekeeper"), tokenKeys, apiKeyService, sessionService)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/warehouse/reserve", middleware.AuthMiddleware(middleware.RoleMiddleware(warehouseController.Reserve, "admin", "manager"), tokenKeys, apiKeyService, sessionService)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/warehouse/inventory", middleware.AuthMiddleware(warehouseController.GetInventory, tokenKeys, apiKeyService, sessionService)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/warehouse/balances/check", middleware.AuthMiddleware(middleware.RoleMiddleware(warehouseController.CheckBalances, "admin"), tokenKeys, apiKeyService, sessionService)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/warehouse/balances/rebuild", middleware.AuthMiddleware(middleware.RoleMiddleware(warehouseController.RebuildBalances, "admin"), tokenKeys, apiKeyService, sessionService)).Methods("POST", "OPTIONS")
 
 	// Orders routes
-	api.HandleFunc("/orders", middleware.AuthMiddleware(orderController.GetOrders, cfg.JWTSecret)).Methods("GET", "OPTIONS")
-	api.HandleFunc("/orders", middleware.AuthMiddleware(middleware.RoleMiddleware(orderController.CreateOrder, "admin", "manager"), cfg.JWTSecret)).Methods("POST", "OPTIONS")
-	api.HandleFunc("/orders/{id}", middleware.AuthMiddleware(orderController.GetOrder, cfg.JWTSecret)).Methods("GET", "OPTIONS")
-	api.HandleFunc("/orders/{id}/status", middleware.AuthMiddleware(middleware.RoleMiddleware(orderController.UpdateOrderStatus, "admin", "manager"), cfg.JWTSecret)).Methods("PUT", "OPTIONS")
+	api.HandleFunc("/orders", middleware.AuthMiddleware(orderController.GetOrders, tokenKeys, apiKeyService, sessionService)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/orders", middleware.AuthMiddleware(middleware.RoleMiddleware(orderController.CreateOrder, "admin", "manager"), tokenKeys, apiKeyService, sessionService)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/orders/{id}", middleware.AuthMiddleware(orderController.GetOrder, tokenKeys, apiKeyService, sessionService)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/orders/{id}/status", middleware.AuthMiddleware(middleware.RoleMiddleware(orderController.UpdateOrderStatus, "admin", "manager"), tokenKeys, apiKeyService, sessionService)).Methods("PUT", "OPTIONS")
+
+	// Price lists routes
+	api.HandleFunc("/price-lists", middleware.AuthMiddleware(priceListController.GetPriceLists, tokenKeys, apiKeyService, sessionService)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/price-lists", middleware.AuthMiddleware(middleware.RoleMiddleware(priceListController.CreatePriceList, "admin", "manager"), tokenKeys, apiKeyService, sessionService)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/price-lists/{id}", middleware.AuthMiddleware(priceListController.GetPriceList, tokenKeys, apiKeyService, sessionService)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/price-lists/{id}", middleware.AuthMiddleware(middleware.RoleMiddleware(priceListController.UpdatePriceList, "admin", "manager"), tokenKeys, apiKeyService, sessionService)).Methods("PUT", "OPTIONS")
+	api.HandleFunc("/price-lists/{id}", middleware.AuthMiddleware(middleware.RoleMiddleware(priceListController.DeletePriceList, "admin", "manager"), tokenKeys, apiKeyService, sessionService)).Methods("DELETE", "OPTIONS")
+	api.HandleFunc("/price-lists/{id}/items/{productId}", middleware.AuthMiddleware(middleware.RoleMiddleware(priceListController.SetItem, "admin", "manager"), tokenKeys, apiKeyService, sessionService)).Methods("PUT", "OPTIONS")
+	api.HandleFunc("/price-lists/{id}/items/{productId}", middleware.AuthMiddleware(middleware.RoleMiddleware(priceListController.DeleteItem, "admin", "manager"), tokenKeys, apiKeyService, sessionService)).Methods("DELETE", "OPTIONS")
+
+	// API keys routes (управление ключами интеграций — только для администраторов)
+	api.HandleFunc("/api-keys", middleware.AuthMiddleware(middleware.RoleMiddleware(apiKeyController.GetAPIKeys, "admin"), tokenKeys, apiKeyService, sessionService)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/api-keys", middleware.AuthMiddleware(middleware.RoleMiddleware(apiKeyController.CreateAPIKey, "admin"), tokenKeys, apiKeyService, sessionService)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/api-keys/{id}", middleware.AuthMiddleware(middleware.RoleMiddleware(apiKeyController.RevokeAPIKey, "admin"), tokenKeys, apiKeyService, sessionService)).Methods("DELETE", "OPTIONS")
+
+	// User sessions routes (просмотр и принудительное завершение входов — только для администраторов)
+	api.HandleFunc("/users/{id}/sessions", middleware.AuthMiddleware(middleware.RoleMiddleware(sessionController.GetUserSessions, "admin"), tokenKeys, apiKeyService, sessionService)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/users/{id}/sessions", middleware.AuthMiddleware(middleware.RoleMiddleware(sessionController.RevokeUserSessions, "admin"), tokenKeys, apiKeyService, sessionService)).Methods("DELETE", "OPTIONS")
+	api.HandleFunc("/users/{id}/sessions/{sessionId}", middleware.AuthMiddleware(middleware.RoleMiddleware(sessionController.RevokeUserSession, "admin"), tokenKeys, apiKeyService, sessionService)).Methods("DELETE", "OPTIONS")
+
+	// User identities routes (явная привязка учётных записей LDAP/OIDC к существующим пользователям — только для администраторов)
+	api.HandleFunc("/users/{id}/identities", middleware.AuthMiddleware(middleware.RoleMiddleware(identityController.GetUserIdentities, "admin"), tokenKeys, apiKeyService, sessionService)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/users/{id}/identities", middleware.AuthMiddleware(middleware.RoleMiddleware(identityController.LinkUserIdentity, "admin"), tokenKeys, apiKeyService, sessionService)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/users/{id}/identities", middleware.AuthMiddleware(middleware.RoleMiddleware(identityController.UnlinkUserIdentity, "admin"), tokenKeys, apiKeyService, sessionService)).Methods("DELETE", "OPTIONS")
+
+	// Audit routes (журнал изменений — только для администраторов)
+	api.HandleFunc("/audit", middleware.AuthMiddleware(middleware.RoleMiddleware(auditController.GetAuditLog, "admin"), tokenKeys, apiKeyService, sessionService)).Methods("GET", "OPTIONS")
+
+	// Backup routes (резервные копии и проверка целостности БД — только для администраторов)
+	api.HandleFunc("/backups", middleware.AuthMiddleware(middleware.RoleMiddleware(backupController.GetBackups, "admin"), tokenKeys, apiKeyService, sessionService)).Methods("GET", "OPTIONS")
+	timeouts.Route(api.HandleFunc("/backups", middleware.AuthMiddleware(middleware.RoleMiddleware(backupController.CreateBackup, "admin"), tokenKeys, apiKeyService, sessionService)).Methods("POST", "OPTIONS"), cfg.LongRequestTimeout)
+	timeouts.Route(api.HandleFunc("/database/integrity", middleware.AuthMiddleware(middleware.RoleMiddleware(backupController.CheckIntegrity, "admin"), tokenKeys, apiKeyService, sessionService)).Methods("GET", "OPTIONS"), cfg.LongRequestTimeout)
 
 	// Отдача страниц фронтенда (пути относительно корня проекта).
 	router.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
@@ -135,8 +292,83 @@ func main() {
 		port = "8080"
 	}
 
-	log.Printf("Server starting on http://localhost:%s", port)
-	if err := http.ListenAndServe(":"+port, router); err != nil {
+	// Контексты всех запросов наследуются от requestsCtx: его отмена прерывает
+	// запросы к БД, не успевшие завершиться за время плавной остановки.
+	requestsCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+
+	server := &http.Server{
+		Addr:              ":" + port,
+		Handler:           router,
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return requestsCtx },
+	}
+
+	if backupManager != nil && cfg.BackupInterval > 0 {
+		log.Printf("Scheduled backups every %s into %s (keeping %d)", cfg.BackupInterval, cfg.BackupDir, cfg.BackupRetention)
+		go backupManager.RunSchedule(requestsCtx, cfg.BackupInterval)
+	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Printf("Server starting on http://localhost:%s", port)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+
+	select {
+	case err := <-serverErr:
 		log.Fatal(err)
+	case sig := <-stop:
+		log.Printf("Received %s, shutting down", sig)
+	}
+
+	// Плавная остановка: новые соединения не принимаются, начатые запросы дорабатывают
+	// до ShutdownTimeout, после чего их контексты отменяются.
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Graceful shutdown incomplete: %v", err)
+	}
+	cancelRequests()
+}
+
+// roleMapping собирает сопоставление групп внешних провайдеров ролям из конфигурации.
+func roleMapping(cfg *config.Config) services.RoleMapping {
+	groups := make(map[string]models.Role, len(cfg.AuthGroupRoles))
+	for group, role := range cfg.AuthGroupRoles {
+		groups[group] = models.Role(role)
+	}
+	return services.RoleMapping{
+		Groups:      groups,
+		DefaultRole: models.Role(cfg.AuthDefaultRole),
+	}
+}
+
+// authProviders возвращает внешних провайдеров аутентификации, включённых в конфигурации.
+func authProviders(cfg *config.Config) []services.Authenticator {
+	var providers []services.Authenticator
+	if cfg.LDAP.URL != "" {
+		log.Println("Auth provider enabled: ldap", cfg.LDAP.URL)
+		providers = append(providers, services.NewLDAPAuthenticator(services.LDAPConfig(cfg.LDAP)))
+	}
+	if cfg.OIDC.Issuer != "" {
+		log.Println("Auth provider enabled: oidc", cfg.OIDC.Issuer)
+		providers = append(providers, services.NewOIDCAuthenticator(services.OIDCConfig(cfg.OIDC), nil))
+	}
+	return providers
+}
+
+// loadTokenKeys создаёт набор ключей подписи JWT согласно выбранному алгоритму.
+func loadTokenKeys(cfg *config.Config) (*services.TokenKeySet, error) {
+	switch {
+	case cfg.JWTAlgorithm == services.AlgHS256:
+		return services.NewHMACKeySet(cfg.JWTSecret), nil
+	case cfg.JWTKeysDir != "":
+		return services.LoadTokenKeySet(cfg.JWTAlgorithm, cfg.JWTKeysDir, cfg.JWTActiveKeyID)
+	default:
+		return services.GenerateEphemeralKeySet(cfg.JWTAlgorithm)
 	}
 }
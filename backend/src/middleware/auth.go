@@ -3,22 +3,48 @@ package middleware
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
 	"strings"
+	"warehouse-management-system/src/models"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
 const (
-	contextUserIDKey = "userID"
-	contextRoleKey   = "role"
+	contextUserIDKey   = "userID"
+	contextRoleKey     = "role"
+	contextAPIKeyIDKey = "apiKeyID"
+	contextSessionKey  = "sessionID"
 )
 
+// TokenKeyProvider предоставляет ключи проверки подписи JWT (по kid из заголовка токена).
+type TokenKeyProvider interface {
+	Keyfunc(token *jwt.Token) (interface{}, error)
+	ValidMethods() []string
+}
+
+// APIKeyAuthenticator проверяет API-ключи машинных интеграций.
+// Реализуется сервисом API-ключей; middleware не знает, как ключи хранятся.
+// Отказ в ключе — models.ErrAPIKeyRejected; другие ошибки означают, что проверить ключ не удалось.
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, rawKey string) (*models.APIKey, error)
+}
+
+// SessionValidator проверяет серверную сессию, к которой привязан JWT (claim jti),
+// и возвращает текущую роль пользователя. Реализуется сервисом сессий: так отозванный
+// администратором вход перестаёт работать сразу, а смена роли действует без повторного входа.
+// Ошибка означает, что проверить сессию не удалось (например, недоступна БД), а не отказ.
+type SessionValidator interface {
+	ValidateSession(ctx context.Context, sessionID, userID string) (models.Role, bool, error)
+}
+
 // authClaims описывает часть пейлоада JWT, которую мы используем в middleware.
 // Поля должны совпадать с теми, что устанавливаются в сервисе аутентификации.
+// Claim role не читается: роль берётся из записи пользователя при проверке сессии.
 type authClaims struct {
 	UserID string `json:"user_id"`
-	Role   string `json:"role"`
 	jwt.RegisteredClaims
 }
 
@@ -28,7 +54,9 @@ type errorResponse struct {
 
 // AuthMiddleware проверяет JWT-токен в заголовке Authorization и,
 // если он валиден, добавляет userID и роль в контекст запроса.
-func AuthMiddleware(next http.HandlerFunc, jwtSecret string) http.HandlerFunc {
+// Вместо JWT можно передать API-ключ (заголовок X-API-Key или "Authorization: ApiKey <key>"),
+// если apiKeys не nil. JWT принимается, только если его сессия активна в sessions.
+func AuthMiddleware(next http.HandlerFunc, tokenKeys TokenKeyProvider, apiKeys APIKeyAuthenticator, sessions SessionValidator) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Пропускаем preflight-запросы CORS.
 		if r.Method == http.MethodOptions {
@@ -36,6 +64,15 @@ func AuthMiddleware(next http.HandlerFunc, jwtSecret string) http.HandlerFunc {
 			return
 		}
 
+		if rawKey, ok := apiKeyFromRequest(r); ok {
+			if apiKeys == nil {
+				unauthorized(w, "api keys are not accepted")
+				return
+			}
+			authenticateAPIKey(w, r, next, apiKeys, rawKey)
+			return
+		}
+
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
 			unauthorized(w, "missing Authorization header")
@@ -55,13 +92,10 @@ func AuthMiddleware(next http.HandlerFunc, jwtSecret string) http.HandlerFunc {
 		}
 
 		claims := &authClaims{}
-		token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
-			// Проверяем, что используется ожидаемый алгоритм.
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrTokenUnverifiable
-			}
-			return []byte(jwtSecret), nil
-		})
+		// Допустимые алгоритмы задаёт набор ключей: токен с чужим алгоритмом отклоняется.
+		token, err := jwt.ParseWithClaims(tokenStr, claims, tokenKeys.Keyfunc,
+			jwt.WithValidMethods(tokenKeys.ValidMethods()),
+		)
 		if err != nil || !token.Valid {
 			unauthorized(w, "invalid or expired token")
 			return
@@ -72,16 +106,70 @@ func AuthMiddleware(next http.HandlerFunc, jwtSecret string) http.HandlerFunc {
 			return
 		}
 
+		// Токены без jti выданы до появления серверных сессий и не принимаются.
+		role, active, err := sessions.ValidateSession(r.Context(), claims.ID, claims.UserID)
+		if err != nil {
+			log.Printf("auth: session check failed: %v", err)
+			internalError(w, "cannot verify session")
+			return
+		}
+		if !active {
+			unauthorized(w, "session is not active")
+			return
+		}
+
 		// Добавляем данные в контекст для последующих обработчиков.
 		ctx := context.WithValue(r.Context(), contextUserIDKey, claims.UserID)
-		if claims.Role != "" {
-			ctx = context.WithValue(ctx, contextRoleKey, claims.Role)
-		}
+		ctx = context.WithValue(ctx, contextSessionKey, claims.ID)
+		ctx = context.WithValue(ctx, contextRoleKey, string(role))
 
 		next(w, r.WithContext(ctx))
 	}
 }
 
+// apiKeyFromRequest извлекает API-ключ из запроса, если клиент передал именно его.
+func apiKeyFromRequest(r *http.Request) (string, bool) {
+	if key := strings.TrimSpace(r.Header.Get("X-API-Key")); key != "" {
+		return key, true
+	}
+	parts := strings.SplitN(r.Header.Get("Authorization"), " ", 2)
+	if len(parts) == 2 && strings.EqualFold(parts[0], "ApiKey") {
+		return strings.TrimSpace(parts[1]), true
+	}
+	return "", false
+}
+
+// authenticateAPIKey проверяет API-ключ и его права на запрошенный ресурс.
+// Ресурс определяется по первому сегменту пути после /api/, действие — по HTTP-методу.
+func authenticateAPIKey(w http.ResponseWriter, r *http.Request, next http.HandlerFunc, apiKeys APIKeyAuthenticator, rawKey string) {
+	key, err := apiKeys.AuthenticateAPIKey(r.Context(), rawKey)
+	if errors.Is(err, models.ErrAPIKeyRejected) {
+		unauthorized(w, "invalid, expired or revoked api key")
+		return
+	}
+	if err != nil {
+		log.Printf("auth: api key check failed: %v", err)
+		internalError(w, "cannot verify api key")
+		return
+	}
+
+	resource := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/api/"), "/", 2)[0]
+	action := models.ScopeWrite
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		action = models.ScopeRead
+	}
+	if !key.Allows(resource, action) {
+		forbidden(w, "api key scope does not allow this operation")
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), contextUserIDKey, key.UserID)
+	ctx = context.WithValue(ctx, contextRoleKey, string(key.Role))
+	ctx = context.WithValue(ctx, contextAPIKeyIDKey, key.ID)
+
+	next(w, r.WithContext(ctx))
+}
+
 // RoleMiddleware ограничивает доступ к обработчику по ролям.
 // Ожидается, что AuthMiddleware уже положил роль пользователя в контекст.
 func RoleMiddleware(next http.HandlerFunc, allowedRoles ...string) http.HandlerFunc {
@@ -116,6 +204,12 @@ func unauthorized(w http.ResponseWriter, msg string) {
 	_ = json.NewEncoder(w).Encode(errorResponse{Error: msg})
 }
 
+func internalError(w http.ResponseWriter, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusInternalServerError)
+	_ = json.NewEncoder(w).Encode(errorResponse{Error: msg})
+}
+
 func forbidden(w http.ResponseWriter, msg string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusForbidden)
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"warehouse-management-system/src/models"
+)
+
+type apiKeyAuthenticatorFunc func(ctx context.Context, rawKey string) (*models.APIKey, error)
+
+func (f apiKeyAuthenticatorFunc) AuthenticateAPIKey(ctx context.Context, rawKey string) (*models.APIKey, error) {
+	return f(ctx, rawKey)
+}
+
+func TestAuthMiddlewareAPIKeyErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "rejected", err: models.ErrAPIKeyRejected, want: http.StatusUnauthorized},
+		{name: "database error", err: errors.New("database is locked"), want: http.StatusInternalServerError},
+		{name: "canceled", err: context.Canceled, want: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		keys := apiKeyAuthenticatorFunc(func(context.Context, string) (*models.APIKey, error) { return nil, tt.err })
+		handler := AuthMiddleware(func(http.ResponseWriter, *http.Request) {
+			t.Errorf("%s: handler called", tt.name)
+		}, nil, keys, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/products", nil)
+		req.Header.Set("X-API-Key", "wms_prefix_secret")
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.name, rec.Code, tt.want)
+		}
+	}
+}
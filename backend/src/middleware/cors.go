@@ -8,8 +8,8 @@ func CORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		w.Header().Set("Access-Control-Expose-Headers", "Content-Type")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, X-Request-ID, If-Match")
+		w.Header().Set("Access-Control-Expose-Headers", "Content-Type, Content-Disposition, X-Request-ID, ETag, X-Total-Count, X-Next-Cursor")
 
 		if r.Method == http.MethodOptions {
 			w.WriteHeader(http.StatusNoContent)
@@ -14,7 +14,8 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 		duration := time.Since(start)
 
-		log.Printf("%s %s %s (%s)", r.RemoteAddr, r.Method, r.URL.Path, duration)
+		requestID, _ := r.Context().Value(contextRequestIDKey).(string)
+		log.Printf("%s %s %s (%s) request_id=%s", r.RemoteAddr, r.Method, r.URL.Path, duration, requestID)
 	})
 }
 
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+const (
+	contextRequestIDKey = "requestID"
+	requestIDHeader     = "X-Request-ID"
+)
+
+// RequestID — глобальный middleware, присваивающий каждому запросу идентификатор.
+// Идентификатор берётся из заголовка X-Request-ID (если клиент его передал) или генерируется,
+// кладётся в контекст и возвращается в ответе — по нему запрос находится в логах и журнале аудита.
+// Подключается через router.Use(middleware.RequestID).
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		w.Header().Set(requestIDHeader, id)
+		ctx := context.WithValue(r.Context(), contextRequestIDKey, id)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// validRequestID отсекает слишком длинные и непечатаемые значения из внешнего заголовка.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, ch := range id {
+		if ch < 0x21 || ch > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(buf)
+}
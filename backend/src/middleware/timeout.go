@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Timeouts — глобальный middleware, ограничивающий время обработки запроса.
+// Контекст запроса получает дедлайн и передаётся сервисам и репозиториям, поэтому
+// запросы к БД прерываются по таймауту, при отключении клиента и при остановке сервера.
+// Долгим маршрутам (резервные копии, файлы) Route задаёт собственное ограничение вместо общего.
+// Подключается через router.Use(timeouts.Middleware).
+type Timeouts struct {
+	def    time.Duration
+	routes map[*mux.Route]time.Duration
+}
+
+// NewTimeouts создаёт middleware с ограничением d для всех маршрутов. d <= 0 отключает ограничение.
+func NewTimeouts(d time.Duration) *Timeouts {
+	return &Timeouts{def: d, routes: map[*mux.Route]time.Duration{}}
+}
+
+// Route задаёт маршруту ограничение d вместо общего (d <= 0 — без ограничения) и возвращает маршрут.
+// Вызывается при настройке роутера, до запуска сервера.
+func (t *Timeouts) Route(route *mux.Route, d time.Duration) *mux.Route {
+	t.routes[route] = d
+	return route
+}
+
+// Middleware применяет к запросу ограничение его маршрута.
+func (t *Timeouts) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		d := t.def
+		if route := mux.CurrentRoute(r); route != nil {
+			if own, ok := t.routes[route]; ok {
+				d = own
+			}
+		}
+		if d <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), d)
+		defer cancel()
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
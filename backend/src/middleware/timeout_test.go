@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestTimeoutsRouteOverride(t *testing.T) {
+	timeouts := NewTimeouts(time.Second)
+	router := mux.NewRouter()
+	router.Use(timeouts.Middleware)
+
+	deadlines := map[string]time.Duration{}
+	record := func(w http.ResponseWriter, r *http.Request) {
+		if deadline, ok := r.Context().Deadline(); ok {
+			deadlines[r.URL.Path] = time.Until(deadline)
+		} else {
+			deadlines[r.URL.Path] = -1
+		}
+	}
+	router.HandleFunc("/short", record)
+	timeouts.Route(router.HandleFunc("/long", record), time.Hour)
+	timeouts.Route(router.HandleFunc("/unlimited", record), 0)
+
+	for _, path := range []string{"/short", "/long", "/unlimited"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if d := deadlines["/short"]; d <= 0 || d > time.Second {
+		t.Fatalf("/short deadline in %s, want the default 1s", d)
+	}
+	if d := deadlines["/long"]; d <= time.Second || d > time.Hour {
+		t.Fatalf("/long deadline in %s, want the route's 1h", d)
+	}
+	if d := deadlines["/unlimited"]; d != -1 {
+		t.Fatalf("/unlimited has a deadline in %s, want none", d)
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"warehouse-management-system/src/config"
+)
+
+const migrateUsage = `usage: wms migrate <command> [steps]
+
+commands:
+  up [N]     apply N pending migrations (all by default)
+  down [N]   roll back N last applied migrations (1 by default)
+  status     show applied and pending migrations`
+
+// runMigrateCommand выполняет подкоманду migrate: применение, откат и просмотр статуса миграций.
+func runMigrateCommand(db *config.DB, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing migrate command\n%s", migrateUsage)
+	}
+
+	steps := 0
+	if len(args) > 1 {
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid steps %q\n%s", args[1], migrateUsage)
+		}
+		steps = n
+	}
+
+	m, err := config.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := m.Up(steps)
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("Database is up to date")
+		}
+	case "down":
+		if _, err := m.Down(steps); err != nil {
+			return err
+		}
+	case "status":
+		return printMigrationStatus(m)
+	default:
+		return fmt.Errorf("unknown migrate command %q\n%s", args[0], migrateUsage)
+	}
+
+	version, err := m.Version()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Schema version: %d (latest %d)\n", version, m.Latest())
+	return nil
+}
+
+func printMigrationStatus(m *config.Migrator) error {
+	statuses, err := m.Status()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+	for _, st := range statuses {
+		appliedAt := "pending"
+		if st.AppliedAt != nil {
+			appliedAt = st.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\n", st.Version, st.Name, appliedAt)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrAPIKeyRejected возвращается при проверке API-ключа, если ключ неверный, истёк, отозван
+// или его владелец удалён. Любая другая ошибка проверки — сбой, а не отказ.
+var ErrAPIKeyRejected = errors.New("invalid, expired or revoked api key")
+
+// Права (scopes) API-ключа задаются в формате "<ресурс>:<действие>",
+// например "products:read" или "warehouse:write". "*" разрешает всё.
+const (
+	ScopeAll    = "*"
+	ScopeRead   = "read"
+	ScopeWrite  = "write"
+	scopeSep    = ":"
+	scopeAnyAct = "*"
+)
+
+// APIKey — долгоживущий ключ для машинных интеграций (ERP, сканеры и т.п.).
+// Сам ключ хранится только в виде хэша, для поиска используется открытый префикс.
+type APIKey struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	KeyHash    string     `json:"-"` // не возвращаем хэш наружу
+	UserID     string     `json:"user_id"`
+	Role       Role       `json:"role"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// NewAPIKey — фабричный метод создания API-ключа на доменном уровне.
+func NewAPIKey(name, prefix, keyHash, userID string, role Role, scopes []string, expiresAt *time.Time) *APIKey {
+	return &APIKey{
+		ID:        "",
+		Name:      name,
+		Prefix:    prefix,
+		KeyHash:   keyHash,
+		UserID:    userID,
+		Role:      role,
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+// IsActive сообщает, можно ли использовать ключ в момент now (не отозван и не истёк).
+func (k *APIKey) IsActive(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	if k.ExpiresAt != nil && !now.Before(*k.ExpiresAt) {
+		return false
+	}
+	return true
+}
+
+// Allows проверяет, разрешает ли набор scopes ключа действие action над ресурсом resource.
+func (k *APIKey) Allows(resource, action string) bool {
+	for _, scope := range k.Scopes {
+		if scope == ScopeAll {
+			return true
+		}
+		res, act, ok := strings.Cut(scope, scopeSep)
+		if !ok || res != resource {
+			continue
+		}
+		// write подразумевает read: интеграции, которые пишут, обычно и читают.
+		if act == scopeAnyAct || act == action || (act == ScopeWrite && action == ScopeRead) {
+			return true
+		}
+	}
+	return false
+}
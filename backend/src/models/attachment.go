@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// AttachmentOwner — тип сущности, к которой прикреплён файл.
+type AttachmentOwner string
+
+const (
+	AttachmentOwnerProduct  AttachmentOwner = "product"
+	AttachmentOwnerSupplier AttachmentOwner = "supplier"
+)
+
+// AttachmentKind — назначение вложения.
+type AttachmentKind string
+
+const (
+	AttachmentPhoto       AttachmentKind = "photo"
+	AttachmentSpecSheet   AttachmentKind = "spec_sheet"
+	AttachmentCertificate AttachmentKind = "certificate"
+	AttachmentOther       AttachmentKind = "other"
+)
+
+// Valid сообщает, поддерживается ли назначение вложения.
+func (k AttachmentKind) Valid() bool {
+	switch k {
+	case AttachmentPhoto, AttachmentSpecSheet, AttachmentCertificate, AttachmentOther:
+		return true
+	}
+	return false
+}
+
+// Attachment — файл, прикреплённый к товару или поставщику. Содержимое хранится вне БД
+// под ключом SHA256 (hex), уменьшенная копия изображения — под ключом ThumbnailSHA256.
+type Attachment struct {
+	ID              string          `json:"id"`
+	OwnerType       AttachmentOwner `json:"owner_type"`
+	OwnerID         string          `json:"owner_id"`
+	Kind            AttachmentKind  `json:"kind"`
+	FileName        string          `json:"file_name"`
+	ContentType     string          `json:"content_type"` // определяется по содержимому, а не по заголовку клиента
+	Size            int64           `json:"size"`
+	SHA256          string          `json:"sha256"`
+	ThumbnailSHA256 string          `json:"-"`
+	HasThumbnail    bool            `json:"has_thumbnail"`
+	CreatedBy       string          `json:"created_by,omitempty"`
+	CreatedAt       time.Time       `json:"created_at"`
+}
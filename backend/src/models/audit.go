@@ -0,0 +1,70 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Действия, фиксируемые в журнале аудита.
+const (
+	AuditActionCreate       = "create"
+	AuditActionUpdate       = "update"
+	AuditActionDelete       = "delete"
+	AuditActionArchive      = "archive"
+	AuditActionRestore      = "restore"
+	AuditActionRevoke       = "revoke"
+	AuditActionStatusChange = "status_change"
+	AuditActionReceipt      = "receipt"
+	AuditActionWriteOff     = "write_off"
+	AuditActionReserve      = "reserve"
+	AuditActionRebuild      = "rebuild"
+	AuditActionBackup       = "backup"
+)
+
+// Типы сущностей в журнале аудита.
+const (
+	AuditEntityUser          = "user"
+	AuditEntityUserIdentity  = "user_identity"
+	AuditEntityAPIKey        = "api_key"
+	AuditEntitySession       = "session"
+	AuditEntityProduct       = "product"
+	AuditEntityBarcode       = "barcode"
+	AuditEntityProductUnit   = "product_unit"
+	AuditEntityCategory      = "category"
+	AuditEntitySupplier      = "supplier"
+	AuditEntitySupplierCost  = "supplier_cost"
+	AuditEntityPriceList     = "price_list"
+	AuditEntityAttachment    = "attachment"
+	AuditEntityOrder         = "order"
+	AuditEntityStockMovement = "stock_movement"
+	AuditEntityStockBalance  = "stock_balance"
+	AuditEntityDatabase      = "database"
+)
+
+// AuditEntry — запись журнала аудита: кто, когда и что изменил.
+// Before/After содержат JSON-снимки сущности до и после изменения (null для создания/удаления).
+type AuditEntry struct {
+	ID         int64           `json:"id"`
+	ActorID    string          `json:"actor_id,omitempty"`
+	APIKeyID   string          `json:"api_key_id,omitempty"`
+	Action     string          `json:"action"`
+	EntityType string          `json:"entity_type"`
+	EntityID   string          `json:"entity_id"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	RequestID  string          `json:"request_id,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// AuditFilter — параметры выборки из журнала аудита. Пустые поля не ограничивают выборку.
+type AuditFilter struct {
+	ActorID    string
+	Action     string
+	EntityType string
+	EntityID   string
+	RequestID  string
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+	Offset     int
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// BackupInfo — сведения о файле резервной копии базы данных.
+type BackupInfo struct {
+	Name          string    `json:"name"`
+	Size          int64     `json:"size"`
+	CreatedAt     time.Time `json:"created_at"`
+	SchemaVersion int       `json:"schema_version,omitempty"`
+}
+
+// IntegrityReport — результат проверки целостности базы данных.
+type IntegrityReport struct {
+	OK       bool     `json:"ok"`
+	Problems []string `json:"problems"`
+}
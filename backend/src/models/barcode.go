@@ -0,0 +1,133 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// BarcodeType — символика штрихкода.
+type BarcodeType string
+
+const (
+	BarcodeEAN13   BarcodeType = "ean13"   // 13 цифр, последняя — контрольная
+	BarcodeUPCA    BarcodeType = "upca"    // 12 цифр, последняя — контрольная
+	BarcodeCode128 BarcodeType = "code128" // произвольная строка из печатных символов ASCII
+)
+
+// MaxCode128Length ограничивает длину Code 128: более длинный штрихкод не поместится на этикетке
+// и плохо читается ручными сканерами.
+const MaxCode128Length = 48
+
+// Упаковка по умолчанию — одна единица товара.
+const DefaultBarcodePackaging = "unit"
+
+var (
+	ErrInvalidBarcode  = errors.New("invalid barcode")
+	ErrBarcodeChecksum = errors.New("invalid barcode check digit")
+)
+
+// Barcode — штрихкод товара на определённом уровне упаковки: при сканировании кода коробки
+// на складе учитывается Quantity единиц товара.
+type Barcode struct {
+	Code      string      `json:"code"`
+	ProductID string      `json:"product_id"`
+	Type      BarcodeType `json:"type"`
+	Packaging string      `json:"packaging"`
+	Quantity  Decimal     `json:"quantity"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// Valid сообщает, поддерживается ли символика.
+func (t BarcodeType) Valid() bool {
+	switch t {
+	case BarcodeEAN13, BarcodeUPCA, BarcodeCode128:
+		return true
+	}
+	return false
+}
+
+// DetectBarcodeType определяет символику по виду кода: 13 цифр — EAN-13, 12 цифр — UPC-A,
+// остальное — Code 128.
+func DetectBarcodeType(code string) BarcodeType {
+	if isDigits(code) {
+		switch len(code) {
+		case 13:
+			return BarcodeEAN13
+		case 12:
+			return BarcodeUPCA
+		}
+	}
+	return BarcodeCode128
+}
+
+// ValidateBarcode проверяет, что code допустим для символики t. Для EAN-13 и UPC-A
+// проверяется контрольная цифра (ErrBarcodeChecksum), чтобы опечатка не превратилась в чужой код.
+func ValidateBarcode(t BarcodeType, code string) error {
+	switch t {
+	case BarcodeEAN13, BarcodeUPCA:
+		length := 13
+		if t == BarcodeUPCA {
+			length = 12
+		}
+		if len(code) != length || !isDigits(code) {
+			return ErrInvalidBarcode
+		}
+		if EANCheckDigit(code[:length-1]) != code[length-1] {
+			return ErrBarcodeChecksum
+		}
+		return nil
+	case BarcodeCode128:
+		if code == "" || len(code) > MaxCode128Length {
+			return ErrInvalidBarcode
+		}
+		for i := 0; i < len(code); i++ {
+			if code[i] < ' ' || code[i] > '~' {
+				return ErrInvalidBarcode
+			}
+		}
+		return nil
+	}
+	return ErrInvalidBarcode
+}
+
+// EANCheckDigit вычисляет контрольную цифру EAN/UPC для цифр без неё: веса 3 и 1 чередуются,
+// начиная с 3 у самой правой цифры.
+func EANCheckDigit(digits string) byte {
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if (len(digits)-1-i)%2 == 0 {
+			d *= 3
+		}
+		sum += d
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+// BarcodeAliases возвращает варианты записи кода, под которыми сканер может прочитать один и тот же
+// штрихкод: UPC-A часто передаётся как EAN-13 с ведущим нулём, и наоборот.
+func BarcodeAliases(code string) []string {
+	aliases := []string{code}
+	if isDigits(code) {
+		switch {
+		case len(code) == 12:
+			aliases = append(aliases, "0"+code)
+		case len(code) == 13 && strings.HasPrefix(code, "0"):
+			aliases = append(aliases, code[1:])
+		}
+	}
+	return aliases
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEANCheckDigit(t *testing.T) {
+	tests := []struct {
+		digits string
+		want   byte
+	}{
+		{digits: "400638133393", want: '1'}, // EAN-13 4006381333931
+		{digits: "03600029145", want: '2'},  // UPC-A 036000291452
+		{digits: "003600029145", want: '2'}, // тот же UPC-A в записи EAN-13
+		{digits: "200000000000", want: '8'},
+	}
+	for _, tt := range tests {
+		if got := EANCheckDigit(tt.digits); got != tt.want {
+			t.Errorf("EANCheckDigit(%s) = %c, want %c", tt.digits, got, tt.want)
+		}
+	}
+}
+
+func TestValidateBarcode(t *testing.T) {
+	tests := []struct {
+		typ  BarcodeType
+		code string
+		want error
+	}{
+		{typ: BarcodeEAN13, code: "4006381333931"},
+		{typ: BarcodeEAN13, code: "4006381333932", want: ErrBarcodeChecksum},
+		{typ: BarcodeEAN13, code: "400638133393", want: ErrInvalidBarcode},
+		{typ: BarcodeEAN13, code: "40063813339x1", want: ErrInvalidBarcode},
+		{typ: BarcodeUPCA, code: "036000291452"},
+		{typ: BarcodeUPCA, code: "036000291453", want: ErrBarcodeChecksum},
+		{typ: BarcodeEAN13, code: "0036000291452"},
+		{typ: BarcodeCode128, code: "Wikipedia"},
+		{typ: BarcodeCode128, code: "", want: ErrInvalidBarcode},
+		{typ: BarcodeCode128, code: "tab\there", want: ErrInvalidBarcode},
+		{typ: "qr", code: "4006381333931", want: ErrInvalidBarcode},
+	}
+	for _, tt := range tests {
+		if err := ValidateBarcode(tt.typ, tt.code); err != tt.want {
+			t.Errorf("ValidateBarcode(%s, %q) = %v, want %v", tt.typ, tt.code, err, tt.want)
+		}
+	}
+}
+
+func TestBarcodeAliases(t *testing.T) {
+	tests := []struct {
+		code string
+		want []string
+	}{
+		{code: "036000291452", want: []string{"036000291452", "0036000291452"}},
+		{code: "0036000291452", want: []string{"0036000291452", "036000291452"}},
+		{code: "4006381333931", want: []string{"4006381333931"}},
+		{code: "ABC-1", want: []string{"ABC-1"}},
+	}
+	for _, tt := range tests {
+		if got := BarcodeAliases(tt.code); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("BarcodeAliases(%s) = %v, want %v", tt.code, got, tt.want)
+		}
+	}
+}
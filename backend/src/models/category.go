@@ -1,24 +1,41 @@
 package models
 
-import "time"
+import (
+	"errors"
+	"time"
+)
+
+// ErrCategoryCycle возвращается хранилищем, если новый родитель категории — она сама или её потомок.
+var ErrCategoryCycle = errors.New("category cannot be moved into itself or its subcategory")
 
 // Category представляет доменную модель категории товара.
 // Категория нужна для группировки товаров и дальнейшей фильтрации/отчётности.
+// Категории образуют дерево: ParentID — родительская категория, пустой у категорий верхнего уровня.
 type Category struct {
-	ID        string    `json:"id"`
-	Name      string    `json:"name"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	ParentID   string     `json:"parent_id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	Version    int64      `json:"version"`
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
 }
 
 // NewCategory — фабричный метод создания категории на доменном уровне.
 // ID оставляем пустым — его сгенерирует репозиторий/БД.
-func NewCategory(name string) *Category {
+func NewCategory(name, parentID string) *Category {
 	now := time.Now().UTC()
 	return &Category{
 		ID:        "",
 		Name:      name,
+		ParentID:  parentID,
 		CreatedAt: now,
 		UpdatedAt: now,
 	}
 }
+
+// CategoryNode — категория с дочерними категориями для вывода дерева.
+type CategoryNode struct {
+	*Category
+	Children []*CategoryNode `json:"children"`
+}
@@ -0,0 +1,187 @@
+package models
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DecimalScale — число знаков после запятой, которое хранит Decimal.
+const DecimalScale = 4
+
+// decimalFactor = 10^DecimalScale.
+const decimalFactor = 10000
+
+// Decimal — десятичное число с фиксированной точностью (DecimalScale знаков после запятой).
+// Хранится как целое число десятитысячных долей, поэтому сложение и сравнение количеств
+// и сумм точны, в отличие от float64. В БД — INTEGER, в JSON — число (например 12.5);
+// на вход принимается и число, и строка, без промежуточного преобразования во float64.
+type Decimal int64
+
+var (
+	ErrInvalidDecimal  = errors.New("invalid decimal number")
+	ErrDecimalOverflow = errors.New("decimal number is out of range")
+)
+
+var decimalRe = regexp.MustCompile(`^(-)?(\d+)(?:\.(\d+))?$`)
+
+// ParseDecimal разбирает число вида "12", "-0.5", "3.1415". Знаков после запятой может быть
+// не больше DecimalScale: лишние знаки означали бы молчаливое округление.
+func ParseDecimal(s string) (Decimal, error) {
+	m := decimalRe.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return 0, ErrInvalidDecimal
+	}
+	intPart, frac := m[2], strings.TrimRight(m[3], "0")
+	if len(frac) > DecimalScale {
+		return 0, fmt.Errorf("%w: more than %d decimal places", ErrInvalidDecimal, DecimalScale)
+	}
+
+	whole, err := strconv.ParseInt(intPart, 10, 64)
+	if err != nil || whole > math.MaxInt64/decimalFactor {
+		return 0, ErrDecimalOverflow
+	}
+	fracUnits := int64(0)
+	if frac != "" {
+		fracUnits, _ = strconv.ParseInt(frac+strings.Repeat("0", DecimalScale-len(frac)), 10, 64)
+	}
+
+	units := whole*decimalFactor + fracUnits
+	if units < 0 {
+		return 0, ErrDecimalOverflow
+	}
+	if m[1] == "-" {
+		units = -units
+	}
+	return Decimal(units), nil
+}
+
+// DecimalFromInt возвращает целое число n как Decimal.
+func DecimalFromInt(n int64) Decimal {
+	return Decimal(n * decimalFactor)
+}
+
+// String возвращает число без лишних нулей в дробной части: "12", "0.5", "-3.125".
+func (d Decimal) String() string {
+	units := int64(d)
+	sign := ""
+	if units < 0 {
+		sign = "-"
+		units = -units
+	}
+	whole, frac := units/decimalFactor, units%decimalFactor
+	if frac == 0 {
+		return sign + strconv.FormatInt(whole, 10)
+	}
+	fracStr := strings.TrimRight(fmt.Sprintf("%0*d", DecimalScale, frac), "0")
+	return sign + strconv.FormatInt(whole, 10) + "." + fracStr
+}
+
+// HasPrecision сообщает, укладывается ли число в places знаков после запятой.
+func (d Decimal) HasPrecision(places int) bool {
+	if places >= DecimalScale {
+		return true
+	}
+	if places < 0 {
+		places = 0
+	}
+	step := int64(math.Pow10(DecimalScale - places))
+	return int64(d)%step == 0
+}
+
+// Neg возвращает число с противоположным знаком.
+func (d Decimal) Neg() Decimal {
+	return -d
+}
+
+// Mul возвращает произведение d*o. Если в произведении больше DecimalScale знаков после запятой,
+// возвращается ErrInvalidDecimal: пересчёт количеств не должен молча округлять.
+func (d Decimal) Mul(o Decimal) (Decimal, error) {
+	product := new(big.Int).Mul(big.NewInt(int64(d)), big.NewInt(int64(o)))
+	quo, rem := new(big.Int).QuoRem(product, big.NewInt(decimalFactor), new(big.Int))
+	if rem.Sign() != 0 {
+		return 0, fmt.Errorf("%w: more than %d decimal places", ErrInvalidDecimal, DecimalScale)
+	}
+	if !quo.IsInt64() {
+		return 0, ErrDecimalOverflow
+	}
+	return Decimal(quo.Int64()), nil
+}
+
+// MulRound возвращает произведение d*o, округлённое до DecimalScale знаков (половина — от нуля).
+// Подходит для цен, где округление до сотых долей копейки допустимо.
+func (d Decimal) MulRound(o Decimal) (Decimal, error) {
+	num := new(big.Int).Mul(big.NewInt(int64(d)), big.NewInt(int64(o)))
+	return roundQuo(num, big.NewInt(decimalFactor))
+}
+
+// Div возвращает частное d/o, округлённое до DecimalScale знаков (половина — от нуля).
+func (d Decimal) Div(o Decimal) (Decimal, error) {
+	if o == 0 {
+		return 0, ErrInvalidDecimal
+	}
+	num := new(big.Int).Mul(big.NewInt(int64(d)), big.NewInt(decimalFactor))
+	return roundQuo(num, big.NewInt(int64(o)))
+}
+
+// roundQuo возвращает num/den в единицах Decimal, округлённое до целого (половина — от нуля).
+func roundQuo(num, den *big.Int) (Decimal, error) {
+	quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	// |2·остаток| >= |делитель| — округляем от нуля.
+	if new(big.Int).Abs(new(big.Int).Lsh(rem, 1)).Cmp(new(big.Int).Abs(den)) >= 0 {
+		if num.Sign()*den.Sign() < 0 {
+			quo.Sub(quo, big.NewInt(1))
+		} else {
+			quo.Add(quo, big.NewInt(1))
+		}
+	}
+	if !quo.IsInt64() {
+		return 0, ErrDecimalOverflow
+	}
+	return Decimal(quo.Int64()), nil
+}
+
+// MarshalJSON записывает число как JSON-число с точным десятичным представлением.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON принимает JSON-число или строку с числом.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	raw := string(data)
+	if raw == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(raw); err == nil {
+		raw = unquoted
+	}
+	v, err := ParseDecimal(raw)
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
+
+// Value сохраняет число в БД как целое число десятитысячных долей.
+func (d Decimal) Value() (driver.Value, error) {
+	return int64(d), nil
+}
+
+// Scan читает число, сохранённое Value.
+func (d *Decimal) Scan(src any) error {
+	switch v := src.(type) {
+	case int64:
+		*d = Decimal(v)
+	case nil:
+		*d = 0
+	default:
+		return fmt.Errorf("cannot scan %T into Decimal", src)
+	}
+	return nil
+}
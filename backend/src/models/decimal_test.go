@@ -0,0 +1,163 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Decimal
+		err  error
+	}{
+		{in: "12", want: 120000},
+		{in: "-0.5", want: -5000},
+		{in: "3.1415", want: 31415},
+		{in: " 2.5 ", want: 25000},
+		{in: "1.23450", want: 12345}, // пятый знак — ноль, округления нет
+		{in: "1.00001", err: ErrInvalidDecimal},
+		{in: "-0.00005", err: ErrInvalidDecimal},
+		{in: "922337203685477.5807", want: math.MaxInt64},
+		{in: "-922337203685477.5807", want: -math.MaxInt64},
+		{in: "922337203685477.5808", err: ErrDecimalOverflow},
+		{in: "922337203685478", err: ErrDecimalOverflow},
+		{in: "99999999999999999999", err: ErrDecimalOverflow},
+		{in: "", err: ErrInvalidDecimal},
+		{in: "1.", err: ErrInvalidDecimal},
+		{in: ".5", err: ErrInvalidDecimal},
+		{in: "1e3", err: ErrInvalidDecimal},
+		{in: "+1", err: ErrInvalidDecimal},
+	}
+	for _, tt := range tests {
+		got, err := ParseDecimal(tt.in)
+		if !errors.Is(err, tt.err) || (tt.err == nil && got != tt.want) {
+			t.Errorf("ParseDecimal(%q) = %d, %v; want %d, %v", tt.in, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestDecimalString(t *testing.T) {
+	tests := []struct {
+		in   Decimal
+		want string
+	}{
+		{in: 120000, want: "12"},
+		{in: -5000, want: "-0.5"},
+		{in: 31250, want: "3.125"},
+		{in: 1, want: "0.0001"},
+		{in: -1, want: "-0.0001"},
+		{in: 0, want: "0"},
+	}
+	for _, tt := range tests {
+		if got := tt.in.String(); got != tt.want {
+			t.Errorf("Decimal(%d).String() = %q, want %q", int64(tt.in), got, tt.want)
+		}
+		if back, err := ParseDecimal(tt.want); err != nil || back != tt.in {
+			t.Errorf("ParseDecimal(%q) = %d, %v; want %d", tt.want, back, err, int64(tt.in))
+		}
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	d := func(s string) Decimal {
+		v, err := ParseDecimal(s)
+		if err != nil {
+			t.Fatalf("ParseDecimal(%q): %v", s, err)
+		}
+		return v
+	}
+	max := Decimal(math.MaxInt64)
+
+	tests := []struct {
+		name string
+		op   func(a, b Decimal) (Decimal, error)
+		a, b Decimal
+		want Decimal
+		err  error
+	}{
+		{name: "mul", op: Decimal.Mul, a: d("1.5"), b: d("2"), want: d("3")},
+		{name: "mul negative", op: Decimal.Mul, a: d("-12"), b: d("0.25"), want: d("-3")},
+		{name: "mul fifth digit", op: Decimal.Mul, a: d("0.0001"), b: d("0.5"), err: ErrInvalidDecimal},
+		{name: "mul overflow", op: Decimal.Mul, a: max, b: d("2"), err: ErrDecimalOverflow},
+
+		{name: "mul round", op: Decimal.MulRound, a: d("19.99"), b: d("3"), want: d("59.97")},
+		{name: "mul round half up", op: Decimal.MulRound, a: d("0.0001"), b: d("0.5"), want: d("0.0001")},
+		{name: "mul round half away from zero", op: Decimal.MulRound, a: d("-0.0001"), b: d("0.5"), want: d("-0.0001")},
+		{name: "mul round down", op: Decimal.MulRound, a: d("0.0001"), b: d("0.4"), want: 0},
+		{name: "mul round price per gram", op: Decimal.MulRound, a: d("123.4567"), b: d("0.001"), want: d("0.1235")},
+		{name: "mul round overflow", op: Decimal.MulRound, a: max, b: d("1.5"), err: ErrDecimalOverflow},
+
+		{name: "div", op: Decimal.Div, a: d("1"), b: d("3"), want: d("0.3333")},
+		{name: "div half up", op: Decimal.Div, a: d("2"), b: d("3"), want: d("0.6667")},
+		{name: "div negative", op: Decimal.Div, a: d("-2"), b: d("3"), want: d("-0.6667")},
+		{name: "div negative divisor", op: Decimal.Div, a: d("2"), b: d("-3"), want: d("-0.6667")},
+		{name: "div fifth digit half", op: Decimal.Div, a: d("0.0001"), b: d("2"), want: d("0.0001")},
+		{name: "div by zero", op: Decimal.Div, a: d("1"), b: 0, err: ErrInvalidDecimal},
+		{name: "div overflow", op: Decimal.Div, a: max, b: d("0.5"), err: ErrDecimalOverflow},
+	}
+	for _, tt := range tests {
+		got, err := tt.op(tt.a, tt.b)
+		if !errors.Is(err, tt.err) || (tt.err == nil && got != tt.want) {
+			t.Errorf("%s: %s op %s = %s, %v; want %s, %v", tt.name, tt.a, tt.b, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestDecimalJSON(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Decimal
+		err  bool
+	}{
+		{in: `12.5`, want: 125000},
+		{in: `"12.5"`, want: 125000},
+		{in: `-0.0001`, want: -1},
+		{in: `null`, want: 7}, // null не меняет значение
+		{in: `1e3`, err: true},
+		{in: `"1.00001"`, err: true},
+		{in: `true`, err: true},
+		{in: `"abc"`, err: true},
+	}
+	for _, tt := range tests {
+		got := Decimal(7)
+		err := json.Unmarshal([]byte(tt.in), &got)
+		if (err != nil) != tt.err || (!tt.err && got != tt.want) {
+			t.Errorf("Unmarshal(%s) = %d, %v; want %d, error %v", tt.in, got, err, tt.want, tt.err)
+		}
+	}
+
+	out, err := json.Marshal(struct {
+		Price Decimal `json:"price"`
+	}{Price: 1234500})
+	if err != nil || string(out) != `{"price":123.45}` {
+		t.Fatalf("Marshal = %s, %v", out, err)
+	}
+}
+
+func TestDecimalScan(t *testing.T) {
+	tests := []struct {
+		src  any
+		want Decimal
+		err  bool
+	}{
+		{src: int64(125000), want: 125000},
+		{src: int64(-1), want: -1},
+		{src: nil, want: 0},
+		{src: 12.5, err: true},
+		{src: "12.5", err: true},
+	}
+	for _, tt := range tests {
+		got := Decimal(7)
+		err := got.Scan(tt.src)
+		if (err != nil) != tt.err || (!tt.err && got != tt.want) {
+			t.Errorf("Scan(%#v) = %d, %v; want %d, error %v", tt.src, got, err, tt.want, tt.err)
+		}
+	}
+
+	if v, err := Decimal(125000).Value(); err != nil || v != int64(125000) {
+		t.Fatalf("Value() = %#v, %v", v, err)
+	}
+}
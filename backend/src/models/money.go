@@ -0,0 +1,13 @@
+package models
+
+import "regexp"
+
+// DefaultCurrency — валюта цен, если клиент её не указал.
+const DefaultCurrency = "RUB"
+
+var currencyRe = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// ValidCurrency проверяет, что code — трёхбуквенный код валюты ISO 4217 (например RUB, USD).
+func ValidCurrency(code string) bool {
+	return currencyRe.MatchString(code)
+}
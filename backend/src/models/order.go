@@ -14,9 +14,13 @@ const (
 
 // OrderItem описывает позицию в заказе.
 type OrderItem struct {
-	ProductID string  `json:"product_id"`
-	Quantity  float64 `json:"quantity"`
-	Price     float64 `json:"price"` // цена продажи за единицу
+	ProductID string        `json:"product_id"`
+	Quantity  Decimal       `json:"quantity"`
+	Unit      UnitOfMeasure `json:"unit,omitempty"` // единица количества и цены; пустая — базовая единица товара
+	Price     Decimal       `json:"price"`          // цена продажи за единицу
+	Currency  string        `json:"currency"`       // валюта цены
+	// PriceListID — прайс-лист, из которого подставлена цена; пусто, если цену указал клиент.
+	PriceListID string `json:"price_list_id,omitempty"`
 }
 
 // Order представляет доменную модель заказа.
@@ -28,16 +32,18 @@ type Order struct {
 	CreatedAt  time.Time     `json:"created_at"`
 	UpdatedAt  time.Time     `json:"updated_at"`
 	StatusHist []StatusEntry `json:"status_history"`
+	Version    int64         `json:"version"`
 }
 
 // StatusEntry описывает изменение статуса заказа.
 type StatusEntry struct {
 	Status    OrderStatus `json:"status"`
+	ChangedBy string      `json:"changed_by,omitempty"`
 	ChangedAt time.Time   `json:"changed_at"`
 }
 
-// NewOrder — фабрика для создания нового заказа.
-func NewOrder(customer string, items []OrderItem) *Order {
+// NewOrder — фабрика для создания нового заказа. createdBy — пользователь, создавший заказ.
+func NewOrder(customer string, items []OrderItem, createdBy string) *Order {
 	now := time.Now().UTC()
 	return &Order{
 		ID:        "",
@@ -49,10 +55,9 @@ func NewOrder(customer string, items []OrderItem) *Order {
 		StatusHist: []StatusEntry{
 			{
 				Status:    OrderStatusNew,
+				ChangedBy: createdBy,
 				ChangedAt: now,
 			},
 		},
 	}
 }
-
-
@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// SupplierCost — условия поставщика на товар: закупочная цена за базовую единицу товара,
+// срок поставки и минимальная партия (в базовых единицах, 0 — без ограничения).
+type SupplierCost struct {
+	SupplierID       string    `json:"supplier_id"`
+	ProductID        string    `json:"product_id"`
+	SupplierSKU      string    `json:"supplier_sku,omitempty"` // артикул товара у поставщика
+	Cost             Decimal   `json:"cost"`
+	Currency         string    `json:"currency"`
+	LeadTimeDays     int       `json:"lead_time_days"`
+	MinOrderQuantity Decimal   `json:"min_order_quantity"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// PriceList — прайс-лист продаж. Действует с ValidFrom до ValidTo (nil — бессрочно).
+// Прайс-лист с Customer применяется только к заказам этого покупателя и важнее общего.
+type PriceList struct {
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	Currency  string          `json:"currency"`
+	Customer  string          `json:"customer,omitempty"`
+	ValidFrom time.Time       `json:"valid_from"`
+	ValidTo   *time.Time      `json:"valid_to,omitempty"`
+	Items     []PriceListItem `json:"items,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+	Version   int64           `json:"version"`
+}
+
+// ActiveAt сообщает, действует ли прайс-лист в момент t.
+func (l *PriceList) ActiveAt(t time.Time) bool {
+	return !t.Before(l.ValidFrom) && (l.ValidTo == nil || t.Before(*l.ValidTo))
+}
+
+// PriceListItem — цена товара в прайс-листе за базовую единицу товара.
+type PriceListItem struct {
+	ProductID string  `json:"product_id"`
+	Price     Decimal `json:"price"`
+}
+
+// ProductPrice — действующая цена продажи товара и прайс-лист, из которого она взята.
+type ProductPrice struct {
+	ProductID   string  `json:"product_id"`
+	Price       Decimal `json:"price"`
+	Currency    string  `json:"currency"`
+	PriceListID string  `json:"price_list_id"`
+}
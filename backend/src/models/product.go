@@ -1,30 +1,70 @@
 package models
 
-import "time"
+import (
+	"errors"
+	"time"
+)
+
+// ErrDuplicateSKU возвращается хранилищем, если товар с таким SKU уже есть
+// (например, его успела сохранить параллельная транзакция).
+var ErrDuplicateSKU = errors.New("product with this SKU already exists")
 
 // UnitOfMeasure описывает единицу измерения товара (штуки, килограммы и т.п.).
 // Используем string, чтобы не привязываться к конкретному типу в БД.
 type UnitOfMeasure string
 
 const (
-	UnitPiece UnitOfMeasure = "pcs"  // штуки
-	UnitKg    UnitOfMeasure = "kg"   // килограммы
-	UnitLitre UnitOfMeasure = "l"    // литры
-	UnitBox   UnitOfMeasure = "box"  // коробки/упаковки
+	UnitPiece  UnitOfMeasure = "pcs"    // штуки
+	UnitKg     UnitOfMeasure = "kg"     // килограммы
+	UnitGram   UnitOfMeasure = "g"      // граммы
+	UnitLitre  UnitOfMeasure = "l"      // литры
+	UnitMl     UnitOfMeasure = "ml"     // миллилитры
+	UnitMetre  UnitOfMeasure = "m"      // метры
+	UnitPack   UnitOfMeasure = "pack"   // упаковки
+	UnitBox    UnitOfMeasure = "box"    // коробки/упаковки
+	UnitPallet UnitOfMeasure = "pallet" // паллеты
 )
 
+// Valid сообщает, известна ли единица измерения.
+func (u UnitOfMeasure) Valid() bool {
+	switch u {
+	case UnitPiece, UnitKg, UnitGram, UnitLitre, UnitMl, UnitMetre, UnitPack, UnitBox, UnitPallet:
+		return true
+	}
+	return false
+}
+
+// Precision возвращает допустимое число знаков после запятой в количестве товара:
+// штучный товар учитывается только целыми единицами, весовой и наливной — с точностью до грамма/миллилитра.
+func (u UnitOfMeasure) Precision() int {
+	switch u {
+	case UnitPiece, UnitGram, UnitMl, UnitPack, UnitBox, UnitPallet:
+		return 0
+	case UnitKg, UnitLitre, UnitMetre:
+		return 3
+	}
+	return DecimalScale
+}
+
 // Product представляет доменную модель товара.
 // Здесь нет деталей хранения (таблицы, индексы и т.п.), только бизнес-сущность.
+// Заархивированный товар (ArchivedAt != nil) скрыт из списков, но хранится ради истории движений и заказов.
+// Вариант товара (ParentID != "") — конкретный размер, цвет и т.п. шаблона ParentID;
+// чем варианты шаблона отличаются друг от друга, описывают Attributes.
 type Product struct {
-	ID          string        `json:"id"`
-	SKU         string        `json:"sku"`
-	Name        string        `json:"name"`
-	Description string        `json:"description,omitempty"`
-	CategoryID  string        `json:"category_id"`
-	SupplierID  string        `json:"supplier_id,omitempty"`
-	Unit        UnitOfMeasure `json:"unit"`
-	CreatedAt   time.Time     `json:"created_at"`
-	UpdatedAt   time.Time     `json:"updated_at"`
+	ID          string             `json:"id"`
+	SKU         string             `json:"sku"`
+	Name        string             `json:"name"`
+	Description string             `json:"description,omitempty"`
+	CategoryID  string             `json:"category_id"`
+	SupplierID  string             `json:"supplier_id,omitempty"`
+	Unit        UnitOfMeasure      `json:"unit"`
+	ParentID    string             `json:"parent_id,omitempty"`
+	Attributes  []ProductAttribute `json:"attributes,omitempty"`
+	CreatedAt   time.Time          `json:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+	Version     int64              `json:"version"`
+	ArchivedAt  *time.Time         `json:"archived_at,omitempty"`
 }
 
 // NewProduct — фабричный метод создания товара на доменном уровне.
@@ -43,5 +83,3 @@ func NewProduct(sku, name, description, categoryID, supplierID string, unit Unit
 		UpdatedAt:   now,
 	}
 }
-
-
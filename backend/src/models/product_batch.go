@@ -0,0 +1,35 @@
+package models
+
+import "fmt"
+
+// ProductChangeOp — вид изменения товара в пакете.
+type ProductChangeOp string
+
+const (
+	ProductChangeCreate  ProductChangeOp = "create"  // новый товар
+	ProductChangeUpdate  ProductChangeOp = "update"  // изменение полей товара
+	ProductChangeArchive ProductChangeOp = "archive" // архивация
+	ProductChangeDelete  ProductChangeOp = "delete"  // физическое удаление
+)
+
+// ProductChange — одно изменение пакета. Product содержит товар в том виде, в каком его нужно
+// сохранить; Version — версия, которую товар должен иметь в БД в момент изменения.
+type ProductChange struct {
+	Op      ProductChangeOp
+	Product *Product
+}
+
+// ProductChangeError — ошибка, на которой хранилище прервало применение пакета;
+// Index — номер изменения в пакете. Ни одно изменение пакета при этом не сохраняется.
+type ProductChangeError struct {
+	Index int
+	Err   error
+}
+
+func (e *ProductChangeError) Error() string {
+	return fmt.Sprintf("change %d: %v", e.Index, e.Err)
+}
+
+func (e *ProductChangeError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,116 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ProductSortField — поле сортировки списка товаров.
+type ProductSortField string
+
+const (
+	ProductSortCreatedAt ProductSortField = "created_at"
+	ProductSortUpdatedAt ProductSortField = "updated_at"
+	ProductSortName      ProductSortField = "name"
+	ProductSortSKU       ProductSortField = "sku"
+)
+
+// Valid сообщает, поддерживается ли сортировка по полю.
+func (f ProductSortField) Valid() bool {
+	switch f {
+	case ProductSortCreatedAt, ProductSortUpdatedAt, ProductSortName, ProductSortSKU:
+		return true
+	}
+	return false
+}
+
+// ProductFilter — параметры выборки товаров. Пустые поля не ограничивают выборку.
+// Страница задаётся либо смещением Offset, либо курсором After (ключ последнего товара
+// предыдущей страницы): курсор не пропускает и не повторяет строки, если список меняется между запросами.
+type ProductFilter struct {
+	// Query — подстрока в SKU, названии или описании.
+	Query string
+	// CategoryID — категория; в выборку входят и товары её подкатегорий.
+	CategoryID string
+	SupplierID string
+	// ParentID — шаблон: выбираются только его варианты.
+	ParentID        string
+	IncludeArchived bool
+	Sort            ProductSortField
+	Desc            bool
+	Limit           int
+	Offset          int
+	After           *ProductCursor
+}
+
+// ProductCursor — позиция в отсортированном списке товаров: значение поля сортировки и ID
+// последнего товара страницы (ID делает порядок однозначным при равных значениях).
+type ProductCursor struct {
+	Sort  ProductSortField `json:"s"`
+	Desc  bool             `json:"d,omitempty"`
+	Value string           `json:"v"`
+	ID    string           `json:"id"`
+}
+
+// ProductPage — страница списка товаров. NextCursor пуст на последней странице.
+type ProductPage struct {
+	Items      []*Product
+	Total      int
+	NextCursor string
+}
+
+var ErrInvalidCursor = errors.New("invalid page cursor")
+
+// Encode возвращает курсор в виде непрозрачной для клиента строки.
+func (c ProductCursor) Encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeProductCursor разбирает строку, полученную от ProductCursor.Encode.
+func DecodeProductCursor(s string) (*ProductCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c ProductCursor
+	if err := json.Unmarshal(raw, &c); err != nil || !c.Sort.Valid() || c.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// NewProductCursor возвращает курсор, указывающий на товар p в списке с сортировкой sort.
+func NewProductCursor(p *Product, sort ProductSortField, desc bool) ProductCursor {
+	c := ProductCursor{Sort: sort, Desc: desc, ID: p.ID}
+	switch sort {
+	case ProductSortCreatedAt:
+		c.Value = p.CreatedAt.UTC().Format(time.RFC3339Nano)
+	case ProductSortUpdatedAt:
+		c.Value = p.UpdatedAt.UTC().Format(time.RFC3339Nano)
+	case ProductSortName:
+		c.Value = p.Name
+	case ProductSortSKU:
+		c.Value = p.SKU
+	}
+	return c
+}
+
+// ProductSearchHit — товар, найденный поиском, с оценкой релевантности и подсветкой совпадений.
+type ProductSearchHit struct {
+	*Product
+	// Score — релевантность: чем больше, тем лучше совпадение (без полнотекстового индекса — 0).
+	Score     float64          `json:"score"`
+	Highlight ProductHighlight `json:"highlight"`
+}
+
+// ProductHighlight — поля товара с совпадениями, обёрнутыми в <mark>...</mark>.
+// Остальной текст экранирован для HTML, поэтому строки можно вставлять в разметку как есть.
+// Description — фрагмент описания вокруг совпадения, а не описание целиком.
+type ProductHighlight struct {
+	SKU         string `json:"sku"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// Session — серверная запись о выданном пользователю JWT (вход с конкретного устройства).
+// ID сессии передаётся в токене (claim jti); токен принимается, только пока сессия активна,
+// поэтому администратор может принудительно завершить вход, не дожидаясь истечения токена.
+type Session struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"user_id"`
+	Provider   string     `json:"provider"`
+	UserAgent  string     `json:"user_agent"`
+	IP         string     `json:"ip"`
+	IssuedAt   time.Time  `json:"issued_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// NewSession — фабричный метод создания сессии на доменном уровне.
+func NewSession(userID, provider, userAgent, ip string, ttl time.Duration) *Session {
+	now := time.Now().UTC()
+	return &Session{
+		ID:         "",
+		UserID:     userID,
+		Provider:   provider,
+		UserAgent:  userAgent,
+		IP:         ip,
+		IssuedAt:   now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(ttl),
+	}
+}
+
+// IsActive сообщает, что сессия не отозвана и не истекла на момент now.
+func (s *Session) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
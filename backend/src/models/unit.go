@@ -0,0 +1,59 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrUnitNotDefined = errors.New("unit is not defined for the product")
+	ErrUnitCycle      = errors.New("unit conversion refers to itself")
+)
+
+// UnitConversion — дополнительная единица измерения товара: 1 Unit = Quantity OfUnit.
+// OfUnit — базовая единица товара или другая его дополнительная единица, так задаётся иерархия
+// упаковок: 1 box = 12 pcs, 1 pallet = 40 box. Factor — сколько базовых единиц в одной Unit
+// (вычисляется по цепочке, в БД не хранится).
+type UnitConversion struct {
+	ProductID string        `json:"product_id"`
+	Unit      UnitOfMeasure `json:"unit"`
+	Quantity  Decimal       `json:"quantity"`
+	OfUnit    UnitOfMeasure `json:"of_unit"`
+	Factor    Decimal       `json:"factor"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+// metricFactors — стандартные соотношения единиц, которые не нужно задавать для каждого товара:
+// metricFactors[база][единица] — сколько базовых единиц в одной единице.
+var metricFactors = map[UnitOfMeasure]map[UnitOfMeasure]Decimal{
+	UnitKg:    {UnitGram: 10},                    // 1 g = 0.001 kg
+	UnitGram:  {UnitKg: DecimalFromInt(1000)},    // 1 kg = 1000 g
+	UnitLitre: {UnitMl: 10},                      // 1 ml = 0.001 l
+	UnitMl:    {UnitLitre: DecimalFromInt(1000)}, // 1 l = 1000 ml
+}
+
+// UnitFactor возвращает, сколько базовых единиц base содержится в одной единице unit, с учётом
+// дополнительных единиц товара conversions (по ключу Unit) и стандартных метрических соотношений.
+func UnitFactor(base, unit UnitOfMeasure, conversions map[UnitOfMeasure]*UnitConversion) (Decimal, error) {
+	factor := DecimalFromInt(1)
+	// Цепочка не длиннее числа единиц товара; более длинная означает цикл.
+	for steps := 0; steps <= len(conversions); steps++ {
+		if unit == base {
+			return factor, nil
+		}
+		if c, ok := conversions[unit]; ok {
+			f, err := factor.Mul(c.Quantity)
+			if err != nil {
+				return 0, err
+			}
+			factor, unit = f, c.OfUnit
+			continue
+		}
+		if metric, ok := metricFactors[base][unit]; ok {
+			return factor.Mul(metric)
+		}
+		return 0, ErrUnitNotDefined
+	}
+	return 0, ErrUnitCycle
+}
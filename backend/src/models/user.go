@@ -1,6 +1,16 @@
 package models
 
-import "time"
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrEmailAlreadyInUse возвращается хранилищем, если email занят другим пользователем.
+	ErrEmailAlreadyInUse = errors.New("email already in use")
+	// ErrIdentityLinked возвращается хранилищем, если внешняя учётная запись уже привязана к пользователю.
+	ErrIdentityLinked = errors.New("external account is already linked to another user")
+)
 
 // Role представляет роль пользователя в системе.
 type Role string
@@ -33,3 +43,11 @@ func NewUser(id, email, passwordHash string, role Role) *User {
 		UpdatedAt:    now,
 	}
 }
+
+// UserIdentity — привязка учётной записи внешнего провайдера (LDAP, OIDC) к пользователю.
+type UserIdentity struct {
+	Provider  string    `json:"provider"`
+	Subject   string    `json:"subject"`
+	UserID    string    `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
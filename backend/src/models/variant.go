@@ -0,0 +1,130 @@
+package models
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// AttributeType — тип атрибута товара.
+type AttributeType string
+
+const (
+	AttributeSize   AttributeType = "size"   // размер
+	AttributeColor  AttributeType = "color"  // цвет
+	AttributeCustom AttributeType = "custom" // произвольная пара ключ/значение
+)
+
+// MaxAttributeValueLength — максимальная длина значения атрибута в символах.
+const MaxAttributeValueLength = 64
+
+var ErrInvalidAttribute = errors.New("invalid product attribute")
+
+// attributeNameRe — допустимое имя произвольного атрибута.
+var attributeNameRe = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+
+// ProductAttribute — атрибут товара. У размера и цвета имя совпадает с типом,
+// у произвольного атрибута имя задаёт клиент (например, material).
+type ProductAttribute struct {
+	Name  string        `json:"name"`
+	Type  AttributeType `json:"type"`
+	Value string        `json:"value"`
+}
+
+// NormalizeAttributes проверяет атрибуты и приводит их к каноническому виду: размер, цвет,
+// затем произвольные атрибуты по имени. Имена не повторяются; пустой тип означает custom,
+// а имя size или color без типа — соответствующий типизированный атрибут.
+func NormalizeAttributes(attrs []ProductAttribute) ([]ProductAttribute, error) {
+	result := make([]ProductAttribute, 0, len(attrs))
+	seen := make(map[string]bool, len(attrs))
+	for _, a := range attrs {
+		a.Name = strings.ToLower(strings.TrimSpace(a.Name))
+		a.Value = strings.TrimSpace(a.Value)
+		if a.Type == "" {
+			a.Type = AttributeCustom
+			if a.Name == string(AttributeSize) || a.Name == string(AttributeColor) {
+				a.Type = AttributeType(a.Name)
+			}
+		}
+		switch a.Type {
+		case AttributeSize, AttributeColor:
+			if a.Name != "" && a.Name != string(a.Type) {
+				return nil, ErrInvalidAttribute
+			}
+			a.Name = string(a.Type)
+		case AttributeCustom:
+			if !attributeNameRe.MatchString(a.Name) ||
+				a.Name == string(AttributeSize) || a.Name == string(AttributeColor) {
+				return nil, ErrInvalidAttribute
+			}
+		default:
+			return nil, ErrInvalidAttribute
+		}
+		if a.Value == "" || utf8.RuneCountInString(a.Value) > MaxAttributeValueLength || seen[a.Name] {
+			return nil, ErrInvalidAttribute
+		}
+		seen[a.Name] = true
+		result = append(result, a)
+	}
+	SortAttributes(result)
+	return result, nil
+}
+
+// SortAttributes упорядочивает атрибуты: размер, цвет, затем произвольные по имени.
+func SortAttributes(attrs []ProductAttribute) {
+	rank := func(a ProductAttribute) int {
+		switch a.Type {
+		case AttributeSize:
+			return 0
+		case AttributeColor:
+			return 1
+		}
+		return 2
+	}
+	sort.SliceStable(attrs, func(i, j int) bool {
+		if ri, rj := rank(attrs[i]), rank(attrs[j]); ri != rj {
+			return ri < rj
+		}
+		return attrs[i].Name < attrs[j].Name
+	})
+}
+
+// AttributesKey — строка, одинаковая для наборов атрибутов с одинаковыми значениями
+// (без учёта регистра). Атрибуты должны быть нормализованы.
+func AttributesKey(attrs []ProductAttribute) string {
+	parts := make([]string, len(attrs))
+	for i, a := range attrs {
+		parts[i] = a.Name + "=" + strings.ToLower(a.Value)
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// VariantSKU формирует SKU варианта из SKU шаблона и значений атрибутов: TSHIRT + M, red → TSHIRT-M-RED.
+// В SKU попадают только буквы и цифры значений.
+func VariantSKU(templateSKU string, attrs []ProductAttribute) string {
+	parts := []string{templateSKU}
+	for _, a := range attrs {
+		part := strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				return unicode.ToUpper(r)
+			}
+			return -1
+		}, a.Value)
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+// VariantName формирует название варианта: «Футболка (M, красный)».
+func VariantName(templateName string, attrs []ProductAttribute) string {
+	values := make([]string, len(attrs))
+	for i, a := range attrs {
+		values[i] = a.Value
+	}
+	return templateName + " (" + strings.Join(values, ", ") + ")"
+}
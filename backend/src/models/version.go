@@ -0,0 +1,10 @@
+package models
+
+import "errors"
+
+// ErrVersionConflict возвращается хранилищем, если запись изменили после того, как её прочитали:
+// условие "version = ожидаемой" в UPDATE/DELETE не выполнилось.
+var ErrVersionConflict = errors.New("resource was modified by another request")
+
+// InitialVersion — версия только что созданной записи. Каждое изменение увеличивает её на 1.
+const InitialVersion int64 = 1
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"errors"
+	"time"
+)
 
 // StockMovementType описывает тип движения товара на складе.
 type StockMovementType string
@@ -11,10 +14,21 @@ const (
 	MovementReserve  StockMovementType = "reserve"
 )
 
-// StockItem представляет текущий остаток товара на складе.
+// ErrInsufficientStock возвращается хранилищем, если списание или резерв увели бы остаток в минус.
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+// StockItem представляет текущий остаток товара на складе. Unit и Base* заполняются, когда
+// остатки запрошены в другой единице: Quantity тогда указан в Unit, BaseQuantity — в базовой единице.
+// ParentID — шаблон, если товар — вариант. В сводке по шаблонам Quantity шаблона включает
+// остатки вариантов, а сами варианты перечислены в Variants.
 type StockItem struct {
-	ProductID string  `json:"product_id"`
-	Quantity  float64 `json:"quantity"`
+	ProductID    string        `json:"product_id"`
+	ParentID     string        `json:"parent_id,omitempty"`
+	Quantity     Decimal       `json:"quantity"`
+	Unit         UnitOfMeasure `json:"unit,omitempty"`
+	BaseQuantity *Decimal      `json:"base_quantity,omitempty"`
+	BaseUnit     UnitOfMeasure `json:"base_unit,omitempty"`
+	Variants     []*StockItem  `json:"variants,omitempty"`
 }
 
 // StockMovement описывает операцию движения товара (приёмка, списание, резервирование).
@@ -24,10 +38,29 @@ type StockMovement struct {
 	ProductID  string            `json:"product_id"`
 	SupplierID string            `json:"supplier_id,omitempty"` // только для приёмки
 	OrderID    string            `json:"order_id,omitempty"`    // для резервирования под заказ
-	Quantity   float64           `json:"quantity"`
-	Price      float64           `json:"price,omitempty"`       // цена закупки (приёмка)
+	Quantity   Decimal           `json:"quantity"`
+	Price      Decimal           `json:"price,omitempty"`       // цена закупки за единицу (приёмка)
+	Currency   string            `json:"currency,omitempty"`    // валюта цены
 	ExpiryDate *time.Time        `json:"expiry_date,omitempty"` // срок годности, если есть
+	CreatedBy  string            `json:"created_by,omitempty"`  // пользователь, выполнивший операцию
 	CreatedAt  time.Time         `json:"created_at"`
 }
 
+// Delta возвращает изменение остатка от движения: приёмка увеличивает остаток,
+// списание и резерв уменьшают.
+func (m *StockMovement) Delta() Decimal {
+	switch m.Type {
+	case MovementReceipt:
+		return m.Quantity
+	case MovementWriteOff, MovementReserve:
+		return m.Quantity.Neg()
+	}
+	return 0
+}
 
+// StockDrift — расхождение материализованного остатка с журналом движений по товару.
+type StockDrift struct {
+	ProductID       string  `json:"product_id"`
+	LedgerQuantity  Decimal `json:"ledger_quantity"`  // остаток, пересчитанный по stock_movements
+	BalanceQuantity Decimal `json:"balance_quantity"` // остаток в stock_balances
+}
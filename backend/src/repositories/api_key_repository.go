@@ -0,0 +1,185 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+	"warehouse-management-system/src/config"
+	"warehouse-management-system/src/models"
+)
+
+// APIKeyRepositorySQL — реализация хранилища API-ключей на SQL (SQLite или PostgreSQL).
+// Scopes хранятся одной строкой через пробел, этого достаточно для небольшого набора прав.
+type APIKeyRepositorySQL struct {
+	db  *config.DB
+	ids IDGenerator
+}
+
+// NewAPIKeyRepository создаёт новый репозиторий API-ключей.
+func NewAPIKeyRepository(db *config.DB, ids IDGenerator) *APIKeyRepositorySQL {
+	return &APIKeyRepositorySQL{db: db, ids: ids}
+}
+
+// apiKeyScanner позволяет использовать один код сканирования для *sql.Row и *sql.Rows.
+type apiKeyScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAPIKey(s apiKeyScanner) (*models.APIKey, error) {
+	var (
+		k          models.APIKey
+		scopes     string
+		expiresAt  sql.NullTime
+		lastUsedAt sql.NullTime
+		revokedAt  sql.NullTime
+	)
+	if err := s.Scan(
+		&k.ID,
+		&k.Name,
+		&k.Prefix,
+		&k.KeyHash,
+		&k.UserID,
+		&k.Role,
+		&scopes,
+		&expiresAt,
+		&lastUsedAt,
+		&revokedAt,
+		&k.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	k.Scopes = strings.Fields(scopes)
+	if expiresAt.Valid {
+		k.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsedAt.Valid {
+		k.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		k.RevokedAt = &revokedAt.Time
+	}
+	return &k, nil
+}
+
+// GetAll возвращает все API-ключи, включая отозванные.
+func (r *APIKeyRepositorySQL) GetAll(ctx context.Context) ([]*models.APIKey, error) {
+	const query = `
+SELECT id, name, prefix, key_hash, user_id, role, scopes, expires_at, last_used_at, revoked_at, created_at
+FROM api_keys
+ORDER BY created_at DESC;
+`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []*models.APIKey
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, k)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetByID возвращает API-ключ по идентификатору.
+func (r *APIKeyRepositorySQL) GetByID(ctx context.Context, id string) (*models.APIKey, error) {
+	const query = `
+SELECT id, name, prefix, key_hash, user_id, role, scopes, expires_at, last_used_at, revoked_at, created_at
+FROM api_keys
+WHERE id = ? LIMIT 1;
+`
+	k, err := scanAPIKey(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return k, nil
+}
+
+// GetByPrefix возвращает API-ключ по его открытому префиксу.
+func (r *APIKeyRepositorySQL) GetByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	const query = `
+SELECT id, name, prefix, key_hash, user_id, role, scopes, expires_at, last_used_at, revoked_at, created_at
+FROM api_keys
+WHERE prefix = ? LIMIT 1;
+`
+	k, err := scanAPIKey(r.db.QueryRowContext(ctx, query, prefix))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return k, nil
+}
+
+// Create сохраняет новый API-ключ.
+func (r *APIKeyRepositorySQL) Create(ctx context.Context, key *models.APIKey) error {
+	const query = `
+INSERT INTO api_keys (id, name, prefix, key_hash, user_id, role, scopes, expires_at, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?);
+`
+	if key.ID == "" {
+		id, err := r.ids.NewID(idPrefixAPIKey)
+		if err != nil {
+			return err
+		}
+		key.ID = id
+	}
+	if key.CreatedAt.IsZero() {
+		key.CreatedAt = time.Now().UTC()
+	}
+
+	_, err := r.db.ExecContext(ctx, query,
+		key.ID,
+		key.Name,
+		key.Prefix,
+		key.KeyHash,
+		key.UserID,
+		key.Role,
+		strings.Join(key.Scopes, " "),
+		key.ExpiresAt,
+		key.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Revoke помечает API-ключ отозванным. Запись не удаляется, чтобы сохранить историю.
+func (r *APIKeyRepositorySQL) Revoke(ctx context.Context, id string, at time.Time) error {
+	const query = `UPDATE api_keys SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL;`
+
+	res, err := r.db.ExecContext(ctx, query, at, id)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("active api key with id %s not found", id)
+	}
+	return nil
+}
+
+// TouchLastUsed обновляет время последнего использования ключа.
+func (r *APIKeyRepositorySQL) TouchLastUsed(ctx context.Context, id string, at time.Time) error {
+	const query = `UPDATE api_keys SET last_used_at = ? WHERE id = ?;`
+
+	_, err := r.db.ExecContext(ctx, query, at, id)
+	return err
+}
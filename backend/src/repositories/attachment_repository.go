@@ -0,0 +1,152 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+	"warehouse-management-system/src/config"
+	"warehouse-management-system/src/models"
+)
+
+// AttachmentRepositorySQL — реализация хранилища сведений о вложениях на SQL (SQLite или PostgreSQL).
+// Содержимое файлов хранится отдельно (см. FileBlobStore).
+type AttachmentRepositorySQL struct {
+	db  *config.DB
+	ids IDGenerator
+}
+
+// NewAttachmentRepository создаёт новый репозиторий вложений.
+func NewAttachmentRepository(db *config.DB, ids IDGenerator) *AttachmentRepositorySQL {
+	return &AttachmentRepositorySQL{db: db, ids: ids}
+}
+
+// attachmentScanner позволяет использовать один код сканирования для *sql.Row и *sql.Rows.
+type attachmentScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAttachment(s attachmentScanner) (*models.Attachment, error) {
+	var (
+		a         models.Attachment
+		thumbnail sql.NullString
+		createdBy sql.NullString
+	)
+	if err := s.Scan(
+		&a.ID,
+		&a.OwnerType,
+		&a.OwnerID,
+		&a.Kind,
+		&a.FileName,
+		&a.ContentType,
+		&a.Size,
+		&a.SHA256,
+		&thumbnail,
+		&createdBy,
+		&a.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	a.ThumbnailSHA256 = thumbnail.String
+	a.HasThumbnail = thumbnail.Valid
+	a.CreatedBy = createdBy.String
+	return &a, nil
+}
+
+// GetByOwner возвращает вложения товара или поставщика в порядке добавления.
+func (r *AttachmentRepositorySQL) GetByOwner(ctx context.Context, ownerType models.AttachmentOwner, ownerID string) ([]*models.Attachment, error) {
+	const query = `
+SELECT id, owner_type, owner_id, kind, file_name, content_type, size, sha256, thumbnail_sha256, created_by, created_at
+FROM attachments
+WHERE owner_type = ? AND owner_id = ?
+ORDER BY created_at, id;
+`
+	rows, err := r.db.QueryContext(ctx, query, ownerType, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []*models.Attachment{}
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetByID возвращает вложение по идентификатору или nil, если его нет.
+func (r *AttachmentRepositorySQL) GetByID(ctx context.Context, id string) (*models.Attachment, error) {
+	const query = `
+SELECT id, owner_type, owner_id, kind, file_name, content_type, size, sha256, thumbnail_sha256, created_by, created_at
+FROM attachments
+WHERE id = ? LIMIT 1;
+`
+	a, err := scanAttachment(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Create сохраняет сведения о новом вложении.
+func (r *AttachmentRepositorySQL) Create(ctx context.Context, a *models.Attachment) error {
+	const query = `
+INSERT INTO attachments (id, owner_type, owner_id, kind, file_name, content_type, size, sha256, thumbnail_sha256, created_by, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+`
+	if a.ID == "" {
+		id, err := r.ids.NewID(idPrefixAttachment)
+		if err != nil {
+			return err
+		}
+		a.ID = id
+	}
+	if a.CreatedAt.IsZero() {
+		a.CreatedAt = time.Now().UTC()
+	}
+
+	_, err := r.db.ExecContext(ctx, query,
+		a.ID,
+		a.OwnerType,
+		a.OwnerID,
+		a.Kind,
+		a.FileName,
+		a.ContentType,
+		a.Size,
+		a.SHA256,
+		nullIfEmpty(a.ThumbnailSHA256),
+		nullIfEmpty(a.CreatedBy),
+		a.CreatedAt,
+	)
+	return err
+}
+
+// Delete удаляет сведения о вложении.
+func (r *AttachmentRepositorySQL) Delete(ctx context.Context, id string) error {
+	const query = `DELETE FROM attachments WHERE id = ?;`
+	_, err := r.db.ExecContext(ctx, query, id)
+	return err
+}
+
+// IsBlobReferenced сообщает, ссылается ли какое-либо вложение на содержимое с ключом key
+// (как на сам файл или как на уменьшенную копию).
+func (r *AttachmentRepositorySQL) IsBlobReferenced(ctx context.Context, key string) (bool, error) {
+	const query = `
+SELECT EXISTS (SELECT 1 FROM attachments WHERE sha256 = ?)
+    OR EXISTS (SELECT 1 FROM attachments WHERE thumbnail_sha256 = ?);
+`
+	var referenced bool
+	if err := r.db.QueryRowContext(ctx, query, key, key).Scan(&referenced); err != nil {
+		return false, err
+	}
+	return referenced, nil
+}
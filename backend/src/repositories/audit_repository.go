@@ -0,0 +1,138 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+	"warehouse-management-system/src/config"
+	"warehouse-management-system/src/models"
+)
+
+// AuditRepositorySQL — реализация журнала аудита на SQL (SQLite или PostgreSQL).
+// Записи только добавляются, изменение и удаление не предусмотрены.
+type AuditRepositorySQL struct {
+	db *config.DB
+}
+
+// NewAuditRepository создаёт новый репозиторий журнала аудита.
+func NewAuditRepository(db *config.DB) *AuditRepositorySQL {
+	return &AuditRepositorySQL{db: db}
+}
+
+// Create добавляет запись в журнал аудита.
+func (r *AuditRepositorySQL) Create(ctx context.Context, entry *models.AuditEntry) error {
+	const query = `
+INSERT INTO audit_log (actor_id, api_key_id, action, entity_type, entity_id, before_json, after_json, request_id, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id;
+`
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now().UTC()
+	}
+
+	// RETURNING поддерживают и SQLite (3.35+), и PostgreSQL, в отличие от LastInsertId.
+	return r.db.QueryRowContext(ctx, query,
+		nullIfEmpty(entry.ActorID),
+		nullIfEmpty(entry.APIKeyID),
+		entry.Action,
+		entry.EntityType,
+		entry.EntityID,
+		nullIfEmpty(string(entry.Before)),
+		nullIfEmpty(string(entry.After)),
+		nullIfEmpty(entry.RequestID),
+		entry.CreatedAt,
+	).Scan(&entry.ID)
+}
+
+// Find возвращает записи журнала по фильтру, новые первыми.
+func (r *AuditRepositorySQL) Find(ctx context.Context, filter models.AuditFilter) ([]*models.AuditEntry, error) {
+	var (
+		conds []string
+		args  []any
+	)
+	addCond := func(cond string, value any) {
+		conds = append(conds, cond)
+		args = append(args, value)
+	}
+	if filter.ActorID != "" {
+		addCond("actor_id = ?", filter.ActorID)
+	}
+	if filter.Action != "" {
+		addCond("action = ?", filter.Action)
+	}
+	if filter.EntityType != "" {
+		addCond("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID != "" {
+		addCond("entity_id = ?", filter.EntityID)
+	}
+	if filter.RequestID != "" {
+		addCond("request_id = ?", filter.RequestID)
+	}
+	if filter.From != nil {
+		addCond("created_at >= ?", filter.From.UTC())
+	}
+	if filter.To != nil {
+		addCond("created_at < ?", filter.To.UTC())
+	}
+
+	query := `
+SELECT id, actor_id, api_key_id, action, entity_type, entity_id, before_json, after_json, request_id, created_at
+FROM audit_log`
+	if len(conds) > 0 {
+		query += "\nWHERE " + strings.Join(conds, " AND ")
+	}
+	query += "\nORDER BY id DESC\nLIMIT ? OFFSET ?;"
+	args = append(args, filter.Limit, filter.Offset)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []*models.AuditEntry
+	for rows.Next() {
+		var (
+			e                                     models.AuditEntry
+			actorID, apiKeyID, before, after, rid sql.NullString
+		)
+		if err := rows.Scan(
+			&e.ID,
+			&actorID,
+			&apiKeyID,
+			&e.Action,
+			&e.EntityType,
+			&e.EntityID,
+			&before,
+			&after,
+			&rid,
+			&e.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		e.ActorID = actorID.String
+		e.APIKeyID = apiKeyID.String
+		e.RequestID = rid.String
+		if before.Valid {
+			e.Before = []byte(before.String)
+		}
+		if after.Valid {
+			e.After = []byte(after.String)
+		}
+		result = append(result, &e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// nullIfEmpty превращает пустую строку в NULL, чтобы не нарушать внешние ключи и не хранить пустые значения.
+func nullIfEmpty(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,111 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+	"warehouse-management-system/src/config"
+	"warehouse-management-system/src/models"
+)
+
+// BarcodeRepositorySQL — реализация хранилища штрихкодов товаров на SQL (SQLite или PostgreSQL).
+type BarcodeRepositorySQL struct {
+	db *config.DB
+}
+
+// NewBarcodeRepository создаёт новый репозиторий штрихкодов.
+func NewBarcodeRepository(db *config.DB) *BarcodeRepositorySQL {
+	return &BarcodeRepositorySQL{db: db}
+}
+
+// barcodeScanner позволяет использовать один код сканирования для *sql.Row и *sql.Rows.
+type barcodeScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanBarcode(s barcodeScanner) (*models.Barcode, error) {
+	var b models.Barcode
+	if err := s.Scan(
+		&b.Code,
+		&b.ProductID,
+		&b.Type,
+		&b.Packaging,
+		&b.Quantity,
+		&b.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// GetByProduct возвращает штрихкоды товара в порядке добавления.
+func (r *BarcodeRepositorySQL) GetByProduct(ctx context.Context, productID string) ([]*models.Barcode, error) {
+	const query = `
+SELECT code, product_id, type, packaging, quantity, created_at
+FROM product_barcodes
+WHERE product_id = ?
+ORDER BY created_at, code;
+`
+	rows, err := r.db.QueryContext(ctx, query, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []*models.Barcode{}
+	for rows.Next() {
+		b, err := scanBarcode(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetByCode возвращает штрихкод по коду или nil, если такого нет.
+func (r *BarcodeRepositorySQL) GetByCode(ctx context.Context, code string) (*models.Barcode, error) {
+	const query = `
+SELECT code, product_id, type, packaging, quantity, created_at
+FROM product_barcodes
+WHERE code = ? LIMIT 1;
+`
+	b, err := scanBarcode(r.db.QueryRowContext(ctx, query, code))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Create сохраняет новый штрихкод.
+func (r *BarcodeRepositorySQL) Create(ctx context.Context, b *models.Barcode) error {
+	const query = `
+INSERT INTO product_barcodes (code, product_id, type, packaging, quantity, created_at)
+VALUES (?, ?, ?, ?, ?, ?);
+`
+	if b.CreatedAt.IsZero() {
+		b.CreatedAt = time.Now().UTC()
+	}
+	_, err := r.db.ExecContext(ctx, query,
+		b.Code,
+		b.ProductID,
+		b.Type,
+		b.Packaging,
+		b.Quantity,
+		b.CreatedAt,
+	)
+	return err
+}
+
+// Delete удаляет штрихкод по коду.
+func (r *BarcodeRepositorySQL) Delete(ctx context.Context, code string) error {
+	const query = `DELETE FROM product_barcodes WHERE code = ?;`
+	_, err := r.db.ExecContext(ctx, query, code)
+	return err
+}
@@ -3,31 +3,63 @@ package repositories
 import (
 	"context"
 	"database/sql"
-	"fmt"
 	"time"
+	"warehouse-management-system/src/config"
 	"warehouse-management-system/src/models"
 )
 
-// CategoryRepositorySQLite — реализация хранилища категорий на SQLite.
-type CategoryRepositorySQLite struct {
-	db *sql.DB
+// CategoryRepositorySQL — реализация хранилища категорий на SQL (SQLite или PostgreSQL).
+type CategoryRepositorySQL struct {
+	db  *config.DB
+	ids IDGenerator
 }
 
 // NewCategoryRepository создаёт новый репозиторий категорий.
-func NewCategoryRepository(db *sql.DB) *CategoryRepositorySQLite {
-	return &CategoryRepositorySQLite{db: db}
+func NewCategoryRepository(db *config.DB, ids IDGenerator) *CategoryRepositorySQL {
+	return &CategoryRepositorySQL{db: db, ids: ids}
 }
 
-// GetAll возвращает все категории.
-func (r *CategoryRepositorySQLite) GetAll() ([]*models.Category, error) {
-	const query = `
-SELECT id, name, created_at, updated_at
-FROM categories
+// categoryScanner позволяет использовать один код сканирования для *sql.Row и *sql.Rows.
+type categoryScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanCategory(s categoryScanner) (*models.Category, error) {
+	var (
+		c          models.Category
+		parentID   sql.NullString
+		archivedAt sql.NullTime
+	)
+	if err := s.Scan(
+		&c.ID,
+		&c.Name,
+		&parentID,
+		&c.CreatedAt,
+		&c.UpdatedAt,
+		&archivedAt,
+		&c.Version,
+	); err != nil {
+		return nil, err
+	}
+	c.ParentID = parentID.String
+	if archivedAt.Valid {
+		c.ArchivedAt = &archivedAt.Time
+	}
+	return &c, nil
+}
+
+// GetAll возвращает категории. Заархивированные категории включаются, только если includeArchived.
+func (r *CategoryRepositorySQL) GetAll(ctx context.Context, includeArchived bool) ([]*models.Category, error) {
+	query := `
+SELECT id, name, parent_id, created_at, updated_at, archived_at, version
+FROM categories`
+	if !includeArchived {
+		query += `
+WHERE archived_at IS NULL`
+	}
+	query += `
 ORDER BY name;
 `
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
-
 	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
@@ -36,16 +68,11 @@ ORDER BY name;
 
 	var result []*models.Category
 	for rows.Next() {
-		var c models.Category
-		if err := rows.Scan(
-			&c.ID,
-			&c.Name,
-			&c.CreatedAt,
-			&c.UpdatedAt,
-		); err != nil {
+		c, err := scanCategory(rows)
+		if err != nil {
 			return nil, err
 		}
-		result = append(result, &c)
+		result = append(result, c)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
@@ -54,54 +81,50 @@ ORDER BY name;
 }
 
 // GetByID возвращает категорию по идентификатору.
-func (r *CategoryRepositorySQLite) GetByID(id string) (*models.Category, error) {
+func (r *CategoryRepositorySQL) GetByID(ctx context.Context, id string) (*models.Category, error) {
 	const query = `
-SELECT id, name, parent_id, created_at, updated_at
+SELECT id, name, parent_id, created_at, updated_at, archived_at, version
 FROM categories
 WHERE id = ? LIMIT 1;
 `
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
-
 	row := r.db.QueryRowContext(ctx, query, id)
-	var c models.Category
-	if err := row.Scan(
-		&c.ID,
-		&c.Name,
-		&c.CreatedAt,
-		&c.UpdatedAt,
-	); err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
-		}
+	c, err := scanCategory(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
 		return nil, err
 	}
-	return &c, nil
+	return c, nil
 }
 
 // Create сохраняет новую категорию.
-func (r *CategoryRepositorySQLite) Create(category *models.Category) error {
+func (r *CategoryRepositorySQL) Create(ctx context.Context, category *models.Category) error {
 	const query = `
-INSERT INTO categories (id, name, created_at, updated_at)
-VALUES (?, ?, ?, ?);
+INSERT INTO categories (id, name, parent_id, created_at, updated_at, version)
+VALUES (?, ?, ?, ?, ?, ?);
 `
 	if category.ID == "" {
-		category.ID = "c-" + time.Now().UTC().Format("20060102T150405.000000000")
+		id, err := r.ids.NewID(idPrefixCategory)
+		if err != nil {
+			return err
+		}
+		category.ID = id
 	}
 	now := time.Now().UTC()
 	if category.CreatedAt.IsZero() {
 		category.CreatedAt = now
 	}
 	category.UpdatedAt = now
-
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
+	category.Version = models.InitialVersion
 
 	_, err := r.db.ExecContext(ctx, query,
 		category.ID,
 		category.Name,
+		nullIfEmpty(category.ParentID),
 		category.CreatedAt,
 		category.UpdatedAt,
+		category.Version,
 	)
 	if err != nil {
 		return err
@@ -109,54 +132,108 @@ VALUES (?, ?, ?, ?);
 	return nil
 }
 
-// Update обновляет существующую категорию.
-func (r *CategoryRepositorySQLite) Update(category *models.Category) error {
+// Update обновляет существующую категорию, если её версия не изменилась с момента чтения.
+// Иначе возвращает models.ErrVersionConflict; при успехе увеличивает Version.
+// Если новый родитель — сама категория или её потомок, возвращает models.ErrCategoryCycle.
+func (r *CategoryRepositorySQL) Update(ctx context.Context, category *models.Category) (err error) {
 	const query = `
 UPDATE categories
-SET name = ?, updated_at = ?
-WHERE id = ?;
+SET name = ?, parent_id = ?, updated_at = ?, version = version + 1
+WHERE id = ? AND version = ?;
 `
-	now := time.Now().UTC()
-	category.UpdatedAt = now
-
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	// Проверка цикла и запись — в одной транзакции, и переносы в ней идут по одному: иначе два встречных
+	// переноса (A под B и B под A) прошли бы проверку оба. В SQLite транзакции записи уже сериализованы,
+	// в PostgreSQL (READ COMMITTED) таблицу категорий блокирует LockTable.
+	if category.ParentID != "" {
+		if lock := r.db.Dialect().LockTable("categories"); lock != "" {
+			if _, err = tx.ExecContext(ctx, lock); err != nil {
+				return err
+			}
+		}
+		cycle := category.ParentID == category.ID
+		if !cycle {
+			if cycle, err = isDescendant(ctx, tx, category.ParentID, category.ID); err != nil {
+				return err
+			}
+		}
+		if cycle {
+			return models.ErrCategoryCycle
+		}
+	}
 
-	res, err := r.db.ExecContext(ctx, query,
+	now := time.Now().UTC()
+	err = execVersioned(ctx, tx, query,
 		category.Name,
-		category.UpdatedAt,
+		nullIfEmpty(category.ParentID),
+		now,
 		category.ID,
+		category.Version,
 	)
 	if err != nil {
 		return err
 	}
-	affected, err := res.RowsAffected()
-	if err != nil {
+	if err = tx.Commit(); err != nil {
 		return err
 	}
-	if affected == 0 {
-		return fmt.Errorf("category with id %s not found", category.ID)
-	}
+	category.UpdatedAt = now
+	category.Version++
 	return nil
 }
 
-// Delete удаляет категорию по ID.
-func (r *CategoryRepositorySQLite) Delete(id string) error {
-	const query = `DELETE FROM categories WHERE id = ?;`
+// Archive помечает категорию как заархивированную.
+func (r *CategoryRepositorySQL) Archive(ctx context.Context, id string, version int64, at time.Time) error {
+	const query = `UPDATE categories SET archived_at = ?, updated_at = ?, version = version + 1 WHERE id = ? AND version = ?;`
+	return execVersioned(ctx, r.db, query, at, at, id, version)
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
+// Restore снимает с категории пометку архивной.
+func (r *CategoryRepositorySQL) Restore(ctx context.Context, id string, version int64, at time.Time) error {
+	const query = `UPDATE categories SET archived_at = NULL, updated_at = ?, version = version + 1 WHERE id = ? AND version = ?;`
+	return execVersioned(ctx, r.db, query, at, id, version)
+}
 
-	res, err := r.db.ExecContext(ctx, query, id)
-	if err != nil {
-		return err
-	}
-	affected, err := res.RowsAffected()
-	if err != nil {
-		return err
+// IsReferenced сообщает, есть ли в категории товары или дочерние категории (в том числе архивные).
+func (r *CategoryRepositorySQL) IsReferenced(ctx context.Context, id string) (bool, error) {
+	const query = `
+SELECT EXISTS (SELECT 1 FROM products WHERE category_id = ?)
+    OR EXISTS (SELECT 1 FROM categories WHERE parent_id = ?);
+`
+	var referenced bool
+	if err := r.db.QueryRowContext(ctx, query, id, id).Scan(&referenced); err != nil {
+		return false, err
 	}
-	if affected == 0 {
-		return fmt.Errorf("category with id %s not found", id)
+	return referenced, nil
+}
+
+// isDescendant сообщает, является ли категория id потомком ancestorID (на любой глубине).
+func isDescendant(ctx context.Context, q rowQuerier, id, ancestorID string) (bool, error) {
+	const query = `
+WITH RECURSIVE subtree(id) AS (
+    SELECT id FROM categories WHERE parent_id = ?
+    UNION
+    SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+)
+SELECT EXISTS (SELECT 1 FROM subtree WHERE id = ?);
+`
+	var descendant bool
+	if err := q.QueryRowContext(ctx, query, ancestorID, id).Scan(&descendant); err != nil {
+		return false, err
 	}
-	return nil
+	return descendant, nil
+}
+
+// Delete физически удаляет категорию по ID при совпадении версии (иначе models.ErrVersionConflict).
+func (r *CategoryRepositorySQL) Delete(ctx context.Context, id string, version int64) error {
+	const query = `DELETE FROM categories WHERE id = ? AND version = ?;`
+	return execVersioned(ctx, r.db, query, id, version)
 }
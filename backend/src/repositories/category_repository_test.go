@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"warehouse-management-system/src/config"
+	"warehouse-management-system/src/models"
+)
+
+func TestCategoryUpdateRejectsCycles(t *testing.T) {
+	db := openTestSQLite(t)
+	ctx := context.Background()
+	repo := NewCategoryRepository(db, NewUUIDv7Generator())
+
+	create := func(name, parentID string) *models.Category {
+		c := models.NewCategory(name, parentID)
+		if err := repo.Create(ctx, c); err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+	root := create("Root", "")
+	child := create("Child", root.ID)
+	grandchild := create("Grandchild", child.ID)
+
+	for _, parentID := range []string{root.ID, grandchild.ID} {
+		moved := *root
+		moved.ParentID = parentID
+		if err := repo.Update(ctx, &moved); !errors.Is(err, models.ErrCategoryCycle) {
+			t.Fatalf("move root under %s: got %v, want ErrCategoryCycle", parentID, err)
+		}
+	}
+
+	testOppositeCategoryMoves(t, db)
+}
+
+// testOppositeCategoryMoves проверяет встречные переносы A под B и B под A: проходит только первый,
+// иначе A и B образовали бы цикл. Общий для SQLite и интеграционного теста PostgreSQL.
+func testOppositeCategoryMoves(t *testing.T, db *config.DB) {
+	t.Helper()
+	ctx := context.Background()
+	repo := NewCategoryRepository(db, NewUUIDv7Generator())
+
+	// Один прогон редко попадает в гонку, поэтому пар несколько.
+	for round := 0; round < 20; round++ {
+		a := models.NewCategory(fmt.Sprintf("A%d", round), "")
+		b := models.NewCategory(fmt.Sprintf("B%d", round), "")
+		for _, c := range []*models.Category{a, b} {
+			if err := repo.Create(ctx, c); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		errs := make([]error, 2)
+		var wg sync.WaitGroup
+		for i, move := range [][2]*models.Category{{a, b}, {b, a}} {
+			wg.Add(1)
+			go func(i int, category, parent models.Category) {
+				defer wg.Done()
+				category.ParentID = parent.ID
+				errs[i] = repo.Update(ctx, &category)
+			}(i, *move[0], *move[1])
+		}
+		wg.Wait()
+
+		failed := 0
+		for _, err := range errs {
+			switch {
+			case err == nil:
+			case errors.Is(err, models.ErrCategoryCycle):
+				failed++
+			default:
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		if failed != 1 {
+			t.Fatalf("concurrent opposite moves: %v, want exactly one ErrCategoryCycle", errs)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"warehouse-management-system/src/models"
+)
+
+// execer — общее для *config.DB и *config.Tx: запрос можно выполнить как отдельно, так и в транзакции.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// rowQuerier — то же для запросов одной строки.
+type rowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// execVersioned выполняет UPDATE/DELETE одной записи с условием "version = ?".
+// Если ни одна строка не затронута, запись успели изменить или удалить — models.ErrVersionConflict.
+func execVersioned(ctx context.Context, db execer, query string, args ...any) error {
+	res, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return models.ErrVersionConflict
+	}
+	return nil
+}
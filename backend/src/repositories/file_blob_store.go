@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// FileBlobStore хранит содержимое вложений в каталоге на диске. Ключ — SHA-256 содержимого (hex),
+// файл лежит в подкаталоге из первых двух символов ключа, чтобы в одном каталоге не скапливались
+// десятки тысяч файлов: dir/3f/3fa4....
+type FileBlobStore struct {
+	dir string
+}
+
+// NewFileBlobStore создаёт хранилище в каталоге dir; каталог создаётся при первой записи.
+func NewFileBlobStore(dir string) (*FileBlobStore, error) {
+	if dir == "" {
+		return nil, errors.New("attachment directory is not set")
+	}
+	return &FileBlobStore{dir: dir}, nil
+}
+
+// blobKeyRe — допустимый ключ: ключ попадает в путь к файлу, поэтому других символов быть не должно.
+var blobKeyRe = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// Put сохраняет содержимое под ключом key. Файл пишется во временный и переименовывается,
+// поэтому недописанное содержимое никогда не видно под ключом. Если ключ уже есть,
+// содержимое не перезаписывается: при адресации по хешу оно совпадает.
+func (s *FileBlobStore) Put(ctx context.Context, key string, r io.Reader) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), key+".*.partial")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Get открывает содержимое по ключу. Для отсутствующего ключа ошибка удовлетворяет errors.Is(err, fs.ErrNotExist).
+func (s *FileBlobStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+// Delete удаляет содержимое по ключу; отсутствующий ключ ошибкой не считается.
+func (s *FileBlobStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *FileBlobStore) path(key string) (string, error) {
+	if !blobKeyRe.MatchString(key) {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	return filepath.Join(s.dir, key[:2], key), nil
+}
@@ -0,0 +1,43 @@
+package repositories
+
+import "github.com/google/uuid"
+
+// Префиксы идентификаторов сущностей. По префиксу сразу видно, к какой таблице относится ID,
+// поэтому префиксы не должны повторяться.
+const (
+	idPrefixUser          = "u"
+	idPrefixCategory      = "c"
+	idPrefixSupplier      = "s"
+	idPrefixProduct       = "p"
+	idPrefixOrder         = "o"
+	idPrefixStockMovement = "w"
+	idPrefixAPIKey        = "k"
+	idPrefixSession       = "sess"
+	idPrefixPriceList     = "pl"
+	idPrefixAttachment    = "a"
+)
+
+// IDGenerator выдаёт идентификаторы новых сущностей вида "<префикс>-<уникальная часть>".
+// Внедряется в репозитории, чтобы формат ID задавался в одном месте.
+type IDGenerator interface {
+	NewID(prefix string) (string, error)
+}
+
+// UUIDv7Generator генерирует ID на основе UUIDv7 (RFC 9562): 48 бит времени в миллисекундах
+// и 74 случайных бита. В отличие от метки времени с наносекундами, такие ID не совпадают
+// при одновременных запросах и при этом сортируются в порядке создания.
+type UUIDv7Generator struct{}
+
+// NewUUIDv7Generator — конструктор генератора идентификаторов.
+func NewUUIDv7Generator() UUIDv7Generator {
+	return UUIDv7Generator{}
+}
+
+// NewID возвращает новый идентификатор с префиксом, например "p-01928c6e-7a3b-7c1d-9f2e-4b5a6c7d8e9f".
+func (UUIDv7Generator) NewID(prefix string) (string, error) {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return "", err
+	}
+	return prefix + "-" + id.String(), nil
+}
@@ -3,32 +3,30 @@ package repositories
 import (
 	"context"
 	"database/sql"
-	"fmt"
 	"time"
+	"warehouse-management-system/src/config"
 	"warehouse-management-system/src/models"
 )
 
-// OrderRepositorySQLite — реализация хранилища заказов на SQLite.
+// OrderRepositorySQL — реализация хранилища заказов на SQL (SQLite или PostgreSQL).
 // Использует таблицы orders, order_items и order_status_history.
-type OrderRepositorySQLite struct {
-	db *sql.DB
+type OrderRepositorySQL struct {
+	db  *config.DB
+	ids IDGenerator
 }
 
 // NewOrderRepository создаёт новый репозиторий заказов.
-func NewOrderRepository(db *sql.DB) *OrderRepositorySQLite {
-	return &OrderRepositorySQLite{db: db}
+func NewOrderRepository(db *config.DB, ids IDGenerator) *OrderRepositorySQL {
+	return &OrderRepositorySQL{db: db, ids: ids}
 }
 
 // GetAll возвращает все заказы.
-func (r *OrderRepositorySQLite) GetAll() ([]*models.Order, error) {
+func (r *OrderRepositorySQL) GetAll(ctx context.Context) ([]*models.Order, error) {
 	const queryOrders = `
-SELECT id, customer, status, created_at, updated_at
+SELECT id, customer, status, created_at, updated_at, version
 FROM orders
 ORDER BY created_at DESC;
 `
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
 	rows, err := r.db.QueryContext(ctx, queryOrders)
 	if err != nil {
 		return nil, err
@@ -38,11 +36,7 @@ ORDER BY created_at DESC;
 	var orders []*models.Order
 	for rows.Next() {
 		var o models.Order
-		if err := rows.Scan(&o.ID, &o.Customer, &o.Status, &o.CreatedAt, &o.UpdatedAt); err != nil {
-			return nil, err
-		}
-		// Подгружаем позиции и историю статусов.
-		if err := r.loadItemsAndHistory(ctx, &o); err != nil {
+		if err := rows.Scan(&o.ID, &o.Customer, &o.Status, &o.CreatedAt, &o.UpdatedAt, &o.Version); err != nil {
 			return nil, err
 		}
 		orders = append(orders, &o)
@@ -50,22 +44,28 @@ ORDER BY created_at DESC;
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
+	rows.Close()
+
+	// Позиции и историю статусов подгружаем после закрытия курсора заказов: вложенный запрос
+	// при открытом курсоре занимал бы второе подключение пула чтения.
+	for _, o := range orders {
+		if err := r.loadItemsAndHistory(ctx, o); err != nil {
+			return nil, err
+		}
+	}
 	return orders, nil
 }
 
 // GetByID возвращает заказ по ID.
-func (r *OrderRepositorySQLite) GetByID(id string) (*models.Order, error) {
+func (r *OrderRepositorySQL) GetByID(ctx context.Context, id string) (*models.Order, error) {
 	const query = `
-SELECT id, customer, status, created_at, updated_at
+SELECT id, customer, status, created_at, updated_at, version
 FROM orders
 WHERE id = ? LIMIT 1;
 `
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
 	row := r.db.QueryRowContext(ctx, query, id)
 	var o models.Order
-	if err := row.Scan(&o.ID, &o.Customer, &o.Status, &o.CreatedAt, &o.UpdatedAt); err != nil {
+	if err := row.Scan(&o.ID, &o.Customer, &o.Status, &o.CreatedAt, &o.UpdatedAt, &o.Version); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
@@ -77,19 +77,33 @@ WHERE id = ? LIMIT 1;
 	return &o, nil
 }
 
-// Create сохраняет новый заказ, его позиции и историю статусов.
-func (r *OrderRepositorySQLite) Create(order *models.Order) error {
+// Create сохраняет новый заказ, его позиции и историю статусов, а также резервы товаров под заказ
+// (движения reservations получают ID и ссылку на заказ). Всё выполняется в одной транзакции:
+// если остатка на резерв не хватает (models.ErrInsufficientStock), заказ не создаётся.
+func (r *OrderRepositorySQL) Create(ctx context.Context, order *models.Order, reservations []*models.StockMovement) error {
 	if order.ID == "" {
-		order.ID = "o-" + time.Now().UTC().Format("20060102T150405.000000000")
+		id, err := r.ids.NewID(idPrefixOrder)
+		if err != nil {
+			return err
+		}
+		order.ID = id
 	}
 	now := time.Now().UTC()
 	if order.CreatedAt.IsZero() {
 		order.CreatedAt = now
 	}
 	order.UpdatedAt = now
-
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	order.Version = models.InitialVersion
+	for _, m := range reservations {
+		if m.ID == "" {
+			id, err := r.ids.NewID(idPrefixStockMovement)
+			if err != nil {
+				return err
+			}
+			m.ID = id
+		}
+		m.OrderID = order.ID
+	}
 
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -102,8 +116,8 @@ func (r *OrderRepositorySQLite) Create(order *models.Order) error {
 	}()
 
 	const insertOrder = `
-INSERT INTO orders (id, customer, status, created_at, updated_at)
-VALUES (?, ?, ?, ?, ?);
+INSERT INTO orders (id, customer, status, created_at, updated_at, version)
+VALUES (?, ?, ?, ?, ?, ?);
 `
 	if _, err = tx.ExecContext(ctx, insertOrder,
 		order.ID,
@@ -111,53 +125,62 @@ VALUES (?, ?, ?, ?, ?);
 		order.Status,
 		order.CreatedAt,
 		order.UpdatedAt,
+		order.Version,
 	); err != nil {
 		return err
 	}
 
 	const insertItem = `
-INSERT INTO order_items (order_id, product_id, quantity, price)
-VALUES (?, ?, ?, ?);
+INSERT INTO order_items (order_id, product_id, quantity, unit, price, currency, price_list_id)
+VALUES (?, ?, ?, ?, ?, ?, ?);
 `
 	for _, it := range order.Items {
 		if _, err = tx.ExecContext(ctx, insertItem,
 			order.ID,
 			it.ProductID,
 			it.Quantity,
+			nullIfEmpty(string(it.Unit)),
 			it.Price,
+			it.Currency,
+			nullIfEmpty(it.PriceListID),
 		); err != nil {
 			return err
 		}
 	}
 
 	const insertHist = `
-INSERT INTO order_status_history (order_id, status, changed_at)
-VALUES (?, ?, ?);
+INSERT INTO order_status_history (order_id, status, changed_by, changed_at)
+VALUES (?, ?, ?, ?);
 `
 	for _, h := range order.StatusHist {
 		if _, err = tx.ExecContext(ctx, insertHist,
 			order.ID,
 			h.Status,
+			nullIfEmpty(h.ChangedBy),
 			h.ChangedAt,
 		); err != nil {
 			return err
 		}
 	}
 
+	for _, m := range reservations {
+		if err = insertMovement(ctx, tx, m, now); err != nil {
+			return err
+		}
+	}
+
 	if err = tx.Commit(); err != nil {
 		return err
 	}
 	return nil
 }
 
-// Update обновляет существующий заказ (статус, updated_at и историю статусов).
-func (r *OrderRepositorySQLite) Update(order *models.Order) error {
+// Update обновляет существующий заказ (статус, updated_at и историю статусов), если его версия
+// не изменилась с момента чтения. Иначе возвращает models.ErrVersionConflict; при успехе увеличивает Version.
+func (r *OrderRepositorySQL) Update(ctx context.Context, order *models.Order) error {
 	now := time.Now().UTC()
 	order.UpdatedAt = now
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
@@ -170,14 +193,15 @@ func (r *OrderRepositorySQLite) Update(order *models.Order) error {
 
 	const updateOrder = `
 UPDATE orders
-SET customer = ?, status = ?, updated_at = ?
-WHERE id = ?;
+SET customer = ?, status = ?, updated_at = ?, version = version + 1
+WHERE id = ? AND version = ?;
 `
 	res, err := tx.ExecContext(ctx, updateOrder,
 		order.Customer,
 		order.Status,
 		order.UpdatedAt,
 		order.ID,
+		order.Version,
 	)
 	if err != nil {
 		return err
@@ -187,19 +211,21 @@ WHERE id = ?;
 		return err
 	}
 	if affected == 0 {
-		return fmt.Errorf("order with id %s not found", order.ID)
+		err = models.ErrVersionConflict
+		return err
 	}
 
 	// Добавляем последнюю запись истории статусов (если есть).
 	if len(order.StatusHist) > 0 {
 		last := order.StatusHist[len(order.StatusHist)-1]
 		const insertHist = `
-INSERT INTO order_status_history (order_id, status, changed_at)
-VALUES (?, ?, ?);
+INSERT INTO order_status_history (order_id, status, changed_by, changed_at)
+VALUES (?, ?, ?, ?);
 `
 		if _, err = tx.ExecContext(ctx, insertHist,
 			order.ID,
 			last.Status,
+			nullIfEmpty(last.ChangedBy),
 			last.ChangedAt,
 		); err != nil {
 			return err
@@ -209,13 +235,14 @@ VALUES (?, ?, ?);
 	if err = tx.Commit(); err != nil {
 		return err
 	}
+	order.Version++
 	return nil
 }
 
 // loadItemsAndHistory подгружает позиции и историю статусов заказа.
-func (r *OrderRepositorySQLite) loadItemsAndHistory(ctx context.Context, o *models.Order) error {
+func (r *OrderRepositorySQL) loadItemsAndHistory(ctx context.Context, o *models.Order) error {
 	const queryItems = `
-SELECT product_id, quantity, price
+SELECT product_id, quantity, COALESCE(unit, ''), price, currency, COALESCE(price_list_id, '')
 FROM order_items
 WHERE order_id = ?;
 `
@@ -228,7 +255,7 @@ WHERE order_id = ?;
 	var items []models.OrderItem
 	for rows.Next() {
 		var it models.OrderItem
-		if err := rows.Scan(&it.ProductID, &it.Quantity, &it.Price); err != nil {
+		if err := rows.Scan(&it.ProductID, &it.Quantity, &it.Unit, &it.Price, &it.Currency, &it.PriceListID); err != nil {
 			return err
 		}
 		items = append(items, it)
@@ -239,7 +266,7 @@ WHERE order_id = ?;
 	o.Items = items
 
 	const queryHist = `
-SELECT status, changed_at
+SELECT status, COALESCE(changed_by, ''), changed_at
 FROM order_status_history
 WHERE order_id = ?
 ORDER BY changed_at;
@@ -253,7 +280,7 @@ ORDER BY changed_at;
 	var hist []models.StatusEntry
 	for hRows.Next() {
 		var h models.StatusEntry
-		if err := hRows.Scan(&h.Status, &h.ChangedAt); err != nil {
+		if err := hRows.Scan(&h.Status, &h.ChangedBy, &h.ChangedAt); err != nil {
 			return err
 		}
 		hist = append(hist, h)
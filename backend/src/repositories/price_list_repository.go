@@ -0,0 +1,238 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+	"warehouse-management-system/src/config"
+	"warehouse-management-system/src/models"
+)
+
+// PriceListRepositorySQL — реализация хранилища прайс-листов на SQL (SQLite или PostgreSQL).
+type PriceListRepositorySQL struct {
+	db  *config.DB
+	ids IDGenerator
+}
+
+// NewPriceListRepository создаёт новый репозиторий прайс-листов.
+func NewPriceListRepository(db *config.DB, ids IDGenerator) *PriceListRepositorySQL {
+	return &PriceListRepositorySQL{db: db, ids: ids}
+}
+
+// priceListScanner позволяет использовать один код сканирования для *sql.Row и *sql.Rows.
+type priceListScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanPriceList(s priceListScanner) (*models.PriceList, error) {
+	var (
+		l        models.PriceList
+		customer sql.NullString
+		validTo  sql.NullTime
+	)
+	if err := s.Scan(
+		&l.ID,
+		&l.Name,
+		&l.Currency,
+		&customer,
+		&l.ValidFrom,
+		&validTo,
+		&l.CreatedAt,
+		&l.UpdatedAt,
+		&l.Version,
+	); err != nil {
+		return nil, err
+	}
+	l.Customer = customer.String
+	if validTo.Valid {
+		l.ValidTo = &validTo.Time
+	}
+	return &l, nil
+}
+
+// GetAll возвращает прайс-листы без позиций: сначала начинающие действовать позже.
+func (r *PriceListRepositorySQL) GetAll(ctx context.Context) ([]*models.PriceList, error) {
+	const query = `
+SELECT id, name, currency, customer, valid_from, valid_to, created_at, updated_at, version
+FROM price_lists
+ORDER BY valid_from DESC, id;
+`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []*models.PriceList{}
+	for rows.Next() {
+		l, err := scanPriceList(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetByID возвращает прайс-лист вместе с позициями или nil, если его нет.
+func (r *PriceListRepositorySQL) GetByID(ctx context.Context, id string) (*models.PriceList, error) {
+	const query = `
+SELECT id, name, currency, customer, valid_from, valid_to, created_at, updated_at, version
+FROM price_lists
+WHERE id = ? LIMIT 1;
+`
+	l, err := scanPriceList(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	const itemsQuery = `
+SELECT product_id, price
+FROM price_list_items
+WHERE price_list_id = ?
+ORDER BY product_id;
+`
+	rows, err := r.db.QueryContext(ctx, itemsQuery, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	l.Items = []models.PriceListItem{}
+	for rows.Next() {
+		var it models.PriceListItem
+		if err := rows.Scan(&it.ProductID, &it.Price); err != nil {
+			return nil, err
+		}
+		l.Items = append(l.Items, it)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Create сохраняет новый прайс-лист (без позиций).
+func (r *PriceListRepositorySQL) Create(ctx context.Context, list *models.PriceList) error {
+	const query = `
+INSERT INTO price_lists (id, name, currency, customer, valid_from, valid_to, created_at, updated_at, version)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?);
+`
+	if list.ID == "" {
+		id, err := r.ids.NewID(idPrefixPriceList)
+		if err != nil {
+			return err
+		}
+		list.ID = id
+	}
+	now := time.Now().UTC()
+	if list.CreatedAt.IsZero() {
+		list.CreatedAt = now
+	}
+	list.UpdatedAt = now
+	list.Version = models.InitialVersion
+
+	_, err := r.db.ExecContext(ctx, query,
+		list.ID,
+		list.Name,
+		list.Currency,
+		nullIfEmpty(list.Customer),
+		list.ValidFrom,
+		list.ValidTo,
+		list.CreatedAt,
+		list.UpdatedAt,
+		list.Version,
+	)
+	return err
+}
+
+// Update обновляет реквизиты прайс-листа, если его версия не изменилась с момента чтения.
+// Иначе возвращает models.ErrVersionConflict; при успехе увеличивает Version.
+func (r *PriceListRepositorySQL) Update(ctx context.Context, list *models.PriceList) error {
+	const query = `
+UPDATE price_lists
+SET name = ?, currency = ?, customer = ?, valid_from = ?, valid_to = ?, updated_at = ?, version = version + 1
+WHERE id = ? AND version = ?;
+`
+	list.UpdatedAt = time.Now().UTC()
+
+	err := execVersioned(ctx, r.db, query,
+		list.Name,
+		list.Currency,
+		nullIfEmpty(list.Customer),
+		list.ValidFrom,
+		list.ValidTo,
+		list.UpdatedAt,
+		list.ID,
+		list.Version,
+	)
+	if err != nil {
+		return err
+	}
+	list.Version++
+	return nil
+}
+
+// Delete удаляет прайс-лист вместе с позициями при совпадении версии (иначе models.ErrVersionConflict).
+// Ссылки на него в позициях заказов остаются как история.
+func (r *PriceListRepositorySQL) Delete(ctx context.Context, id string, version int64) error {
+	const query = `DELETE FROM price_lists WHERE id = ? AND version = ?;`
+	return execVersioned(ctx, r.db, query, id, version)
+}
+
+// SetItem создаёт или заменяет цену товара в прайс-листе.
+func (r *PriceListRepositorySQL) SetItem(ctx context.Context, listID string, item models.PriceListItem) error {
+	query := `
+INSERT INTO price_list_items (price_list_id, product_id, price)
+VALUES (?, ?, ?)
+` + r.db.Dialect().Upsert([]string{"price_list_id", "product_id"}, "price") + ";"
+	_, err := r.db.ExecContext(ctx, query, listID, item.ProductID, item.Price)
+	return err
+}
+
+// DeleteItem удаляет цену товара из прайс-листа.
+func (r *PriceListRepositorySQL) DeleteItem(ctx context.Context, listID, productID string) error {
+	const query = `DELETE FROM price_list_items WHERE price_list_id = ? AND product_id = ?;`
+	_, err := r.db.ExecContext(ctx, query, listID, productID)
+	return err
+}
+
+// ActivePrice возвращает цену товара из прайс-листа, действующего в момент at, или nil, если такого нет.
+// Прайс-лист покупателя customer важнее общего, из равных — тот, что начал действовать позже.
+// Пустая currency не ограничивает валюту прайс-листа.
+func (r *PriceListRepositorySQL) ActivePrice(ctx context.Context, productID, customer, currency string, at time.Time) (*models.ProductPrice, error) {
+	at = at.UTC()
+	query := `
+SELECT pi.product_id, pi.price, pl.currency, pl.id
+FROM price_list_items pi
+JOIN price_lists pl ON pl.id = pi.price_list_id
+WHERE pi.product_id = ?
+  AND (pl.customer IS NULL OR pl.customer = ?)
+  AND pl.valid_from <= ?
+  AND (pl.valid_to IS NULL OR pl.valid_to > ?)`
+	args := []any{productID, customer, at, at}
+	if currency != "" {
+		query += `
+  AND pl.currency = ?`
+		args = append(args, currency)
+	}
+	query += `
+ORDER BY CASE WHEN pl.customer IS NULL THEN 1 ELSE 0 END, pl.valid_from DESC, pl.id
+LIMIT 1;
+`
+	var p models.ProductPrice
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&p.ProductID, &p.Price, &p.Currency, &p.PriceListID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
@@ -4,210 +4,653 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
+	"warehouse-management-system/src/config"
 	"warehouse-management-system/src/models"
 )
 
-// ProductRepositorySQLite — реализация хранилища товаров на SQLite.
-type ProductRepositorySQLite struct {
-	db *sql.DB
+// ProductRepositorySQL — реализация хранилища товаров на SQL (SQLite или PostgreSQL).
+type ProductRepositorySQL struct {
+	db  *config.DB
+	ids IDGenerator
 }
 
 // NewProductRepository создаёт новый репозиторий товаров.
-func NewProductRepository(db *sql.DB) *ProductRepositorySQLite {
-	return &ProductRepositorySQLite{db: db}
+func NewProductRepository(db *config.DB, ids IDGenerator) *ProductRepositorySQL {
+	return &ProductRepositorySQL{db: db, ids: ids}
 }
 
-// GetAll возвращает список всех товаров.
-func (r *ProductRepositorySQLite) GetAll() ([]*models.Product, error) {
-	const query = `
-SELECT id, sku, name, description, category_id, supplier_id, unit, created_at, updated_at
-FROM products
-ORDER BY created_at DESC;
-`
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
+// productScanner позволяет использовать один код сканирования для *sql.Row и *sql.Rows.
+type productScanner interface {
+	Scan(dest ...any) error
+}
 
-	rows, err := r.db.QueryContext(ctx, query)
+func scanProduct(s productScanner) (*models.Product, error) {
+	var (
+		p          models.Product
+		archivedAt sql.NullTime
+	)
+	if err := s.Scan(
+		&p.ID,
+		&p.SKU,
+		&p.Name,
+		&p.Description,
+		&p.CategoryID,
+		&p.SupplierID,
+		&p.Unit,
+		&p.ParentID,
+		&p.CreatedAt,
+		&p.UpdatedAt,
+		&archivedAt,
+		&p.Version,
+	); err != nil {
+		return nil, err
+	}
+	if archivedAt.Valid {
+		p.ArchivedAt = &archivedAt.Time
+	}
+	return &p, nil
+}
+
+// productSortColumns сопоставляет поля сортировки колонкам таблицы products.
+// Для каждой колонки есть индекс (колонка, id), см. миграцию 0006_product_listing.
+var productSortColumns = map[models.ProductSortField]string{
+	models.ProductSortCreatedAt: "created_at",
+	models.ProductSortUpdatedAt: "updated_at",
+	models.ProductSortName:      "name",
+	models.ProductSortSKU:       "sku",
+}
+
+// Find возвращает страницу товаров по фильтру. Поле сортировки и лимит проверяет сервис.
+func (r *ProductRepositorySQL) Find(ctx context.Context, filter models.ProductFilter) ([]*models.Product, error) {
+	column := productSortColumns[filter.Sort]
+	conds, args := productConditions(r.db.Dialect(), filter)
+
+	dir, cmp := "ASC", ">"
+	if filter.Desc {
+		dir, cmp = "DESC", "<"
+	}
+	if filter.After != nil {
+		value, err := productCursorValue(filter.After)
+		if err != nil {
+			return nil, err
+		}
+		conds = append(conds, "("+column+" "+cmp+" ? OR ("+column+" = ? AND id "+cmp+" ?))")
+		args = append(args, value, value, filter.After.ID)
+	}
+
+	query := `
+SELECT id, sku, name, description, category_id, COALESCE(supplier_id, ''), unit, COALESCE(parent_id, ''), created_at, updated_at, archived_at, version
+FROM products`
+	if len(conds) > 0 {
+		query += "\nWHERE " + strings.Join(conds, " AND ")
+	}
+	query += "\nORDER BY " + column + " " + dir + ", id " + dir + "\nLIMIT ? OFFSET ?;"
+	args = append(args, filter.Limit, filter.Offset)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var result []*models.Product
+	result := []*models.Product{}
 	for rows.Next() {
-		var p models.Product
-		if err := rows.Scan(
-			&p.ID,
-			&p.SKU,
-			&p.Name,
-			&p.Description,
-			&p.CategoryID,
-			&p.SupplierID,
-			&p.Unit,
-			&p.CreatedAt,
-			&p.UpdatedAt,
-		); err != nil {
+		p, err := scanProduct(rows)
+		if err != nil {
 			return nil, err
 		}
-		result = append(result, &p)
+		result = append(result, p)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
+	rows.Close()
+
+	if err := loadProductAttributes(ctx, r.db, result); err != nil {
+		return nil, err
+	}
 	return result, nil
 }
 
+// Count возвращает число товаров, подходящих под фильтр (без учёта страницы).
+func (r *ProductRepositorySQL) Count(ctx context.Context, filter models.ProductFilter) (int, error) {
+	conds, args := productConditions(r.db.Dialect(), filter)
+	query := `SELECT COUNT(*) FROM products`
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+
+	var n int
+	err := r.db.QueryRowContext(ctx, query+";", args...).Scan(&n)
+	return n, err
+}
+
+// productConditions формирует условия WHERE по фильтру товаров.
+// Поиск по подстроке регистронезависим, в том числе для кириллицы.
+func productConditions(d config.Dialect, filter models.ProductFilter) ([]string, []any) {
+	var (
+		conds []string
+		args  []any
+	)
+	if !filter.IncludeArchived {
+		conds = append(conds, "archived_at IS NULL")
+	}
+	if filter.CategoryID != "" {
+		// Товары категории и всех её подкатегорий.
+		conds = append(conds, `category_id IN (
+    WITH RECURSIVE subtree(id) AS (
+        SELECT CAST(? AS TEXT)
+        UNION
+        SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+    )
+    SELECT id FROM subtree
+)`)
+		args = append(args, filter.CategoryID)
+	}
+	if filter.SupplierID != "" {
+		conds = append(conds, "supplier_id = ?")
+		args = append(args, filter.SupplierID)
+	}
+	if filter.ParentID != "" {
+		conds = append(conds, "parent_id = ?")
+		args = append(args, filter.ParentID)
+	}
+	if filter.Query != "" {
+		cond, condArgs := containsFold(d, filter.Query, "sku", "name", "description")
+		conds = append(conds, cond)
+		args = append(args, condArgs...)
+	}
+	return conds, args
+}
+
+// productCursorValue приводит значение курсора к типу колонки сортировки.
+func productCursorValue(c *models.ProductCursor) (any, error) {
+	switch c.Sort {
+	case models.ProductSortCreatedAt, models.ProductSortUpdatedAt:
+		t, err := time.Parse(time.RFC3339Nano, c.Value)
+		if err != nil {
+			return nil, models.ErrInvalidCursor
+		}
+		return t.UTC(), nil
+	}
+	return c.Value, nil
+}
+
+// containsFold возвращает условие «хотя бы одна из columns содержит term без учёта регистра».
+// Регистр колонок понижает диалект (в SQLite — с учётом Unicode), регистр term — strings.ToLower.
+func containsFold(d config.Dialect, term string, columns ...string) (string, []any) {
+	pattern := "%" + escapeLike(strings.ToLower(term)) + "%"
+	parts := make([]string, len(columns))
+	args := make([]any, len(columns))
+	for i, col := range columns {
+		parts[i] = d.Lower(col) + ` LIKE ? ESCAPE '\'`
+		args[i] = pattern
+	}
+	return "(" + strings.Join(parts, " OR ") + ")", args
+}
+
+// escapeLike экранирует спецсимволы LIKE, чтобы % и _ в поисковой строке искались буквально.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
 // GetByID возвращает товар по идентификатору.
-func (r *ProductRepositorySQLite) GetByID(id string) (*models.Product, error) {
+func (r *ProductRepositorySQL) GetByID(ctx context.Context, id string) (*models.Product, error) {
 	const query = `
-SELECT id, sku, name, description, category_id, supplier_id, unit, created_at, updated_at
+SELECT id, sku, name, description, category_id, COALESCE(supplier_id, ''), unit, COALESCE(parent_id, ''), created_at, updated_at, archived_at, version
 FROM products
 WHERE id = ? LIMIT 1;
 `
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
-
 	row := r.db.QueryRowContext(ctx, query, id)
-	var p models.Product
-	if err := row.Scan(
-		&p.ID,
-		&p.SKU,
-		&p.Name,
-		&p.Description,
-		&p.CategoryID,
-		&p.SupplierID,
-		&p.Unit,
-		&p.CreatedAt,
-		&p.UpdatedAt,
-	); err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
-		}
+	p, err := scanProduct(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
 		return nil, err
 	}
-	return &p, nil
+	if err := loadProductAttributes(ctx, r.db, []*models.Product{p}); err != nil {
+		return nil, err
+	}
+	return p, nil
 }
 
 // GetBySKU возвращает товар по SKU.
-func (r *ProductRepositorySQLite) GetBySKU(sku string) (*models.Product, error) {
+func (r *ProductRepositorySQL) GetBySKU(ctx context.Context, sku string) (*models.Product, error) {
 	const query = `
-SELECT id, sku, name, description, category_id, supplier_id, unit, created_at, updated_at
+SELECT id, sku, name, description, category_id, COALESCE(supplier_id, ''), unit, COALESCE(parent_id, ''), created_at, updated_at, archived_at, version
 FROM products
 WHERE sku = ? LIMIT 1;
 `
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
-
 	row := r.db.QueryRowContext(ctx, query, sku)
-	var p models.Product
-	if err := row.Scan(
-		&p.ID,
-		&p.SKU,
-		&p.Name,
-		&p.Description,
-		&p.CategoryID,
-		&p.SupplierID,
-		&p.Unit,
-		&p.CreatedAt,
-		&p.UpdatedAt,
-	); err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
-		}
+	p, err := scanProduct(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
 		return nil, err
 	}
-	return &p, nil
+	if err := loadProductAttributes(ctx, r.db, []*models.Product{p}); err != nil {
+		return nil, err
+	}
+	return p, nil
 }
 
-// Create сохраняет новый товар.
-func (r *ProductRepositorySQLite) Create(product *models.Product) error {
-	const query = `
-INSERT INTO products (id, sku, name, description, category_id, supplier_id, unit, created_at, updated_at)
-VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?);
-`
+// Create сохраняет новый товар вместе с его атрибутами.
+func (r *ProductRepositorySQL) Create(ctx context.Context, product *models.Product) error {
 	if product.ID == "" {
-		product.ID = "p-" + time.Now().UTC().Format("20060102T150405.000000000")
+		id, err := r.ids.NewID(idPrefixProduct)
+		if err != nil {
+			return err
+		}
+		product.ID = id
 	}
 	now := time.Now().UTC()
 	if product.CreatedAt.IsZero() {
 		product.CreatedAt = now
 	}
 	product.UpdatedAt = now
+	product.Version = models.InitialVersion
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
 
-	_, err := r.db.ExecContext(ctx, query,
-		product.ID,
-		product.SKU,
-		product.Name,
-		product.Description,
-		product.CategoryID,
-		product.SupplierID,
-		product.Unit,
-		product.CreatedAt,
-		product.UpdatedAt,
-	)
+	if err = insertProduct(ctx, tx, product); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// updateProductQuery обновляет поля товара при совпадении версии.
+const updateProductQuery = `
+UPDATE products
+SET sku = ?, name = ?, description = ?, category_id = ?, supplier_id = ?, unit = ?, updated_at = ?, version = version + 1
+WHERE id = ? AND version = ?;
+`
+
+// Update обновляет существующий товар, если его версия не изменилась с момента чтения.
+// Иначе возвращает models.ErrVersionConflict; при успехе увеличивает Version.
+func (r *ProductRepositorySQL) Update(ctx context.Context, product *models.Product) error {
+	product.UpdatedAt = time.Now().UTC()
+	if err := updateProduct(ctx, r.db, product); err != nil {
+		return err
+	}
+	product.Version++
+	return nil
+}
+
+// SetAttributes заменяет атрибуты товара, если его версия не изменилась с момента чтения
+// (иначе models.ErrVersionConflict); при успехе увеличивает Version.
+func (r *ProductRepositorySQL) SetAttributes(ctx context.Context, product *models.Product) error {
+	product.UpdatedAt = time.Now().UTC()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	const update = `UPDATE products SET updated_at = ?, version = version + 1 WHERE id = ? AND version = ?;`
+	res, err := tx.ExecContext(ctx, update, product.UpdatedAt, product.ID, product.Version)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
 	if err != nil {
 		return err
 	}
+	if affected == 0 {
+		err = models.ErrVersionConflict
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM product_attributes WHERE product_id = ?;`, product.ID); err != nil {
+		return err
+	}
+	if err = insertProductAttributes(ctx, tx, product); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	product.Version++
 	return nil
 }
 
-// Update обновляет существующий товар.
-func (r *ProductRepositorySQLite) Update(product *models.Product) error {
+// Archive помечает товар как заархивированный: он пропадает из списков, но остаётся в БД.
+// Как и Update, выполняется только при совпадении версии.
+func (r *ProductRepositorySQL) Archive(ctx context.Context, id string, version int64, at time.Time) error {
+	return execVersioned(ctx, r.db, archiveProductQuery, at, at, id, version)
+}
+
+const archiveProductQuery = `UPDATE products SET archived_at = ?, updated_at = ?, version = version + 1 WHERE id = ? AND version = ?;`
+
+// Restore снимает с товара пометку архивного.
+func (r *ProductRepositorySQL) Restore(ctx context.Context, id string, version int64, at time.Time) error {
+	const query = `UPDATE products SET archived_at = NULL, updated_at = ?, version = version + 1 WHERE id = ? AND version = ?;`
+	return execVersioned(ctx, r.db, query, at, id, version)
+}
+
+// IsReferenced сообщает, есть ли у товара движения, позиции заказов, строка остатков, варианты
+// или вложения, из-за которых его нельзя удалить физически.
+func (r *ProductRepositorySQL) IsReferenced(ctx context.Context, id string) (bool, error) {
 	const query = `
-UPDATE products
-SET sku = ?, name = ?, description = ?, category_id = ?, supplier_id = ?, unit = ?, updated_at = ?
-WHERE id = ?;
+SELECT EXISTS (SELECT 1 FROM stock_movements WHERE product_id = ?)
+    OR EXISTS (SELECT 1 FROM order_items WHERE product_id = ?)
+    OR EXISTS (SELECT 1 FROM stock_balances WHERE product_id = ?)
+    OR EXISTS (SELECT 1 FROM products WHERE parent_id = ?)
+    OR EXISTS (SELECT 1 FROM attachments WHERE owner_type = 'product' AND owner_id = ?);
+`
+	var referenced bool
+	if err := r.db.QueryRowContext(ctx, query, id, id, id, id, id).Scan(&referenced); err != nil {
+		return false, err
+	}
+	return referenced, nil
+}
+
+// ReferencedIDs возвращает те из ids, на которые ссылаются записи, перечисленные в IsReferenced.
+// Один запрос на весь список вместо IsReferenced для каждого товара.
+func (r *ProductRepositorySQL) ReferencedIDs(ctx context.Context, ids []string) (map[string]bool, error) {
+	result := map[string]bool{}
+	if len(ids) == 0 {
+		return result, nil
+	}
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	in := `(?` + strings.Repeat(", ?", len(ids)-1) + `)`
+
+	query := `
+SELECT product_id FROM stock_movements WHERE product_id IN ` + in + `
+UNION SELECT product_id FROM order_items WHERE product_id IN ` + in + `
+UNION SELECT product_id FROM stock_balances WHERE product_id IN ` + in + `
+UNION SELECT parent_id FROM products WHERE parent_id IN ` + in + `
+UNION SELECT owner_id FROM attachments WHERE owner_type = 'product' AND owner_id IN ` + in + `;
+`
+	all := make([]any, 0, 5*len(args))
+	for i := 0; i < 5; i++ {
+		all = append(all, args...)
+	}
+	rows, err := r.db.QueryContext(ctx, query, all...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		result[id] = true
+	}
+	return result, rows.Err()
+}
+
+// HasBaseUnitData сообщает, есть ли у товара записи с количествами или ценами в его базовой единице:
+// движения, строка остатков, позиции заказов, цены прайс-листов, условия поставщиков или штрихкоды.
+func (r *ProductRepositorySQL) HasBaseUnitData(ctx context.Context, id string) (bool, error) {
+	const query = `
+SELECT EXISTS (SELECT 1 FROM stock_movements WHERE product_id = ?)
+    OR EXISTS (SELECT 1 FROM stock_balances WHERE product_id = ?)
+    OR EXISTS (SELECT 1 FROM order_items WHERE product_id = ?)
+    OR EXISTS (SELECT 1 FROM price_list_items WHERE product_id = ?)
+    OR EXISTS (SELECT 1 FROM supplier_products WHERE product_id = ?)
+    OR EXISTS (SELECT 1 FROM product_barcodes WHERE product_id = ?);
 `
+	var found bool
+	if err := r.db.QueryRowContext(ctx, query, id, id, id, id, id, id).Scan(&found); err != nil {
+		return false, err
+	}
+	return found, nil
+}
+
+// Delete физически удаляет товар по ID при совпадении версии (иначе models.ErrVersionConflict).
+func (r *ProductRepositorySQL) Delete(ctx context.Context, id string, version int64) error {
+	return execVersioned(ctx, r.db, deleteProductQuery, id, version)
+}
+
+const deleteProductQuery = `DELETE FROM products WHERE id = ? AND version = ?;`
+
+// GetByIDs возвращает товары (в том числе архивные) с указанными ID; отсутствующие ID пропускаются.
+func (r *ProductRepositorySQL) GetByIDs(ctx context.Context, ids []string) ([]*models.Product, error) {
+	return r.findIn(ctx, "id", ids)
+}
+
+// GetBySKUs возвращает товары с указанными SKU; отсутствующие SKU пропускаются.
+func (r *ProductRepositorySQL) GetBySKUs(ctx context.Context, skus []string) ([]*models.Product, error) {
+	return r.findIn(ctx, "sku", skus)
+}
+
+// findIn загружает товары, у которых значение колонки column входит в values, одним запросом.
+func (r *ProductRepositorySQL) findIn(ctx context.Context, column string, values []string) ([]*models.Product, error) {
+	result := []*models.Product{}
+	if len(values) == 0 {
+		return result, nil
+	}
+	args := make([]any, len(values))
+	for i, v := range values {
+		args[i] = v
+	}
+
+	query := `
+SELECT id, sku, name, description, category_id, COALESCE(supplier_id, ''), unit, COALESCE(parent_id, ''), created_at, updated_at, archived_at, version
+FROM products
+WHERE ` + column + ` IN (?` + strings.Repeat(", ?", len(args)-1) + `);
+`
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		p, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	if err := loadProductAttributes(ctx, r.db, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ApplyBatch применяет изменения товаров в одной транзакции в порядке следования: сохраняются либо все,
+// либо ни одно. Изменения, кроме создания, выполняются при совпадении версии товара.
+// Ошибка изменения возвращается как *models.ProductChangeError (гонка — models.ErrVersionConflict);
+// в этом случае товары пакета могли измениться частично, и их не следует использовать.
+// При успехе новые товары получают ID, а версии изменённых товаров увеличиваются.
+func (r *ProductRepositorySQL) ApplyBatch(ctx context.Context, changes []models.ProductChange) error {
 	now := time.Now().UTC()
-	product.UpdatedAt = now
+	for _, c := range changes {
+		if c.Op == models.ProductChangeCreate && c.Product.ID == "" {
+			id, err := r.ids.NewID(idPrefixProduct)
+			if err != nil {
+				return err
+			}
+			c.Product.ID = id
+		}
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	for i, c := range changes {
+		p := c.Product
+		switch c.Op {
+		case models.ProductChangeCreate:
+			p.CreatedAt, p.UpdatedAt, p.Version = now, now, models.InitialVersion
+			err = insertProduct(ctx, tx, p)
+		case models.ProductChangeUpdate:
+			p.UpdatedAt = now
+			err = updateProduct(ctx, tx, p)
+		case models.ProductChangeArchive:
+			err = execVersioned(ctx, tx, archiveProductQuery, now, now, p.ID, p.Version)
+		case models.ProductChangeDelete:
+			err = execVersioned(ctx, tx, deleteProductQuery, p.ID, p.Version)
+		default:
+			err = fmt.Errorf("unknown product change %q", c.Op)
+		}
+		if err != nil {
+			err = &models.ProductChangeError{Index: i, Err: err}
+			return err
+		}
+	}
 
-	res, err := r.db.ExecContext(ctx, query,
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	for _, c := range changes {
+		switch c.Op {
+		case models.ProductChangeUpdate:
+			c.Product.Version++
+		case models.ProductChangeArchive:
+			c.Product.ArchivedAt = &now
+			c.Product.UpdatedAt = now
+			c.Product.Version++
+		}
+	}
+	return nil
+}
+
+// insertProduct сохраняет новый товар и его атрибуты в транзакции tx.
+func insertProduct(ctx context.Context, tx *config.Tx, product *models.Product) error {
+	const query = `
+INSERT INTO products (id, sku, name, description, category_id, supplier_id, unit, parent_id, created_at, updated_at, version)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+`
+	if _, err := tx.ExecContext(ctx, query,
+		product.ID,
 		product.SKU,
 		product.Name,
 		product.Description,
 		product.CategoryID,
-		product.SupplierID,
+		nullIfEmpty(product.SupplierID),
+		product.Unit,
+		nullIfEmpty(product.ParentID),
+		product.CreatedAt,
+		product.UpdatedAt,
+		product.Version,
+	); err != nil {
+		return skuConflict(err)
+	}
+	return insertProductAttributes(ctx, tx, product)
+}
+
+// updateProduct выполняет updateProductQuery для товара; версию товара не меняет.
+func updateProduct(ctx context.Context, db execer, product *models.Product) error {
+	err := execVersioned(ctx, db, updateProductQuery,
+		product.SKU,
+		product.Name,
+		product.Description,
+		product.CategoryID,
+		nullIfEmpty(product.SupplierID),
 		product.Unit,
 		product.UpdatedAt,
 		product.ID,
+		product.Version,
 	)
-	if err != nil {
-		return err
-	}
-	affected, err := res.RowsAffected()
-	if err != nil {
-		return err
+	return skuConflict(err)
+}
+
+// skuConflict заменяет нарушение уникальности при записи строки products на models.ErrDuplicateSKU:
+// единственный уникальный ключ товара, кроме сгенерированного ID, — SKU.
+func skuConflict(err error) error {
+	if config.IsUniqueViolation(err) {
+		return models.ErrDuplicateSKU
 	}
-	if affected == 0 {
-		return fmt.Errorf("product with id %s not found", product.ID)
+	return err
+}
+
+// insertProductAttributes сохраняет атрибуты товара в транзакции tx.
+func insertProductAttributes(ctx context.Context, tx *config.Tx, product *models.Product) error {
+	const query = `
+INSERT INTO product_attributes (product_id, name, type, value)
+VALUES (?, ?, ?, ?);
+`
+	for _, a := range product.Attributes {
+		if _, err := tx.ExecContext(ctx, query, product.ID, a.Name, a.Type, a.Value); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
-// Delete удаляет товар по ID.
-func (r *ProductRepositorySQLite) Delete(id string) error {
-	const query = `DELETE FROM products WHERE id = ?;`
-
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
+// loadProductAttributes подгружает атрибуты товаров одним запросом.
+func loadProductAttributes(ctx context.Context, db *config.DB, products []*models.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+	byID := make(map[string]*models.Product, len(products))
+	args := make([]any, 0, len(products))
+	for _, p := range products {
+		if _, ok := byID[p.ID]; !ok {
+			byID[p.ID] = p
+			args = append(args, p.ID)
+		}
+	}
 
-	res, err := r.db.ExecContext(ctx, query, id)
+	query := `
+SELECT product_id, name, type, value
+FROM product_attributes
+WHERE product_id IN (?` + strings.Repeat(", ?", len(args)-1) + `);
+`
+	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return err
 	}
-	affected, err := res.RowsAffected()
-	if err != nil {
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			productID string
+			a         models.ProductAttribute
+		)
+		if err := rows.Scan(&productID, &a.Name, &a.Type, &a.Value); err != nil {
+			return err
+		}
+		p := byID[productID]
+		p.Attributes = append(p.Attributes, a)
+	}
+	if err := rows.Err(); err != nil {
 		return err
 	}
-	if affected == 0 {
-		return fmt.Errorf("product with id %s not found", id)
+	for _, p := range products {
+		models.SortAttributes(p.Attributes)
 	}
 	return nil
 }
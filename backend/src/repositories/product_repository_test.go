@@ -0,0 +1,207 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+	"warehouse-management-system/src/config"
+	"warehouse-management-system/src/models"
+)
+
+// openTestSQLite открывает временную БД SQLite с применёнными миграциями.
+func openTestSQLite(t *testing.T) *config.DB {
+	t.Helper()
+	db, err := config.OpenSQLite(filepath.Join(t.TempDir(), "test.db"), config.SQLiteConfig{
+		JournalMode: "WAL",
+		Synchronous: "NORMAL",
+		ReadConns:   2,
+	})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := config.Migrate(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+func TestProductFindQueryIsUnicodeCaseInsensitive(t *testing.T) {
+	db := openTestSQLite(t)
+	ctx := context.Background()
+	ids := NewUUIDv7Generator()
+
+	category := models.NewCategory("Молочное", "")
+	if err := NewCategoryRepository(db, ids).Create(ctx, category); err != nil {
+		t.Fatal(err)
+	}
+	repo := NewProductRepository(db, ids)
+	for _, p := range []*models.Product{
+		models.NewProduct("MLK-1", "Молоко Ёлочка", "Пастеризованное", category.ID, "", models.UnitPiece),
+		models.NewProduct("KFR-1", "Кефир", "", category.ID, "", models.UnitPiece),
+	} {
+		if err := repo.Create(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{query: "МОЛОКО", want: 1},
+		{query: "ёлоч", want: 1},
+		{query: "ПАСТЕРИЗ", want: 1},
+		{query: "mlk", want: 1},
+		{query: "к", want: 2},
+		{query: "%", want: 0},
+	}
+	for _, tt := range tests {
+		filter := models.ProductFilter{Query: tt.query, Sort: models.ProductSortName, Limit: 10}
+		found, err := repo.Find(ctx, filter)
+		if err != nil {
+			t.Fatalf("Find(%q): %v", tt.query, err)
+		}
+		n, err := repo.Count(ctx, filter)
+		if err != nil {
+			t.Fatalf("Count(%q): %v", tt.query, err)
+		}
+		if len(found) != tt.want || n != tt.want {
+			t.Errorf("query %q: found %d, counted %d, want %d", tt.query, len(found), n, tt.want)
+		}
+	}
+}
+
+func TestProductHasBaseUnitData(t *testing.T) {
+	db := openTestSQLite(t)
+	ctx := context.Background()
+	ids := NewUUIDv7Generator()
+
+	category := models.NewCategory("Category", "")
+	if err := NewCategoryRepository(db, ids).Create(ctx, category); err != nil {
+		t.Fatal(err)
+	}
+	supplier := models.NewSupplier("Supplier", "", "", "")
+	if err := NewSupplierRepository(db, ids).Create(ctx, supplier); err != nil {
+		t.Fatal(err)
+	}
+	priceList := &models.PriceList{Name: "Retail", Currency: "RUB", ValidFrom: time.Now().UTC()}
+	priceLists := NewPriceListRepository(db, ids)
+	if err := priceLists.Create(ctx, priceList); err != nil {
+		t.Fatal(err)
+	}
+	repo := NewProductRepository(db, ids)
+	one := models.DecimalFromInt(1)
+
+	tests := []struct {
+		name string
+		add  func(productID string) error
+	}{
+		{name: "none"},
+		{name: "receipt", add: func(productID string) error {
+			m := &models.StockMovement{Type: models.MovementReceipt, ProductID: productID, Quantity: one}
+			return NewWarehouseRepository(db, ids).AddMovement(ctx, m)
+		}},
+		{name: "price list item", add: func(productID string) error {
+			return priceLists.SetItem(ctx, priceList.ID, models.PriceListItem{ProductID: productID, Price: one})
+		}},
+		{name: "barcode", add: func(productID string) error {
+			return NewBarcodeRepository(db).Create(ctx, &models.Barcode{
+				Code: "CODE-" + productID, ProductID: productID, Type: models.BarcodeCode128, Packaging: "unit", Quantity: one,
+			})
+		}},
+		{name: "supplier cost", add: func(productID string) error {
+			return NewSupplierCostRepository(db).Save(ctx, &models.SupplierCost{
+				SupplierID: supplier.ID, ProductID: productID, Cost: one, Currency: "RUB", MinOrderQuantity: one,
+			})
+		}},
+	}
+	for _, tt := range tests {
+		product := models.NewProduct("UNIT-"+tt.name, "Product", "", category.ID, "", models.UnitPiece)
+		if err := repo.Create(ctx, product); err != nil {
+			t.Fatal(err)
+		}
+		if tt.add != nil {
+			if err := tt.add(product.ID); err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+		}
+		found, err := repo.HasBaseUnitData(ctx, product.ID)
+		if want := tt.add != nil; err != nil || found != want {
+			t.Errorf("%s: HasBaseUnitData = %v, %v; want %v", tt.name, found, err, want)
+		}
+	}
+}
+
+func TestProductReferencedIDs(t *testing.T) {
+	db := openTestSQLite(t)
+	ctx := context.Background()
+	ids := NewUUIDv7Generator()
+
+	category := models.NewCategory("Category", "")
+	if err := NewCategoryRepository(db, ids).Create(ctx, category); err != nil {
+		t.Fatal(err)
+	}
+	repo := NewProductRepository(db, ids)
+	create := func(sku, parentID string) *models.Product {
+		p := models.NewProduct(sku, sku, "", category.ID, "", models.UnitPiece)
+		p.ParentID = parentID
+		if err := repo.Create(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	stocked := create("REF-STOCK", "")
+	template := create("REF-TEMPLATE", "")
+	variant := create("REF-VARIANT", template.ID)
+	free := create("REF-FREE", "")
+
+	movement := &models.StockMovement{Type: models.MovementReceipt, ProductID: stocked.ID, Quantity: models.DecimalFromInt(1)}
+	if err := NewWarehouseRepository(db, ids).AddMovement(ctx, movement); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := repo.ReferencedIDs(ctx, []string{stocked.ID, template.ID, variant.ID, free.ID, "p-missing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || !got[stocked.ID] || !got[template.ID] {
+		t.Fatalf("ReferencedIDs = %v, want %s and %s", got, stocked.ID, template.ID)
+	}
+	for _, p := range []*models.Product{stocked, template, variant, free} {
+		single, err := repo.IsReferenced(ctx, p.ID)
+		if err != nil || single != got[p.ID] {
+			t.Fatalf("IsReferenced(%s) = %v, %v; ReferencedIDs says %v", p.SKU, single, err, got[p.ID])
+		}
+	}
+}
+
+func TestProductApplyBatchDuplicateSKU(t *testing.T) {
+	db := openTestSQLite(t)
+	ctx := context.Background()
+	ids := NewUUIDv7Generator()
+
+	category := models.NewCategory("Category", "")
+	if err := NewCategoryRepository(db, ids).Create(ctx, category); err != nil {
+		t.Fatal(err)
+	}
+	repo := NewProductRepository(db, ids)
+	// Товар с тем же SKU сохранён уже после того, как пакет прошёл проверку SKU.
+	if err := repo.Create(ctx, models.NewProduct("DUP-1", "First", "", category.ID, "", models.UnitPiece)); err != nil {
+		t.Fatal(err)
+	}
+
+	err := repo.ApplyBatch(ctx, []models.ProductChange{
+		{Op: models.ProductChangeCreate, Product: models.NewProduct("DUP-2", "Other", "", category.ID, "", models.UnitPiece)},
+		{Op: models.ProductChangeCreate, Product: models.NewProduct("DUP-1", "Second", "", category.ID, "", models.UnitPiece)},
+	})
+	var changeErr *models.ProductChangeError
+	if !errors.As(err, &changeErr) || changeErr.Index != 1 || !errors.Is(err, models.ErrDuplicateSKU) {
+		t.Fatalf("ApplyBatch = %v, want ErrDuplicateSKU at change 1", err)
+	}
+	if p, _ := repo.GetBySKU(ctx, "DUP-2"); p != nil {
+		t.Fatal("change from a failed batch was saved")
+	}
+}
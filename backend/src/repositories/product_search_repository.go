@@ -0,0 +1,256 @@
+package repositories
+
+import (
+	"context"
+	"html"
+	"log"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+	"warehouse-management-system/src/config"
+	"warehouse-management-system/src/models"
+)
+
+// Поиск товаров идёт по полнотекстовому индексу SQLite FTS5 (таблица products_fts из миграции
+// 0013_product_search), если он есть, иначе — по LIKE. Индекс хранит только токены и берёт текст
+// из products по rowid; триггеры на products поддерживают его в актуальном состоянии.
+
+// Маркеры начала и конца совпадения в тексте, который возвращают highlight/snippet FTS5.
+// Управляющие символы не встречаются в названиях, поэтому текст можно экранировать для HTML
+// и только после этого заменить маркеры на <mark>.
+const (
+	searchMarkStart = "\x02"
+	searchMarkEnd   = "\x03"
+)
+
+// searchSnippetTokens — длина фрагмента описания в словах.
+const searchSnippetTokens = 16
+
+// Веса колонок products_fts в bm25: совпадение в SKU важнее, чем в названии, а в названии — чем в описании.
+const searchRankWeights = "10.0, 5.0, 1.0"
+
+// ProductSearchRepositorySQL — поиск товаров по SKU, названию и описанию.
+type ProductSearchRepositorySQL struct {
+	db  *config.DB
+	fts bool
+}
+
+// NewProductSearchRepository создаёт репозиторий поиска. Для SQLite проверяет индекс products_fts
+// и перестраивает его, если он разошёлся с products (например, после восстановления копии БД).
+// Без индекса (PostgreSQL) поиск работает через LIKE.
+func NewProductSearchRepository(ctx context.Context, db *config.DB) (*ProductSearchRepositorySQL, error) {
+	r := &ProductSearchRepositorySQL{db: db}
+	if db.Dialect().Name() != config.DriverSQLite {
+		return r, nil
+	}
+
+	var n int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'products_fts';`).Scan(&n); err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		log.Println("WARNING: products_fts index is missing, product search falls back to LIKE")
+		return r, nil
+	}
+	if err := r.syncIndex(ctx); err != nil {
+		return nil, err
+	}
+	r.fts = true
+	return r, nil
+}
+
+// FullText сообщает, используется ли полнотекстовый индекс.
+func (r *ProductSearchRepositorySQL) FullText() bool {
+	return r.fts
+}
+
+// Search ищет незаархивированные товары, в SKU, названии или описании которых есть все terms
+// (с начала слова при FTS5, подстрокой при LIKE). Результаты упорядочены по релевантности.
+func (r *ProductSearchRepositorySQL) Search(ctx context.Context, terms []string, limit int) ([]*models.ProductSearchHit, error) {
+	var (
+		hits []*models.ProductSearchHit
+		err  error
+	)
+	if r.fts {
+		hits, err = r.searchFTS(ctx, terms, limit)
+	} else {
+		hits, err = r.searchLike(ctx, terms, limit)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	products := make([]*models.Product, len(hits))
+	for i, hit := range hits {
+		products[i] = hit.Product
+	}
+	if err := loadProductAttributes(ctx, r.db, products); err != nil {
+		return nil, err
+	}
+	return hits, nil
+}
+
+func (r *ProductSearchRepositorySQL) searchFTS(ctx context.Context, terms []string, limit int) ([]*models.ProductSearchHit, error) {
+	// Каждое слово — строка в кавычках с *, т.е. поиск по префиксу; спецсимволы FTS5 внутри кавычек не действуют.
+	phrases := make([]string, len(terms))
+	for i, t := range terms {
+		phrases[i] = `"` + strings.ReplaceAll(t, `"`, `""`) + `"*`
+	}
+
+	query := `
+SELECT p.id, p.sku, p.name, p.description, p.category_id, COALESCE(p.supplier_id, ''), p.unit, COALESCE(p.parent_id, ''), p.created_at, p.updated_at, p.archived_at, p.version,
+       -bm25(products_fts, ` + searchRankWeights + `) AS score,
+       highlight(products_fts, 0, char(2), char(3)),
+       highlight(products_fts, 1, char(2), char(3)),
+       snippet(products_fts, 2, char(2), char(3), '…', ?)
+FROM products_fts
+JOIN products p ON p.rowid = products_fts.rowid
+WHERE products_fts MATCH ? AND p.archived_at IS NULL
+ORDER BY score DESC, p.name
+LIMIT ?;
+`
+	rows, err := r.db.QueryContext(ctx, query, searchSnippetTokens, strings.Join(phrases, " "), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []*models.ProductSearchHit{}
+	for rows.Next() {
+		var (
+			hit                          models.ProductSearchHit
+			skuMarked, nameMarked, descr string
+		)
+		hit.Product, err = scanProduct(withExtraColumns{rows, []any{&hit.Score, &skuMarked, &nameMarked, &descr}})
+		if err != nil {
+			return nil, err
+		}
+		hit.Highlight = models.ProductHighlight{
+			SKU:         markedToHTML(skuMarked),
+			Name:        markedToHTML(nameMarked),
+			Description: markedToHTML(descr),
+		}
+		result = append(result, &hit)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (r *ProductSearchRepositorySQL) searchLike(ctx context.Context, terms []string, limit int) ([]*models.ProductSearchHit, error) {
+	conds := []string{"archived_at IS NULL"}
+	var args []any
+	for _, t := range terms {
+		cond, condArgs := containsFold(r.db.Dialect(), t, "sku", "name", "description")
+		conds = append(conds, cond)
+		args = append(args, condArgs...)
+	}
+
+	// Без индекса релевантность не считается: товары с совпадением в названии идут первыми.
+	first, firstArgs := containsFold(r.db.Dialect(), terms[0], "name")
+	query := `
+SELECT id, sku, name, description, category_id, COALESCE(supplier_id, ''), unit, COALESCE(parent_id, ''), created_at, updated_at, archived_at, version
+FROM products
+WHERE ` + strings.Join(conds, " AND ") + `
+ORDER BY CASE WHEN ` + first + ` THEN 0 ELSE 1 END, name
+LIMIT ?;
+`
+	args = append(args, firstArgs...)
+	args = append(args, limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	re := termsRegexp(terms)
+	result := []*models.ProductSearchHit{}
+	for rows.Next() {
+		p, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, &models.ProductSearchHit{
+			Product: p,
+			Highlight: models.ProductHighlight{
+				SKU:         markedToHTML(markMatches(p.SKU, re)),
+				Name:        markedToHTML(markMatches(p.Name, re)),
+				Description: markedToHTML(markMatches(likeSnippet(p.Description, re), re)),
+			},
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// syncIndex сверяет products_fts с products (integrity-check с rank = 1 сравнивает индекс
+// с содержимым внешней таблицы) и при расхождении перестраивает индекс командой 'rebuild'.
+// Индекс может устареть, если products менялась без триггеров или у строк сменились rowid.
+func (r *ProductSearchRepositorySQL) syncIndex(ctx context.Context) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO products_fts (products_fts, rank) VALUES ('integrity-check', 1);`)
+	if err == nil {
+		return nil
+	}
+	log.Printf("Product search index is out of date (%v), rebuilding", err)
+	if _, err := r.db.ExecContext(ctx, `INSERT INTO products_fts (products_fts) VALUES ('rebuild');`); err != nil {
+		return err
+	}
+	log.Println("Product search index rebuilt")
+	return nil
+}
+
+// withExtraColumns дописывает к колонкам товара дополнительные колонки строки результата.
+type withExtraColumns struct {
+	productScanner
+	extra []any
+}
+
+func (w withExtraColumns) Scan(dest ...any) error {
+	return w.productScanner.Scan(append(dest, w.extra...)...)
+}
+
+// markedToHTML экранирует текст для HTML и заменяет маркеры совпадений на <mark>.
+func markedToHTML(s string) string {
+	return strings.NewReplacer(searchMarkStart, "<mark>", searchMarkEnd, "</mark>").Replace(html.EscapeString(s))
+}
+
+// termsRegexp возвращает регулярное выражение, находящее любое из слов без учёта регистра.
+func termsRegexp(terms []string) *regexp.Regexp {
+	quoted := make([]string, len(terms))
+	for i, t := range terms {
+		quoted[i] = regexp.QuoteMeta(t)
+	}
+	return regexp.MustCompile(`(?i)` + strings.Join(quoted, "|"))
+}
+
+// markMatches оборачивает совпадения re маркерами, как это делает highlight FTS5.
+func markMatches(s string, re *regexp.Regexp) string {
+	return re.ReplaceAllStringFunc(s, func(m string) string { return searchMarkStart + m + searchMarkEnd })
+}
+
+// likeSnippet вырезает из длинного описания фрагмент вокруг первого совпадения.
+func likeSnippet(s string, re *regexp.Regexp) string {
+	const before, after = 40, 80
+	if utf8.RuneCountInString(s) <= before+after {
+		return s
+	}
+	loc := re.FindStringIndex(s)
+	if loc == nil {
+		return ""
+	}
+	runes := []rune(s)
+	start := utf8.RuneCountInString(s[:loc[0]]) - before
+	prefix := "…"
+	if start <= 0 {
+		start, prefix = 0, ""
+	}
+	end, suffix := start+before+after, "…"
+	if end >= len(runes) {
+		end, suffix = len(runes), ""
+	}
+	return prefix + string(runes[start:end]) + suffix
+}
@@ -0,0 +1,125 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"warehouse-management-system/src/config"
+	"warehouse-management-system/src/models"
+)
+
+// seedSearchProducts создаёт товары для тестов поиска.
+func seedSearchProducts(t *testing.T, db *config.DB) *ProductRepositorySQL {
+	t.Helper()
+	ctx := context.Background()
+	ids := NewUUIDv7Generator()
+
+	category := models.NewCategory("Инструмент", "")
+	if err := NewCategoryRepository(db, ids).Create(ctx, category); err != nil {
+		t.Fatal(err)
+	}
+	repo := NewProductRepository(db, ids)
+	for _, p := range []*models.Product{
+		models.NewProduct("HAM-1", "Молоток слесарный", "Боёк из закалённой стали", category.ID, "", models.UnitPiece),
+		models.NewProduct("SAW-1", "Ножовка", "По дереву", category.ID, "", models.UnitPiece),
+	} {
+		if err := repo.Create(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return repo
+}
+
+func searchNames(t *testing.T, r *ProductSearchRepositorySQL, terms ...string) []string {
+	t.Helper()
+	hits, err := r.Search(context.Background(), terms, 10)
+	if err != nil {
+		t.Fatalf("Search(%v): %v", terms, err)
+	}
+	names := make([]string, len(hits))
+	for i, h := range hits {
+		names[i] = h.Name
+	}
+	return names
+}
+
+func TestProductSearchFTS(t *testing.T) {
+	db := openTestSQLite(t)
+	repo := seedSearchProducts(t, db)
+	ctx := context.Background()
+
+	r, err := NewProductSearchRepository(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.FullText() {
+		t.Fatal("products_fts from migrations is not used")
+	}
+	if got := searchNames(t, r, "МОЛОТ"); len(got) != 1 || got[0] != "Молоток слесарный" {
+		t.Fatalf("search МОЛОТ = %v", got)
+	}
+
+	// Триггеры обновляют индекс при изменении и удалении товара.
+	p, err := repo.GetBySKU(ctx, "SAW-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Name = "Пила ручная"
+	if err := repo.Update(ctx, p); err != nil {
+		t.Fatal(err)
+	}
+	if got := searchNames(t, r, "ножовка"); len(got) != 0 {
+		t.Fatalf("old name is still indexed: %v", got)
+	}
+	if got := searchNames(t, r, "пила"); len(got) != 1 {
+		t.Fatalf("new name is not indexed: %v", got)
+	}
+}
+
+func TestProductSearchRebuildsStaleIndex(t *testing.T) {
+	db := openTestSQLite(t)
+	seedSearchProducts(t, db)
+	ctx := context.Background()
+
+	// Число записей в индексе совпадает с products, но содержимое устарело: название сменилось в обход триггера.
+	for _, stmt := range []string{
+		`DROP TRIGGER products_fts_update;`,
+		`UPDATE products SET name = 'Кувалда' WHERE sku = 'HAM-1';`,
+	} {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r, err := NewProductSearchRepository(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := searchNames(t, r, "кувалда"); len(got) != 1 {
+		t.Fatalf("index was not rebuilt: search кувалда = %v", got)
+	}
+
+	if _, err := db.ExecContext(ctx, `INSERT INTO products_fts (products_fts) VALUES ('delete-all');`); err != nil {
+		t.Fatal(err)
+	}
+	if r, err = NewProductSearchRepository(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+	if got := searchNames(t, r, "ножовка"); len(got) != 1 {
+		t.Fatalf("empty index was not rebuilt: search ножовка = %v", got)
+	}
+}
+
+func TestProductSearchLikeIsUnicodeCaseInsensitive(t *testing.T) {
+	db := openTestSQLite(t)
+	seedSearchProducts(t, db)
+
+	// Поиск без индекса, как в PostgreSQL.
+	r := &ProductSearchRepositorySQL{db: db}
+	got := searchNames(t, r, "СТАЛИ")
+	if len(got) != 1 || got[0] != "Молоток слесарный" {
+		t.Fatalf("search СТАЛИ = %v", got)
+	}
+	if got := searchNames(t, r, "о"); len(got) != 2 || got[0] != "Молоток слесарный" {
+		t.Fatalf("search о = %v, want both products", got)
+	}
+}
@@ -0,0 +1,99 @@
+package repositories
+
+import (
+	"context"
+	"time"
+	"warehouse-management-system/src/config"
+	"warehouse-management-system/src/models"
+)
+
+// ProductUnitRepositorySQL — реализация хранилища дополнительных единиц измерения товаров
+// на SQL (SQLite или PostgreSQL).
+type ProductUnitRepositorySQL struct {
+	db *config.DB
+}
+
+// NewProductUnitRepository создаёт новый репозиторий единиц измерения товаров.
+func NewProductUnitRepository(db *config.DB) *ProductUnitRepositorySQL {
+	return &ProductUnitRepositorySQL{db: db}
+}
+
+// GetByProduct возвращает дополнительные единицы товара.
+func (r *ProductUnitRepositorySQL) GetByProduct(ctx context.Context, productID string) ([]*models.UnitConversion, error) {
+	const query = `
+SELECT product_id, unit, quantity, of_unit, created_at, updated_at
+FROM product_units
+WHERE product_id = ?
+ORDER BY unit;
+`
+	return r.query(ctx, query, productID)
+}
+
+// GetByUnit возвращает дополнительные единицы с именем unit всех товаров (для отчёта об остатках).
+func (r *ProductUnitRepositorySQL) GetByUnit(ctx context.Context, unit models.UnitOfMeasure) ([]*models.UnitConversion, error) {
+	const query = `
+SELECT product_id, unit, quantity, of_unit, created_at, updated_at
+FROM product_units
+WHERE product_id IN (SELECT product_id FROM product_units WHERE unit = ?)
+ORDER BY product_id, unit;
+`
+	return r.query(ctx, query, unit)
+}
+
+// Save создаёт или заменяет дополнительную единицу товара.
+func (r *ProductUnitRepositorySQL) Save(ctx context.Context, c *models.UnitConversion) error {
+	now := time.Now().UTC()
+	if c.CreatedAt.IsZero() {
+		c.CreatedAt = now
+	}
+	c.UpdatedAt = now
+
+	query := `
+INSERT INTO product_units (product_id, unit, quantity, of_unit, created_at, updated_at)
+VALUES (?, ?, ?, ?, ?, ?)
+` + r.db.Dialect().Upsert([]string{"product_id", "unit"}, "quantity", "of_unit", "updated_at") + ";"
+	_, err := r.db.ExecContext(ctx, query,
+		c.ProductID,
+		c.Unit,
+		c.Quantity,
+		c.OfUnit,
+		c.CreatedAt,
+		c.UpdatedAt,
+	)
+	return err
+}
+
+// Delete удаляет дополнительную единицу товара.
+func (r *ProductUnitRepositorySQL) Delete(ctx context.Context, productID string, unit models.UnitOfMeasure) error {
+	const query = `DELETE FROM product_units WHERE product_id = ? AND unit = ?;`
+	_, err := r.db.ExecContext(ctx, query, productID, unit)
+	return err
+}
+
+func (r *ProductUnitRepositorySQL) query(ctx context.Context, query string, args ...any) ([]*models.UnitConversion, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []*models.UnitConversion{}
+	for rows.Next() {
+		var c models.UnitConversion
+		if err := rows.Scan(
+			&c.ProductID,
+			&c.Unit,
+			&c.Quantity,
+			&c.OfUnit,
+			&c.CreatedAt,
+			&c.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		result = append(result, &c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,148 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+	"warehouse-management-system/src/config"
+	"warehouse-management-system/src/models"
+)
+
+// SessionRepositorySQL — реализация хранилища сессий пользователей на SQL (SQLite или PostgreSQL).
+type SessionRepositorySQL struct {
+	db  *config.DB
+	ids IDGenerator
+}
+
+// NewSessionRepository создаёт новый репозиторий сессий.
+func NewSessionRepository(db *config.DB, ids IDGenerator) *SessionRepositorySQL {
+	return &SessionRepositorySQL{db: db, ids: ids}
+}
+
+// sessionScanner позволяет использовать один код сканирования для *sql.Row и *sql.Rows.
+type sessionScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSession(s sessionScanner) (*models.Session, error) {
+	var (
+		sess      models.Session
+		revokedAt sql.NullTime
+	)
+	if err := s.Scan(
+		&sess.ID,
+		&sess.UserID,
+		&sess.Provider,
+		&sess.UserAgent,
+		&sess.IP,
+		&sess.IssuedAt,
+		&sess.LastSeenAt,
+		&sess.ExpiresAt,
+		&revokedAt,
+	); err != nil {
+		return nil, err
+	}
+	if revokedAt.Valid {
+		sess.RevokedAt = &revokedAt.Time
+	}
+	return &sess, nil
+}
+
+// GetByID возвращает сессию по идентификатору.
+func (r *SessionRepositorySQL) GetByID(ctx context.Context, id string) (*models.Session, error) {
+	const query = `
+SELECT id, user_id, provider, user_agent, ip, issued_at, last_seen_at, expires_at, revoked_at
+FROM sessions
+WHERE id = ? LIMIT 1;
+`
+	sess, err := scanSession(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return sess, nil
+}
+
+// GetActiveByUser возвращает неотозванные и неистёкшие на момент now сессии пользователя.
+func (r *SessionRepositorySQL) GetActiveByUser(ctx context.Context, userID string, now time.Time) ([]*models.Session, error) {
+	const query = `
+SELECT id, user_id, provider, user_agent, ip, issued_at, last_seen_at, expires_at, revoked_at
+FROM sessions
+WHERE user_id = ? AND revoked_at IS NULL AND expires_at > ?
+ORDER BY last_seen_at DESC;
+`
+	rows, err := r.db.QueryContext(ctx, query, userID, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []*models.Session{}
+	for rows.Next() {
+		sess, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, sess)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Create сохраняет новую сессию.
+func (r *SessionRepositorySQL) Create(ctx context.Context, session *models.Session) error {
+	const query = `
+INSERT INTO sessions (id, user_id, provider, user_agent, ip, issued_at, last_seen_at, expires_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?);
+`
+	if session.ID == "" {
+		id, err := r.ids.NewID(idPrefixSession)
+		if err != nil {
+			return err
+		}
+		session.ID = id
+	}
+
+	_, err := r.db.ExecContext(ctx, query,
+		session.ID,
+		session.UserID,
+		session.Provider,
+		session.UserAgent,
+		session.IP,
+		session.IssuedAt,
+		session.LastSeenAt,
+		session.ExpiresAt,
+	)
+	return err
+}
+
+// TouchLastSeen обновляет время последней активности сессии.
+func (r *SessionRepositorySQL) TouchLastSeen(ctx context.Context, id string, at time.Time) error {
+	const query = `UPDATE sessions SET last_seen_at = ? WHERE id = ?;`
+
+	_, err := r.db.ExecContext(ctx, query, at, id)
+	return err
+}
+
+// Revoke отзывает сессию. Запись не удаляется, чтобы сохранить историю входов.
+func (r *SessionRepositorySQL) Revoke(ctx context.Context, id string, at time.Time) error {
+	const query = `UPDATE sessions SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL;`
+
+	_, err := r.db.ExecContext(ctx, query, at, id)
+	return err
+}
+
+// RevokeAllByUser отзывает все активные сессии пользователя и возвращает их количество.
+func (r *SessionRepositorySQL) RevokeAllByUser(ctx context.Context, userID string, at time.Time) (int64, error) {
+	const query = `UPDATE sessions SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL AND expires_at > ?;`
+
+	res, err := r.db.ExecContext(ctx, query, at, userID, at)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,126 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+	"warehouse-management-system/src/config"
+	"warehouse-management-system/src/models"
+)
+
+// SupplierCostRepositorySQL — реализация хранилища закупочных условий поставщиков
+// на SQL (SQLite или PostgreSQL).
+type SupplierCostRepositorySQL struct {
+	db *config.DB
+}
+
+// NewSupplierCostRepository создаёт новый репозиторий закупочных условий.
+func NewSupplierCostRepository(db *config.DB) *SupplierCostRepositorySQL {
+	return &SupplierCostRepositorySQL{db: db}
+}
+
+// supplierCostScanner позволяет использовать один код сканирования для *sql.Row и *sql.Rows.
+type supplierCostScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSupplierCost(s supplierCostScanner) (*models.SupplierCost, error) {
+	var (
+		c           models.SupplierCost
+		supplierSKU sql.NullString
+	)
+	if err := s.Scan(
+		&c.SupplierID,
+		&c.ProductID,
+		&supplierSKU,
+		&c.Cost,
+		&c.Currency,
+		&c.LeadTimeDays,
+		&c.MinOrderQuantity,
+		&c.CreatedAt,
+		&c.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	c.SupplierSKU = supplierSKU.String
+	return &c, nil
+}
+
+// GetByProduct возвращает условия всех поставщиков товара: сначала самые дешёвые.
+func (r *SupplierCostRepositorySQL) GetByProduct(ctx context.Context, productID string) ([]*models.SupplierCost, error) {
+	const query = `
+SELECT supplier_id, product_id, supplier_sku, cost, currency, lead_time_days, min_order_quantity, created_at, updated_at
+FROM supplier_products
+WHERE product_id = ?
+ORDER BY currency, cost, lead_time_days, supplier_id;
+`
+	rows, err := r.db.QueryContext(ctx, query, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []*models.SupplierCost{}
+	for rows.Next() {
+		c, err := scanSupplierCost(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Get возвращает условия поставщика на товар или nil, если их нет.
+func (r *SupplierCostRepositorySQL) Get(ctx context.Context, supplierID, productID string) (*models.SupplierCost, error) {
+	const query = `
+SELECT supplier_id, product_id, supplier_sku, cost, currency, lead_time_days, min_order_quantity, created_at, updated_at
+FROM supplier_products
+WHERE supplier_id = ? AND product_id = ? LIMIT 1;
+`
+	c, err := scanSupplierCost(r.db.QueryRowContext(ctx, query, supplierID, productID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Save создаёт или заменяет условия поставщика на товар.
+func (r *SupplierCostRepositorySQL) Save(ctx context.Context, c *models.SupplierCost) error {
+	now := time.Now().UTC()
+	if c.CreatedAt.IsZero() {
+		c.CreatedAt = now
+	}
+	c.UpdatedAt = now
+
+	query := `
+INSERT INTO supplier_products (supplier_id, product_id, supplier_sku, cost, currency, lead_time_days, min_order_quantity, created_at, updated_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+` + r.db.Dialect().Upsert([]string{"supplier_id", "product_id"},
+		"supplier_sku", "cost", "currency", "lead_time_days", "min_order_quantity", "updated_at") + ";"
+	_, err := r.db.ExecContext(ctx, query,
+		c.SupplierID,
+		c.ProductID,
+		nullIfEmpty(c.SupplierSKU),
+		c.Cost,
+		c.Currency,
+		c.LeadTimeDays,
+		c.MinOrderQuantity,
+		c.CreatedAt,
+		c.UpdatedAt,
+	)
+	return err
+}
+
+// Delete удаляет условия поставщика на товар.
+func (r *SupplierCostRepositorySQL) Delete(ctx context.Context, supplierID, productID string) error {
+	const query = `DELETE FROM supplier_products WHERE supplier_id = ? AND product_id = ?;`
+	_, err := r.db.ExecContext(ctx, query, supplierID, productID)
+	return err
+}
@@ -3,31 +3,63 @@ package repositories
 import (
 	"context"
 	"database/sql"
-	"fmt"
 	"time"
+	"warehouse-management-system/src/config"
 	"warehouse-management-system/src/models"
 )
 
-// SupplierRepositorySQLite — реализация хранилища поставщиков на SQLite.
-type SupplierRepositorySQLite struct {
-	db *sql.DB
+// SupplierRepositorySQL — реализация хранилища поставщиков на SQL (SQLite или PostgreSQL).
+type SupplierRepositorySQL struct {
+	db  *config.DB
+	ids IDGenerator
 }
 
 // NewSupplierRepository создаёт новый репозиторий поставщиков.
-func NewSupplierRepository(db *sql.DB) *SupplierRepositorySQLite {
-	return &SupplierRepositorySQLite{db: db}
+func NewSupplierRepository(db *config.DB, ids IDGenerator) *SupplierRepositorySQL {
+	return &SupplierRepositorySQL{db: db, ids: ids}
 }
 
-// GetAll возвращает всех поставщиков.
-func (r *SupplierRepositorySQLite) GetAll() ([]*models.Supplier, error) {
-	const query = `
-SELECT id, name, address, phone, email, created_at, updated_at
-FROM suppliers
+// supplierScanner позволяет использовать один код сканирования для *sql.Row и *sql.Rows.
+type supplierScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSupplier(s supplierScanner) (*models.Supplier, error) {
+	var (
+		sup        models.Supplier
+		archivedAt sql.NullTime
+	)
+	if err := s.Scan(
+		&sup.ID,
+		&sup.Name,
+		&sup.Address,
+		&sup.Phone,
+		&sup.Email,
+		&sup.CreatedAt,
+		&sup.UpdatedAt,
+		&archivedAt,
+		&sup.Version,
+	); err != nil {
+		return nil, err
+	}
+	if archivedAt.Valid {
+		sup.ArchivedAt = &archivedAt.Time
+	}
+	return &sup, nil
+}
+
+// GetAll возвращает поставщиков. Заархивированные поставщики включаются, только если includeArchived.
+func (r *SupplierRepositorySQL) GetAll(ctx context.Context, includeArchived bool) ([]*models.Supplier, error) {
+	query := `
+SELECT id, name, address, phone, email, created_at, updated_at, archived_at, version
+FROM suppliers`
+	if !includeArchived {
+		query += `
+WHERE archived_at IS NULL`
+	}
+	query += `
 ORDER BY name;
 `
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
-
 	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
@@ -36,19 +68,11 @@ ORDER BY name;
 
 	var result []*models.Supplier
 	for rows.Next() {
-		var s models.Supplier
-		if err := rows.Scan(
-			&s.ID,
-			&s.Name,
-			&s.Address,
-			&s.Phone,
-			&s.Email,
-			&s.CreatedAt,
-			&s.UpdatedAt,
-		); err != nil {
+		s, err := scanSupplier(rows)
+		if err != nil {
 			return nil, err
 		}
-		result = append(result, &s)
+		result = append(result, s)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
@@ -57,51 +81,42 @@ ORDER BY name;
 }
 
 // GetByID возвращает поставщика по идентификатору.
-func (r *SupplierRepositorySQLite) GetByID(id string) (*models.Supplier, error) {
+func (r *SupplierRepositorySQL) GetByID(ctx context.Context, id string) (*models.Supplier, error) {
 	const query = `
-SELECT id, name, address, phone, email, created_at, updated_at
+SELECT id, name, address, phone, email, created_at, updated_at, archived_at, version
 FROM suppliers
 WHERE id = ? LIMIT 1;
 `
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
-
 	row := r.db.QueryRowContext(ctx, query, id)
-	var s models.Supplier
-	if err := row.Scan(
-		&s.ID,
-		&s.Name,
-		&s.Address,
-		&s.Phone,
-		&s.Email,
-		&s.CreatedAt,
-		&s.UpdatedAt,
-	); err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
-		}
+	s, err := scanSupplier(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
 		return nil, err
 	}
-	return &s, nil
+	return s, nil
 }
 
 // Create сохраняет нового поставщика.
-func (r *SupplierRepositorySQLite) Create(supplier *models.Supplier) error {
+func (r *SupplierRepositorySQL) Create(ctx context.Context, supplier *models.Supplier) error {
 	const query = `
-INSERT INTO suppliers (id, name, address, phone, email, created_at, updated_at)
-VALUES (?, ?, ?, ?, ?, ?, ?);
+INSERT INTO suppliers (id, name, address, phone, email, created_at, updated_at, version)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?);
 `
 	if supplier.ID == "" {
-		supplier.ID = "s-" + time.Now().UTC().Format("20060102T150405.000000000")
+		id, err := r.ids.NewID(idPrefixSupplier)
+		if err != nil {
+			return err
+		}
+		supplier.ID = id
 	}
 	now := time.Now().UTC()
 	if supplier.CreatedAt.IsZero() {
 		supplier.CreatedAt = now
 	}
 	supplier.UpdatedAt = now
-
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
+	supplier.Version = models.InitialVersion
 
 	_, err := r.db.ExecContext(ctx, query,
 		supplier.ID,
@@ -111,6 +126,7 @@ VALUES (?, ?, ?, ?, ?, ?, ?);
 		supplier.Email,
 		supplier.CreatedAt,
 		supplier.UpdatedAt,
+		supplier.Version,
 	)
 	if err != nil {
 		return err
@@ -118,57 +134,61 @@ VALUES (?, ?, ?, ?, ?, ?, ?);
 	return nil
 }
 
-// Update обновляет данные поставщика.
-func (r *SupplierRepositorySQLite) Update(supplier *models.Supplier) error {
+// Update обновляет данные поставщика, если его версия не изменилась с момента чтения.
+// Иначе возвращает models.ErrVersionConflict; при успехе увеличивает Version.
+func (r *SupplierRepositorySQL) Update(ctx context.Context, supplier *models.Supplier) error {
 	const query = `
 UPDATE suppliers
-SET name = ?, address = ?, phone = ?, email = ?, updated_at = ?
-WHERE id = ?;
+SET name = ?, address = ?, phone = ?, email = ?, updated_at = ?, version = version + 1
+WHERE id = ? AND version = ?;
 `
 	now := time.Now().UTC()
 	supplier.UpdatedAt = now
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
-
-	res, err := r.db.ExecContext(ctx, query,
+	err := execVersioned(ctx, r.db, query,
 		supplier.Name,
 		supplier.Address,
 		supplier.Phone,
 		supplier.Email,
 		supplier.UpdatedAt,
 		supplier.ID,
+		supplier.Version,
 	)
 	if err != nil {
 		return err
 	}
-	affected, err := res.RowsAffected()
-	if err != nil {
-		return err
-	}
-	if affected == 0 {
-		return fmt.Errorf("supplier with id %s not found", supplier.ID)
-	}
+	supplier.Version++
 	return nil
 }
 
-// Delete удаляет поставщика по ID.
-func (r *SupplierRepositorySQLite) Delete(id string) error {
-	const query = `DELETE FROM suppliers WHERE id = ?;`
+// Archive помечает поставщика как заархивированного.
+func (r *SupplierRepositorySQL) Archive(ctx context.Context, id string, version int64, at time.Time) error {
+	const query = `UPDATE suppliers SET archived_at = ?, updated_at = ?, version = version + 1 WHERE id = ? AND version = ?;`
+	return execVersioned(ctx, r.db, query, at, at, id, version)
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
+// Restore снимает с поставщика пометку архивного.
+func (r *SupplierRepositorySQL) Restore(ctx context.Context, id string, version int64, at time.Time) error {
+	const query = `UPDATE suppliers SET archived_at = NULL, updated_at = ?, version = version + 1 WHERE id = ? AND version = ?;`
+	return execVersioned(ctx, r.db, query, at, id, version)
+}
 
-	res, err := r.db.ExecContext(ctx, query, id)
-	if err != nil {
-		return err
-	}
-	affected, err := res.RowsAffected()
-	if err != nil {
-		return err
-	}
-	if affected == 0 {
-		return fmt.Errorf("supplier with id %s not found", id)
+// IsReferenced сообщает, ссылаются ли на поставщика товары (в том числе архивные), приёмки или вложения.
+func (r *SupplierRepositorySQL) IsReferenced(ctx context.Context, id string) (bool, error) {
+	const query = `
+SELECT EXISTS (SELECT 1 FROM products WHERE supplier_id = ?)
+    OR EXISTS (SELECT 1 FROM stock_movements WHERE supplier_id = ?)
+    OR EXISTS (SELECT 1 FROM attachments WHERE owner_type = 'supplier' AND owner_id = ?);
+`
+	var referenced bool
+	if err := r.db.QueryRowContext(ctx, query, id, id, id).Scan(&referenced); err != nil {
+		return false, err
 	}
-	return nil
+	return referenced, nil
+}
+
+// Delete физически удаляет поставщика по ID при совпадении версии (иначе models.ErrVersionConflict).
+func (r *SupplierRepositorySQL) Delete(ctx context.Context, id string, version int64) error {
+	const query = `DELETE FROM suppliers WHERE id = ? AND version = ?;`
+	return execVersioned(ctx, r.db, query, id, version)
 }
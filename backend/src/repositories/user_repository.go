@@ -5,18 +5,20 @@ import (
 	"database/sql"
 	"errors"
 	"time"
+	"warehouse-management-system/src/config"
 	"warehouse-management-system/src/models"
 )
 
-// UserRepositorySQLite — реализация хранилища пользователей на SQLite.
-// Она использует таблицу users, описанную в миграции MigrateSQLite.
-type UserRepositorySQLite struct {
-	db *sql.DB
+// UserRepositorySQL — реализация хранилища пользователей на SQL (SQLite или PostgreSQL).
+// Она использует таблицу users, описанную в миграциях config/migrations.
+type UserRepositorySQL struct {
+	db  *config.DB
+	ids IDGenerator
 }
 
 // NewUserRepository создаёт новый репозиторий пользователей на основе *sql.DB.
-func NewUserRepository(db *sql.DB) *UserRepositorySQLite {
-	return &UserRepositorySQLite{db: db}
+func NewUserRepository(db *config.DB, ids IDGenerator) *UserRepositorySQL {
+	return &UserRepositorySQL{db: db, ids: ids}
 }
 
 var (
@@ -25,16 +27,13 @@ var (
 )
 
 // FindByEmail ищет пользователя по email.
-func (r *UserRepositorySQLite) FindByEmail(email string) (*models.User, error) {
+func (r *UserRepositorySQL) FindByEmail(ctx context.Context, email string) (*models.User, error) {
 	const query = `
 SELECT id, email, password_hash, role, created_at, updated_at
 FROM users
 WHERE email = ? LIMIT 1;
 `
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
-
 	row := r.db.QueryRowContext(ctx, query, email)
 
 	var u models.User
@@ -49,16 +48,13 @@ WHERE email = ? LIMIT 1;
 }
 
 // FindByID ищет пользователя по ID.
-func (r *UserRepositorySQLite) FindByID(id string) (*models.User, error) {
+func (r *UserRepositorySQL) FindByID(ctx context.Context, id string) (*models.User, error) {
 	const query = `
 SELECT id, email, password_hash, role, created_at, updated_at
 FROM users
 WHERE id = ? LIMIT 1;
 `
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
-
 	row := r.db.QueryRowContext(ctx, query, id)
 
 	var u models.User
@@ -72,16 +68,54 @@ WHERE id = ? LIMIT 1;
 	return &u, nil
 }
 
-// Create сохраняет нового пользователя.
-func (r *UserRepositorySQLite) Create(user *models.User) error {
+// Create сохраняет нового пользователя. Занятый email — models.ErrEmailAlreadyInUse.
+func (r *UserRepositorySQL) Create(ctx context.Context, user *models.User) error {
+	return r.insertUser(ctx, r.db, user)
+}
+
+// CreateWithIdentity сохраняет нового пользователя и привязку внешней учётной записи в одной транзакции:
+// пользователь без привязки занял бы email, и следующие входы через провайдера упирались бы в него.
+// Если учётная запись уже привязана (например, параллельный вход опередил этот), возвращает models.ErrIdentityLinked.
+func (r *UserRepositorySQL) CreateWithIdentity(ctx context.Context, user *models.User, provider, subject string) (err error) {
+	const query = `
+INSERT INTO user_identities (provider, subject, user_id, created_at)
+VALUES (?, ?, ?, ?);
+`
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err = r.insertUser(ctx, tx, user); err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, query, provider, subject, user.ID, user.CreatedAt); err != nil {
+		if config.IsUniqueViolation(err) {
+			return models.ErrIdentityLinked
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *UserRepositorySQL) insertUser(ctx context.Context, db execer, user *models.User) error {
 	const query = `
 INSERT INTO users (id, email, password_hash, role, created_at, updated_at)
 VALUES (?, ?, ?, ?, ?, ?);
 `
 
-	// В SQLite мы генерируем ID на уровне приложения (т.к. в схеме он TEXT).
+	// Мы генерируем ID на уровне приложения (т.к. в схеме он TEXT).
 	if user.ID == "" {
-		user.ID = "u-" + time.Now().UTC().Format("20060102T150405.000000000")
+		id, err := r.ids.NewID(idPrefixUser)
+		if err != nil {
+			return err
+		}
+		user.ID = id
 	}
 
 	now := time.Now().UTC()
@@ -90,10 +124,7 @@ VALUES (?, ?, ?, ?, ?, ?);
 	}
 	user.UpdatedAt = now
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
-
-	_, err := r.db.ExecContext(ctx, query,
+	_, err := db.ExecContext(ctx, query,
 		user.ID,
 		user.Email,
 		user.PasswordHash,
@@ -101,9 +132,118 @@ VALUES (?, ?, ?, ?, ?, ?);
 		user.CreatedAt,
 		user.UpdatedAt,
 	)
+	if config.IsUniqueViolation(err) {
+		return models.ErrEmailAlreadyInUse
+	}
+	return err
+}
+
+// Update обновляет email и роль пользователя. Занятый email — models.ErrEmailAlreadyInUse.
+func (r *UserRepositorySQL) Update(ctx context.Context, user *models.User) error {
+	const query = `
+UPDATE users
+SET email = ?, role = ?, updated_at = ?
+WHERE id = ?;
+`
+	user.UpdatedAt = time.Now().UTC()
+
+	res, err := r.db.ExecContext(ctx, query,
+		user.Email,
+		user.Role,
+		user.UpdatedAt,
+		user.ID,
+	)
+	if config.IsUniqueViolation(err) {
+		return models.ErrEmailAlreadyInUse
+	}
 	if err != nil {
 		return err
 	}
-
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrUserNotFound
+	}
 	return nil
 }
+
+// FindByIdentity ищет пользователя, привязанного к учётной записи внешнего провайдера.
+func (r *UserRepositorySQL) FindByIdentity(ctx context.Context, provider, subject string) (*models.User, error) {
+	const query = `
+SELECT u.id, u.email, u.password_hash, u.role, u.created_at, u.updated_at
+FROM user_identities i
+JOIN users u ON u.id = i.user_id
+WHERE i.provider = ? AND i.subject = ? LIMIT 1;
+`
+
+	row := r.db.QueryRowContext(ctx, query, provider, subject)
+
+	var u models.User
+	if err := row.Scan(&u.ID, &u.Email, &u.PasswordHash, &u.Role, &u.CreatedAt, &u.UpdatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &u, nil
+}
+
+// LinkIdentity привязывает учётную запись внешнего провайдера к пользователю.
+// Повторная привязка той же учётной записи ничего не меняет.
+func (r *UserRepositorySQL) LinkIdentity(ctx context.Context, provider, subject, userID string) error {
+	query := `
+INSERT INTO user_identities (provider, subject, user_id, created_at)
+VALUES (?, ?, ?, ?)
+` + r.db.Dialect().Upsert([]string{"provider", "subject"}) + ";"
+
+	_, err := r.db.ExecContext(ctx, query, provider, subject, userID, time.Now().UTC())
+	return err
+}
+
+// ListIdentities возвращает внешние учётные записи, привязанные к пользователю.
+func (r *UserRepositorySQL) ListIdentities(ctx context.Context, userID string) ([]models.UserIdentity, error) {
+	const query = `
+SELECT provider, subject, user_id, created_at
+FROM user_identities
+WHERE user_id = ?
+ORDER BY provider, subject;
+`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	identities := []models.UserIdentity{}
+	for rows.Next() {
+		var i models.UserIdentity
+		if err := rows.Scan(&i.Provider, &i.Subject, &i.UserID, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		identities = append(identities, i)
+	}
+	return identities, rows.Err()
+}
+
+// UnlinkIdentity удаляет привязку внешней учётной записи к пользователю.
+// Возвращает false, если такой привязки не было.
+func (r *UserRepositorySQL) UnlinkIdentity(ctx context.Context, provider, subject, userID string) (bool, error) {
+	const query = `
+DELETE FROM user_identities
+WHERE provider = ? AND subject = ? AND user_id = ?;
+`
+
+	res, err := r.db.ExecContext(ctx, query, provider, subject, userID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
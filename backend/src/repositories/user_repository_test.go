@@ -0,0 +1,44 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"warehouse-management-system/src/models"
+)
+
+func TestUserCreateWithIdentity(t *testing.T) {
+	db := openTestSQLite(t)
+	ctx := context.Background()
+	repo := NewUserRepository(db, NewUUIDv7Generator())
+
+	first := models.NewUser("", "first@example.com", "", models.RoleManager)
+	if err := repo.CreateWithIdentity(ctx, first, "oidc", "sub-1"); err != nil {
+		t.Fatal(err)
+	}
+	if linked, err := repo.FindByIdentity(ctx, "oidc", "sub-1"); err != nil || linked == nil || linked.ID != first.ID {
+		t.Fatalf("FindByIdentity = %+v, %v; want %s", linked, err, first.ID)
+	}
+
+	// Привязка не удалась — пользователь не остаётся в БД без неё и не занимает email.
+	second := models.NewUser("", "second@example.com", "", models.RoleManager)
+	if err := repo.CreateWithIdentity(ctx, second, "oidc", "sub-1"); !errors.Is(err, models.ErrIdentityLinked) {
+		t.Fatalf("linked subject: got %v, want ErrIdentityLinked", err)
+	}
+	if u, err := repo.FindByEmail(ctx, "second@example.com"); err != nil || u != nil {
+		t.Fatalf("user from a failed create was saved: %+v, %v", u, err)
+	}
+
+	duplicate := models.NewUser("", "first@example.com", "", models.RoleManager)
+	if err := repo.CreateWithIdentity(ctx, duplicate, "oidc", "sub-2"); !errors.Is(err, models.ErrEmailAlreadyInUse) {
+		t.Fatalf("taken email: got %v, want ErrEmailAlreadyInUse", err)
+	}
+	if err := repo.Create(ctx, models.NewUser("", "third@example.com", "", models.RoleManager)); err != nil {
+		t.Fatal(err)
+	}
+	third, _ := repo.FindByEmail(ctx, "third@example.com")
+	third.Email = "first@example.com"
+	if err := repo.Update(ctx, third); !errors.Is(err, models.ErrEmailAlreadyInUse) {
+		t.Fatalf("update to a taken email: got %v, want ErrEmailAlreadyInUse", err)
+	}
+}
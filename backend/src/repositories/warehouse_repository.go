@@ -2,41 +2,49 @@ package repositories
 
 import (
 	"context"
-	"database/sql"
 	//"fmt"
 	"time"
+	"warehouse-management-system/src/config"
 	"warehouse-management-system/src/models"
 )
 
-// WarehouseRepositorySQLite — реализация складского хранилища на SQLite.
-// Хранит только таблицу движений stock_movements, остатки считаются на лету агрегированными запросами.
-type WarehouseRepositorySQLite struct {
-	db *sql.DB
+// WarehouseRepositorySQL — реализация складского хранилища на SQL (SQLite или PostgreSQL).
+// Журнал движений хранится в stock_movements, текущие остатки — в stock_balances,
+// которая обновляется в той же транзакции, что и запись движения.
+type WarehouseRepositorySQL struct {
+	db  *config.DB
+	ids IDGenerator
 }
 
 // NewWarehouseRepository создаёт новый репозиторий складских данных.
-func NewWarehouseRepository(db *sql.DB) *WarehouseRepositorySQLite {
-	return &WarehouseRepositorySQLite{db: db}
+func NewWarehouseRepository(db *config.DB, ids IDGenerator) *WarehouseRepositorySQL {
+	return &WarehouseRepositorySQL{db: db, ids: ids}
 }
 
-// GetInventory возвращает текущие остатки по всем товарам.
-func (r *WarehouseRepositorySQLite) GetInventory() ([]*models.StockItem, error) {
-	const query = `
+// ledgerBalancesQuery пересчитывает остатки по журналу движений.
+// Количества хранятся целыми (models.Decimal); CAST нужен PostgreSQL, где SUM(BIGINT) — NUMERIC.
+const ledgerBalancesQuery = `
 SELECT
     product_id,
-    SUM(
+    CAST(SUM(
         CASE type
-            WHEN 'receipt'  THEN quantity
+            WHEN 'receipt'   THEN quantity
             WHEN 'write_off' THEN -quantity
-            WHEN 'reserve'  THEN -quantity
+            WHEN 'reserve'   THEN -quantity
+            ELSE 0
         END
-    ) AS quantity
+    ) AS BIGINT) AS quantity
 FROM stock_movements
-GROUP BY product_id;
-`
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+GROUP BY product_id`
 
+// GetInventory возвращает текущие остатки по всем товарам (для вариантов — с шаблоном в ParentID).
+func (r *WarehouseRepositorySQL) GetInventory(ctx context.Context) ([]*models.StockItem, error) {
+	const query = `
+SELECT b.product_id, COALESCE(p.parent_id, ''), b.quantity
+FROM stock_balances b
+LEFT JOIN products p ON p.id = b.product_id
+ORDER BY b.product_id;
+`
 	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
@@ -46,7 +54,7 @@ GROUP BY product_id;
 	var result []*models.StockItem
 	for rows.Next() {
 		var it models.StockItem
-		if err := rows.Scan(&it.ProductID, &it.Quantity); err != nil {
+		if err := rows.Scan(&it.ProductID, &it.ParentID, &it.Quantity); err != nil {
 			return nil, err
 		}
 		result = append(result, &it)
@@ -58,59 +66,172 @@ GROUP BY product_id;
 }
 
 // GetStockByProduct возвращает текущий остаток по конкретному товару.
-func (r *WarehouseRepositorySQLite) GetStockByProduct(productID string) (float64, error) {
+func (r *WarehouseRepositorySQL) GetStockByProduct(ctx context.Context, productID string) (models.Decimal, error) {
 	const query = `
-SELECT
-    COALESCE(SUM(
-        CASE type
-            WHEN 'receipt'  THEN quantity
-            WHEN 'write_off' THEN -quantity
-            WHEN 'reserve'  THEN -quantity
-        END
-    ), 0) AS quantity
-FROM stock_movements
+SELECT COALESCE(MAX(quantity), 0)
+FROM stock_balances
 WHERE product_id = ?;
 `
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	var qty float64
+	var qty models.Decimal
 	if err := r.db.QueryRowContext(ctx, query, productID).Scan(&qty); err != nil {
 		return 0, err
 	}
 	return qty, nil
 }
 
-// AddMovement добавляет движение товара.
-func (r *WarehouseRepositorySQLite) AddMovement(m *models.StockMovement) error {
-	const query = `
-INSERT INTO stock_movements (id, type, product_id, supplier_id, order_id, quantity, price, expiry_date, created_at)
-VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?);
-`
-
+// AddMovement добавляет движение товара и в той же транзакции изменяет остаток.
+// Списание и резерв проходят, только если остатка хватает, иначе models.ErrInsufficientStock.
+func (r *WarehouseRepositorySQL) AddMovement(ctx context.Context, m *models.StockMovement) error {
 	if m.ID == "" {
-		m.ID = "w-" + time.Now().UTC().Format("20060102T150405.000000000")
+		id, err := r.ids.NewID(idPrefixStockMovement)
+		if err != nil {
+			return err
+		}
+		m.ID = id
 	}
-	now := time.Now().UTC()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err = insertMovement(ctx, tx, m, time.Now().UTC()); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// insertMovement сохраняет движение m с уже назначенным ID и применяет его к остатку в транзакции tx.
+// Уменьшение остатка выполняется условным UPDATE: проверка и списание атомарны, поэтому
+// параллельные списания не могут увести остаток в минус (models.ErrInsufficientStock).
+func insertMovement(ctx context.Context, tx *config.Tx, m *models.StockMovement, now time.Time) error {
+	const insertQuery = `
+INSERT INTO stock_movements (id, type, product_id, supplier_id, order_id, quantity, price, currency, expiry_date, created_by, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+`
+	// ON CONFLICT ... DO UPDATE одинаково поддерживают SQLite и PostgreSQL; Dialect.Upsert
+	// здесь не подходит, так как остаток не заменяется, а увеличивается на delta.
+	const increaseQuery = `
+INSERT INTO stock_balances (product_id, quantity, updated_at)
+VALUES (?, ?, ?)
+ON CONFLICT (product_id) DO UPDATE SET
+    quantity   = stock_balances.quantity + excluded.quantity,
+    updated_at = excluded.updated_at;
+`
+	const decreaseQuery = `
+UPDATE stock_balances
+SET quantity = quantity - ?, updated_at = ?
+WHERE product_id = ? AND quantity >= ?;
+`
+
 	if m.CreatedAt.IsZero() {
 		m.CreatedAt = now
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	if delta := m.Delta(); delta >= 0 {
+		if _, err := tx.ExecContext(ctx, increaseQuery, m.ProductID, delta, now); err != nil {
+			return err
+		}
+	} else {
+		res, err := tx.ExecContext(ctx, decreaseQuery, -delta, now, m.ProductID, -delta)
+		if err != nil {
+			return err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return models.ErrInsufficientStock
+		}
+	}
 
-	_, err := r.db.ExecContext(ctx, query,
+	_, err := tx.ExecContext(ctx, insertQuery,
 		m.ID,
 		m.Type,
 		m.ProductID,
-		m.SupplierID,
-		m.OrderID,
+		nullIfEmpty(m.SupplierID),
+		nullIfEmpty(m.OrderID),
 		m.Quantity,
 		m.Price,
+		nullIfEmpty(m.Currency),
 		m.ExpiryDate,
+		nullIfEmpty(m.CreatedBy),
 		m.CreatedAt,
 	)
+	return err
+}
+
+// CheckBalances сверяет stock_balances с остатками, пересчитанными по журналу движений,
+// и возвращает товары с расхождением.
+func (r *WarehouseRepositorySQL) CheckBalances(ctx context.Context) ([]*models.StockDrift, error) {
+	query := `
+SELECT product_id, CAST(SUM(ledger_quantity) AS BIGINT), CAST(SUM(balance_quantity) AS BIGINT)
+FROM (
+    SELECT product_id, quantity AS ledger_quantity, 0 AS balance_quantity
+    FROM (` + ledgerBalancesQuery + `) AS ledger
+    UNION ALL
+    SELECT product_id, 0, quantity
+    FROM stock_balances
+) AS combined
+GROUP BY product_id
+HAVING SUM(ledger_quantity) <> SUM(balance_quantity)
+ORDER BY product_id;
+`
+	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []*models.StockDrift
+	for rows.Next() {
+		var d models.StockDrift
+		if err := rows.Scan(&d.ProductID, &d.LedgerQuantity, &d.BalanceQuantity); err != nil {
+			return nil, err
+		}
+		result = append(result, &d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// RebuildBalances пересчитывает stock_balances по журналу движений.
+func (r *WarehouseRepositorySQL) RebuildBalances(ctx context.Context) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM stock_balances;`); err != nil {
+		return err
+	}
+	insert := `
+INSERT INTO stock_balances (product_id, quantity, updated_at)
+SELECT product_id, quantity, ?
+FROM (` + ledgerBalancesQuery + `) AS ledger;
+`
+	if _, err = tx.ExecContext(ctx, insert, time.Now().UTC()); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
 		return err
 	}
 	return nil
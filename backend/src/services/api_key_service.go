@@ -0,0 +1,239 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strings"
+	"time"
+	"warehouse-management-system/src/models"
+)
+
+// APIKeyRepository описывает поведение хранилища API-ключей, нужное слою сервисов.
+type APIKeyRepository interface {
+	GetAll(ctx context.Context) ([]*models.APIKey, error)
+	GetByID(ctx context.Context, id string) (*models.APIKey, error)
+	GetByPrefix(ctx context.Context, prefix string) (*models.APIKey, error)
+	Create(ctx context.Context, key *models.APIKey) error
+	Revoke(ctx context.Context, id string, at time.Time) error
+	TouchLastUsed(ctx context.Context, id string, at time.Time) error
+}
+
+// APIKeyService инкапсулирует выпуск, отзыв и проверку API-ключей интеграций.
+type APIKeyService struct {
+	repo     APIKeyRepository
+	userRepo UserRepository
+	audit    AuditRecorder
+}
+
+// NewAPIKeyService — конструктор сервиса API-ключей.
+func NewAPIKeyService(repo APIKeyRepository, userRepo UserRepository, audit AuditRecorder) *APIKeyService {
+	return &APIKeyService{
+		repo:     repo,
+		userRepo: userRepo,
+		audit:    audit,
+	}
+}
+
+var (
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	ErrInvalidAPIKey  = errors.New("invalid api key data")
+	ErrAPIKeyRejected = models.ErrAPIKeyRejected
+	ErrAPIKeyRole     = errors.New("api key role cannot exceed the role of its owner")
+)
+
+// Формат ключа: "wms_<prefix>_<secret>". Префикс хранится открыто и служит для поиска,
+// от всего ключа в БД хранится только SHA-256: секрет случайный и длинный, медленный хэш не нужен.
+const (
+	apiKeyTag         = "wms"
+	apiKeyPrefixBytes = 6
+	apiKeySecretBytes = 24
+
+	// apiKeyTouchInterval ограничивает частоту записи last_used_at: не чаще раза в минуту на ключ,
+	// чтобы запросы на чтение по ключу не становились записью в БД.
+	apiKeyTouchInterval = time.Minute
+)
+
+// apiKeyResources — ресурсы API, на которые можно выдавать права ключам.
+// Вложения загружаются через /products/{id}/attachments и /suppliers/{id}/attachments (права products и suppliers),
+// а читаются и удаляются через /attachments/{id} — для этого нужен ресурс attachments.
+var apiKeyResources = map[string]bool{
+	"products":    true,
+	"categories":  true,
+	"suppliers":   true,
+	"warehouse":   true,
+	"orders":      true,
+	"attachments": true,
+	"price-lists": true,
+}
+
+// ListKeys возвращает все выпущенные API-ключи (без секретов).
+func (s *APIKeyService) ListKeys(ctx context.Context) ([]*models.APIKey, error) {
+	return s.repo.GetAll(ctx)
+}
+
+// CreateKey выпускает новый API-ключ. Открытое значение ключа возвращается только здесь,
+// повторно получить его невозможно. Роль ключа не может быть выше роли владельца (ErrAPIKeyRole).
+func (s *APIKeyService) CreateKey(ctx context.Context, name, userID string, role models.Role, scopes []string, expiresAt *time.Time) (*models.APIKey, string, error) {
+	name = strings.TrimSpace(name)
+	userID = strings.TrimSpace(userID)
+
+	if name == "" || userID == "" || !isKnownRole(role) || len(scopes) == 0 {
+		return nil, "", ErrInvalidAPIKey
+	}
+	for _, scope := range scopes {
+		if !isValidScope(scope) {
+			return nil, "", ErrInvalidAPIKey
+		}
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now().UTC()) {
+		return nil, "", ErrInvalidAPIKey
+	}
+
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, "", err
+	}
+	if user == nil {
+		return nil, "", ErrUserNotFound
+	}
+	if rolePriority[role] > rolePriority[user.Role] {
+		return nil, "", ErrAPIKeyRole
+	}
+
+	prefix, err := randomHex(apiKeyPrefixBytes)
+	if err != nil {
+		return nil, "", err
+	}
+	secret, err := randomHex(apiKeySecretBytes)
+	if err != nil {
+		return nil, "", err
+	}
+	rawKey := apiKeyTag + "_" + prefix + "_" + secret
+
+	key := models.NewAPIKey(name, prefix, hashAPIKey(rawKey), userID, role, scopes, expiresAt)
+	if err := s.repo.Create(ctx, key); err != nil {
+		return nil, "", err
+	}
+
+	s.audit.Record(ctx, models.AuditActionCreate, models.AuditEntityAPIKey, key.ID, nil, key)
+	return key, rawKey, nil
+}
+
+// RevokeKey отзывает API-ключ по ID.
+func (s *APIKeyService) RevokeKey(ctx context.Context, id string) error {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return ErrInvalidAPIKey
+	}
+
+	key, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if key == nil || key.RevokedAt != nil {
+		return ErrAPIKeyNotFound
+	}
+
+	now := time.Now().UTC()
+	if err := s.repo.Revoke(ctx, id, now); err != nil {
+		return err
+	}
+
+	before := *key
+	key.RevokedAt = &now
+	s.audit.Record(ctx, models.AuditActionRevoke, models.AuditEntityAPIKey, id, before, key)
+	return nil
+}
+
+// AuthenticateAPIKey проверяет открытое значение ключа и возвращает его запись,
+// попутно обновляя время последнего использования (не чаще apiKeyTouchInterval).
+// Если роль владельца с момента выпуска понизили, ключ действует с ролью владельца;
+// ключ удалённого пользователя отклоняется.
+func (s *APIKeyService) AuthenticateAPIKey(ctx context.Context, rawKey string) (*models.APIKey, error) {
+	parts := strings.Split(strings.TrimSpace(rawKey), "_")
+	if len(parts) != 3 || parts[0] != apiKeyTag || parts[1] == "" || parts[2] == "" {
+		return nil, ErrAPIKeyRejected
+	}
+
+	key, err := s.repo.GetByPrefix(ctx, parts[1])
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, ErrAPIKeyRejected
+	}
+
+	hash := hashAPIKey(rawKey)
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(key.KeyHash)) != 1 {
+		return nil, ErrAPIKeyRejected
+	}
+
+	now := time.Now().UTC()
+	if !key.IsActive(now) {
+		return nil, ErrAPIKeyRejected
+	}
+
+	owner, err := s.userRepo.FindByID(ctx, key.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if owner == nil {
+		return nil, ErrAPIKeyRejected
+	}
+	if rolePriority[key.Role] > rolePriority[owner.Role] {
+		key.Role = owner.Role
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+		// Отметка использования — вспомогательная: из-за её сбоя (например, занятой записи в SQLite)
+		// действующий ключ не отклоняется.
+		if err := s.repo.TouchLastUsed(ctx, key.ID, now); err != nil {
+			log.Printf("api key %s: update last used: %v", key.ID, err)
+		} else {
+			key.LastUsedAt = &now
+		}
+	}
+
+	return key, nil
+}
+
+func hashAPIKey(rawKey string) string {
+	sum := sha256.Sum256([]byte(rawKey))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func isKnownRole(role models.Role) bool {
+	switch role {
+	case models.RoleAdmin, models.RoleManager, models.RoleStorekeeper:
+		return true
+	}
+	return false
+}
+
+func isValidScope(scope string) bool {
+	if scope == models.ScopeAll {
+		return true
+	}
+	resource, action, ok := strings.Cut(scope, ":")
+	if !ok || !apiKeyResources[resource] {
+		return false
+	}
+	switch action {
+	case models.ScopeRead, models.ScopeWrite, "*":
+		return true
+	}
+	return false
+}
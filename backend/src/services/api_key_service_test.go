@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"warehouse-management-system/src/models"
+)
+
+// memoryAPIKeyRepo — APIKeyRepository в памяти; touchErr имитирует сбой записи отметки использования.
+type memoryAPIKeyRepo struct {
+	keys     map[string]*models.APIKey
+	touchErr error
+}
+
+func (r *memoryAPIKeyRepo) GetAll(context.Context) ([]*models.APIKey, error) {
+	var result []*models.APIKey
+	for _, k := range r.keys {
+		clone := *k
+		result = append(result, &clone)
+	}
+	return result, nil
+}
+
+func (r *memoryAPIKeyRepo) GetByID(_ context.Context, id string) (*models.APIKey, error) {
+	if k, ok := r.keys[id]; ok {
+		clone := *k
+		return &clone, nil
+	}
+	return nil, nil
+}
+
+func (r *memoryAPIKeyRepo) GetByPrefix(_ context.Context, prefix string) (*models.APIKey, error) {
+	for _, k := range r.keys {
+		if k.Prefix == prefix {
+			clone := *k
+			return &clone, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryAPIKeyRepo) Create(_ context.Context, key *models.APIKey) error {
+	key.ID = "ak_" + key.Prefix
+	clone := *key
+	r.keys[key.ID] = &clone
+	return nil
+}
+
+func (r *memoryAPIKeyRepo) Revoke(_ context.Context, id string, at time.Time) error {
+	r.keys[id].RevokedAt = &at
+	return nil
+}
+
+func (r *memoryAPIKeyRepo) TouchLastUsed(_ context.Context, id string, at time.Time) error {
+	if r.touchErr != nil {
+		return r.touchErr
+	}
+	r.keys[id].LastUsedAt = &at
+	return nil
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	users := newMemoryUserRepo(models.NewUser("u_1", "a@example.com", "hash", models.RoleManager))
+	keys := &memoryAPIKeyRepo{keys: map[string]*models.APIKey{}}
+	s := NewAPIKeyService(keys, users, nopAudit{})
+	ctx := context.Background()
+
+	key, rawKey, err := s.CreateKey(ctx, "ERP", "u_1", models.RoleManager, []string{"products:read"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Сбой отметки использования не делает действующий ключ недействительным.
+	keys.touchErr = errors.New("database is locked")
+	if got, err := s.AuthenticateAPIKey(ctx, rawKey); err != nil || got.ID != key.ID {
+		t.Fatalf("AuthenticateAPIKey with a failing touch = %v, %v; want the key", got, err)
+	}
+
+	for _, bad := range []string{"", "wms_x", rawKey + "0", "wms_unknown_secret"} {
+		if _, err := s.AuthenticateAPIKey(ctx, bad); !errors.Is(err, models.ErrAPIKeyRejected) {
+			t.Errorf("AuthenticateAPIKey(%q) = %v, want ErrAPIKeyRejected", bad, err)
+		}
+	}
+	if err := s.RevokeKey(ctx, key.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.AuthenticateAPIKey(ctx, rawKey); !errors.Is(err, models.ErrAPIKeyRejected) {
+		t.Fatalf("revoked key: got %v, want ErrAPIKeyRejected", err)
+	}
+}
+
+func TestCreateKeyScopes(t *testing.T) {
+	users := newMemoryUserRepo(models.NewUser("u_1", "a@example.com", "hash", models.RoleAdmin))
+	s := NewAPIKeyService(&memoryAPIKeyRepo{keys: map[string]*models.APIKey{}}, users, nopAudit{})
+
+	tests := []struct {
+		scope string
+		want  error
+	}{
+		{scope: "products:write"},
+		{scope: "attachments:read"},
+		{scope: "price-lists:write"},
+		{scope: "*"},
+		{scope: "users:read", want: ErrInvalidAPIKey},
+		{scope: "products:delete", want: ErrInvalidAPIKey},
+	}
+	for _, tt := range tests {
+		_, _, err := s.CreateKey(context.Background(), "ERP", "u_1", models.RoleManager, []string{tt.scope}, nil)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("scope %q: got %v, want %v", tt.scope, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	_ "image/gif"  // декодер GIF для image.Decode
+	_ "image/jpeg" // декодер JPEG для image.Decode
+	"image/png"
+)
+
+// Уменьшенные копии изображений строятся без внешних библиотек: каждый пиксель копии — среднее
+// цветов прямоугольника исходного изображения, который он покрывает. Копия сохраняется в PNG.
+
+const (
+	// ThumbnailSize — наибольшая сторона уменьшенной копии в пикселях.
+	ThumbnailSize = 256
+	// maxThumbnailSourcePixels ограничивает размер изображения, для которого строится копия:
+	// распакованное изображение занимает 4 байта на пиксель, и маленький файл с огромными размерами
+	// не должен занимать гигабайты памяти. Для более крупных изображений копия не строится.
+	maxThumbnailSourcePixels = 25_000_000
+)
+
+// thumbnailable — типы содержимого, которые умеет декодировать стандартная библиотека.
+var thumbnailable = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+// makeThumbnail возвращает уменьшенную копию изображения data в PNG или nil, если копия не строится
+// (слишком большое изображение). Повреждённое изображение — ErrInvalidAttachment.
+func makeThumbnail(data []byte) ([]byte, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || cfg.Width <= 0 || cfg.Height <= 0 {
+		return nil, ErrInvalidAttachment
+	}
+	if int64(cfg.Width)*int64(cfg.Height) > maxThumbnailSourcePixels {
+		return nil, nil
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidAttachment
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, scaleDown(img, ThumbnailSize)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// scaleDown уменьшает изображение так, чтобы большая сторона была не больше size, сохраняя пропорции.
+// Изображение меньше size не увеличивается.
+func scaleDown(src image.Image, size int) *image.RGBA {
+	b := src.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	dw, dh := sw, sh
+	if sw > size || sh > size {
+		if sw >= sh {
+			dw, dh = size, max(1, sh*size/sw)
+		} else {
+			dw, dh = max(1, sw*size/sh), size
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0, y1 := b.Min.Y+y*sh/dh, b.Min.Y+(y+1)*sh/dh
+		for x := 0; x < dw; x++ {
+			x0, x1 := b.Min.X+x*sw/dw, b.Min.X+(x+1)*sw/dw
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			// Цвета RGBA() уже умножены на альфу, поэтому их можно усреднять по отдельности.
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8(r / n >> 8),
+				G: uint8(g / n >> 8),
+				B: uint8(bl / n >> 8),
+				A: uint8(a / n >> 8),
+			})
+		}
+	}
+	return dst
+}
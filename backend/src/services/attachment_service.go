@@ -0,0 +1,287 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"log"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+	"warehouse-management-system/src/models"
+)
+
+// AttachmentRepository описывает поведение хранилища сведений о вложениях.
+type AttachmentRepository interface {
+	GetByOwner(ctx context.Context, ownerType models.AttachmentOwner, ownerID string) ([]*models.Attachment, error)
+	GetByID(ctx context.Context, id string) (*models.Attachment, error)
+	Create(ctx context.Context, a *models.Attachment) error
+	Delete(ctx context.Context, id string) error
+	IsBlobReferenced(ctx context.Context, key string) (bool, error)
+}
+
+// BlobStore хранит содержимое вложений по ключу — SHA-256 содержимого в hex. Реализуется
+// repositories.FileBlobStore (каталог на диске); хранилище, совместимое с S3, подключается
+// реализацией того же интерфейса (ключ — имя объекта в бакете).
+// Get для отсутствующего ключа возвращает ошибку, для которой errors.Is(err, fs.ErrNotExist);
+// Delete отсутствующего ключа ошибкой не считается.
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// AttachmentService инкапсулирует бизнес-логику вложений товаров и поставщиков: проверку размера
+// и типа файла, хранение содержимого по хешу и построение уменьшенных копий изображений.
+type AttachmentService struct {
+	repo         AttachmentRepository
+	store        BlobStore
+	productRepo  ProductRepository
+	supplierRepo SupplierRepository
+	maxSize      int64
+	audit        AuditRecorder
+}
+
+// NewAttachmentService — конструктор сервиса вложений. maxSize — наибольший размер файла в байтах.
+func NewAttachmentService(repo AttachmentRepository, store BlobStore, productRepo ProductRepository, supplierRepo SupplierRepository, maxSize int64, audit AuditRecorder) *AttachmentService {
+	return &AttachmentService{
+		repo:         repo,
+		store:        store,
+		productRepo:  productRepo,
+		supplierRepo: supplierRepo,
+		maxSize:      maxSize,
+		audit:        audit,
+	}
+}
+
+// maxAttachmentNameLength ограничивает длину имени файла (в символах).
+const maxAttachmentNameLength = 255
+
+// AllowedAttachmentTypes — типы содержимого, которые можно загружать: изображения, PDF и текст.
+// Тип определяется по первым байтам файла (http.DetectContentType), заголовок клиента не учитывается.
+var AllowedAttachmentTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+	"text/plain":      true,
+}
+
+var (
+	ErrAttachmentNotFound = errors.New("attachment not found")
+	ErrInvalidAttachment  = errors.New("invalid attachment")
+	ErrAttachmentTooLarge = errors.New("attachment is too large")
+	ErrAttachmentType     = errors.New("attachment type is not allowed")
+	ErrNoThumbnail        = errors.New("attachment has no thumbnail")
+)
+
+// MaxSize возвращает наибольший допустимый размер файла в байтах.
+func (s *AttachmentService) MaxSize() int64 {
+	return s.maxSize
+}
+
+// ListAttachments возвращает вложения товара или поставщика.
+func (s *AttachmentService) ListAttachments(ctx context.Context, ownerType models.AttachmentOwner, ownerID string) ([]*models.Attachment, error) {
+	ownerID, err := s.checkOwner(ctx, ownerType, ownerID, false)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetByOwner(ctx, ownerType, ownerID)
+}
+
+// GetAttachment возвращает сведения о вложении.
+func (s *AttachmentService) GetAttachment(ctx context.Context, id string) (*models.Attachment, error) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return nil, ErrInvalidAttachment
+	}
+	a, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if a == nil {
+		return nil, ErrAttachmentNotFound
+	}
+	return a, nil
+}
+
+// Upload прикрепляет файл к незаархивированному товару или поставщику. Из r читается не больше
+// maxSize байт, иначе — ErrAttachmentTooLarge. Пустой kind — photo для изображений, other для
+// остальных файлов. Для изображений JPEG, PNG и GIF строится уменьшенная копия.
+func (s *AttachmentService) Upload(ctx context.Context, ownerType models.AttachmentOwner, ownerID string, kind models.AttachmentKind, fileName string, r io.Reader) (*models.Attachment, error) {
+	ownerID, err := s.checkOwner(ctx, ownerType, ownerID, true)
+	if err != nil {
+		return nil, err
+	}
+	fileName = filepath.Base(strings.ReplaceAll(strings.TrimSpace(fileName), `\`, "/"))
+	if fileName == "." || fileName == "/" || !utf8.ValidString(fileName) ||
+		utf8.RuneCountInString(fileName) > maxAttachmentNameLength {
+		return nil, ErrInvalidAttachment
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, s.maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > s.maxSize {
+		return nil, ErrAttachmentTooLarge
+	}
+	if len(data) == 0 {
+		return nil, ErrInvalidAttachment
+	}
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(data))
+	if !AllowedAttachmentTypes[contentType] {
+		return nil, ErrAttachmentType
+	}
+
+	if kind == "" {
+		kind = models.AttachmentOther
+		if strings.HasPrefix(contentType, "image/") {
+			kind = models.AttachmentPhoto
+		}
+	}
+	if !kind.Valid() {
+		return nil, ErrInvalidAttachment
+	}
+
+	a := &models.Attachment{
+		OwnerType:   ownerType,
+		OwnerID:     ownerID,
+		Kind:        kind,
+		FileName:    fileName,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		SHA256:      blobKey(data),
+		CreatedBy:   actorFromContext(ctx),
+	}
+	if thumbnailable[contentType] {
+		thumbnail, err := makeThumbnail(data)
+		if err != nil {
+			return nil, err
+		}
+		if thumbnail != nil {
+			a.ThumbnailSHA256 = blobKey(thumbnail)
+			a.HasThumbnail = true
+			if err := s.store.Put(ctx, a.ThumbnailSHA256, bytes.NewReader(thumbnail)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := s.store.Put(ctx, a.SHA256, bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(ctx, a); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, models.AuditActionCreate, models.AuditEntityAttachment, a.ID, nil, a)
+	return a, nil
+}
+
+// Open возвращает сведения о вложении и его содержимое (thumbnail — уменьшенную копию в PNG).
+// Вызывающий закрывает содержимое.
+func (s *AttachmentService) Open(ctx context.Context, id string, thumbnail bool) (*models.Attachment, io.ReadCloser, error) {
+	a, err := s.GetAttachment(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	key := a.SHA256
+	if thumbnail {
+		if !a.HasThumbnail {
+			return nil, nil, ErrNoThumbnail
+		}
+		key = a.ThumbnailSHA256
+	}
+
+	content, err := s.store.Get(ctx, key)
+	if errors.Is(err, fs.ErrNotExist) {
+		log.Printf("attachment %s: content %s is missing from the store", a.ID, key)
+		return nil, nil, ErrAttachmentNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return a, content, nil
+}
+
+// DeleteAttachment удаляет вложение. Содержимое удаляется из хранилища, только если на него
+// не ссылаются другие вложения (одинаковые файлы хранятся один раз).
+func (s *AttachmentService) DeleteAttachment(ctx context.Context, id string) error {
+	a, err := s.GetAttachment(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, a.ID); err != nil {
+		return err
+	}
+
+	for _, key := range []string{a.SHA256, a.ThumbnailSHA256} {
+		if key == "" {
+			continue
+		}
+		// Ошибка очистки хранилища не отменяет удаление: лишний файл безвреден и не виден через API.
+		referenced, err := s.repo.IsBlobReferenced(ctx, key)
+		if err == nil && !referenced {
+			err = s.store.Delete(ctx, key)
+		}
+		if err != nil {
+			log.Printf("attachment %s: failed to remove content %s: %v", a.ID, key, err)
+		}
+	}
+
+	s.audit.Record(ctx, models.AuditActionDelete, models.AuditEntityAttachment, a.ID, a, nil)
+	return nil
+}
+
+// checkOwner проверяет, что владелец вложения существует (и, если active, не заархивирован),
+// и возвращает его ID без пробелов по краям.
+func (s *AttachmentService) checkOwner(ctx context.Context, ownerType models.AttachmentOwner, ownerID string, active bool) (string, error) {
+	ownerID = strings.TrimSpace(ownerID)
+	switch ownerType {
+	case models.AttachmentOwnerProduct:
+		if ownerID == "" {
+			return "", ErrInvalidProduct
+		}
+		product, err := s.productRepo.GetByID(ctx, ownerID)
+		if err != nil {
+			return "", err
+		}
+		if product == nil {
+			return "", ErrProductNotFound
+		}
+		if active && product.ArchivedAt != nil {
+			return "", ErrProductArchived
+		}
+	case models.AttachmentOwnerSupplier:
+		if ownerID == "" {
+			return "", ErrInvalidSupplier
+		}
+		supplier, err := s.supplierRepo.GetByID(ctx, ownerID)
+		if err != nil {
+			return "", err
+		}
+		if supplier == nil {
+			return "", ErrSupplierNotFound
+		}
+		if active && supplier.ArchivedAt != nil {
+			return "", ErrSupplierArchived
+		}
+	default:
+		return "", ErrInvalidAttachment
+	}
+	return ownerID, nil
+}
+
+// blobKey возвращает ключ содержимого в хранилище — SHA-256 в hex.
+func blobKey(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
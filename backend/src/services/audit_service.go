@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+	"warehouse-management-system/src/models"
+)
+
+// Ключи контекста, которые заполняют middleware (AuthMiddleware, RequestID).
+// Значения должны совпадать с ключами в пакете middleware.
+const (
+	contextUserIDKey    = "userID"
+	contextAPIKeyIDKey  = "apiKeyID"
+	contextRequestIDKey = "requestID"
+)
+
+// AuditRepository описывает поведение хранилища журнала аудита.
+type AuditRepository interface {
+	Create(ctx context.Context, entry *models.AuditEntry) error
+	Find(ctx context.Context, filter models.AuditFilter) ([]*models.AuditEntry, error)
+}
+
+// AuditRecorder фиксирует изменения в журнале аудита. Его вызывают все сервисы,
+// изменяющие данные; автор изменения и ID запроса берутся из контекста.
+type AuditRecorder interface {
+	Record(ctx context.Context, action, entityType, entityID string, before, after any)
+}
+
+// AuditService ведёт журнал аудита и отдаёт его администраторам.
+type AuditService struct {
+	repo AuditRepository
+}
+
+// NewAuditService — конструктор сервиса аудита.
+func NewAuditService(repo AuditRepository) *AuditService {
+	return &AuditService{repo: repo}
+}
+
+var ErrInvalidAuditFilter = errors.New("invalid audit filter")
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+	// auditRecordTimeout ограничивает запись в журнал, которая не зависит от отмены запроса.
+	auditRecordTimeout = 5 * time.Second
+)
+
+// Record сохраняет запись аудита. before/after сериализуются в JSON (nil — отсутствие состояния).
+// Ошибка записи журнала не отменяет уже выполненную операцию, поэтому она только логируется.
+// Изменение к этому моменту уже сохранено, поэтому запись не прерывается, даже если клиент отключился.
+func (s *AuditService) Record(ctx context.Context, action, entityType, entityID string, before, after any) {
+	entry := &models.AuditEntry{
+		ActorID:    actorFromContext(ctx),
+		APIKeyID:   stringFromContext(ctx, contextAPIKeyIDKey),
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		RequestID:  stringFromContext(ctx, contextRequestIDKey),
+	}
+
+	var err error
+	if entry.Before, err = marshalAuditState(before); err != nil {
+		log.Printf("audit: cannot encode state of %s %s: %v", entityType, entityID, err)
+	}
+	if entry.After, err = marshalAuditState(after); err != nil {
+		log.Printf("audit: cannot encode state of %s %s: %v", entityType, entityID, err)
+	}
+
+	writeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), auditRecordTimeout)
+	defer cancel()
+	if err := s.repo.Create(writeCtx, entry); err != nil {
+		log.Printf("audit: failed to record %s %s %s (request_id=%s): %v",
+			action, entityType, entityID, entry.RequestID, err)
+	}
+}
+
+// ListEntries возвращает записи журнала по фильтру.
+func (s *AuditService) ListEntries(ctx context.Context, filter models.AuditFilter) ([]*models.AuditEntry, error) {
+	if filter.Limit < 0 || filter.Offset < 0 || filter.Limit > maxAuditLimit {
+		return nil, ErrInvalidAuditFilter
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, ErrInvalidAuditFilter
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultAuditLimit
+	}
+	return s.repo.Find(ctx, filter)
+}
+
+// actorFromContext возвращает ID пользователя, выполняющего запрос.
+func actorFromContext(ctx context.Context) string {
+	return stringFromContext(ctx, contextUserIDKey)
+}
+
+func stringFromContext(ctx context.Context, key string) string {
+	if ctx == nil {
+		return ""
+	}
+	v, _ := ctx.Value(key).(string)
+	return v
+}
+
+func marshalAuditState(state any) (json.RawMessage, error) {
+	if state == nil {
+		return nil, nil
+	}
+	return json.Marshal(state)
+}
@@ -1,7 +1,10 @@
 package services
 
 import (
+	"context"
 	"errors"
+	"log"
+	"sort"
 	"time"
 	"warehouse-management-system/src/models"
 
@@ -13,28 +16,74 @@ import (
 // Конкретная реализация будет находиться в пакете repositories.
 type UserRepository interface {
 	// FindByEmail возвращает пользователя по email или nil, если не найден.
-	FindByEmail(email string) (*models.User, error)
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
 	// FindByID возвращает пользователя по идентификатору или nil, если не найден.
-	FindByID(id string) (*models.User, error)
+	FindByID(ctx context.Context, id string) (*models.User, error)
 	// Create сохраняет нового пользователя в хранилище.
-	Create(user *models.User) error
+	Create(ctx context.Context, user *models.User) error
+	// CreateWithIdentity атомарно сохраняет нового пользователя вместе с привязкой внешней учётной записи.
+	// Занятый email — models.ErrEmailAlreadyInUse, уже привязанная учётная запись — models.ErrIdentityLinked.
+	CreateWithIdentity(ctx context.Context, user *models.User, provider, subject string) error
+	// Update обновляет email и роль пользователя; занятый email — models.ErrEmailAlreadyInUse.
+	Update(ctx context.Context, user *models.User) error
+	// FindByIdentity возвращает пользователя, привязанного к учётной записи внешнего провайдера, или nil.
+	FindByIdentity(ctx context.Context, provider, subject string) (*models.User, error)
+	// LinkIdentity привязывает учётную запись внешнего провайдера к пользователю.
+	LinkIdentity(ctx context.Context, provider, subject, userID string) error
+	// ListIdentities возвращает внешние учётные записи, привязанные к пользователю.
+	ListIdentities(ctx context.Context, userID string) ([]models.UserIdentity, error)
+	// UnlinkIdentity удаляет привязку; false — привязки не было.
+	UnlinkIdentity(ctx context.Context, provider, subject, userID string) (bool, error)
 }
 
 // AuthService инкапсулирует бизнес-логику аутентификации и авторизации.
+// Проверка учётных данных делегируется провайдерам (Authenticator): встроенному local
+// и, при настройке, внешним (LDAP, OIDC).
 type AuthService struct {
-	userRepo  UserRepository
-	jwtSecret string
+	userRepo          UserRepository
+	tokenKeys         *TokenKeySet
+	sessions          *SessionService
+	audit             AuditRecorder
+	roles             RoleMapping
+	passwordProviders map[string]PasswordAuthenticator
+	redirectProviders map[string]RedirectAuthenticator
 }
 
 // NewAuthService — конструктор сервиса аутентификации.
-func NewAuthService(userRepo UserRepository, jwtSecret string) *AuthService {
-	return &AuthService{
-		userRepo:  userRepo,
-		jwtSecret: jwtSecret,
+// Провайдер local подключается всегда; roles используется для пользователей внешних провайдеров.
+// Каждый выданный токен привязывается к сессии из sessions.
+func NewAuthService(userRepo UserRepository, tokenKeys *TokenKeySet, sessions *SessionService, audit AuditRecorder, roles RoleMapping, providers ...Authenticator) *AuthService {
+	s := &AuthService{
+		userRepo:          userRepo,
+		tokenKeys:         tokenKeys,
+		sessions:          sessions,
+		audit:             audit,
+		roles:             roles,
+		passwordProviders: map[string]PasswordAuthenticator{},
+		redirectProviders: map[string]RedirectAuthenticator{},
 	}
+
+	providers = append([]Authenticator{&localAuthenticator{userRepo: userRepo}}, providers...)
+	for _, p := range providers {
+		if pa, ok := p.(PasswordAuthenticator); ok {
+			s.passwordProviders[p.Name()] = pa
+		}
+		if ra, ok := p.(RedirectAuthenticator); ok {
+			s.redirectProviders[p.Name()] = ra
+		}
+	}
+
+	return s
+}
+
+// ProviderInfo описывает доступный способ входа для клиента.
+type ProviderInfo struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"` // password или redirect
 }
 
 // Claims описывает JWT-пейлоад, который мы отдаём клиенту.
+// ID сессии передаётся в стандартном claim jti (RegisteredClaims.ID).
 type Claims struct {
 	UserID string      `json:"user_id"`
 	Role   models.Role `json:"role"`
@@ -42,15 +91,15 @@ type Claims struct {
 }
 
 var (
-	ErrEmailAlreadyInUse  = errors.New("email already in use")
+	ErrEmailAlreadyInUse  = models.ErrEmailAlreadyInUse
 	ErrInvalidCredentials = errors.New("invalid email or password")
 	ErrUserNotFound       = errors.New("user not found")
 )
 
 // Register регистрирует нового пользователя: валидирует данные, хэширует пароль,
 // создаёт запись пользователя и возвращает JWT-токен.
-func (s *AuthService) Register(email, password string, role models.Role) (*models.User, error) {
-	existing, err := s.userRepo.FindByEmail(email)
+func (s *AuthService) Register(ctx context.Context, email, password string, role models.Role) (*models.User, error) {
+	existing, err := s.userRepo.FindByEmail(ctx, email)
 	if err != nil {
 		return nil, err
 	}
@@ -65,16 +114,82 @@ func (s *AuthService) Register(email, password string, role models.Role) (*model
 
 	user := models.NewUser("", email, passwordHash, role)
 
-	if err := s.userRepo.Create(user); err != nil {
+	if err := s.userRepo.Create(ctx, user); err != nil {
 		return nil, err
 	}
 
+	s.audit.Record(ctx, models.AuditActionCreate, models.AuditEntityUser, user.ID, nil, user)
 	return user, nil
 }
 
-// Login выполняет вход пользователя: проверяет email/пароль и выдаёт JWT-токен.
-func (s *AuthService) Login(email, password string) (*models.User, string, error) {
-	user, err := s.userRepo.FindByEmail(email)
+// Providers возвращает список настроенных способов входа.
+func (s *AuthService) Providers() []ProviderInfo {
+	result := make([]ProviderInfo, 0, len(s.passwordProviders)+len(s.redirectProviders))
+	for name := range s.passwordProviders {
+		result = append(result, ProviderInfo{Name: name, Kind: "password"})
+	}
+	for name := range s.redirectProviders {
+		result = append(result, ProviderInfo{Name: name, Kind: "redirect"})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// Login выполняет вход пользователя через провайдер с логином/паролем и выдаёт JWT-токен.
+// Пустое имя провайдера означает local.
+func (s *AuthService) Login(ctx context.Context, provider, login, password string, client ClientInfo) (*models.User, string, error) {
+	if provider == "" {
+		provider = LocalProvider
+	}
+	p, ok := s.passwordProviders[provider]
+	if !ok {
+		return nil, "", ErrUnknownProvider
+	}
+
+	identity, err := p.AuthenticatePassword(ctx, login, password)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return s.completeLogin(ctx, identity, client)
+}
+
+// LoginRedirectURL возвращает адрес страницы входа внешнего провайдера.
+func (s *AuthService) LoginRedirectURL(ctx context.Context, provider, state, nonce string) (string, error) {
+	p, ok := s.redirectProviders[provider]
+	if !ok {
+		return "", ErrUnknownProvider
+	}
+	return p.AuthCodeURL(ctx, state, nonce)
+}
+
+// CompleteRedirectLogin завершает вход через внешнего провайдера по коду авторизации.
+func (s *AuthService) CompleteRedirectLogin(ctx context.Context, provider, code, nonce string, client ClientInfo) (*models.User, string, error) {
+	p, ok := s.redirectProviders[provider]
+	if !ok {
+		return nil, "", ErrUnknownProvider
+	}
+
+	identity, err := p.Exchange(ctx, code, nonce)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return s.completeLogin(ctx, identity, client)
+}
+
+// completeLogin находит (или создаёт) пользователя для проверенной личности,
+// открывает для него сессию и выдаёт токен.
+func (s *AuthService) completeLogin(ctx context.Context, identity *Identity, client ClientInfo) (*models.User, string, error) {
+	var (
+		user *models.User
+		err  error
+	)
+	if identity.Provider == LocalProvider {
+		user, err = s.userRepo.FindByID(ctx, identity.Subject)
+	} else {
+		user, err = s.provisionUser(ctx, identity)
+	}
 	if err != nil {
 		return nil, "", err
 	}
@@ -82,11 +197,12 @@ func (s *AuthService) Login(email, password string) (*models.User, string, error
 		return nil, "", ErrInvalidCredentials
 	}
 
-	if err := comparePassword(user.PasswordHash, password); err != nil {
-		return nil, "", ErrInvalidCredentials
+	session, err := s.sessions.StartSession(ctx, user, identity.Provider, client)
+	if err != nil {
+		return nil, "", err
 	}
 
-	token, err := s.generateToken(user)
+	token, err := s.generateToken(user, session)
 	if err != nil {
 		return nil, "", err
 	}
@@ -94,9 +210,172 @@ func (s *AuthService) Login(email, password string) (*models.User, string, error
 	return user, token, nil
 }
 
+// Logout завершает сессию, которой аутентифицирован текущий запрос.
+func (s *AuthService) Logout(ctx context.Context, userID, sessionID string) error {
+	return s.sessions.RevokeSession(ctx, userID, sessionID)
+}
+
+// provisionUser реализует just-in-time создание пользователя внешнего провайдера.
+// Новая внешняя учётная запись получает нового пользователя; если email уже занят,
+// вход запрещается до явной привязки администратором (LinkIdentity): иначе владелец
+// учётной записи у провайдера с тем же адресом получил бы чужой аккаунт.
+// Роль при каждом входе синхронизируется с группами провайдера, email — с адресом у провайдера,
+// если он не занят другим пользователем.
+// Изменения пишутся в аудит от имени самого пользователя: запрос входа ещё не аутентифицирован.
+func (s *AuthService) provisionUser(ctx context.Context, identity *Identity) (*models.User, error) {
+	role, ok := s.roles.Resolve(identity.Groups)
+	if !ok {
+		return nil, ErrNoRoleMapping
+	}
+
+	user, err := s.userRepo.FindByIdentity(ctx, identity.Provider, identity.Subject)
+	if err != nil {
+		return nil, err
+	}
+
+	if user == nil {
+		if user, err = s.createExternalUser(ctx, identity, role); err != nil {
+			return nil, err
+		}
+	}
+
+	if user.Role == role && user.Email == identity.Email {
+		return user, nil
+	}
+	before := *user
+	user.Role = role
+	user.Email = identity.Email
+	err = s.userRepo.Update(ctx, user)
+	if errors.Is(err, ErrEmailAlreadyInUse) {
+		// Адрес у провайдера занят другим пользователем (например, учётную запись привязал администратор):
+		// локальный email остаётся прежним, иначе вход падал бы при каждой попытке.
+		log.Printf("auth: %s account %s of user %s: email %s is used by another user, keeping %s",
+			identity.Provider, identity.Subject, user.ID, identity.Email, before.Email)
+		user.Email = before.Email
+		err = nil
+		if user.Role != before.Role {
+			err = s.userRepo.Update(ctx, user)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.Role != before.Role || user.Email != before.Email {
+		s.audit.Record(context.WithValue(ctx, contextUserIDKey, user.ID),
+			models.AuditActionUpdate, models.AuditEntityUser, user.ID, before, user)
+	}
+	return user, nil
+}
+
+// createExternalUser создаёт пользователя для новой внешней учётной записи вместе с привязкой.
+// Если email занят, вход запрещается до явной привязки администратором. Если же учётную запись
+// тем временем привязал параллельный вход (повторный callback), возвращается созданный им пользователь.
+func (s *AuthService) createExternalUser(ctx context.Context, identity *Identity, role models.Role) (*models.User, error) {
+	existing, err := s.userRepo.FindByEmail(ctx, identity.Email)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrIdentityNotLinked
+	}
+
+	// Пароль не задаём: такой пользователь не может войти через local.
+	user := models.NewUser("", identity.Email, "", role)
+	err = s.userRepo.CreateWithIdentity(ctx, user, identity.Provider, identity.Subject)
+	if errors.Is(err, ErrEmailAlreadyInUse) || errors.Is(err, ErrIdentityLinked) {
+		linked, findErr := s.userRepo.FindByIdentity(ctx, identity.Provider, identity.Subject)
+		if findErr != nil {
+			return nil, findErr
+		}
+		if linked != nil {
+			return linked, nil
+		}
+		return nil, ErrIdentityNotLinked
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(context.WithValue(ctx, contextUserIDKey, user.ID),
+		models.AuditActionCreate, models.AuditEntityUser, user.ID, nil, user)
+	return user, nil
+}
+
+// ListIdentities возвращает внешние учётные записи пользователя.
+func (s *AuthService) ListIdentities(ctx context.Context, userID string) ([]models.UserIdentity, error) {
+	if _, err := s.GetUserByID(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.userRepo.ListIdentities(ctx, userID)
+}
+
+// LinkIdentity явно привязывает учётную запись внешнего провайдера к существующему пользователю.
+// Используется администратором, когда пользователь с тем же email уже заведён локально.
+func (s *AuthService) LinkIdentity(ctx context.Context, userID, provider, subject string) (*models.UserIdentity, error) {
+	if subject == "" {
+		return nil, ErrInvalidIdentity
+	}
+	if !s.isExternalProvider(provider) {
+		return nil, ErrUnknownProvider
+	}
+	if _, err := s.GetUserByID(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	linked, err := s.userRepo.FindByIdentity(ctx, provider, subject)
+	if err != nil {
+		return nil, err
+	}
+	if linked != nil && linked.ID != userID {
+		return nil, ErrIdentityLinked
+	}
+	if err := s.userRepo.LinkIdentity(ctx, provider, subject, userID); err != nil {
+		return nil, err
+	}
+
+	identity := &models.UserIdentity{Provider: provider, Subject: subject, UserID: userID, CreatedAt: time.Now().UTC()}
+	if linked == nil {
+		s.audit.Record(ctx, models.AuditActionCreate, models.AuditEntityUserIdentity, userID, nil, identity)
+	}
+	return identity, nil
+}
+
+// UnlinkIdentity отвязывает внешнюю учётную запись от пользователя.
+func (s *AuthService) UnlinkIdentity(ctx context.Context, userID, provider, subject string) error {
+	identities, err := s.userRepo.ListIdentities(ctx, userID)
+	if err != nil {
+		return err
+	}
+	var before *models.UserIdentity
+	for i := range identities {
+		if identities[i].Provider == provider && identities[i].Subject == subject {
+			before = &identities[i]
+		}
+	}
+
+	removed, err := s.userRepo.UnlinkIdentity(ctx, provider, subject, userID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return ErrIdentityNotFound
+	}
+	s.audit.Record(ctx, models.AuditActionDelete, models.AuditEntityUserIdentity, userID, before, nil)
+	return nil
+}
+
+// isExternalProvider сообщает, подключён ли внешний провайдер с таким именем.
+func (s *AuthService) isExternalProvider(name string) bool {
+	if name == LocalProvider {
+		return false
+	}
+	_, password := s.passwordProviders[name]
+	_, redirect := s.redirectProviders[name]
+	return password || redirect
+}
+
 // GetUserByID возвращает пользователя по его идентификатору.
-func (s *AuthService) GetUserByID(id string) (*models.User, error) {
-	user, err := s.userRepo.FindByID(id)
+func (s *AuthService) GetUserByID(ctx context.Context, id string) (*models.User, error) {
+	user, err := s.userRepo.FindByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -106,21 +385,25 @@ func (s *AuthService) GetUserByID(id string) (*models.User, error) {
 	return user, nil
 }
 
-// generateToken создаёт JWT-токен с данными пользователя.
-func (s *AuthService) generateToken(user *models.User) (string, error) {
-	now := time.Now().UTC()
+// generateToken создаёт JWT-токен с данными пользователя; срок действия совпадает со сроком сессии.
+func (s *AuthService) generateToken(user *models.User, session *models.Session) (string, error) {
 	claims := &Claims{
 		UserID: user.ID,
 		Role:   user.Role,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        session.ID,
 			Subject:   user.ID,
-			ExpiresAt: jwt.NewNumericDate(now.Add(24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(session.ExpiresAt),
+			IssuedAt:  jwt.NewNumericDate(session.IssuedAt),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.jwtSecret))
+	return s.tokenKeys.Sign(claims)
+}
+
+// JWKS возвращает открытые ключи, которыми можно проверить выданные токены.
+func (s *AuthService) JWKS() JSONWebKeySet {
+	return s.tokenKeys.JWKS()
 }
 
 func hashPassword(password string) (string, error) {
@@ -0,0 +1,288 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"warehouse-management-system/src/models"
+)
+
+// memoryUserRepo — UserRepository в памяти для тестов сервиса аутентификации.
+type memoryUserRepo struct {
+	users      map[string]*models.User
+	identities map[[2]string]string // (provider, subject) -> user_id
+	seq        int
+	// beforeCreate вызывается в начале CreateWithIdentity: так тест имитирует параллельный вход.
+	beforeCreate func()
+}
+
+func newMemoryUserRepo(users ...*models.User) *memoryUserRepo {
+	r := &memoryUserRepo{users: map[string]*models.User{}, identities: map[[2]string]string{}}
+	for _, u := range users {
+		r.users[u.ID] = u
+	}
+	return r
+}
+
+func (r *memoryUserRepo) FindByEmail(_ context.Context, email string) (*models.User, error) {
+	for _, u := range r.users {
+		if u.Email == email {
+			clone := *u
+			return &clone, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryUserRepo) FindByID(_ context.Context, id string) (*models.User, error) {
+	if u, ok := r.users[id]; ok {
+		clone := *u
+		return &clone, nil
+	}
+	return nil, nil
+}
+
+func (r *memoryUserRepo) Create(_ context.Context, user *models.User) error {
+	if r.emailTaken(user.Email, "") {
+		return models.ErrEmailAlreadyInUse
+	}
+	r.seq++
+	user.ID = fmt.Sprintf("u_%d", r.seq)
+	clone := *user
+	r.users[user.ID] = &clone
+	return nil
+}
+
+func (r *memoryUserRepo) CreateWithIdentity(ctx context.Context, user *models.User, provider, subject string) error {
+	if r.beforeCreate != nil {
+		r.beforeCreate()
+	}
+	if _, ok := r.identities[[2]string{provider, subject}]; ok {
+		return models.ErrIdentityLinked
+	}
+	if err := r.Create(ctx, user); err != nil {
+		return err
+	}
+	return r.LinkIdentity(ctx, provider, subject, user.ID)
+}
+
+func (r *memoryUserRepo) Update(_ context.Context, user *models.User) error {
+	if _, ok := r.users[user.ID]; !ok {
+		return ErrUserNotFound
+	}
+	if r.emailTaken(user.Email, user.ID) {
+		return models.ErrEmailAlreadyInUse
+	}
+	clone := *user
+	r.users[user.ID] = &clone
+	return nil
+}
+
+func (r *memoryUserRepo) emailTaken(email, exceptID string) bool {
+	for id, u := range r.users {
+		if u.Email == email && id != exceptID {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *memoryUserRepo) FindByIdentity(ctx context.Context, provider, subject string) (*models.User, error) {
+	id, ok := r.identities[[2]string{provider, subject}]
+	if !ok {
+		return nil, nil
+	}
+	return r.FindByID(ctx, id)
+}
+
+func (r *memoryUserRepo) LinkIdentity(_ context.Context, provider, subject, userID string) error {
+	key := [2]string{provider, subject}
+	if _, ok := r.identities[key]; !ok {
+		r.identities[key] = userID
+	}
+	return nil
+}
+
+func (r *memoryUserRepo) ListIdentities(_ context.Context, userID string) ([]models.UserIdentity, error) {
+	var result []models.UserIdentity
+	for key, id := range r.identities {
+		if id == userID {
+			result = append(result, models.UserIdentity{Provider: key[0], Subject: key[1], UserID: id})
+		}
+	}
+	return result, nil
+}
+
+func (r *memoryUserRepo) UnlinkIdentity(_ context.Context, provider, subject, userID string) (bool, error) {
+	key := [2]string{provider, subject}
+	if r.identities[key] != userID {
+		return false, nil
+	}
+	delete(r.identities, key)
+	return true, nil
+}
+
+type nopAudit struct{}
+
+func (nopAudit) Record(context.Context, string, string, string, any, any) {}
+
+func newTestAuthService(repo *memoryUserRepo) *AuthService {
+	roles := RoleMapping{Groups: map[string]models.Role{"wms-managers": models.RoleManager}}
+	return NewAuthService(repo, nil, nil, nopAudit{}, roles, NewOIDCAuthenticator(OIDCConfig{}, nil))
+}
+
+func oidcIdentity(subject, email string) *Identity {
+	return &Identity{Provider: "oidc", Subject: subject, Email: email, Groups: []string{"wms-managers"}}
+}
+
+func TestProvisionUserCreatesAndLinksNewUser(t *testing.T) {
+	repo := newMemoryUserRepo()
+	s := newTestAuthService(repo)
+
+	user, err := s.provisionUser(context.Background(), oidcIdentity("sub-1", "new@example.com"))
+	if err != nil {
+		t.Fatalf("provisionUser: %v", err)
+	}
+	if user.Email != "new@example.com" || user.Role != models.RoleManager {
+		t.Fatalf("unexpected user: %+v", user)
+	}
+	if repo.identities[[2]string{"oidc", "sub-1"}] != user.ID {
+		t.Fatalf("identity is not linked to the new user")
+	}
+
+	again, err := s.provisionUser(context.Background(), oidcIdentity("sub-1", "new@example.com"))
+	if err != nil || again.ID != user.ID {
+		t.Fatalf("second login: user %+v, err %v", again, err)
+	}
+}
+
+func TestProvisionUserDoesNotLinkExistingEmail(t *testing.T) {
+	admin := models.NewUser("u_admin", "boss@example.com", "hash", models.RoleAdmin)
+	repo := newMemoryUserRepo(admin)
+	s := newTestAuthService(repo)
+
+	_, err := s.provisionUser(context.Background(), oidcIdentity("attacker", "boss@example.com"))
+	if !errors.Is(err, ErrIdentityNotLinked) {
+		t.Fatalf("expected ErrIdentityNotLinked, got %v", err)
+	}
+	if len(repo.identities) != 0 {
+		t.Fatalf("identity must not be linked implicitly: %v", repo.identities)
+	}
+	if repo.users["u_admin"].Role != models.RoleAdmin {
+		t.Fatalf("existing user role was changed to %s", repo.users["u_admin"].Role)
+	}
+}
+
+func TestProvisionUserConcurrentFirstLogin(t *testing.T) {
+	repo := newMemoryUserRepo()
+	s := newTestAuthService(repo)
+	ctx := context.Background()
+
+	// Повторный callback того же входа успел создать и привязать пользователя между проверкой и вставкой.
+	var first *models.User
+	repo.beforeCreate = func() {
+		repo.beforeCreate = nil
+		first = models.NewUser("", "new@example.com", "", models.RoleManager)
+		if err := repo.CreateWithIdentity(ctx, first, "oidc", "sub-1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	user, err := s.provisionUser(ctx, oidcIdentity("sub-1", "new@example.com"))
+	if err != nil || user.ID != first.ID {
+		t.Fatalf("provisionUser = %+v, %v; want the user %s created by the concurrent login", user, err, first.ID)
+	}
+	if len(repo.users) != 1 {
+		t.Fatalf("%d users created, want 1", len(repo.users))
+	}
+}
+
+func TestProvisionUserKeepsEmailTakenByAnotherUser(t *testing.T) {
+	repo := newMemoryUserRepo(
+		models.NewUser("u_1", "alice@corp.example", "", models.RoleStorekeeper),
+		models.NewUser("u_2", "alice@example.com", "hash", models.RoleStorekeeper),
+	)
+	s := newTestAuthService(repo)
+	ctx := context.Background()
+	if _, err := s.LinkIdentity(ctx, "u_1", "oidc", "sub-alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	// У провайдера адрес, который локально принадлежит u_2: вход проходит, email u_1 не меняется, роль синхронизируется.
+	for i := 0; i < 2; i++ {
+		user, err := s.provisionUser(ctx, oidcIdentity("sub-alice", "alice@example.com"))
+		if err != nil {
+			t.Fatalf("login %d: %v", i+1, err)
+		}
+		if user.ID != "u_1" || user.Email != "alice@corp.example" || user.Role != models.RoleManager {
+			t.Fatalf("login %d: unexpected user %+v", i+1, user)
+		}
+	}
+	if got := repo.users["u_1"]; got.Email != "alice@corp.example" || got.Role != models.RoleManager {
+		t.Fatalf("stored user = %+v", got)
+	}
+}
+
+func TestLinkIdentityAllowsLoginForExistingUser(t *testing.T) {
+	local := models.NewUser("u_1", "alice@example.com", "hash", models.RoleStorekeeper)
+	repo := newMemoryUserRepo(local)
+	s := newTestAuthService(repo)
+	ctx := context.Background()
+
+	if _, err := s.LinkIdentity(ctx, "u_1", "oidc", "sub-alice"); err != nil {
+		t.Fatalf("LinkIdentity: %v", err)
+	}
+
+	user, err := s.provisionUser(ctx, oidcIdentity("sub-alice", "alice@example.com"))
+	if err != nil {
+		t.Fatalf("provisionUser: %v", err)
+	}
+	if user.ID != "u_1" || user.Role != models.RoleManager {
+		t.Fatalf("unexpected user after linked login: %+v", user)
+	}
+
+	if err := s.UnlinkIdentity(ctx, "u_1", "oidc", "sub-alice"); err != nil {
+		t.Fatalf("UnlinkIdentity: %v", err)
+	}
+	if _, err := s.provisionUser(ctx, oidcIdentity("sub-alice", "alice@example.com")); !errors.Is(err, ErrIdentityNotLinked) {
+		t.Fatalf("expected ErrIdentityNotLinked after unlink, got %v", err)
+	}
+}
+
+func TestLinkIdentityValidation(t *testing.T) {
+	repo := newMemoryUserRepo(
+		models.NewUser("u_1", "a@example.com", "hash", models.RoleManager),
+		models.NewUser("u_2", "b@example.com", "hash", models.RoleManager),
+	)
+	s := newTestAuthService(repo)
+	ctx := context.Background()
+
+	if _, err := s.LinkIdentity(ctx, "u_1", "oidc", "sub-1"); err != nil {
+		t.Fatalf("LinkIdentity: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		userID   string
+		provider string
+		subject  string
+		want     error
+	}{
+		{name: "linked to another user", userID: "u_2", provider: "oidc", subject: "sub-1", want: ErrIdentityLinked},
+		{name: "local provider", userID: "u_2", provider: LocalProvider, subject: "u_2", want: ErrUnknownProvider},
+		{name: "unknown provider", userID: "u_2", provider: "saml", subject: "x", want: ErrUnknownProvider},
+		{name: "empty subject", userID: "u_2", provider: "oidc", subject: "", want: ErrInvalidIdentity},
+		{name: "unknown user", userID: "u_404", provider: "oidc", subject: "sub-2", want: ErrUserNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.LinkIdentity(ctx, tt.userID, tt.provider, tt.subject); !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+
+	if _, err := s.LinkIdentity(ctx, "u_1", "oidc", "sub-1"); err != nil {
+		t.Fatalf("relinking to the same user must be idempotent: %v", err)
+	}
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"warehouse-management-system/src/models"
+)
+
+// LocalProvider — имя встроенного провайдера (таблица users + bcrypt).
+const LocalProvider = "local"
+
+// Identity — результат успешной проверки учётных данных провайдером.
+// Subject уникален в рамках провайдера (для local — ID пользователя, для LDAP — DN, для OIDC — claim sub).
+type Identity struct {
+	Provider string
+	Subject  string
+	Email    string
+	Groups   []string
+}
+
+// Authenticator — общий интерфейс провайдера аутентификации.
+// Конкретный провайдер реализует PasswordAuthenticator и/или RedirectAuthenticator.
+type Authenticator interface {
+	Name() string
+}
+
+// PasswordAuthenticator проверяет пару логин/пароль (локальная БД, LDAP bind).
+type PasswordAuthenticator interface {
+	Authenticator
+	AuthenticatePassword(ctx context.Context, login, password string) (*Identity, error)
+}
+
+// RedirectAuthenticator реализует вход через браузерный редирект к внешнему провайдеру
+// (OIDC authorization code flow).
+type RedirectAuthenticator interface {
+	Authenticator
+	// AuthCodeURL возвращает адрес страницы входа провайдера.
+	AuthCodeURL(ctx context.Context, state, nonce string) (string, error)
+	// Exchange обменивает код авторизации на проверенную личность пользователя.
+	Exchange(ctx context.Context, code, nonce string) (*Identity, error)
+}
+
+var (
+	ErrUnknownProvider = errors.New("unknown authentication provider")
+	ErrNoRoleMapping   = errors.New("user groups are not mapped to any role")
+	// ErrIdentityNotLinked — email внешней учётной записи занят пользователем, к которому она не привязана.
+	ErrIdentityNotLinked = errors.New("external account is not linked to the existing user with this email; ask an administrator to link it")
+	ErrIdentityLinked    = models.ErrIdentityLinked
+	ErrIdentityNotFound  = errors.New("identity link not found")
+	ErrInvalidIdentity   = errors.New("provider and subject are required")
+)
+
+// RoleMapping сопоставляет группы внешнего провайдера ролям приложения.
+// Ключи Groups сравниваются без учёта регистра; если групп несколько, выбирается роль с наибольшими правами.
+type RoleMapping struct {
+	Groups      map[string]models.Role
+	DefaultRole models.Role
+}
+
+// rolePriority задаёт порядок ролей по объёму прав.
+var rolePriority = map[models.Role]int{
+	models.RoleStorekeeper: 1,
+	models.RoleManager:     2,
+	models.RoleAdmin:       3,
+}
+
+// Resolve возвращает роль для набора групп. ok=false, если ни одна группа не сопоставлена
+// и роль по умолчанию не задана.
+func (m RoleMapping) Resolve(groups []string) (models.Role, bool) {
+	var best models.Role
+	for _, g := range groups {
+		role, found := m.lookup(g)
+		if found && rolePriority[role] > rolePriority[best] {
+			best = role
+		}
+	}
+	if best != "" {
+		return best, true
+	}
+	if m.DefaultRole != "" {
+		return m.DefaultRole, true
+	}
+	return "", false
+}
+
+func (m RoleMapping) lookup(group string) (models.Role, bool) {
+	for name, role := range m.Groups {
+		if strings.EqualFold(name, group) {
+			return role, true
+		}
+	}
+	return "", false
+}
+
+// localAuthenticator — провайдер на основе таблицы users и bcrypt.
+type localAuthenticator struct {
+	userRepo UserRepository
+}
+
+func (a *localAuthenticator) Name() string {
+	return LocalProvider
+}
+
+func (a *localAuthenticator) AuthenticatePassword(ctx context.Context, email, password string) (*Identity, error) {
+	user, err := a.userRepo.FindByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || user.PasswordHash == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	if err := comparePassword(user.PasswordHash, password); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	return &Identity{
+		Provider: LocalProvider,
+		Subject:  user.ID,
+		Email:    user.Email,
+	}, nil
+}
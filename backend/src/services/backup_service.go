@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"warehouse-management-system/src/models"
+)
+
+// BackupStore описывает создание резервных копий и проверку целостности БД.
+// Реализуется config.BackupManager (только для SQLite).
+type BackupStore interface {
+	Create(ctx context.Context) (*models.BackupInfo, error)
+	List() ([]models.BackupInfo, error)
+	IntegrityCheck(ctx context.Context) ([]string, error)
+}
+
+// BackupService — операции администратора над резервными копиями базы данных.
+type BackupService struct {
+	store BackupStore
+	audit AuditRecorder
+}
+
+// NewBackupService — конструктор сервиса резервного копирования.
+// store может быть nil, если драйвер БД не поддерживает встроенные копии (PostgreSQL).
+func NewBackupService(store BackupStore, audit AuditRecorder) *BackupService {
+	return &BackupService{
+		store: store,
+		audit: audit,
+	}
+}
+
+var ErrBackupUnsupported = errors.New("backups are not supported for this database driver, use pg_dump")
+
+// CreateBackup снимает резервную копию работающей БД.
+func (s *BackupService) CreateBackup(ctx context.Context) (*models.BackupInfo, error) {
+	if s.store == nil {
+		return nil, ErrBackupUnsupported
+	}
+	info, err := s.store.Create(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, models.AuditActionBackup, models.AuditEntityDatabase, info.Name, nil, info)
+	return info, nil
+}
+
+// ListBackups возвращает имеющиеся резервные копии, новые первыми.
+func (s *BackupService) ListBackups(ctx context.Context) ([]models.BackupInfo, error) {
+	if s.store == nil {
+		return nil, ErrBackupUnsupported
+	}
+	return s.store.List()
+}
+
+// CheckIntegrity проверяет целостность БД и ссылочную целостность внешних ключей.
+func (s *BackupService) CheckIntegrity(ctx context.Context) (*models.IntegrityReport, error) {
+	if s.store == nil {
+		return nil, ErrBackupUnsupported
+	}
+	problems, err := s.store.IntegrityCheck(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &models.IntegrityReport{OK: len(problems) == 0, Problems: problems}, nil
+}
@@ -0,0 +1,425 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+	"warehouse-management-system/src/models"
+)
+
+// Изображения штрихкодов строятся без внешних библиотек: штрихкод кодируется в последовательность
+// модулей (узких полос), которая рисуется в SVG или PNG. Подписи в PNG выводятся встроенным
+// растровым шрифтом 5×7 (цифры, латиница, кириллица в верхнем регистре), в SVG — текстом.
+
+// BarcodeImageFormat — формат изображения штрихкода.
+type BarcodeImageFormat string
+
+const (
+	BarcodeImageSVG BarcodeImageFormat = "svg"
+	BarcodeImagePNG BarcodeImageFormat = "png"
+)
+
+// BarcodeImageOptions — параметры изображения. Нулевые значения заменяются значениями по умолчанию.
+type BarcodeImageOptions struct {
+	Format BarcodeImageFormat
+	// Scale — ширина модуля (самой узкой полосы) в пикселях.
+	Scale int
+	// Height — высота полос в модулях.
+	Height int
+	// Label добавляет над штрихкодом название товара и SKU — получается этикетка для печати.
+	Label bool
+}
+
+const (
+	defaultBarcodeScale  = 2
+	maxBarcodeScale      = 10
+	defaultBarcodeHeight = 60
+	maxBarcodeHeight     = 300
+	// barcodeQuietZone — светлое поле слева и справа в модулях; без него сканер не находит начало кода.
+	barcodeQuietZone = 11
+)
+
+var ErrInvalidBarcodeImage = errors.New("invalid barcode image options")
+
+// normalize проверяет параметры и подставляет значения по умолчанию.
+func (o BarcodeImageOptions) normalize() (BarcodeImageOptions, error) {
+	if o.Format == "" {
+		o.Format = BarcodeImageSVG
+	}
+	if o.Scale == 0 {
+		o.Scale = defaultBarcodeScale
+	}
+	if o.Height == 0 {
+		o.Height = defaultBarcodeHeight
+	}
+	if (o.Format != BarcodeImageSVG && o.Format != BarcodeImagePNG) ||
+		o.Scale < 1 || o.Scale > maxBarcodeScale || o.Height < 1 || o.Height > maxBarcodeHeight {
+		return o, ErrInvalidBarcodeImage
+	}
+	return o, nil
+}
+
+// ContentType возвращает MIME-тип изображения.
+func (f BarcodeImageFormat) ContentType() string {
+	if f == BarcodeImagePNG {
+		return "image/png"
+	}
+	return "image/svg+xml"
+}
+
+// RenderBarcode рисует штрихкод b товара product в w. Под полосами всегда печатается сам код.
+func RenderBarcode(w io.Writer, product *models.Product, b *models.Barcode, opts BarcodeImageOptions) error {
+	opts, err := opts.normalize()
+	if err != nil {
+		return err
+	}
+	modules, err := barcodeModules(b.Type, b.Code)
+	if err != nil {
+		return err
+	}
+
+	var title []string
+	if opts.Label {
+		title = []string{product.Name, "SKU " + product.SKU}
+		if b.Packaging != models.DefaultBarcodePackaging || b.Quantity != models.DecimalFromInt(1) {
+			title[1] += " · " + b.Packaging + " × " + b.Quantity.String()
+		}
+	}
+	l := newBarcodeLayout(len(modules), opts, len(title))
+
+	if opts.Format == BarcodeImagePNG {
+		return renderBarcodePNG(w, modules, l, title, b.Code)
+	}
+	return renderBarcodeSVG(w, modules, l, title, b.Code)
+}
+
+// barcodeLayout — размеры изображения в пикселях.
+type barcodeLayout struct {
+	scale, width, height int
+	barsTop, barsHeight  int
+	lineHeight           int // высота строки текста (глиф 7 точек + интервал), в пикселях
+}
+
+func newBarcodeLayout(modules int, opts BarcodeImageOptions, titleLines int) barcodeLayout {
+	l := barcodeLayout{scale: opts.Scale, lineHeight: 10 * opts.Scale}
+	l.width = (modules + 2*barcodeQuietZone) * opts.Scale
+	l.barsTop = l.scale*4 + titleLines*l.lineHeight
+	l.barsHeight = opts.Height * opts.Scale
+	l.height = l.barsTop + l.barsHeight + l.lineHeight + l.scale*2
+	return l
+}
+
+func renderBarcodeSVG(w io.Writer, modules []bool, l barcodeLayout, title []string, code string) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`, l.width, l.height, l.width, l.height)
+	fmt.Fprintf(&sb, `<rect width="%d" height="%d" fill="#fff"/><g fill="#000">`, l.width, l.height)
+	for x, n := range barRuns(modules) {
+		if n > 0 {
+			fmt.Fprintf(&sb, `<rect x="%d" y="%d" width="%d" height="%d"/>`, (barcodeQuietZone+x)*l.scale, l.barsTop, n*l.scale, l.barsHeight)
+		}
+	}
+	sb.WriteString(`</g>`)
+
+	fontSize := 8 * l.scale
+	text := func(y int, s string) {
+		fmt.Fprintf(&sb, `<text x="%d" y="%d" font-family="monospace" font-size="%d" text-anchor="middle">%s</text>`,
+			l.width/2, y, fontSize, html.EscapeString(s))
+	}
+	for i, line := range title {
+		text(l.scale*2+(i+1)*l.lineHeight-2*l.scale, line)
+	}
+	text(l.barsTop+l.barsHeight+l.lineHeight, code)
+	sb.WriteString(`</svg>`)
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func renderBarcodePNG(w io.Writer, modules []bool, l barcodeLayout, title []string, code string) error {
+	img := image.NewPaletted(image.Rect(0, 0, l.width, l.height), color.Palette{color.White, color.Black})
+	for x, n := range barRuns(modules) {
+		for dx := 0; dx < n*l.scale; dx++ {
+			for y := l.barsTop; y < l.barsTop+l.barsHeight; y++ {
+				img.SetColorIndex((barcodeQuietZone+x)*l.scale+dx, y, 1)
+			}
+		}
+	}
+	for i, line := range title {
+		drawLabelText(img, l, l.scale*2+i*l.lineHeight, line)
+	}
+	drawLabelText(img, l, l.barsTop+l.barsHeight+l.scale*2, code)
+	return png.Encode(w, img)
+}
+
+// barRuns возвращает для каждого модуля, с которого начинается полоса, её ширину в модулях;
+// для остальных модулей — 0. Соседние чёрные модули рисуются одним прямоугольником.
+func barRuns(modules []bool) []int {
+	runs := make([]int, len(modules))
+	for i := 0; i < len(modules); {
+		if !modules[i] {
+			i++
+			continue
+		}
+		j := i
+		for j < len(modules) && modules[j] {
+			j++
+		}
+		runs[i] = j - i
+		i = j
+	}
+	return runs
+}
+
+// drawLabelText печатает строку шрифтом 5×7, увеличенным в l.scale раз, по центру изображения.
+// Строка, которая не помещается по ширине, обрезается с многоточием.
+func drawLabelText(img *image.Paletted, l barcodeLayout, top int, s string) {
+	const advance = 6 // ширина глифа с интервалом
+	maxChars := l.width / (advance * l.scale)
+	if n := utf8.RuneCountInString(s); n > maxChars {
+		if maxChars <= 3 {
+			return
+		}
+		s = string([]rune(s)[:maxChars-3]) + "..."
+	}
+	x0 := (l.width - utf8.RuneCountInString(s)*advance*l.scale) / 2
+	for i, r := range []rune(s) {
+		glyph := labelGlyph(r)
+		for row, bits := range glyph {
+			for col := 0; col < 5; col++ {
+				if bits&(0b10000>>col) == 0 {
+					continue
+				}
+				for dy := 0; dy < l.scale; dy++ {
+					for dx := 0; dx < l.scale; dx++ {
+						img.SetColorIndex(x0+(i*advance+col)*l.scale+dx, top+row*l.scale+dy, 1)
+					}
+				}
+			}
+		}
+	}
+}
+
+// barcodeModules кодирует штрихкод в последовательность модулей (true — полоса) без светлых полей.
+func barcodeModules(t models.BarcodeType, code string) ([]bool, error) {
+	if err := models.ValidateBarcode(t, code); err != nil {
+		return nil, err
+	}
+	switch t {
+	case models.BarcodeEAN13:
+		return ean13Modules(code), nil
+	case models.BarcodeUPCA:
+		// UPC-A — это EAN-13 с ведущим нулём: полосы совпадают.
+		return ean13Modules("0" + code), nil
+	default:
+		return code128Modules(code), nil
+	}
+}
+
+// Кодировки цифр EAN: набор L (нечётная чётность), набор R — инверсия L, набор G — R задом наперёд.
+var eanLCodes = [10]string{"0001101", "0011001", "0010011", "0111101", "0100011", "0110001", "0101111", "0111011", "0110111", "0001011"}
+
+// eanParity задаёт по первой цифре EAN-13, какие из шести цифр левой половины кодируются набором G.
+var eanParity = [10]string{"LLLLLL", "LLGLGG", "LLGGLG", "LLGGGL", "LGLLGG", "LGGLLG", "LGGGLL", "LGLGLG", "LGLGGL", "LGGLGL"}
+
+func ean13Modules(code string) []bool {
+	var sb strings.Builder
+	sb.WriteString("101")
+	parity := eanParity[code[0]-'0']
+	for i := 1; i <= 6; i++ {
+		l := eanLCodes[code[i]-'0']
+		if parity[i-1] == 'G' {
+			l = reverseBits(invertBits(l))
+		}
+		sb.WriteString(l)
+	}
+	sb.WriteString("01010")
+	for i := 7; i <= 12; i++ {
+		sb.WriteString(invertBits(eanLCodes[code[i]-'0']))
+	}
+	sb.WriteString("101")
+	return bitsToModules(sb.String())
+}
+
+// code128Widths — ширины полос и пробелов (чередуются, начиная с полосы) символов Code 128 по их значению.
+var code128Widths = [107]string{
+	"212222", "222122", "222221", "121223", "121322", "131222", "122213", "122312", "132212", "221213",
+	"221312", "231212", "112232", "122132", "122231", "113222", "123122", "123221", "223211", "221132",
+	"221231", "213212", "223112", "312131", "311222", "321122", "321221", "312212", "322112", "322211",
+	"212123", "212321", "232121", "111323", "131123", "131321", "112313", "132113", "132311", "211313",
+	"231113", "231311", "112133", "112331", "132131", "113123", "113321", "133121", "313121", "211331",
+	"231131", "213113", "213311", "213131", "311123", "311321", "331121", "312113", "312311", "332111",
+	"314111", "221411", "431111", "111224", "111422", "121124", "121421", "141122", "141221", "112214",
+	"112412", "122114", "122411", "142112", "142211", "241211", "221114", "413111", "241112", "134111",
+	"111242", "121142", "121241", "114212", "124112", "124211", "411212", "421112", "421211", "212141",
+	"214121", "412121", "111143", "111341", "131141", "114113", "114311", "411113", "411311", "113141",
+	"114131", "311141", "411131", "211412", "211214", "211232", "2331112",
+}
+
+const (
+	code128StartB = 104
+	code128StartC = 105
+	code128Stop   = 106
+)
+
+// code128Modules кодирует строку набором C (пары цифр), если она состоит из чётного числа цифр,
+// иначе набором B (печатные символы ASCII).
+func code128Modules(code string) []bool {
+	var values []int
+	if len(code)%2 == 0 && strings.Trim(code, "0123456789") == "" {
+		values = append(values, code128StartC)
+		for i := 0; i < len(code); i += 2 {
+			values = append(values, int(code[i]-'0')*10+int(code[i+1]-'0'))
+		}
+	} else {
+		values = append(values, code128StartB)
+		for i := 0; i < len(code); i++ {
+			values = append(values, int(code[i])-' ')
+		}
+	}
+
+	checksum := values[0]
+	for i := 1; i < len(values); i++ {
+		checksum += i * values[i]
+	}
+	values = append(values, checksum%103, code128Stop)
+
+	var modules []bool
+	for _, v := range values {
+		for i, w := range code128Widths[v] {
+			for n := 0; n < int(w-'0'); n++ {
+				modules = append(modules, i%2 == 0)
+			}
+		}
+	}
+	return modules
+}
+
+func invertBits(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '0' {
+			return '1'
+		}
+		return '0'
+	}, s)
+}
+
+func reverseBits(s string) string {
+	b := []byte(s)
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return string(b)
+}
+
+func bitsToModules(s string) []bool {
+	modules := make([]bool, len(s))
+	for i := range s {
+		modules[i] = s[i] == '1'
+	}
+	return modules
+}
+
+// labelGlyph возвращает глиф символа; строчные буквы печатаются заглавными, неизвестные символы — «?».
+func labelGlyph(r rune) [7]uint8 {
+	r = unicode.ToUpper(r)
+	if alias, ok := labelFontAliases[r]; ok {
+		r = alias
+	}
+	if g, ok := labelFont[r]; ok {
+		return g
+	}
+	return labelFont['?']
+}
+
+// labelFontAliases — кириллические буквы, совпадающие по начертанию с латинскими.
+var labelFontAliases = map[rune]rune{
+	'А': 'A', 'В': 'B', 'Е': 'E', 'Ё': 'E', 'К': 'K', 'М': 'M', 'Н': 'H',
+	'О': 'O', 'Р': 'P', 'С': 'C', 'Т': 'T', 'Х': 'X', '×': 'X', '·': '.',
+}
+
+// labelFont — растровый шрифт 5×7: семь строк по пять точек, старший бит — левая точка.
+var labelFont = map[rune][7]uint8{
+	' ':  {},
+	'0':  {0b01110, 0b10001, 0b10011, 0b10101, 0b11001, 0b10001, 0b01110},
+	'1':  {0b00100, 0b01100, 0b00100, 0b00100, 0b00100, 0b00100, 0b01110},
+	'2':  {0b01110, 0b10001, 0b00001, 0b00010, 0b00100, 0b01000, 0b11111},
+	'3':  {0b11111, 0b00010, 0b00100, 0b00010, 0b00001, 0b10001, 0b01110},
+	'4':  {0b00010, 0b00110, 0b01010, 0b10010, 0b11111, 0b00010, 0b00010},
+	'5':  {0b11111, 0b10000, 0b11110, 0b00001, 0b00001, 0b10001, 0b01110},
+	'6':  {0b00110, 0b01000, 0b10000, 0b11110, 0b10001, 0b10001, 0b01110},
+	'7':  {0b11111, 0b00001, 0b00010, 0b00100, 0b01000, 0b01000, 0b01000},
+	'8':  {0b01110, 0b10001, 0b10001, 0b01110, 0b10001, 0b10001, 0b01110},
+	'9':  {0b01110, 0b10001, 0b10001, 0b01111, 0b00001, 0b00010, 0b01100},
+	'A':  {0b01110, 0b10001, 0b10001, 0b11111, 0b10001, 0b10001, 0b10001},
+	'B':  {0b11110, 0b10001, 0b10001, 0b11110, 0b10001, 0b10001, 0b11110},
+	'C':  {0b01110, 0b10001, 0b10000, 0b10000, 0b10000, 0b10001, 0b01110},
+	'D':  {0b11100, 0b10010, 0b10001, 0b10001, 0b10001, 0b10010, 0b11100},
+	'E':  {0b11111, 0b10000, 0b10000, 0b11110, 0b10000, 0b10000, 0b11111},
+	'F':  {0b11111, 0b10000, 0b10000, 0b11110, 0b10000, 0b10000, 0b10000},
+	'G':  {0b01110, 0b10001, 0b10000, 0b10111, 0b10001, 0b10001, 0b01111},
+	'H':  {0b10001, 0b10001, 0b10001, 0b11111, 0b10001, 0b10001, 0b10001},
+	'I':  {0b01110, 0b00100, 0b00100, 0b00100, 0b00100, 0b00100, 0b01110},
+	'J':  {0b00111, 0b00010, 0b00010, 0b00010, 0b00010, 0b10010, 0b01100},
+	'K':  {0b10001, 0b10010, 0b10100, 0b11000, 0b10100, 0b10010, 0b10001},
+	'L':  {0b10000, 0b10000, 0b10000, 0b10000, 0b10000, 0b10000, 0b11111},
+	'M':  {0b10001, 0b11011, 0b10101, 0b10101, 0b10001, 0b10001, 0b10001},
+	'N':  {0b10001, 0b10001, 0b11001, 0b10101, 0b10011, 0b10001, 0b10001},
+	'O':  {0b01110, 0b10001, 0b10001, 0b10001, 0b10001, 0b10001, 0b01110},
+	'P':  {0b11110, 0b10001, 0b10001, 0b11110, 0b10000, 0b10000, 0b10000},
+	'Q':  {0b01110, 0b10001, 0b10001, 0b10001, 0b10101, 0b10010, 0b01101},
+	'R':  {0b11110, 0b10001, 0b10001, 0b11110, 0b10100, 0b10010, 0b10001},
+	'S':  {0b01111, 0b10000, 0b10000, 0b01110, 0b00001, 0b00001, 0b11110},
+	'T':  {0b11111, 0b00100, 0b00100, 0b00100, 0b00100, 0b00100, 0b00100},
+	'U':  {0b10001, 0b10001, 0b10001, 0b10001, 0b10001, 0b10001, 0b01110},
+	'V':  {0b10001, 0b10001, 0b10001, 0b10001, 0b10001, 0b01010, 0b00100},
+	'W':  {0b10001, 0b10001, 0b10001, 0b10101, 0b10101, 0b10101, 0b01010},
+	'X':  {0b10001, 0b10001, 0b01010, 0b00100, 0b01010, 0b10001, 0b10001},
+	'Y':  {0b10001, 0b10001, 0b10001, 0b01010, 0b00100, 0b00100, 0b00100},
+	'Z':  {0b11111, 0b00001, 0b00010, 0b00100, 0b01000, 0b10000, 0b11111},
+	'Б':  {0b11111, 0b10000, 0b10000, 0b11110, 0b10001, 0b10001, 0b11110},
+	'Г':  {0b11111, 0b10000, 0b10000, 0b10000, 0b10000, 0b10000, 0b10000},
+	'Д':  {0b00110, 0b01010, 0b01010, 0b01010, 0b01010, 0b11111, 0b10001},
+	'Ж':  {0b10101, 0b10101, 0b10101, 0b01110, 0b10101, 0b10101, 0b10101},
+	'З':  {0b01110, 0b10001, 0b00001, 0b00110, 0b00001, 0b10001, 0b01110},
+	'И':  {0b10001, 0b10001, 0b10011, 0b10101, 0b11001, 0b10001, 0b10001},
+	'Й':  {0b01010, 0b00100, 0b10001, 0b10011, 0b10101, 0b11001, 0b10001},
+	'Л':  {0b00111, 0b01001, 0b01001, 0b01001, 0b01001, 0b01001, 0b10001},
+	'П':  {0b11111, 0b10001, 0b10001, 0b10001, 0b10001, 0b10001, 0b10001},
+	'У':  {0b10001, 0b10001, 0b10001, 0b01111, 0b00001, 0b10001, 0b01110},
+	'Ф':  {0b00100, 0b01110, 0b10101, 0b10101, 0b10101, 0b01110, 0b00100},
+	'Ц':  {0b10010, 0b10010, 0b10010, 0b10010, 0b10010, 0b11111, 0b00001},
+	'Ч':  {0b10001, 0b10001, 0b10001, 0b01111, 0b00001, 0b00001, 0b00001},
+	'Ш':  {0b10101, 0b10101, 0b10101, 0b10101, 0b10101, 0b10101, 0b11111},
+	'Щ':  {0b10101, 0b10101, 0b10101, 0b10101, 0b10101, 0b11111, 0b00001},
+	'Ъ':  {0b11000, 0b01000, 0b01000, 0b01110, 0b01001, 0b01001, 0b01110},
+	'Ы':  {0b10001, 0b10001, 0b10001, 0b11101, 0b10011, 0b10011, 0b11101},
+	'Ь':  {0b10000, 0b10000, 0b10000, 0b11110, 0b10001, 0b10001, 0b11110},
+	'Э':  {0b01110, 0b10001, 0b00001, 0b00111, 0b00001, 0b10001, 0b01110},
+	'Ю':  {0b10010, 0b10101, 0b10101, 0b11101, 0b10101, 0b10101, 0b10010},
+	'Я':  {0b01111, 0b10001, 0b10001, 0b01111, 0b00101, 0b01001, 0b10001},
+	'-':  {0, 0, 0, 0b11111, 0, 0, 0},
+	'.':  {0, 0, 0, 0, 0, 0b01100, 0b01100},
+	',':  {0, 0, 0, 0, 0b01100, 0b00100, 0b01000},
+	'/':  {0b00001, 0b00010, 0b00010, 0b00100, 0b01000, 0b01000, 0b10000},
+	':':  {0, 0b01100, 0b01100, 0, 0b01100, 0b01100, 0},
+	'(':  {0b00010, 0b00100, 0b01000, 0b01000, 0b01000, 0b00100, 0b00010},
+	')':  {0b01000, 0b00100, 0b00010, 0b00010, 0b00010, 0b00100, 0b01000},
+	'+':  {0, 0b00100, 0b00100, 0b11111, 0b00100, 0b00100, 0},
+	'=':  {0, 0, 0b11111, 0, 0b11111, 0, 0},
+	'*':  {0, 0b00100, 0b10101, 0b01110, 0b10101, 0b00100, 0},
+	'#':  {0b01010, 0b01010, 0b11111, 0b01010, 0b11111, 0b01010, 0b01010},
+	'%':  {0b11000, 0b11001, 0b00010, 0b00100, 0b01000, 0b10011, 0b00011},
+	'&':  {0b01100, 0b10010, 0b10100, 0b01000, 0b10101, 0b10010, 0b01101},
+	'_':  {0, 0, 0, 0, 0, 0, 0b11111},
+	'!':  {0b00100, 0b00100, 0b00100, 0b00100, 0b00100, 0, 0b00100},
+	'?':  {0b01110, 0b10001, 0b00001, 0b00010, 0b00100, 0, 0b00100},
+	'"':  {0b01010, 0b01010, 0b01010, 0, 0, 0, 0},
+	'\'': {0b00100, 0b00100, 0b00100, 0, 0, 0, 0},
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"warehouse-management-system/src/models"
+)
+
+// modulesString записывает модули как "1" (полоса) и "0" (пробел).
+func modulesString(modules []bool) string {
+	var sb strings.Builder
+	for _, m := range modules {
+		if m {
+			sb.WriteByte('1')
+		} else {
+			sb.WriteByte('0')
+		}
+	}
+	return sb.String()
+}
+
+func TestEANModules(t *testing.T) {
+	// Ожидаемые полосы собраны по таблицам наборов L, G и R из спецификации GS1, а не по коду рендера.
+	tests := []struct {
+		typ  models.BarcodeType
+		code string
+		want string
+	}{
+		{
+			typ:  models.BarcodeEAN13,
+			code: "4006381333931", // первая цифра 4 — чётность LGLLGG
+			want: "101" + "0001101" + "0100111" + "0101111" + "0111101" + "0001001" + "0110011" +
+				"01010" + "1000010" + "1000010" + "1000010" + "1110100" + "1000010" + "1100110" + "101",
+		},
+		{
+			typ:  models.BarcodeUPCA,
+			code: "036000291452", // UPC-A — EAN-13 с ведущим нулём, левая половина целиком набором L
+			want: "101" + "0001101" + "0111101" + "0101111" + "0001101" + "0001101" + "0001101" +
+				"01010" + "1101100" + "1110100" + "1100110" + "1011100" + "1001110" + "1101100" + "101",
+		},
+	}
+	for _, tt := range tests {
+		modules, err := barcodeModules(tt.typ, tt.code)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.code, err)
+		}
+		if got := modulesString(modules); got != tt.want {
+			t.Errorf("%s %s:\n got %s\nwant %s", tt.typ, tt.code, got, tt.want)
+		}
+	}
+
+	alias, _ := barcodeModules(models.BarcodeEAN13, "0036000291452")
+	upc, _ := barcodeModules(models.BarcodeUPCA, "036000291452")
+	if modulesString(alias) != modulesString(upc) {
+		t.Error("UPC-A and its 13-digit alias render differently")
+	}
+	if _, err := barcodeModules(models.BarcodeEAN13, "4006381333932"); err != models.ErrBarcodeChecksum {
+		t.Errorf("bad check digit: got %v, want ErrBarcodeChecksum", err)
+	}
+}
+
+func TestCode128Modules(t *testing.T) {
+	const (
+		startB = "11010010000"
+		startC = "11010011100"
+		stop   = "1100011101011"
+	)
+	tests := []struct {
+		code  string
+		start string
+		check string // контрольный символ
+		count int    // модулей: по 11 на старт, данные и контрольный символ, 13 на стоп
+	}{
+		// Набор B: 104 + 1·55 (W) + 2·73 (i) + ... + 9·65 (a) = 3281, 3281 mod 103 = 88 — символ 421211.
+		{code: "Wikipedia", start: startB, check: "11110010010", count: (1+9+1)*11 + 13},
+		// Набор C: 105 + 1·12 + 2·34 + 3·56 = 353, 353 mod 103 = 44 — символ 132131.
+		{code: "123456", start: startC, check: "10001101110", count: (1+3+1)*11 + 13},
+	}
+	for _, tt := range tests {
+		modules, err := barcodeModules(models.BarcodeCode128, tt.code)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.code, err)
+		}
+		got := modulesString(modules)
+		if len(got) != tt.count {
+			t.Fatalf("%s: %d modules, want %d", tt.code, len(got), tt.count)
+		}
+		if !strings.HasPrefix(got, tt.start) || !strings.HasSuffix(got, stop) {
+			t.Errorf("%s: wrong start or stop symbol: %s", tt.code, got)
+		}
+		if check := got[len(got)-len(stop)-11 : len(got)-len(stop)]; check != tt.check {
+			t.Errorf("%s: check symbol %s, want %s", tt.code, check, tt.check)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"warehouse-management-system/src/models"
+)
+
+// BarcodeRepository описывает поведение хранилища штрихкодов для слоя сервисов.
+type BarcodeRepository interface {
+	GetByProduct(ctx context.Context, productID string) ([]*models.Barcode, error)
+	GetByCode(ctx context.Context, code string) (*models.Barcode, error)
+	Create(ctx context.Context, b *models.Barcode) error
+	Delete(ctx context.Context, code string) error
+}
+
+// BarcodeService инкапсулирует бизнес-логику штрихкодов товаров.
+type BarcodeService struct {
+	repo        BarcodeRepository
+	productRepo ProductRepository
+	units       UnitRepository
+	audit       AuditRecorder
+}
+
+// NewBarcodeService — конструктор сервиса штрихкодов.
+func NewBarcodeService(repo BarcodeRepository, productRepo ProductRepository, units UnitRepository, audit AuditRecorder) *BarcodeService {
+	return &BarcodeService{repo: repo, productRepo: productRepo, units: units, audit: audit}
+}
+
+var (
+	ErrBarcodeNotFound    = errors.New("barcode not found")
+	ErrBarcodeAlreadyUsed = errors.New("barcode is already assigned to a product")
+	ErrInvalidPackaging   = errors.New("packaging quantity must be positive")
+	ErrPackagingMismatch  = errors.New("packaging quantity does not match the unit conversion of the product")
+)
+
+// inStoreEANPrefix — префикс внутренних EAN-13: коды 200–299 GS1 оставляет для использования
+// внутри организации, поэтому сгенерированный код не совпадёт с кодом производителя.
+const inStoreEANPrefix = "200"
+
+// maxBarcodeGenerateAttempts — сколько раз пробуем сгенерировать незанятый код.
+const maxBarcodeGenerateAttempts = 10
+
+// ListBarcodes возвращает штрихкоды товара.
+func (s *BarcodeService) ListBarcodes(ctx context.Context, productID string) ([]*models.Barcode, error) {
+	product, err := s.getProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetByProduct(ctx, product.ID)
+}
+
+// AddBarcode добавляет товару штрихкод для упаковки packaging, содержащей quantity единиц товара.
+// Если packaging — единица измерения (box, pallet, ...), она должна быть базовой или дополнительной
+// единицей товара, а quantity берётся из её коэффициента; указанное иное количество — ErrPackagingMismatch.
+// Пустой barcodeType определяется по виду кода. Если code пуст, генерируется внутренний EAN-13.
+func (s *BarcodeService) AddBarcode(ctx context.Context, productID, code string, barcodeType models.BarcodeType, packaging string, quantity models.Decimal) (*models.Barcode, error) {
+	product, err := s.getProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if product.ArchivedAt != nil {
+		return nil, ErrProductArchived
+	}
+
+	code = strings.TrimSpace(code)
+	packaging = strings.TrimSpace(packaging)
+	if packaging == "" {
+		packaging = models.DefaultBarcodePackaging
+	}
+	if quantity < 0 {
+		return nil, ErrInvalidPackaging
+	}
+	if unit := normalizeUnit(models.UnitOfMeasure(packaging)); unit.Valid() {
+		conversions, err := s.units.GetByProduct(ctx, product.ID)
+		if err != nil {
+			return nil, err
+		}
+		factor, err := models.UnitFactor(product.Unit, unit, unitMap(conversions))
+		if err != nil {
+			return nil, unitError(err)
+		}
+		if quantity != 0 && quantity != factor {
+			return nil, ErrPackagingMismatch
+		}
+		packaging, quantity = string(unit), factor
+	}
+	if quantity == 0 {
+		quantity = models.DecimalFromInt(1)
+	}
+	if !quantity.HasPrecision(product.Unit.Precision()) {
+		return nil, ErrQuantityPrecision
+	}
+
+	if code == "" {
+		if barcodeType != "" && barcodeType != models.BarcodeEAN13 {
+			return nil, models.ErrInvalidBarcode
+		}
+		if code, err = s.generateEAN13(ctx); err != nil {
+			return nil, err
+		}
+		barcodeType = models.BarcodeEAN13
+	}
+	if barcodeType == "" {
+		barcodeType = models.DetectBarcodeType(code)
+	}
+	if err := models.ValidateBarcode(barcodeType, code); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.findByCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrBarcodeAlreadyUsed
+	}
+
+	barcode := &models.Barcode{
+		Code:      code,
+		ProductID: product.ID,
+		Type:      barcodeType,
+		Packaging: packaging,
+		Quantity:  quantity,
+	}
+	if err := s.repo.Create(ctx, barcode); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, models.AuditActionCreate, models.AuditEntityBarcode, barcode.Code, nil, barcode)
+	return barcode, nil
+}
+
+// RemoveBarcode удаляет штрихкод товара.
+func (s *BarcodeService) RemoveBarcode(ctx context.Context, productID, code string) error {
+	barcode, err := s.GetBarcode(ctx, productID, code)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, barcode.Code); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, models.AuditActionDelete, models.AuditEntityBarcode, barcode.Code, barcode, nil)
+	return nil
+}
+
+// GetBarcode возвращает штрихкод товара по коду.
+func (s *BarcodeService) GetBarcode(ctx context.Context, productID, code string) (*models.Barcode, error) {
+	barcode, err := s.repo.GetByCode(ctx, strings.TrimSpace(code))
+	if err != nil {
+		return nil, err
+	}
+	if barcode == nil || barcode.ProductID != strings.TrimSpace(productID) {
+		return nil, ErrBarcodeNotFound
+	}
+	return barcode, nil
+}
+
+// BarcodeImage рисует штрихкод товара (или этикетку с ним) в формате opts.Format.
+func (s *BarcodeService) BarcodeImage(ctx context.Context, productID, code string, opts BarcodeImageOptions) ([]byte, error) {
+	barcode, err := s.GetBarcode(ctx, productID, code)
+	if err != nil {
+		return nil, err
+	}
+	product, err := s.getProduct(ctx, barcode.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := RenderBarcode(&buf, product, barcode, opts); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// FindByBarcode ищет товар по отсканированному коду (в том числе архивный — сканер должен узнать
+// и снятый с продажи товар). UPC-A находится и по 13-значной записи с ведущим нулём, и наоборот.
+func (s *BarcodeService) FindByBarcode(ctx context.Context, code string) (*models.Product, *models.Barcode, error) {
+	barcode, err := s.findByCode(ctx, strings.TrimSpace(code))
+	if err != nil {
+		return nil, nil, err
+	}
+	if barcode == nil {
+		return nil, nil, ErrBarcodeNotFound
+	}
+	product, err := s.getProduct(ctx, barcode.ProductID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return product, barcode, nil
+}
+
+// findByCode ищет штрихкод под любой из равнозначных записей кода.
+func (s *BarcodeService) findByCode(ctx context.Context, code string) (*models.Barcode, error) {
+	if code == "" {
+		return nil, nil
+	}
+	for _, alias := range models.BarcodeAliases(code) {
+		barcode, err := s.repo.GetByCode(ctx, alias)
+		if err != nil || barcode != nil {
+			return barcode, err
+		}
+	}
+	return nil, nil
+}
+
+// generateEAN13 возвращает незанятый внутренний EAN-13 со случайной частью.
+func (s *BarcodeService) generateEAN13(ctx context.Context) (string, error) {
+	limit := big.NewInt(1_000_000_000) // 9 случайных цифр после префикса
+	for i := 0; i < maxBarcodeGenerateAttempts; i++ {
+		n, err := rand.Int(rand.Reader, limit)
+		if err != nil {
+			return "", err
+		}
+		digits := fmt.Sprintf("%s%09d", inStoreEANPrefix, n.Int64())
+		code := digits + string(models.EANCheckDigit(digits))
+
+		existing, err := s.findByCode(ctx, code)
+		if err != nil {
+			return "", err
+		}
+		if existing == nil {
+			return code, nil
+		}
+	}
+	return "", errors.New("failed to generate a unique barcode")
+}
+
+func (s *BarcodeService) getProduct(ctx context.Context, id string) (*models.Product, error) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return nil, ErrInvalidProduct
+	}
+	product, err := s.productRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, ErrProductNotFound
+	}
+	return product, nil
+}
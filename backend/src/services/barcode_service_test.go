@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"warehouse-management-system/src/config"
+	"warehouse-management-system/src/models"
+	"warehouse-management-system/src/repositories"
+)
+
+func TestBarcodePackagingFollowsProductUnits(t *testing.T) {
+	db, err := config.OpenSQLite(filepath.Join(t.TempDir(), "barcodes.db"), config.SQLiteConfig{JournalMode: "WAL", Synchronous: "NORMAL", ReadConns: 2})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := config.Migrate(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	ctx := context.Background()
+	ids := repositories.NewUUIDv7Generator()
+
+	category := models.NewCategory("Category", "")
+	if err := repositories.NewCategoryRepository(db, ids).Create(ctx, category); err != nil {
+		t.Fatal(err)
+	}
+	productRepo := repositories.NewProductRepository(db, ids)
+	product := models.NewProduct("BOX-1", "Product", "", category.ID, "", models.UnitPiece)
+	if err := productRepo.Create(ctx, product); err != nil {
+		t.Fatal(err)
+	}
+	unitRepo := repositories.NewProductUnitRepository(db)
+	barcodeRepo := repositories.NewBarcodeRepository(db)
+	units := NewUnitService(unitRepo, productRepo, barcodeRepo, nopAudit{})
+	barcodes := NewBarcodeService(barcodeRepo, productRepo, unitRepo, nopAudit{})
+
+	if _, err := barcodes.AddBarcode(ctx, product.ID, "", "", "box", 0); err != models.ErrUnitNotDefined {
+		t.Fatalf("box before the unit is defined: got %v, want ErrUnitNotDefined", err)
+	}
+	if _, err := units.SetUnit(ctx, product.ID, models.UnitBox, models.DecimalFromInt(12), ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := barcodes.AddBarcode(ctx, product.ID, "", "", "box", models.DecimalFromInt(10)); err != ErrPackagingMismatch {
+		t.Fatalf("box of 10 when 1 box = 12 pcs: got %v, want ErrPackagingMismatch", err)
+	}
+	box, err := barcodes.AddBarcode(ctx, product.ID, "", "", "Box", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if box.Packaging != "box" || box.Quantity != models.DecimalFromInt(12) {
+		t.Fatalf("box barcode = %s x %s, want box x 12", box.Packaging, box.Quantity)
+	}
+	// Упаковка, не являющаяся единицей измерения, остаётся произвольной.
+	if b, err := barcodes.AddBarcode(ctx, product.ID, "", "", "blister", models.DecimalFromInt(6)); err != nil || b.Quantity != models.DecimalFromInt(6) {
+		t.Fatalf("blister barcode = %+v, %v", b, err)
+	}
+
+	// Единицы, от которых зависит количество в штрихкоде, не меняются и не удаляются; остальные — можно.
+	if _, err := units.SetUnit(ctx, product.ID, models.UnitBox, models.DecimalFromInt(10), ""); err != ErrUnitHasBarcodes {
+		t.Fatalf("change box to 10 pcs: got %v, want ErrUnitHasBarcodes", err)
+	}
+	if err := units.DeleteUnit(ctx, product.ID, models.UnitBox); err != ErrUnitHasBarcodes {
+		t.Fatalf("delete box: got %v, want ErrUnitHasBarcodes", err)
+	}
+	if _, err := units.SetUnit(ctx, product.ID, models.UnitPallet, models.DecimalFromInt(40), models.UnitBox); err != nil {
+		t.Fatalf("add pallet: %v", err)
+	}
+	if err := barcodes.RemoveBarcode(ctx, product.ID, box.Code); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := units.SetUnit(ctx, product.ID, models.UnitBox, models.DecimalFromInt(10), ""); err != nil {
+		t.Fatalf("change box after its barcode is removed: %v", err)
+	}
+}
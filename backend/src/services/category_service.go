@@ -1,48 +1,83 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"strings"
+	"time"
 	"warehouse-management-system/src/models"
 )
 
 // CategoryRepository описывает поведение хранилища категорий для слоя сервисов.
 type CategoryRepository interface {
-	GetAll() ([]*models.Category, error)
-	GetByID(id string) (*models.Category, error)
-	Create(category *models.Category) error
-	Update(category *models.Category) error
-	Delete(id string) error
+	GetAll(ctx context.Context, includeArchived bool) ([]*models.Category, error)
+	GetByID(ctx context.Context, id string) (*models.Category, error)
+	Create(ctx context.Context, category *models.Category) error
+	Update(ctx context.Context, category *models.Category) error
+	Archive(ctx context.Context, id string, version int64, at time.Time) error
+	Restore(ctx context.Context, id string, version int64, at time.Time) error
+	IsReferenced(ctx context.Context, id string) (bool, error)
+	Delete(ctx context.Context, id string, version int64) error
 }
 
 // CategoryService инкапсулирует бизнес-логику работы с категориями.
 type CategoryService struct {
-	repo CategoryRepository
+	repo  CategoryRepository
+	audit AuditRecorder
 }
 
 // NewCategoryService — конструктор сервиса категорий.
-func NewCategoryService(repo CategoryRepository) *CategoryService {
-	return &CategoryService{repo: repo}
+func NewCategoryService(repo CategoryRepository, audit AuditRecorder) *CategoryService {
+	return &CategoryService{repo: repo, audit: audit}
 }
 
 var (
 	ErrCategoryNotFound = errors.New("category not found")
 	ErrInvalidCategory  = errors.New("invalid category data")
+	ErrCategoryInUse    = errors.New("category has products or subcategories, archive it instead")
+
+	ErrParentCategoryNotFound = errors.New("parent category not found")
+	ErrCategoryCycle          = models.ErrCategoryCycle
 )
 
-// ListCategories возвращает список всех категорий.
-func (s *CategoryService) ListCategories() ([]*models.Category, error) {
-	return s.repo.GetAll()
+// ListCategories возвращает список категорий; заархивированные — только если includeArchived.
+func (s *CategoryService) ListCategories(ctx context.Context, includeArchived bool) ([]*models.Category, error) {
+	return s.repo.GetAll(ctx, includeArchived)
+}
+
+// CategoryTree возвращает категории в виде дерева, упорядоченного по названию на каждом уровне.
+// Если родитель категории не попал в выборку (заархивирован, а includeArchived не задан),
+// категория выводится на верхнем уровне.
+func (s *CategoryService) CategoryTree(ctx context.Context, includeArchived bool) ([]*models.CategoryNode, error) {
+	categories, err := s.repo.GetAll(ctx, includeArchived)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make(map[string]*models.CategoryNode, len(categories))
+	for _, c := range categories {
+		nodes[c.ID] = &models.CategoryNode{Category: c, Children: []*models.CategoryNode{}}
+	}
+	// GetAll отдаёт категории по названию, поэтому дети добавляются в уже упорядоченном виде.
+	roots := []*models.CategoryNode{}
+	for _, c := range categories {
+		if parent, ok := nodes[c.ParentID]; ok {
+			parent.Children = append(parent.Children, nodes[c.ID])
+		} else {
+			roots = append(roots, nodes[c.ID])
+		}
+	}
+	return roots, nil
 }
 
 // GetCategory возвращает категорию по ID.
-func (s *CategoryService) GetCategory(id string) (*models.Category, error) {
+func (s *CategoryService) GetCategory(ctx context.Context, id string) (*models.Category, error) {
 	id = strings.TrimSpace(id)
 	if id == "" {
 		return nil, ErrInvalidCategory
 	}
 
-	c, err := s.repo.GetByID(id)
+	c, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -52,58 +87,184 @@ func (s *CategoryService) GetCategory(id string) (*models.Category, error) {
 	return c, nil
 }
 
-// CreateCategory создаёт новую категорию.
-func (s *CategoryService) CreateCategory(name string) (*models.Category, error) {
+// CreateCategory создаёт новую категорию. Пустой parentID — категория верхнего уровня.
+func (s *CategoryService) CreateCategory(ctx context.Context, name, parentID string) (*models.Category, error) {
 	name = strings.TrimSpace(name)
+	parentID = strings.TrimSpace(parentID)
 
 	if name == "" {
 		return nil, ErrInvalidCategory
 	}
+	if err := s.checkParent(ctx, "", parentID); err != nil {
+		return nil, err
+	}
 
-	category := models.NewCategory(name)
+	category := models.NewCategory(name, parentID)
 
-	if err := s.repo.Create(category); err != nil {
+	if err := s.repo.Create(ctx, category); err != nil {
 		return nil, err
 	}
 
+	s.audit.Record(ctx, models.AuditActionCreate, models.AuditEntityCategory, category.ID, nil, category)
 	return category, nil
 }
 
-// UpdateCategory обновляет существующую категорию.
-func (s *CategoryService) UpdateCategory(id, name string) (*models.Category, error) {
+// UpdateCategory обновляет существующую категорию. parentID == nil оставляет категорию на месте,
+// иначе переносит её вместе с поддеревом под *parentID (пустая строка — на верхний уровень).
+// Если запись изменили после чтения клиентом (expectedVersion из If-Match), возвращается ErrVersionMismatch.
+func (s *CategoryService) UpdateCategory(ctx context.Context, id string, expectedVersion int64, name string, parentID *string) (*models.Category, error) {
 	id = strings.TrimSpace(id)
 	if id == "" {
 		return nil, ErrInvalidCategory
 	}
 
-	category, err := s.repo.GetByID(id)
+	category, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 	if category == nil {
 		return nil, ErrCategoryNotFound
 	}
+	if err := checkVersion(category.Version, expectedVersion); err != nil {
+		return nil, err
+	}
 
 	name = strings.TrimSpace(name)
 	if name == "" {
 		return nil, ErrInvalidCategory
 	}
 
+	before := *category
 	category.Name = name
+	if parentID != nil {
+		newParent := strings.TrimSpace(*parentID)
+		if newParent != category.ParentID {
+			if err := s.checkParent(ctx, category.ID, newParent); err != nil {
+				return nil, err
+			}
+		}
+		category.ParentID = newParent
+	}
 
-	if err := s.repo.Update(category); err != nil {
+	if err := s.repo.Update(ctx, category); err != nil {
 		return nil, err
 	}
 
+	s.audit.Record(ctx, models.AuditActionUpdate, models.AuditEntityCategory, category.ID, before, category)
 	return category, nil
 }
 
-// DeleteCategory удаляет категорию по ID.
-func (s *CategoryService) DeleteCategory(id string) error {
-	id = strings.TrimSpace(id)
-	if id == "" {
-		return ErrInvalidCategory
+// MoveCategory переносит категорию вместе со всеми подкатегориями под parentID
+// (пустая строка — на верхний уровень). Товары остаются в своих категориях.
+func (s *CategoryService) MoveCategory(ctx context.Context, id string, expectedVersion int64, parentID string) (*models.Category, error) {
+	category, err := s.GetCategory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(category.Version, expectedVersion); err != nil {
+		return nil, err
 	}
+	return s.UpdateCategory(ctx, category.ID, category.Version, category.Name, &parentID)
+}
 
-	return s.repo.Delete(id)
+// ArchiveCategory архивирует категорию (мягкое удаление). Повторная архивация ничего не меняет.
+func (s *CategoryService) ArchiveCategory(ctx context.Context, id string, expectedVersion int64) error {
+	category, err := s.GetCategory(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := checkVersion(category.Version, expectedVersion); err != nil {
+		return err
+	}
+	if category.ArchivedAt != nil {
+		return nil
+	}
+
+	before := *category
+	now := time.Now().UTC()
+	if err := s.repo.Archive(ctx, category.ID, category.Version, now); err != nil {
+		return err
+	}
+	category.ArchivedAt = &now
+	category.UpdatedAt = now
+	category.Version++
+
+	s.audit.Record(ctx, models.AuditActionArchive, models.AuditEntityCategory, category.ID, before, category)
+	return nil
+}
+
+// RestoreCategory возвращает заархивированную категорию в работу.
+func (s *CategoryService) RestoreCategory(ctx context.Context, id string, expectedVersion int64) (*models.Category, error) {
+	category, err := s.GetCategory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(category.Version, expectedVersion); err != nil {
+		return nil, err
+	}
+	if category.ArchivedAt == nil {
+		return category, nil
+	}
+
+	before := *category
+	now := time.Now().UTC()
+	if err := s.repo.Restore(ctx, category.ID, category.Version, now); err != nil {
+		return nil, err
+	}
+	category.ArchivedAt = nil
+	category.UpdatedAt = now
+	category.Version++
+
+	s.audit.Record(ctx, models.AuditActionRestore, models.AuditEntityCategory, category.ID, before, category)
+	return category, nil
+}
+
+// DeleteCategory физически удаляет категорию по ID. Категорию с товарами (в том числе архивными)
+// удалить нельзя (ErrCategoryInUse) — её можно только заархивировать.
+func (s *CategoryService) DeleteCategory(ctx context.Context, id string, expectedVersion int64) error {
+	category, err := s.GetCategory(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := checkVersion(category.Version, expectedVersion); err != nil {
+		return err
+	}
+
+	referenced, err := s.repo.IsReferenced(ctx, category.ID)
+	if err != nil {
+		return err
+	}
+	if referenced {
+		return ErrCategoryInUse
+	}
+
+	if err := s.repo.Delete(ctx, category.ID, category.Version); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, models.AuditActionDelete, models.AuditEntityCategory, category.ID, category, nil)
+	return nil
+}
+
+// checkParent проверяет, что категорию id можно поместить под parentID: родитель существует,
+// не заархивирован и не совпадает с самой категорией. Что родитель не потомок категории
+// (иначе в дереве появился бы цикл), проверяет Update репозитория в одной транзакции с записью:
+// проверка заранее пропустила бы два встречных переноса, выполненных одновременно.
+// Для новой категории id пуст.
+func (s *CategoryService) checkParent(ctx context.Context, id, parentID string) error {
+	if parentID == "" {
+		return nil
+	}
+	if parentID == id {
+		return ErrCategoryCycle
+	}
+
+	parent, err := s.repo.GetByID(ctx, parentID)
+	if err != nil {
+		return err
+	}
+	if parent == nil || parent.ArchivedAt != nil {
+		return ErrParentCategoryNotFound
+	}
+	return nil
 }
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// LDAPConfig содержит параметры подключения к LDAP-каталогу.
+type LDAPConfig struct {
+	URL          string // ldap://host:389 или ldaps://host:636
+	StartTLS     bool
+	BindDN       string // сервисная учётная запись для поиска пользователя (опционально)
+	BindPassword string
+	BaseDN       string
+	UserFilter   string // например "(uid=%s)"; %s заменяется экранированным логином
+	EmailAttr    string
+	GroupAttr    string
+}
+
+// LDAPAuthenticator проверяет логин/пароль через LDAP bind:
+// сначала ищет пользователя сервисной учётной записью, затем выполняет bind от его DN.
+type LDAPAuthenticator struct {
+	cfg LDAPConfig
+}
+
+// NewLDAPAuthenticator — конструктор LDAP-провайдера. Незаданные атрибуты получают типовые значения.
+func NewLDAPAuthenticator(cfg LDAPConfig) *LDAPAuthenticator {
+	if cfg.UserFilter == "" {
+		cfg.UserFilter = "(uid=%s)"
+	}
+	if cfg.EmailAttr == "" {
+		cfg.EmailAttr = "mail"
+	}
+	if cfg.GroupAttr == "" {
+		cfg.GroupAttr = "memberOf"
+	}
+	return &LDAPAuthenticator{cfg: cfg}
+}
+
+// Name возвращает имя провайдера.
+func (a *LDAPAuthenticator) Name() string {
+	return "ldap"
+}
+
+// AuthenticatePassword выполняет поиск пользователя и bind с его паролем.
+func (a *LDAPAuthenticator) AuthenticatePassword(ctx context.Context, login, password string) (*Identity, error) {
+	// Пустой пароль в LDAP означает анонимный bind, который «успешен» — отсекаем заранее.
+	if login == "" || password == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	conn, err := ldap.DialURL(a.cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("ldap dial: %w", err)
+	}
+	defer conn.Close()
+	// go-ldap не принимает контекст: при отмене запроса закрываем соединение, прерывая текущую операцию.
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	if a.cfg.StartTLS {
+		if err := conn.StartTLS(&tls.Config{ServerName: hostFromURL(a.cfg.URL)}); err != nil {
+			return nil, fmt.Errorf("ldap starttls: %w", err)
+		}
+	}
+
+	if a.cfg.BindDN != "" {
+		if err := conn.Bind(a.cfg.BindDN, a.cfg.BindPassword); err != nil {
+			return nil, fmt.Errorf("ldap service bind: %w", err)
+		}
+	}
+
+	req := ldap.NewSearchRequest(
+		a.cfg.BaseDN,
+		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2, 0, false,
+		fmt.Sprintf(a.cfg.UserFilter, ldap.EscapeFilter(login)),
+		[]string{"dn", a.cfg.EmailAttr, a.cfg.GroupAttr},
+		nil,
+	)
+	res, err := conn.Search(req)
+	if err != nil {
+		return nil, fmt.Errorf("ldap search: %w", err)
+	}
+	if len(res.Entries) != 1 {
+		return nil, ErrInvalidCredentials
+	}
+	entry := res.Entries[0]
+
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("ldap user bind: %w", err)
+	}
+
+	email := entry.GetAttributeValue(a.cfg.EmailAttr)
+	if email == "" {
+		return nil, errors.New("ldap entry has no email attribute")
+	}
+
+	return &Identity{
+		Provider: a.Name(),
+		Subject:  entry.DN,
+		Email:    email,
+		Groups:   ldapGroupNames(entry.GetAttributeValues(a.cfg.GroupAttr)),
+	}, nil
+}
+
+// ldapGroupNames возвращает для каждой группы и полный DN, и её CN,
+// чтобы в маппинге ролей можно было указывать любой из вариантов.
+func ldapGroupNames(values []string) []string {
+	groups := make([]string, 0, len(values)*2)
+	for _, v := range values {
+		groups = append(groups, v)
+		dn, err := ldap.ParseDN(v)
+		if err != nil || len(dn.RDNs) == 0 {
+			continue
+		}
+		for _, attr := range dn.RDNs[0].Attributes {
+			if strings.EqualFold(attr.Type, "cn") {
+				groups = append(groups, attr.Value)
+			}
+		}
+	}
+	return groups
+}
+
+func hostFromURL(rawURL string) string {
+	host := rawURL
+	if i := strings.Index(host, "://"); i >= 0 {
+		host = host[i+3:]
+	}
+	if i := strings.IndexAny(host, ":/"); i >= 0 {
+		host = host[:i]
+	}
+	return host
+}
@@ -0,0 +1,259 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+)
+
+const (
+	testLDAPBaseDN      = "ou=people,dc=example,dc=com"
+	testLDAPServiceDN   = "cn=wms,dc=example,dc=com"
+	testLDAPServicePass = "service-pass"
+)
+
+// Коды операций LDAPv3 (RFC 4511), которые понимает mockLDAP.
+const (
+	ldapOpBindRequest    = 0
+	ldapOpBindResponse   = 1
+	ldapOpUnbindRequest  = 2
+	ldapOpSearchRequest  = 3
+	ldapOpSearchEntry    = 4
+	ldapOpSearchDone     = 5
+	ldapResultSuccess    = 0
+	ldapResultInvalidPwd = 49
+)
+
+// ldapEntry — запись каталога mockLDAP.
+type ldapEntry struct {
+	dn       string
+	uid      string
+	password string
+	attrs    map[string][]string
+}
+
+// mockLDAP — минимальный LDAP-сервер: simple bind и поиск по фильтру (uid=...).
+// Поиск разрешён только после bind сервисной учётной записью.
+type mockLDAP struct {
+	addr        string
+	entries     []ldapEntry
+	connections atomic.Int32
+}
+
+func newMockLDAP(t *testing.T, entries ...ldapEntry) *mockLDAP {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	srv := &mockLDAP{addr: ln.Addr().String(), entries: entries}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			srv.connections.Add(1)
+			go srv.serve(conn)
+		}
+	}()
+	return srv
+}
+
+func (s *mockLDAP) url() string {
+	return "ldap://" + s.addr
+}
+
+func (s *mockLDAP) serve(conn net.Conn) {
+	defer conn.Close()
+
+	boundDN := ""
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil || len(packet.Children) < 2 {
+			return
+		}
+		id, _ := packet.Children[0].Value.(int64)
+		op := packet.Children[1]
+
+		switch op.Tag {
+		case ldapOpBindRequest:
+			dn, _ := op.Children[1].Value.(string)
+			password := op.Children[2].Data.String()
+			code := int64(ldapResultInvalidPwd)
+			if s.checkPassword(dn, password) {
+				code, boundDN = ldapResultSuccess, dn
+			}
+			s.write(conn, id, ldapOpBindResponse, ldapResult(code)...)
+		case ldapOpSearchRequest:
+			if boundDN == testLDAPServiceDN {
+				filter, _ := ldap.DecompileFilter(op.Children[6])
+				for _, e := range s.entries {
+					if filter == fmt.Sprintf("(uid=%s)", ldap.EscapeFilter(e.uid)) {
+						s.write(conn, id, ldapOpSearchEntry, e.packet()...)
+					}
+				}
+			}
+			s.write(conn, id, ldapOpSearchDone, ldapResult(ldapResultSuccess)...)
+		case ldapOpUnbindRequest:
+			return
+		}
+	}
+}
+
+func (s *mockLDAP) checkPassword(dn, password string) bool {
+	if dn == testLDAPServiceDN {
+		return password == testLDAPServicePass
+	}
+	for _, e := range s.entries {
+		if e.dn == dn {
+			return password != "" && password == e.password
+		}
+	}
+	return false
+}
+
+func (s *mockLDAP) write(conn net.Conn, id int64, tag ber.Tag, children ...*ber.Packet) {
+	msg := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+	msg.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, id, ""))
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "")
+	for _, c := range children {
+		op.AppendChild(c)
+	}
+	msg.AppendChild(op)
+	_, _ = conn.Write(msg.Bytes())
+}
+
+func ldapResult(code int64) []*ber.Packet {
+	return []*ber.Packet{
+		ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, code, ""),
+		ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""),
+		ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""),
+	}
+}
+
+func (e ldapEntry) packet() []*ber.Packet {
+	attrs := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+	for name, values := range e.attrs {
+		attr := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+		attr.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, ""))
+		vals := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "")
+		for _, v := range values {
+			vals.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, v, ""))
+		}
+		attr.AppendChild(vals)
+		attrs.AppendChild(attr)
+	}
+	return []*ber.Packet{
+		ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, e.dn, ""),
+		attrs,
+	}
+}
+
+func testLDAPDirectory(t *testing.T) *mockLDAP {
+	return newMockLDAP(t,
+		ldapEntry{
+			dn:       "uid=alice," + testLDAPBaseDN,
+			uid:      "alice",
+			password: "alice-pass",
+			attrs: map[string][]string{
+				"mail":     {"alice@example.com"},
+				"memberOf": {"cn=wms-managers,ou=groups,dc=example,dc=com"},
+			},
+		},
+		ldapEntry{
+			dn:       "uid=nomail," + testLDAPBaseDN,
+			uid:      "nomail",
+			password: "nomail-pass",
+			attrs:    map[string][]string{},
+		},
+	)
+}
+
+func testLDAPAuthenticator(srv *mockLDAP) *LDAPAuthenticator {
+	return NewLDAPAuthenticator(LDAPConfig{
+		URL:          srv.url(),
+		BindDN:       testLDAPServiceDN,
+		BindPassword: testLDAPServicePass,
+		BaseDN:       testLDAPBaseDN,
+	})
+}
+
+func TestLDAPAuthenticatePassword(t *testing.T) {
+	srv := testLDAPDirectory(t)
+
+	identity, err := testLDAPAuthenticator(srv).AuthenticatePassword(context.Background(), "alice", "alice-pass")
+	if err != nil {
+		t.Fatalf("AuthenticatePassword: %v", err)
+	}
+	if identity.Provider != "ldap" || identity.Subject != "uid=alice,"+testLDAPBaseDN || identity.Email != "alice@example.com" {
+		t.Fatalf("unexpected identity: %+v", identity)
+	}
+	want := []string{"cn=wms-managers,ou=groups,dc=example,dc=com", "wms-managers"}
+	if len(identity.Groups) != len(want) || identity.Groups[0] != want[0] || identity.Groups[1] != want[1] {
+		t.Fatalf("groups = %v, want %v", identity.Groups, want)
+	}
+}
+
+func TestLDAPAuthenticatePasswordRejects(t *testing.T) {
+	tests := []struct {
+		name     string
+		login    string
+		password string
+	}{
+		{name: "wrong password", login: "alice", password: "nope"},
+		{name: "unknown user", login: "bob", password: "alice-pass"},
+		{name: "filter injection", login: "*", password: "alice-pass"},
+	}
+
+	srv := testLDAPDirectory(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := testLDAPAuthenticator(srv).AuthenticatePassword(context.Background(), tt.login, tt.password)
+			if !errors.Is(err, ErrInvalidCredentials) {
+				t.Fatalf("expected ErrInvalidCredentials, got %v", err)
+			}
+		})
+	}
+}
+
+func TestLDAPAuthenticatePasswordEmptyPasswordSkipsBind(t *testing.T) {
+	srv := testLDAPDirectory(t)
+
+	_, err := testLDAPAuthenticator(srv).AuthenticatePassword(context.Background(), "alice", "")
+	if !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("expected ErrInvalidCredentials, got %v", err)
+	}
+	if n := srv.connections.Load(); n != 0 {
+		t.Fatalf("empty password must not reach the directory, got %d connections", n)
+	}
+}
+
+func TestLDAPAuthenticatePasswordWithoutEmail(t *testing.T) {
+	srv := testLDAPDirectory(t)
+
+	_, err := testLDAPAuthenticator(srv).AuthenticatePassword(context.Background(), "nomail", "nomail-pass")
+	if err == nil || errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("expected missing email error, got %v", err)
+	}
+}
+
+func TestLDAPAuthenticatePasswordServiceBindFails(t *testing.T) {
+	srv := testLDAPDirectory(t)
+	a := testLDAPAuthenticator(srv)
+	a.cfg.BindPassword = "wrong"
+
+	_, err := a.AuthenticatePassword(context.Background(), "alice", "alice-pass")
+	if err == nil || errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("expected service bind error, got %v", err)
+	}
+}
@@ -0,0 +1,353 @@
+package services
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// OIDCConfig содержит параметры клиента OpenID Connect.
+type OIDCConfig struct {
+	Issuer       string // например https://idp.example.com/realms/wms
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string // адрес нашего callback, зарегистрированный у провайдера
+	Scopes       []string
+	GroupsClaim  string // claim со списком групп, по умолчанию "groups"
+}
+
+// OIDCAuthenticator реализует authorization code flow OpenID Connect.
+// Эндпоинты провайдера берутся из discovery-документа issuer/.well-known/openid-configuration,
+// поэтому для разработки достаточно указать Issuer локального mock-IdP.
+type OIDCAuthenticator struct {
+	cfg    OIDCConfig
+	client *http.Client
+
+	mu        sync.Mutex
+	discovery *oidcDiscovery
+	keys      map[string]any
+}
+
+// oidcDiscovery — нужная нам часть discovery-документа провайдера.
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// NewOIDCAuthenticator — конструктор OIDC-провайдера. client может быть nil.
+func NewOIDCAuthenticator(cfg OIDCConfig, client *http.Client) *OIDCAuthenticator {
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "email", "profile"}
+	}
+	if cfg.GroupsClaim == "" {
+		cfg.GroupsClaim = "groups"
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &OIDCAuthenticator{cfg: cfg, client: client}
+}
+
+var ErrOIDCTokenInvalid = errors.New("invalid id token")
+
+// Name возвращает имя провайдера.
+func (a *OIDCAuthenticator) Name() string {
+	return "oidc"
+}
+
+// AuthCodeURL формирует адрес страницы входа провайдера.
+func (a *OIDCAuthenticator) AuthCodeURL(ctx context.Context, state, nonce string) (string, error) {
+	d, err := a.getDiscovery(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", a.cfg.ClientID)
+	q.Set("redirect_uri", a.cfg.RedirectURL)
+	q.Set("scope", strings.Join(a.cfg.Scopes, " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+
+	sep := "?"
+	if strings.Contains(d.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return d.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+// Exchange обменивает код авторизации на id_token и проверяет его подпись и claims.
+func (a *OIDCAuthenticator) Exchange(ctx context.Context, code, nonce string) (*Identity, error) {
+	d, err := a.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", a.cfg.RedirectURL)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(a.cfg.ClientID), url.QueryEscape(a.cfg.ClientSecret))
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("oidc token request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oidc token endpoint returned %s", resp.Status)
+	}
+
+	var tokenResp struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return nil, fmt.Errorf("oidc token response: %w", err)
+	}
+	if tokenResp.IDToken == "" {
+		return nil, ErrOIDCTokenInvalid
+	}
+
+	return a.verifyIDToken(ctx, d, tokenResp.IDToken, nonce)
+}
+
+// verifyIDToken проверяет подпись id_token по JWKS провайдера, issuer, audience, срок и nonce.
+func (a *OIDCAuthenticator) verifyIDToken(ctx context.Context, d *oidcDiscovery, rawToken, nonce string) (*Identity, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(rawToken, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return a.getKey(ctx, d, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512", "EdDSA"}),
+		jwt.WithIssuer(d.Issuer),
+		jwt.WithAudience(a.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOIDCTokenInvalid, err)
+	}
+
+	if got, _ := claims["nonce"].(string); nonce == "" || got != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrOIDCTokenInvalid)
+	}
+
+	sub, _ := claims["sub"].(string)
+	email, _ := claims["email"].(string)
+	if sub == "" || email == "" {
+		return nil, fmt.Errorf("%w: sub and email claims are required", ErrOIDCTokenInvalid)
+	}
+	// Без подтверждённого email провайдер не ручается за адрес: отсутствие claim — тоже отказ.
+	if !emailVerified(claims["email_verified"]) {
+		return nil, fmt.Errorf("%w: email is not verified", ErrOIDCTokenInvalid)
+	}
+
+	var groups []string
+	switch v := claims[a.cfg.GroupsClaim].(type) {
+	case []interface{}:
+		for _, g := range v {
+			if s, ok := g.(string); ok {
+				groups = append(groups, s)
+			}
+		}
+	case string:
+		groups = strings.Fields(v)
+	}
+
+	return &Identity{
+		Provider: a.Name(),
+		Subject:  sub,
+		Email:    email,
+		Groups:   groups,
+	}, nil
+}
+
+func (a *OIDCAuthenticator) getDiscovery(ctx context.Context) (*oidcDiscovery, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.discovery != nil {
+		return a.discovery, nil
+	}
+
+	var d oidcDiscovery
+	wellKnown := strings.TrimSuffix(a.cfg.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := a.getJSON(ctx, wellKnown, &d); err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		return nil, errors.New("oidc discovery document is incomplete")
+	}
+	if d.Issuer == "" {
+		d.Issuer = a.cfg.Issuer
+	}
+
+	a.discovery = &d
+	return a.discovery, nil
+}
+
+// getKey возвращает открытый ключ по kid. При неизвестном kid JWKS перечитывается —
+// так подхватывается ротация ключей на стороне провайдера.
+func (a *OIDCAuthenticator) getKey(ctx context.Context, d *oidcDiscovery, kid string) (any, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if key, ok := a.keys[kid]; ok {
+		return key, nil
+	}
+
+	var set JSONWebKeySet
+	if err := a.getJSON(ctx, d.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("oidc jwks: %w", err)
+	}
+	keys, err := set.publicKeys()
+	if err != nil {
+		return nil, err
+	}
+	a.keys = keys
+
+	if key, ok := a.keys[kid]; ok {
+		return key, nil
+	}
+	// Провайдеры с единственным ключом иногда не указывают kid.
+	if kid == "" && len(a.keys) == 1 {
+		for _, key := range a.keys {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("oidc jwks: key %q not found", kid)
+}
+
+func (a *OIDCAuthenticator) getJSON(ctx context.Context, rawURL string, dst any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned %s", rawURL, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(dst)
+}
+
+// JSONWebKeySet — JWKS (RFC 7517) с ключами RSA, EC и OKP (Ed25519).
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// JSONWebKey — открытый ключ в формате JWK.
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// EC и OKP
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// publicKeys разбирает ключи подписи; ключи неподдерживаемых типов пропускаются.
+func (s JSONWebKeySet) publicKeys() (map[string]any, error) {
+	keys := make(map[string]any, len(s.Keys))
+	for _, k := range s.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		switch k.Kty {
+		case "RSA":
+			n, err := decodeBigInt(k.N)
+			if err != nil {
+				return nil, err
+			}
+			e, err := decodeBigInt(k.E)
+			if err != nil {
+				return nil, err
+			}
+			keys[k.Kid] = &rsa.PublicKey{N: n, E: int(e.Int64())}
+		case "EC":
+			var curve elliptic.Curve
+			switch k.Crv {
+			case "P-256":
+				curve = elliptic.P256()
+			case "P-384":
+				curve = elliptic.P384()
+			case "P-521":
+				curve = elliptic.P521()
+			default:
+				continue
+			}
+			x, err := decodeBigInt(k.X)
+			if err != nil {
+				return nil, err
+			}
+			y, err := decodeBigInt(k.Y)
+			if err != nil {
+				return nil, err
+			}
+			keys[k.Kid] = &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+		case "OKP":
+			if k.Crv != "Ed25519" {
+				continue
+			}
+			x, err := base64.RawURLEncoding.DecodeString(k.X)
+			if err != nil || len(x) != ed25519.PublicKeySize {
+				return nil, fmt.Errorf("jwks: invalid Ed25519 key %q", k.Kid)
+			}
+			keys[k.Kid] = ed25519.PublicKey(x)
+		}
+	}
+	return keys, nil
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("jwks: invalid base64url value: %w", err)
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// emailVerified разбирает claim email_verified. Часть провайдеров (например, Cognito)
+// передаёт его строкой, поэтому кроме true принимается и "true".
+func emailVerified(v any) bool {
+	switch v := v.(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	default:
+		return false
+	}
+}
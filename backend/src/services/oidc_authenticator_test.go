@@ -0,0 +1,221 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testOIDCClientID     = "wms"
+	testOIDCClientSecret = "s3cret"
+	testOIDCCode         = "auth-code"
+	testOIDCNonce        = "nonce-123"
+)
+
+// mockIdP — минимальный OpenID-провайдер: discovery, token endpoint и JWKS.
+// В JWKS публикуется keys; id_token из claims подписывается signer (обычно тем же набором).
+type mockIdP struct {
+	server *httptest.Server
+	keys   *TokenKeySet
+	signer *TokenKeySet
+	claims jwt.MapClaims
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	t.Helper()
+
+	keys, err := GenerateEphemeralKeySet(AlgRS256)
+	if err != nil {
+		t.Fatalf("generate keys: %v", err)
+	}
+	idp := &mockIdP{keys: keys, signer: keys}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(oidcDiscovery{
+			Issuer:                idp.server.URL,
+			AuthorizationEndpoint: idp.server.URL + "/authorize",
+			TokenEndpoint:         idp.server.URL + "/token",
+			JWKSURI:               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(idp.keys.JWKS())
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		id, secret, ok := r.BasicAuth()
+		if r.Method != http.MethodPost || !ok || id != testOIDCClientID || secret != testOIDCClientSecret {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.FormValue("grant_type") != "authorization_code" || r.FormValue("code") != testOIDCCode {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		token, err := idp.signer.Sign(idp.claims)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"id_token": token})
+	})
+
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	idp.claims = idp.validClaims()
+	return idp
+}
+
+// validClaims возвращает claims корректного id_token для testOIDCNonce.
+func (idp *mockIdP) validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":            idp.server.URL,
+		"aud":            testOIDCClientID,
+		"sub":            "user-42",
+		"email":          "alice@example.com",
+		"email_verified": true,
+		"nonce":          testOIDCNonce,
+		"groups":         []string{"wms-managers"},
+		"exp":            time.Now().Add(time.Minute).Unix(),
+		"iat":            time.Now().Unix(),
+	}
+}
+
+func (idp *mockIdP) authenticator() *OIDCAuthenticator {
+	return NewOIDCAuthenticator(OIDCConfig{
+		Issuer:       idp.server.URL,
+		ClientID:     testOIDCClientID,
+		ClientSecret: testOIDCClientSecret,
+		RedirectURL:  "http://localhost/api/auth/oidc/callback",
+	}, idp.server.Client())
+}
+
+func TestOIDCExchangeValidToken(t *testing.T) {
+	idp := newMockIdP(t)
+
+	identity, err := idp.authenticator().Exchange(context.Background(), testOIDCCode, testOIDCNonce)
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if identity.Provider != "oidc" || identity.Subject != "user-42" || identity.Email != "alice@example.com" {
+		t.Fatalf("unexpected identity: %+v", identity)
+	}
+	if len(identity.Groups) != 1 || identity.Groups[0] != "wms-managers" {
+		t.Fatalf("unexpected groups: %v", identity.Groups)
+	}
+}
+
+func TestOIDCExchangeRejectsInvalidTokens(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(idp *mockIdP, claims jwt.MapClaims)
+		nonce  string
+	}{
+		{
+			name:   "email_verified missing",
+			mutate: func(_ *mockIdP, c jwt.MapClaims) { delete(c, "email_verified") },
+		},
+		{
+			name:   "email_verified false",
+			mutate: func(_ *mockIdP, c jwt.MapClaims) { c["email_verified"] = false },
+		},
+		{
+			name:   "email_verified string false",
+			mutate: func(_ *mockIdP, c jwt.MapClaims) { c["email_verified"] = "false" },
+		},
+		{
+			name:   "nonce mismatch",
+			mutate: func(_ *mockIdP, c jwt.MapClaims) {},
+			nonce:  "other-nonce",
+		},
+		{
+			name:   "wrong audience",
+			mutate: func(_ *mockIdP, c jwt.MapClaims) { c["aud"] = "another-client" },
+		},
+		{
+			name:   "wrong issuer",
+			mutate: func(_ *mockIdP, c jwt.MapClaims) { c["iss"] = "https://evil.example.com" },
+		},
+		{
+			name:   "expired",
+			mutate: func(_ *mockIdP, c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() },
+		},
+		{
+			name:   "email missing",
+			mutate: func(_ *mockIdP, c jwt.MapClaims) { delete(c, "email") },
+		},
+		{
+			// Ключ с тем же kid, что и опубликованный, но другой: подпись не сходится.
+			name: "signed by unknown key",
+			mutate: func(idp *mockIdP, _ jwt.MapClaims) {
+				foreign, err := GenerateEphemeralKeySet(AlgRS256)
+				if err != nil {
+					panic(err)
+				}
+				idp.signer = foreign
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idp := newMockIdP(t)
+			tt.mutate(idp, idp.claims)
+
+			nonce := tt.nonce
+			if nonce == "" {
+				nonce = testOIDCNonce
+			}
+			if _, err := idp.authenticator().Exchange(context.Background(), testOIDCCode, nonce); !errors.Is(err, ErrOIDCTokenInvalid) {
+				t.Fatalf("expected ErrOIDCTokenInvalid, got %v", err)
+			}
+		})
+	}
+}
+
+func TestOIDCExchangeAcceptsStringEmailVerified(t *testing.T) {
+	idp := newMockIdP(t)
+	idp.claims["email_verified"] = "true"
+
+	if _, err := idp.authenticator().Exchange(context.Background(), testOIDCCode, testOIDCNonce); err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+}
+
+func TestOIDCExchangeTokenEndpointError(t *testing.T) {
+	idp := newMockIdP(t)
+
+	_, err := idp.authenticator().Exchange(context.Background(), "wrong-code", testOIDCNonce)
+	if err == nil || errors.Is(err, ErrOIDCTokenInvalid) {
+		t.Fatalf("expected token endpoint error, got %v", err)
+	}
+}
+
+func TestOIDCAuthCodeURL(t *testing.T) {
+	idp := newMockIdP(t)
+
+	raw, err := idp.authenticator().AuthCodeURL(context.Background(), "state-1", testOIDCNonce)
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	if !strings.HasPrefix(raw, idp.server.URL+"/authorize?") {
+		t.Fatalf("unexpected authorization endpoint: %s", raw)
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if q.Get("client_id") != testOIDCClientID || q.Get("state") != "state-1" || q.Get("nonce") != testOIDCNonce || q.Get("response_type") != "code" {
+		t.Fatalf("unexpected query: %s", u.RawQuery)
+	}
+}
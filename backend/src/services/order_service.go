@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"strings"
 	"time"
@@ -9,26 +10,31 @@ import (
 
 // OrderRepository описывает поведение хранилища заказов.
 type OrderRepository interface {
-	GetAll() ([]*models.Order, error)
-	GetByID(id string) (*models.Order, error)
-	Create(order *models.Order) error
-	Update(order *models.Order) error
+	GetAll(ctx context.Context) ([]*models.Order, error)
+	GetByID(ctx context.Context, id string) (*models.Order, error)
+	// Create сохраняет заказ вместе с резервами товаров под него в одной транзакции.
+	Create(ctx context.Context, order *models.Order, reservations []*models.StockMovement) error
+	Update(ctx context.Context, order *models.Order) error
 }
 
 // OrderService инкапсулирует бизнес-логику работы с заказами,
 // включая автоматическое резервирование товаров при создании/изменении заказа.
 type OrderService struct {
-	orderRepo     OrderRepository
-	warehouseRepo WarehouseRepository
-	productRepo   ProductRepository
+	orderRepo   OrderRepository
+	productRepo ProductRepository
+	units       *UnitService
+	prices      *PriceListService
+	audit       AuditRecorder
 }
 
 // NewOrderService — конструктор сервиса заказов.
-func NewOrderService(orderRepo OrderRepository, warehouseRepo WarehouseRepository, productRepo ProductRepository) *OrderService {
+func NewOrderService(orderRepo OrderRepository, productRepo ProductRepository, units *UnitService, prices *PriceListService, audit AuditRecorder) *OrderService {
 	return &OrderService{
-		orderRepo:     orderRepo,
-		warehouseRepo: warehouseRepo,
-		productRepo:   productRepo,
+		orderRepo:   orderRepo,
+		productRepo: productRepo,
+		units:       units,
+		prices:      prices,
+		audit:       audit,
 	}
 }
 
@@ -39,18 +45,18 @@ var (
 )
 
 // ListOrders возвращает список заказов.
-func (s *OrderService) ListOrders() ([]*models.Order, error) {
-	return s.orderRepo.GetAll()
+func (s *OrderService) ListOrders(ctx context.Context) ([]*models.Order, error) {
+	return s.orderRepo.GetAll(ctx)
 }
 
 // GetOrder возвращает заказ по ID.
-func (s *OrderService) GetOrder(id string) (*models.Order, error) {
+func (s *OrderService) GetOrder(ctx context.Context, id string) (*models.Order, error) {
 	id = strings.TrimSpace(id)
 	if id == "" {
 		return nil, ErrInvalidOrder
 	}
 
-	order, err := s.orderRepo.GetByID(id)
+	order, err := s.orderRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -60,60 +66,142 @@ func (s *OrderService) GetOrder(id string) (*models.Order, error) {
 	return order, nil
 }
 
-// CreateOrder создаёт новый заказ и автоматически резервирует товары.
-func (s *OrderService) CreateOrder(customer string, items []models.OrderItem) (*models.Order, error) {
+// CreateOrder создаёт новый заказ и автоматически резервирует товары. Количество и цена позиции
+// указаны в её единице (по умолчанию — базовая единица товара), резерв — в базовой единице.
+// Позиция без цены и валюты считается позицией без указанной цены: цена берётся из действующего
+// прайс-листа покупателя или общего прайс-листа (в валюте заказа) и пересчитывается в единицу позиции.
+// Если подходящего прайс-листа нет, цена остаётся нулевой в валюте заказа.
+func (s *OrderService) CreateOrder(ctx context.Context, customer string, items []models.OrderItem) (*models.Order, error) {
 	customer = strings.TrimSpace(customer)
 	if customer == "" || len(items) == 0 {
 		return nil, ErrInvalidOrder
 	}
 
-	// Проверяем, что товары существуют.
+	// Валюта заказа — валюта первой позиции с указанной ценой; цены из прайс-листов ищутся в ней.
+	orderCurrency := ""
 	for _, it := range items {
+		if currency, err := normalizePrice(it.Price, it.Currency); err == nil && currency != "" {
+			orderCurrency = currency
+			break
+		}
+	}
+
+	// Проверяем, что товары существуют и не заархивированы, количества допустимы для их единиц измерения,
+	// а все цены заказа указаны в одной валюте.
+	reserved := make([]models.Decimal, len(items))
+	for i := range items {
+		it := &items[i]
 		if it.ProductID == "" || it.Quantity <= 0 {
 			return nil, ErrInvalidOrder
 		}
-		if _, err := s.productRepo.GetByID(it.ProductID); err != nil {
+		product, err := checkQuantity(ctx, s.productRepo, it.ProductID, it.Quantity)
+		if err != nil {
 			return nil, err
 		}
-	}
+		if product.ArchivedAt != nil {
+			return nil, ErrProductArchived
+		}
+		var factor models.Decimal
+		if reserved[i], factor, err = s.units.toBase(ctx, product, it.Quantity, it.Unit); err != nil {
+			return nil, err
+		}
+		it.Unit = normalizeUnit(it.Unit)
+		if it.Unit == "" {
+			it.Unit = product.Unit
+		}
 
-	order := models.NewOrder(customer, items)
+		if it.Price == 0 && strings.TrimSpace(it.Currency) == "" {
+			if err := s.defaultPrice(ctx, it, customer, orderCurrency, factor); err != nil {
+				return nil, err
+			}
+		}
 
-	if err := s.orderRepo.Create(order); err != nil {
-		return nil, err
+		currency := it.Currency
+		if it.PriceListID == "" {
+			if currency, err = normalizePrice(it.Price, it.Currency); err != nil {
+				return nil, err
+			}
+		}
+		if currency == "" {
+			currency = orderCurrency
+		}
+		if currency == "" {
+			currency = models.DefaultCurrency
+		}
+		if i > 0 && currency != items[0].Currency {
+			return nil, ErrInvalidPrice
+		}
+		it.Currency = currency
+		if orderCurrency == "" {
+			orderCurrency = currency
+		}
 	}
 
-	// Автоматическое резервирование товаров под заказ.
-	for _, it := range order.Items {
-		if err := s.warehouseRepo.AddMovement(&models.StockMovement{
+	actor := actorFromContext(ctx)
+	order := models.NewOrder(customer, items, actor)
+
+	// Автоматическое резервирование товаров под заказ: резервы сохраняются вместе с заказом,
+	// и при нехватке остатка заказ не создаётся (ErrInsufficientStock).
+	reservations := make([]*models.StockMovement, len(order.Items))
+	for i, it := range order.Items {
+		reservations[i] = &models.StockMovement{
 			ID:        "",
 			Type:      models.MovementReserve,
 			ProductID: it.ProductID,
-			OrderID:   order.ID,
-			Quantity:  it.Quantity,
+			Quantity:  reserved[i],
+			CreatedBy: actor,
 			CreatedAt: time.Now().UTC(),
-		}); err != nil {
-			return nil, err
 		}
 	}
 
+	if err := s.orderRepo.Create(ctx, order, reservations); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, models.AuditActionCreate, models.AuditEntityOrder, order.ID, nil, order)
+	for _, m := range reservations {
+		s.audit.Record(ctx, models.AuditActionReserve, models.AuditEntityStockMovement, m.ID, nil, m)
+	}
+
 	return order, nil
 }
 
+// defaultPrice подставляет в позицию it цену из действующего прайс-листа в валюте currency
+// (пустая — любая), пересчитанную из базовой единицы товара в единицу позиции (factor).
+func (s *OrderService) defaultPrice(ctx context.Context, it *models.OrderItem, customer, currency string, factor models.Decimal) error {
+	price, err := s.prices.ResolvePrice(ctx, it.ProductID, customer, currency, time.Now().UTC())
+	if err == ErrPriceNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if it.Price, err = price.Price.MulRound(factor); err != nil {
+		return ErrInvalidPrice
+	}
+	it.Currency = price.Currency
+	it.PriceListID = price.PriceListID
+	return nil
+}
+
 // UpdateOrderStatus обновляет статус заказа и фиксирует историю изменений.
-func (s *OrderService) UpdateOrderStatus(id string, newStatus models.OrderStatus) (*models.Order, error) {
+// Если заказ изменили после чтения клиентом (expectedVersion из If-Match), возвращается ErrVersionMismatch.
+func (s *OrderService) UpdateOrderStatus(ctx context.Context, id string, expectedVersion int64, newStatus models.OrderStatus) (*models.Order, error) {
 	id = strings.TrimSpace(id)
 	if id == "" {
 		return nil, ErrInvalidOrder
 	}
 
-	order, err := s.orderRepo.GetByID(id)
+	order, err := s.orderRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 	if order == nil {
 		return nil, ErrOrderNotFound
 	}
+	if err := checkVersion(order.Version, expectedVersion); err != nil {
+		return nil, err
+	}
 
 	// Простейшая проверка допустимости переходов статусов.
 	switch order.Status {
@@ -123,17 +211,20 @@ func (s *OrderService) UpdateOrderStatus(id string, newStatus models.OrderStatus
 		return nil, ErrOrderBadStatus
 	}
 
+	before := *order
 	now := time.Now().UTC()
 	order.Status = newStatus
 	order.UpdatedAt = now
 	order.StatusHist = append(order.StatusHist, models.StatusEntry{
 		Status:    newStatus,
+		ChangedBy: actorFromContext(ctx),
 		ChangedAt: now,
 	})
 
-	if err := s.orderRepo.Update(order); err != nil {
+	if err := s.orderRepo.Update(ctx, order); err != nil {
 		return nil, err
 	}
 
+	s.audit.Record(ctx, models.AuditActionStatusChange, models.AuditEntityOrder, order.ID, before, order)
 	return order, nil
 }
@@ -0,0 +1,252 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"warehouse-management-system/src/models"
+)
+
+// PriceListRepository описывает поведение хранилища прайс-листов для слоя сервисов.
+type PriceListRepository interface {
+	GetAll(ctx context.Context) ([]*models.PriceList, error)
+	GetByID(ctx context.Context, id string) (*models.PriceList, error)
+	Create(ctx context.Context, list *models.PriceList) error
+	Update(ctx context.Context, list *models.PriceList) error
+	Delete(ctx context.Context, id string, version int64) error
+	SetItem(ctx context.Context, listID string, item models.PriceListItem) error
+	DeleteItem(ctx context.Context, listID, productID string) error
+	ActivePrice(ctx context.Context, productID, customer, currency string, at time.Time) (*models.ProductPrice, error)
+}
+
+// PriceListService инкапсулирует бизнес-логику прайс-листов продаж и выбора действующей цены товара.
+type PriceListService struct {
+	repo        PriceListRepository
+	productRepo ProductRepository
+	audit       AuditRecorder
+}
+
+// NewPriceListService — конструктор сервиса прайс-листов.
+func NewPriceListService(repo PriceListRepository, productRepo ProductRepository, audit AuditRecorder) *PriceListService {
+	return &PriceListService{repo: repo, productRepo: productRepo, audit: audit}
+}
+
+var (
+	ErrPriceListNotFound = errors.New("price list not found")
+	ErrInvalidPriceList  = errors.New("invalid price list data")
+	ErrPriceNotFound     = errors.New("no active price for the product")
+)
+
+// ListPriceLists возвращает прайс-листы без позиций.
+func (s *PriceListService) ListPriceLists(ctx context.Context) ([]*models.PriceList, error) {
+	return s.repo.GetAll(ctx)
+}
+
+// GetPriceList возвращает прайс-лист вместе с позициями.
+func (s *PriceListService) GetPriceList(ctx context.Context, id string) (*models.PriceList, error) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return nil, ErrInvalidPriceList
+	}
+
+	list, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if list == nil {
+		return nil, ErrPriceListNotFound
+	}
+	return list, nil
+}
+
+// CreatePriceList создаёт прайс-лист. Пустая валюта — models.DefaultCurrency, нулевой validFrom —
+// текущий момент, validTo nil — бессрочно. Пустой customer делает прайс-лист общим.
+func (s *PriceListService) CreatePriceList(ctx context.Context, name, currency, customer string, validFrom time.Time, validTo *time.Time) (*models.PriceList, error) {
+	list := &models.PriceList{}
+	if err := applyPriceList(list, name, currency, customer, validFrom, validTo); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(ctx, list); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, models.AuditActionCreate, models.AuditEntityPriceList, list.ID, nil, list)
+	return list, nil
+}
+
+// UpdatePriceList обновляет реквизиты прайс-листа; позиции не меняются.
+// Если прайс-лист изменили после чтения клиентом (expectedVersion из If-Match), возвращается ErrVersionMismatch.
+func (s *PriceListService) UpdatePriceList(ctx context.Context, id string, expectedVersion int64, name, currency, customer string, validFrom time.Time, validTo *time.Time) (*models.PriceList, error) {
+	list, err := s.GetPriceList(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(list.Version, expectedVersion); err != nil {
+		return nil, err
+	}
+
+	before := *list
+	if err := applyPriceList(list, name, currency, customer, validFrom, validTo); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Update(ctx, list); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, models.AuditActionUpdate, models.AuditEntityPriceList, list.ID, before, list)
+	return list, nil
+}
+
+// DeletePriceList удаляет прайс-лист вместе с позициями. Цены уже созданных заказов не меняются.
+func (s *PriceListService) DeletePriceList(ctx context.Context, id string, expectedVersion int64) error {
+	list, err := s.GetPriceList(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := checkVersion(list.Version, expectedVersion); err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, list.ID, list.Version); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, models.AuditActionDelete, models.AuditEntityPriceList, list.ID, list, nil)
+	return nil
+}
+
+// SetItem задаёт цену товара в прайс-листе за базовую единицу товара.
+func (s *PriceListService) SetItem(ctx context.Context, listID, productID string, price models.Decimal) (*models.PriceListItem, error) {
+	list, err := s.GetPriceList(ctx, listID)
+	if err != nil {
+		return nil, err
+	}
+	if price < 0 {
+		return nil, ErrInvalidPrice
+	}
+	product, err := s.getProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if product.ArchivedAt != nil {
+		return nil, ErrProductArchived
+	}
+
+	item := models.PriceListItem{ProductID: product.ID, Price: price}
+	if err := s.repo.SetItem(ctx, list.ID, item); err != nil {
+		return nil, err
+	}
+
+	action := models.AuditActionCreate
+	var before any
+	for _, it := range list.Items {
+		if it.ProductID == product.ID {
+			action = models.AuditActionUpdate
+			before = it
+		}
+	}
+	s.audit.Record(ctx, action, models.AuditEntityPriceList, priceItemAuditID(list.ID, product.ID), before, item)
+	return &item, nil
+}
+
+// DeleteItem удаляет цену товара из прайс-листа.
+func (s *PriceListService) DeleteItem(ctx context.Context, listID, productID string) error {
+	list, err := s.GetPriceList(ctx, listID)
+	if err != nil {
+		return err
+	}
+
+	productID = strings.TrimSpace(productID)
+	var target *models.PriceListItem
+	for i := range list.Items {
+		if list.Items[i].ProductID == productID {
+			target = &list.Items[i]
+		}
+	}
+	if target == nil {
+		return ErrPriceNotFound
+	}
+
+	if err := s.repo.DeleteItem(ctx, list.ID, productID); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, models.AuditActionDelete, models.AuditEntityPriceList, priceItemAuditID(list.ID, productID), target, nil)
+	return nil
+}
+
+// ResolvePrice возвращает цену товара за базовую единицу из прайс-листа, действующего в момент at:
+// прайс-лист покупателя customer важнее общего, из равных выбирается начавший действовать позже.
+// Пустая currency означает любую валюту. Если подходящей цены нет, возвращается ErrPriceNotFound.
+func (s *PriceListService) ResolvePrice(ctx context.Context, productID, customer, currency string, at time.Time) (*models.ProductPrice, error) {
+	product, err := s.getProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency != "" && !models.ValidCurrency(currency) {
+		return nil, ErrInvalidPrice
+	}
+
+	price, err := s.repo.ActivePrice(ctx, product.ID, strings.TrimSpace(customer), currency, at)
+	if err != nil {
+		return nil, err
+	}
+	if price == nil {
+		return nil, ErrPriceNotFound
+	}
+	return price, nil
+}
+
+func (s *PriceListService) getProduct(ctx context.Context, id string) (*models.Product, error) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return nil, ErrInvalidProduct
+	}
+	product, err := s.productRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, ErrProductNotFound
+	}
+	return product, nil
+}
+
+// applyPriceList проверяет реквизиты и записывает их в прайс-лист.
+func applyPriceList(list *models.PriceList, name, currency, customer string, validFrom time.Time, validTo *time.Time) error {
+	name = strings.TrimSpace(name)
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" {
+		currency = models.DefaultCurrency
+	}
+	if name == "" || !models.ValidCurrency(currency) {
+		return ErrInvalidPriceList
+	}
+	if validFrom.IsZero() {
+		validFrom = time.Now()
+	}
+	validFrom = validFrom.UTC()
+	if validTo != nil {
+		to := validTo.UTC()
+		if !to.After(validFrom) {
+			return ErrInvalidPriceList
+		}
+		validTo = &to
+	}
+
+	list.Name = name
+	list.Currency = currency
+	list.Customer = strings.TrimSpace(customer)
+	list.ValidFrom = validFrom
+	list.ValidTo = validTo
+	return nil
+}
+
+// priceItemAuditID — идентификатор цены товара в прайс-листе в журнале аудита.
+func priceItemAuditID(listID, productID string) string {
+	return listID + "/" + productID
+}
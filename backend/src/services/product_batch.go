@@ -0,0 +1,398 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"warehouse-management-system/src/models"
+)
+
+// Пакетные операции с товарами: строки пакета проверяются заранее (включая уникальность SKU
+// и ссылки на категории и поставщиков) несколькими запросами на весь пакет, а затем применяются в одной
+// транзакции. Если хоть одна строка не прошла проверку, не сохраняется ни одна.
+
+// maxProductBatchSize ограничивает число строк в пакете.
+const maxProductBatchSize = 1000
+
+var (
+	ErrInvalidProductBatch  = errors.New("batch must contain from 1 to 1000 products")
+	ErrProductBatchRejected = errors.New("batch rejected, no changes were applied")
+	ErrBatchRowNotApplied   = errors.New("row is valid but was not applied because other rows failed")
+	ErrBatchDuplicateRow    = errors.New("product appears in the batch more than once")
+	ErrBatchVersionRequired = errors.New("version is required")
+)
+
+// ProductBatchItem — строка пакета. ID и Version (версия, которую видел клиент) нужны для изменения
+// и удаления, остальные поля — для создания и изменения.
+type ProductBatchItem struct {
+	ID          string
+	Version     int64
+	SKU         string
+	Name        string
+	Description string
+	CategoryID  string
+	SupplierID  string
+	Unit        string
+}
+
+// ProductBatchResult — результат строки пакета с номером Index. При успехе Product — товар после
+// изменения (nil для удалённого), при отказе Err — ошибка строки или ErrBatchRowNotApplied.
+type ProductBatchResult struct {
+	Index   int
+	Product *models.Product
+	Err     error
+}
+
+// BatchCreateProducts создаёт товары пакетом. Возвращает результат по каждой строке;
+// если пакет отклонён, ошибка — ErrProductBatchRejected, а причины — в результатах строк.
+func (s *ProductService) BatchCreateProducts(ctx context.Context, items []ProductBatchItem) ([]ProductBatchResult, error) {
+	if len(items) == 0 || len(items) > maxProductBatchSize {
+		return nil, ErrInvalidProductBatch
+	}
+	results := newBatchResults(len(items))
+
+	skus := make([]string, 0, len(items))
+	for i := range items {
+		items[i].SKU = strings.TrimSpace(items[i].SKU)
+		skus = append(skus, items[i].SKU)
+	}
+	taken, err := s.productsBySKU(ctx, skus)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := s.loadProductRefs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]models.ProductChange, 0, len(items))
+	rows := make([]int, 0, len(items))
+	inBatch := make(map[string]bool, len(items))
+	for i, item := range items {
+		product, err := batchProductFields(item, nil, refs)
+		if err == nil && (taken[product.SKU] != nil || inBatch[product.SKU]) {
+			err = ErrSKUAlreadyUsed
+		}
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		inBatch[product.SKU] = true
+		results[i].Product = product
+		changes = append(changes, models.ProductChange{Op: models.ProductChangeCreate, Product: product})
+		rows = append(rows, i)
+	}
+
+	if err := s.applyBatch(ctx, results, changes, rows); err != nil {
+		return results, err
+	}
+	for _, r := range results {
+		s.audit.Record(ctx, models.AuditActionCreate, models.AuditEntityProduct, r.Product.ID, nil, r.Product)
+	}
+	return results, nil
+}
+
+// BatchUpdateProducts изменяет товары пакетом. Каждая строка, как и PUT /products/{id}, заменяет
+// все поля товара и должна содержать версию товара. Обменяться SKU в одном пакете товары не могут:
+// SKU, занятый другим товаром, считается занятым, даже если в пакете этот товар его меняет.
+func (s *ProductService) BatchUpdateProducts(ctx context.Context, items []ProductBatchItem) ([]ProductBatchResult, error) {
+	if len(items) == 0 || len(items) > maxProductBatchSize {
+		return nil, ErrInvalidProductBatch
+	}
+	results := newBatchResults(len(items))
+
+	ids := make([]string, 0, len(items))
+	skus := make([]string, 0, len(items))
+	for i := range items {
+		items[i].ID = strings.TrimSpace(items[i].ID)
+		items[i].SKU = strings.TrimSpace(items[i].SKU)
+		ids = append(ids, items[i].ID)
+		skus = append(skus, items[i].SKU)
+	}
+	current, err := s.productsByID(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	taken, err := s.productsBySKU(ctx, skus)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := s.loadProductRefs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]models.ProductChange, 0, len(items))
+	rows := make([]int, 0, len(items))
+	before := make([]models.Product, len(items))
+	seen := make(map[string]bool, len(items))
+	inBatch := make(map[string]bool, len(items))
+	for i, item := range items {
+		product, err := s.batchTarget(item, current, seen)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		fields, err := batchProductFields(item, product, refs)
+		if err == nil {
+			if owner := taken[fields.SKU]; (owner != nil && owner.ID != product.ID) || inBatch[fields.SKU] {
+				err = ErrSKUAlreadyUsed
+			}
+		}
+		if err == nil {
+			err = s.checkUnitChange(ctx, product, fields.Unit)
+		}
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		inBatch[fields.SKU] = true
+
+		before[i] = *product
+		product.SKU = fields.SKU
+		product.Name = fields.Name
+		product.Description = fields.Description
+		product.CategoryID = fields.CategoryID
+		product.SupplierID = fields.SupplierID
+		product.Unit = fields.Unit
+		results[i].Product = product
+		changes = append(changes, models.ProductChange{Op: models.ProductChangeUpdate, Product: product})
+		rows = append(rows, i)
+	}
+
+	if err := s.applyBatch(ctx, results, changes, rows); err != nil {
+		return results, err
+	}
+	for i, r := range results {
+		s.audit.Record(ctx, models.AuditActionUpdate, models.AuditEntityProduct, r.Product.ID, before[i], r.Product)
+	}
+	return results, nil
+}
+
+// BatchDeleteProducts архивирует товары пакетом, а при hard — удаляет физически (только товары,
+// на которые ничего не ссылается, иначе ErrProductInUse). Уже заархивированные товары при
+// архивации не меняются. В результатах строк удалённых товаров Product пустой.
+func (s *ProductService) BatchDeleteProducts(ctx context.Context, items []ProductBatchItem, hard bool) ([]ProductBatchResult, error) {
+	if len(items) == 0 || len(items) > maxProductBatchSize {
+		return nil, ErrInvalidProductBatch
+	}
+	results := newBatchResults(len(items))
+
+	ids := make([]string, 0, len(items))
+	for i := range items {
+		items[i].ID = strings.TrimSpace(items[i].ID)
+		ids = append(ids, items[i].ID)
+	}
+	current, err := s.productsByID(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	op := models.ProductChangeArchive
+	if hard {
+		op = models.ProductChangeDelete
+	}
+	changes := make([]models.ProductChange, 0, len(items))
+	rows := make([]int, 0, len(items))
+	before := make([]models.Product, len(items))
+	var referenced map[string]bool
+	if hard {
+		if referenced, err = s.repo.ReferencedIDs(ctx, nonEmptyUnique(ids)); err != nil {
+			return nil, err
+		}
+	}
+	seen := make(map[string]bool, len(items))
+	for i, item := range items {
+		product, err := s.batchTarget(item, current, seen)
+		if err == nil && referenced[product.ID] {
+			err = ErrProductInUse
+		}
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+
+		before[i] = *product
+		results[i].Product = product
+		if !hard && product.ArchivedAt != nil {
+			continue
+		}
+		changes = append(changes, models.ProductChange{Op: op, Product: product})
+		rows = append(rows, i)
+	}
+
+	if err := s.applyBatch(ctx, results, changes, rows); err != nil {
+		return results, err
+	}
+	for _, i := range rows {
+		if hard {
+			s.audit.Record(ctx, models.AuditActionDelete, models.AuditEntityProduct, before[i].ID, before[i], nil)
+			results[i].Product = nil
+		} else {
+			s.audit.Record(ctx, models.AuditActionArchive, models.AuditEntityProduct, before[i].ID, before[i], results[i].Product)
+		}
+	}
+	return results, nil
+}
+
+// applyBatch сохраняет изменения пакета, если все строки прошли проверку. rows[i] — номер строки
+// изменения changes[i]. Если пакет отклонён, строки без своей ошибки получают ErrBatchRowNotApplied.
+func (s *ProductService) applyBatch(ctx context.Context, results []ProductBatchResult, changes []models.ProductChange, rows []int) error {
+	for _, r := range results {
+		if r.Err != nil {
+			rejectBatch(results)
+			return ErrProductBatchRejected
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	err := s.repo.ApplyBatch(ctx, changes)
+	var changeErr *models.ProductChangeError
+	if !errors.As(err, &changeErr) {
+		return err
+	}
+	switch {
+	case errors.Is(changeErr.Err, models.ErrVersionConflict):
+		// Товар изменили между проверкой и записью.
+		results[rows[changeErr.Index]].Err = ErrVersionMismatch
+	case errors.Is(changeErr.Err, models.ErrDuplicateSKU):
+		// SKU занял товар, сохранённый параллельно между проверкой и записью.
+		results[rows[changeErr.Index]].Err = ErrSKUAlreadyUsed
+	default:
+		return err
+	}
+	rejectBatch(results)
+	return ErrProductBatchRejected
+}
+
+// batchTarget находит товар строки изменения или удаления и сверяет его версию.
+// seen — ID, уже встреченные в пакете.
+func (s *ProductService) batchTarget(item ProductBatchItem, current map[string]*models.Product, seen map[string]bool) (*models.Product, error) {
+	if item.ID == "" {
+		return nil, ErrInvalidProduct
+	}
+	if seen[item.ID] {
+		return nil, ErrBatchDuplicateRow
+	}
+	seen[item.ID] = true
+	if item.Version <= 0 {
+		return nil, ErrBatchVersionRequired
+	}
+	product := current[item.ID]
+	if product == nil {
+		return nil, ErrProductNotFound
+	}
+	if err := checkVersion(product.Version, item.Version); err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+// productsByID загружает товары с указанными ID одним запросом.
+func (s *ProductService) productsByID(ctx context.Context, ids []string) (map[string]*models.Product, error) {
+	products, err := s.repo.GetByIDs(ctx, nonEmptyUnique(ids))
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]*models.Product, len(products))
+	for _, p := range products {
+		result[p.ID] = p
+	}
+	return result, nil
+}
+
+// productsBySKU загружает товары с указанными SKU одним запросом.
+func (s *ProductService) productsBySKU(ctx context.Context, skus []string) (map[string]*models.Product, error) {
+	products, err := s.repo.GetBySKUs(ctx, nonEmptyUnique(skus))
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]*models.Product, len(products))
+	for _, p := range products {
+		result[p.SKU] = p
+	}
+	return result, nil
+}
+
+// productRefs — категории и поставщики (включая архивные) для проверки ссылок строк пакета.
+type productRefs struct {
+	categories map[string]*models.Category
+	suppliers  map[string]*models.Supplier
+}
+
+// loadProductRefs загружает все категории и всех поставщиков: их намного меньше, чем товаров,
+// и два запроса на пакет дешевле, чем по запросу на строку.
+func (s *ProductService) loadProductRefs(ctx context.Context) (*productRefs, error) {
+	categories, err := s.categories.GetAll(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	suppliers, err := s.suppliers.GetAll(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	refs := &productRefs{
+		categories: make(map[string]*models.Category, len(categories)),
+		suppliers:  make(map[string]*models.Supplier, len(suppliers)),
+	}
+	for _, c := range categories {
+		refs.categories[c.ID] = c
+	}
+	for _, sup := range suppliers {
+		refs.suppliers[sup.ID] = sup
+	}
+	return refs, nil
+}
+
+// batchProductFields проверяет поля строки создания или изменения (current — товар до изменения,
+// nil при создании) и возвращает товар с ними.
+func batchProductFields(item ProductBatchItem, current *models.Product, refs *productRefs) (*models.Product, error) {
+	name := strings.TrimSpace(item.Name)
+	categoryID := strings.TrimSpace(item.CategoryID)
+	supplierID := strings.TrimSpace(item.SupplierID)
+	if item.SKU == "" || name == "" || categoryID == "" {
+		return nil, ErrInvalidProduct
+	}
+	unit, err := productUnit(item.Unit)
+	if err != nil {
+		return nil, err
+	}
+	if err := productReferenceError(current, categoryID, refs.categories[categoryID], supplierID, refs.suppliers[supplierID]); err != nil {
+		return nil, err
+	}
+	return models.NewProduct(item.SKU, name, item.Description, categoryID, supplierID, unit), nil
+}
+
+// newBatchResults создаёт результаты для n строк пакета.
+func newBatchResults(n int) []ProductBatchResult {
+	results := make([]ProductBatchResult, n)
+	for i := range results {
+		results[i].Index = i
+	}
+	return results
+}
+
+// rejectBatch помечает строки отклонённого пакета: у корректных строк — ErrBatchRowNotApplied.
+func rejectBatch(results []ProductBatchResult) {
+	for i := range results {
+		results[i].Product = nil
+		if results[i].Err == nil {
+			results[i].Err = ErrBatchRowNotApplied
+		}
+	}
+}
+
+// nonEmptyUnique возвращает непустые значения без повторов в исходном порядке.
+func nonEmptyUnique(values []string) []string {
+	result := make([]string, 0, len(values))
+	seen := make(map[string]bool, len(values))
+	for _, v := range values {
+		if v != "" && !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -1,46 +1,158 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"strings"
+	"time"
 	"warehouse-management-system/src/models"
 )
 
 // ProductRepository описывает поведение хранилища товаров, нужное слою сервисов.
 type ProductRepository interface {
-	GetAll() ([]*models.Product, error)
-	GetByID(id string) (*models.Product, error)
-	GetBySKU(sku string) (*models.Product, error)
-	Create(product *models.Product) error
-	Update(product *models.Product) error
-	Delete(id string) error
+	Find(ctx context.Context, filter models.ProductFilter) ([]*models.Product, error)
+	Count(ctx context.Context, filter models.ProductFilter) (int, error)
+	GetByID(ctx context.Context, id string) (*models.Product, error)
+	GetBySKU(ctx context.Context, sku string) (*models.Product, error)
+	Create(ctx context.Context, product *models.Product) error
+	Update(ctx context.Context, product *models.Product) error
+	SetAttributes(ctx context.Context, product *models.Product) error
+	Archive(ctx context.Context, id string, version int64, at time.Time) error
+	Restore(ctx context.Context, id string, version int64, at time.Time) error
+	IsReferenced(ctx context.Context, id string) (bool, error)
+	ReferencedIDs(ctx context.Context, ids []string) (map[string]bool, error)
+	HasBaseUnitData(ctx context.Context, id string) (bool, error)
+	Delete(ctx context.Context, id string, version int64) error
+	GetByIDs(ctx context.Context, ids []string) ([]*models.Product, error)
+	GetBySKUs(ctx context.Context, skus []string) ([]*models.Product, error)
+	ApplyBatch(ctx context.Context, changes []models.ProductChange) error
+}
+
+// ProductSearchIndex описывает полнотекстовый поиск товаров.
+type ProductSearchIndex interface {
+	Search(ctx context.Context, terms []string, limit int) ([]*models.ProductSearchHit, error)
 }
 
 // ProductService инкапсулирует бизнес-логику работы с товарами.
 type ProductService struct {
-	repo ProductRepository
+	repo       ProductRepository
+	search     ProductSearchIndex
+	units      UnitRepository
+	categories CategoryRepository
+	suppliers  SupplierRepository
+	audit      AuditRecorder
 }
 
 // NewProductService — конструктор сервиса товаров.
-func NewProductService(repo ProductRepository) *ProductService {
-	return &ProductService{repo: repo}
+func NewProductService(repo ProductRepository, search ProductSearchIndex, units UnitRepository, categories CategoryRepository, suppliers SupplierRepository, audit AuditRecorder) *ProductService {
+	return &ProductService{repo: repo, search: search, units: units, categories: categories, suppliers: suppliers, audit: audit}
 }
 
 var (
 	ErrProductNotFound = errors.New("product not found")
-	ErrSKUAlreadyUsed  = errors.New("product with this SKU already exists")
+	ErrSKUAlreadyUsed  = models.ErrDuplicateSKU
 	ErrInvalidProduct  = errors.New("invalid product data")
+	ErrProductArchived = errors.New("product is archived")
+	ErrProductInUse    = errors.New("product is referenced by stock movements, orders, variants or attachments, archive it instead")
+	ErrUnitHasStock    = errors.New("unit of measure cannot be changed for a product with stock movements, balances, order lines, prices, supplier costs or barcodes")
+
+	ErrInvalidProductFilter = errors.New("invalid product filter")
+
+	ErrInvalidReference = errors.New("product references missing or archived records")
 )
 
-// ListProducts возвращает все товары.
-// Позже сюда можно будет добавить параметры пагинации, фильтрации и сортировки.
-func (s *ProductService) ListProducts() ([]*models.Product, error) {
-	return s.repo.GetAll()
+// FieldError — ошибка значения одного поля запроса.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// ReferenceError — товар ссылается на несуществующую или архивную категорию либо поставщика.
+// Fields перечисляет поля со ссылками; errors.Is(err, ErrInvalidReference) для неё истинно.
+type ReferenceError struct {
+	Fields []FieldError
+}
+
+func (e *ReferenceError) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		parts[i] = f.Field + ": " + f.Message
+	}
+	return ErrInvalidReference.Error() + " (" + strings.Join(parts, "; ") + ")"
+}
+
+func (e *ReferenceError) Is(target error) bool {
+	return target == ErrInvalidReference
+}
+
+const (
+	defaultProductLimit = 50
+	maxProductLimit     = 500
+
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+	// maxSearchTerms ограничивает число слов в поисковом запросе.
+	maxSearchTerms = 8
+)
+
+// ListProducts возвращает страницу товаров по фильтру и общее число подходящих товаров.
+// По умолчанию товары отсортированы от новых к старым, страница — defaultProductLimit товаров.
+// Курсор страницы действителен только для той сортировки, с которой он получен.
+func (s *ProductService) ListProducts(ctx context.Context, filter models.ProductFilter) (*models.ProductPage, error) {
+	filter.Query = strings.TrimSpace(filter.Query)
+	filter.CategoryID = strings.TrimSpace(filter.CategoryID)
+	filter.SupplierID = strings.TrimSpace(filter.SupplierID)
+	filter.ParentID = strings.TrimSpace(filter.ParentID)
+	if filter.Sort == "" {
+		filter.Sort, filter.Desc = models.ProductSortCreatedAt, true
+	}
+	if !filter.Sort.Valid() || filter.Limit < 0 || filter.Offset < 0 || filter.Limit > maxProductLimit {
+		return nil, ErrInvalidProductFilter
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultProductLimit
+	}
+	if filter.After != nil && (filter.Offset > 0 || filter.After.Sort != filter.Sort || filter.After.Desc != filter.Desc) {
+		return nil, models.ErrInvalidCursor
+	}
+
+	total, err := s.repo.Count(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// Запрашиваем на один товар больше, чтобы узнать, есть ли следующая страница.
+	limit := filter.Limit
+	filter.Limit++
+	items, err := s.repo.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.ProductPage{Items: items, Total: total}
+	if len(items) > limit {
+		page.Items = items[:limit]
+		page.NextCursor = models.NewProductCursor(items[limit-1], filter.Sort, filter.Desc).Encode()
+	}
+	return page, nil
+}
+
+// SearchProducts ищет незаархивированные товары, в SKU, названии или описании которых встречаются
+// все слова запроса, и возвращает не более limit лучших совпадений (0 — defaultSearchLimit).
+func (s *ProductService) SearchProducts(ctx context.Context, query string, limit int) ([]*models.ProductSearchHit, error) {
+	terms := strings.Fields(query)
+	if len(terms) == 0 || len(terms) > maxSearchTerms || limit < 0 || limit > maxSearchLimit {
+		return nil, ErrInvalidProductFilter
+	}
+	if limit == 0 {
+		limit = defaultSearchLimit
+	}
+	return s.search.Search(ctx, terms, limit)
 }
 
 // GetProduct возвращает товар по ID.
-func (s *ProductService) GetProduct(id string) (*models.Product, error) {
-	product, err := s.repo.GetByID(id)
+func (s *ProductService) GetProduct(ctx context.Context, id string) (*models.Product, error) {
+	product, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -50,18 +162,27 @@ func (s *ProductService) GetProduct(id string) (*models.Product, error) {
 	return product, nil
 }
 
-// CreateProduct создаёт новый товар.
-func (s *ProductService) CreateProduct(sku, name, description, categoryID, supplierID, unit string) (*models.Product, error) {
+// CreateProduct создаёт новый товар. Категория обязательна, поставщик — нет (пустой supplierID);
+// ссылки должны вести на существующие незаархивированные записи, иначе — *ReferenceError.
+func (s *ProductService) CreateProduct(ctx context.Context, sku, name, description, categoryID, supplierID, unit string) (*models.Product, error) {
 	sku = strings.TrimSpace(sku)
 	name = strings.TrimSpace(name)
 	categoryID = strings.TrimSpace(categoryID)
+	supplierID = strings.TrimSpace(supplierID)
 
 	if sku == "" || name == "" || categoryID == "" {
 		return nil, ErrInvalidProduct
 	}
+	baseUnit, err := productUnit(unit)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkReferences(ctx, nil, categoryID, supplierID); err != nil {
+		return nil, err
+	}
 
 	// Проверяем уникальность SKU на уровне сервиса, чтобы бизнес-правило не зависело от конкретной БД.
-	existing, err := s.repo.GetBySKU(sku)
+	existing, err := s.repo.GetBySKU(ctx, sku)
 	if err != nil {
 		return nil, err
 	}
@@ -75,42 +196,59 @@ func (s *ProductService) CreateProduct(sku, name, description, categoryID, suppl
 		description,
 		categoryID,
 		supplierID,
-		models.UnitOfMeasure(unit),
+		baseUnit,
 	)
 
-	if err := s.repo.Create(product); err != nil {
+	if err := s.repo.Create(ctx, product); err != nil {
 		return nil, err
 	}
 
+	s.audit.Record(ctx, models.AuditActionCreate, models.AuditEntityProduct, product.ID, nil, product)
 	return product, nil
 }
 
-// UpdateProduct обновляет данные товара.
-func (s *ProductService) UpdateProduct(id, sku, name, description, categoryID, supplierID, unit string) (*models.Product, error) {
+// UpdateProduct обновляет данные товара. expectedVersion — версия, которую видел клиент (If-Match):
+// если товар с тех пор изменили, возвращается ErrVersionMismatch. Ссылки проверяются, как при создании,
+// но прежняя категория или поставщик, заархивированные после создания товара, допустимы.
+func (s *ProductService) UpdateProduct(ctx context.Context, id string, expectedVersion int64, sku, name, description, categoryID, supplierID, unit string) (*models.Product, error) {
 	id = strings.TrimSpace(id)
 	if id == "" {
 		return nil, ErrInvalidProduct
 	}
 
-	product, err := s.repo.GetByID(id)
+	product, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 	if product == nil {
 		return nil, ErrProductNotFound
 	}
+	if err := checkVersion(product.Version, expectedVersion); err != nil {
+		return nil, err
+	}
 
 	sku = strings.TrimSpace(sku)
 	name = strings.TrimSpace(name)
 	categoryID = strings.TrimSpace(categoryID)
+	supplierID = strings.TrimSpace(supplierID)
 
 	if sku == "" || name == "" || categoryID == "" {
 		return nil, ErrInvalidProduct
 	}
+	baseUnit, err := productUnit(unit)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkReferences(ctx, product, categoryID, supplierID); err != nil {
+		return nil, err
+	}
+	if err := s.checkUnitChange(ctx, product, baseUnit); err != nil {
+		return nil, err
+	}
 
 	// Проверка уникальности SKU при изменении.
 	if product.SKU != sku {
-		existing, err := s.repo.GetBySKU(sku)
+		existing, err := s.repo.GetBySKU(ctx, sku)
 		if err != nil {
 			return nil, err
 		}
@@ -119,27 +257,197 @@ func (s *ProductService) UpdateProduct(id, sku, name, description, categoryID, s
 		}
 	}
 
+	before := *product
 	product.SKU = sku
 	product.Name = name
 	product.Description = description
 	product.CategoryID = categoryID
 	product.SupplierID = supplierID
-	product.Unit = models.UnitOfMeasure(unit)
+	product.Unit = baseUnit
 	// Обновление UpdatedAt можно сделать здесь или на уровне репозитория/БД.
 
-	if err := s.repo.Update(product); err != nil {
+	if err := s.repo.Update(ctx, product); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, models.AuditActionUpdate, models.AuditEntityProduct, product.ID, before, product)
+	return product, nil
+}
+
+// ArchiveProduct архивирует товар (мягкое удаление). Повторная архивация ничего не меняет.
+func (s *ProductService) ArchiveProduct(ctx context.Context, id string, expectedVersion int64) error {
+	product, err := s.findProduct(ctx, id, expectedVersion)
+	if err != nil {
+		return err
+	}
+	if product.ArchivedAt != nil {
+		return nil
+	}
+
+	before := *product
+	now := time.Now().UTC()
+	if err := s.repo.Archive(ctx, product.ID, product.Version, now); err != nil {
+		return err
+	}
+	product.ArchivedAt = &now
+	product.UpdatedAt = now
+	product.Version++
+
+	s.audit.Record(ctx, models.AuditActionArchive, models.AuditEntityProduct, product.ID, before, product)
+	return nil
+}
+
+// RestoreProduct возвращает заархивированный товар в работу.
+func (s *ProductService) RestoreProduct(ctx context.Context, id string, expectedVersion int64) (*models.Product, error) {
+	product, err := s.findProduct(ctx, id, expectedVersion)
+	if err != nil {
 		return nil, err
 	}
+	if product.ArchivedAt == nil {
+		return product, nil
+	}
 
+	before := *product
+	now := time.Now().UTC()
+	if err := s.repo.Restore(ctx, product.ID, product.Version, now); err != nil {
+		return nil, err
+	}
+	product.ArchivedAt = nil
+	product.UpdatedAt = now
+	product.Version++
+
+	s.audit.Record(ctx, models.AuditActionRestore, models.AuditEntityProduct, product.ID, before, product)
 	return product, nil
 }
 
-// DeleteProduct удаляет товар по ID.
-func (s *ProductService) DeleteProduct(id string) error {
+// DeleteProduct физически удаляет товар по ID. Товар, по которому есть движения или заказы,
+// удалить нельзя (ErrProductInUse) — его можно только заархивировать.
+func (s *ProductService) DeleteProduct(ctx context.Context, id string, expectedVersion int64) error {
+	product, err := s.findProduct(ctx, id, expectedVersion)
+	if err != nil {
+		return err
+	}
+
+	referenced, err := s.repo.IsReferenced(ctx, product.ID)
+	if err != nil {
+		return err
+	}
+	if referenced {
+		return ErrProductInUse
+	}
+
+	if err := s.repo.Delete(ctx, product.ID, product.Version); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, models.AuditActionDelete, models.AuditEntityProduct, product.ID, product, nil)
+	return nil
+}
+
+// findProduct загружает товар (в том числе архивный) для операций над ним и сверяет его версию.
+func (s *ProductService) findProduct(ctx context.Context, id string, expectedVersion int64) (*models.Product, error) {
 	id = strings.TrimSpace(id)
 	if id == "" {
-		return ErrInvalidProduct
+		return nil, ErrInvalidProduct
+	}
+
+	product, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, ErrProductNotFound
+	}
+	if err := checkVersion(product.Version, expectedVersion); err != nil {
+		return nil, err
 	}
+	return product, nil
+}
 
-	return s.repo.Delete(id)
+// checkReferences проверяет, что категория и поставщик (если задан) товара существуют.
+// current — товар до изменения (nil при создании): ссылку на архивную запись можно оставить прежней,
+// но нельзя установить заново.
+func (s *ProductService) checkReferences(ctx context.Context, current *models.Product, categoryID, supplierID string) error {
+	category, err := s.categories.GetByID(ctx, categoryID)
+	if err != nil {
+		return err
+	}
+	var supplier *models.Supplier
+	if supplierID != "" {
+		if supplier, err = s.suppliers.GetByID(ctx, supplierID); err != nil {
+			return err
+		}
+	}
+	return productReferenceError(current, categoryID, category, supplierID, supplier)
+}
+
+// productReferenceError сверяет ссылки товара с найденными записями (nil — запись не найдена)
+// и возвращает *ReferenceError со всеми неверными полями или nil.
+func productReferenceError(current *models.Product, categoryID string, category *models.Category, supplierID string, supplier *models.Supplier) error {
+	var fields []FieldError
+	switch {
+	case category == nil:
+		fields = append(fields, FieldError{Field: "category_id", Message: "category " + categoryID + " not found"})
+	case category.ArchivedAt != nil && (current == nil || current.CategoryID != categoryID):
+		fields = append(fields, FieldError{Field: "category_id", Message: "category " + categoryID + " is archived"})
+	}
+	switch {
+	case supplierID == "":
+	case supplier == nil:
+		fields = append(fields, FieldError{Field: "supplier_id", Message: "supplier " + supplierID + " not found"})
+	case supplier.ArchivedAt != nil && (current == nil || current.SupplierID != supplierID):
+		fields = append(fields, FieldError{Field: "supplier_id", Message: "supplier " + supplierID + " is archived"})
+	}
+	if len(fields) > 0 {
+		return &ReferenceError{Fields: fields}
+	}
+	return nil
+}
+
+// checkUnitChange проверяет, можно ли сменить базовую единицу товара на unit.
+// Дополнительные единицы заданы через базовую, поэтому сменить её можно только без них.
+// Шаблон и его варианты учитываются в одной единице, иначе остатки нельзя сложить.
+// Движения, остатки, позиции заказов, цены прайс-листов, условия поставщиков и количества в штрихкодах
+// записаны в прежней единице: после смены они читались бы в новой, поэтому у товара с ними единица не меняется.
+func (s *ProductService) checkUnitChange(ctx context.Context, product *models.Product, unit models.UnitOfMeasure) error {
+	if unit == product.Unit {
+		return nil
+	}
+	if product.ParentID != "" {
+		return ErrVariantUnit
+	}
+	variants, err := s.repo.Count(ctx, models.ProductFilter{ParentID: product.ID, IncludeArchived: true})
+	if err != nil {
+		return err
+	}
+	if variants > 0 {
+		return ErrVariantUnit
+	}
+	conversions, err := s.units.GetByProduct(ctx, product.ID)
+	if err != nil {
+		return err
+	}
+	if len(conversions) > 0 {
+		return ErrUnitInUse
+	}
+	inUse, err := s.repo.HasBaseUnitData(ctx, product.ID)
+	if err != nil {
+		return err
+	}
+	if inUse {
+		return ErrUnitHasStock
+	}
+	return nil
+}
+
+// productUnit проверяет базовую единицу измерения товара; пустая означает штуки.
+func productUnit(unit string) (models.UnitOfMeasure, error) {
+	u := normalizeUnit(models.UnitOfMeasure(unit))
+	if u == "" {
+		return models.UnitPiece, nil
+	}
+	if !u.Valid() {
+		return "", ErrInvalidUnit
+	}
+	return u, nil
 }
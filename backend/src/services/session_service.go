@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"warehouse-management-system/src/models"
+)
+
+// SessionRepository описывает поведение хранилища сессий пользователей.
+type SessionRepository interface {
+	GetByID(ctx context.Context, id string) (*models.Session, error)
+	GetActiveByUser(ctx context.Context, userID string, now time.Time) ([]*models.Session, error)
+	Create(ctx context.Context, session *models.Session) error
+	TouchLastSeen(ctx context.Context, id string, at time.Time) error
+	Revoke(ctx context.Context, id string, at time.Time) error
+}
+
+// ClientInfo — сведения об устройстве, с которого выполняется вход.
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+}
+
+// SessionService ведёт серверные записи о выданных токенах: по ним AuthMiddleware
+// проверяет, что вход не был завершён, а администратор видит и завершает сессии пользователей.
+type SessionService struct {
+	repo     SessionRepository
+	userRepo UserRepository
+	audit    AuditRecorder
+}
+
+// NewSessionService — конструктор сервиса сессий.
+func NewSessionService(repo SessionRepository, userRepo UserRepository, audit AuditRecorder) *SessionService {
+	return &SessionService{
+		repo:     repo,
+		userRepo: userRepo,
+		audit:    audit,
+	}
+}
+
+var ErrSessionNotFound = errors.New("session not found")
+
+const (
+	// sessionTTL совпадает со сроком действия JWT.
+	sessionTTL = 24 * time.Hour
+	// sessionTouchInterval ограничивает частоту записи last_seen_at: не чаще раза в минуту на сессию.
+	sessionTouchInterval = time.Minute
+	// maxUserAgentLength обрезает слишком длинные User-Agent перед сохранением.
+	maxUserAgentLength = 512
+)
+
+// StartSession создаёт сессию для пользователя, успешно прошедшего аутентификацию.
+func (s *SessionService) StartSession(ctx context.Context, user *models.User, provider string, client ClientInfo) (*models.Session, error) {
+	userAgent := client.UserAgent
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+
+	session := models.NewSession(user.ID, provider, userAgent, client.IP, sessionTTL)
+	if err := s.repo.Create(ctx, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// ValidateSession проверяет, что сессия из токена принадлежит пользователю и активна,
+// попутно обновляя время последней активности, и возвращает текущую роль пользователя:
+// роль в JWT фиксируется при входе и после её смены администратором или провайдером устаревает.
+// Ошибка возвращается, только если проверку не удалось выполнить.
+func (s *SessionService) ValidateSession(ctx context.Context, sessionID, userID string) (models.Role, bool, error) {
+	if sessionID == "" {
+		return "", false, nil
+	}
+
+	session, err := s.repo.GetByID(ctx, sessionID)
+	if err != nil {
+		return "", false, err
+	}
+	now := time.Now().UTC()
+	if session == nil || session.UserID != userID || !session.IsActive(now) {
+		return "", false, nil
+	}
+
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return "", false, err
+	}
+	if user == nil {
+		return "", false, nil
+	}
+
+	if now.Sub(session.LastSeenAt) >= sessionTouchInterval {
+		if err := s.repo.TouchLastSeen(ctx, session.ID, now); err != nil {
+			return "", false, err
+		}
+	}
+	return user.Role, true, nil
+}
+
+// ListUserSessions возвращает активные сессии пользователя.
+func (s *SessionService) ListUserSessions(ctx context.Context, userID string) ([]*models.Session, error) {
+	if err := s.ensureUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetActiveByUser(ctx, userID, time.Now().UTC())
+}
+
+// RevokeSession завершает одну сессию пользователя.
+func (s *SessionService) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	sessionID = strings.TrimSpace(sessionID)
+	if sessionID == "" {
+		return ErrSessionNotFound
+	}
+	if err := s.ensureUser(ctx, userID); err != nil {
+		return err
+	}
+
+	session, err := s.repo.GetByID(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	if session == nil || session.UserID != userID || !session.IsActive(now) {
+		return ErrSessionNotFound
+	}
+
+	return s.revoke(ctx, session, now)
+}
+
+// RevokeUserSessions завершает все активные сессии пользователя и возвращает их количество.
+func (s *SessionService) RevokeUserSessions(ctx context.Context, userID string) (int, error) {
+	if err := s.ensureUser(ctx, userID); err != nil {
+		return 0, err
+	}
+
+	now := time.Now().UTC()
+	sessions, err := s.repo.GetActiveByUser(ctx, userID, now)
+	if err != nil {
+		return 0, err
+	}
+	for _, session := range sessions {
+		if err := s.revoke(ctx, session, now); err != nil {
+			return 0, err
+		}
+	}
+	return len(sessions), nil
+}
+
+func (s *SessionService) revoke(ctx context.Context, session *models.Session, now time.Time) error {
+	if err := s.repo.Revoke(ctx, session.ID, now); err != nil {
+		return err
+	}
+
+	before := *session
+	session.RevokedAt = &now
+	s.audit.Record(ctx, models.AuditActionRevoke, models.AuditEntitySession, session.ID, before, session)
+	return nil
+}
+
+func (s *SessionService) ensureUser(ctx context.Context, userID string) error {
+	if userID == "" {
+		return ErrUserNotFound
+	}
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+	"warehouse-management-system/src/models"
+)
+
+// memorySessionRepo — SessionRepository в памяти для тестов сервиса сессий.
+type memorySessionRepo struct {
+	sessions map[string]*models.Session
+	touches  int
+}
+
+func (r *memorySessionRepo) GetByID(_ context.Context, id string) (*models.Session, error) {
+	if s, ok := r.sessions[id]; ok {
+		clone := *s
+		return &clone, nil
+	}
+	return nil, nil
+}
+
+func (r *memorySessionRepo) GetActiveByUser(_ context.Context, userID string, now time.Time) ([]*models.Session, error) {
+	var result []*models.Session
+	for _, s := range r.sessions {
+		if s.UserID == userID && s.IsActive(now) {
+			clone := *s
+			result = append(result, &clone)
+		}
+	}
+	return result, nil
+}
+
+func (r *memorySessionRepo) Create(_ context.Context, session *models.Session) error {
+	session.ID = "s_" + session.UserID
+	clone := *session
+	r.sessions[session.ID] = &clone
+	return nil
+}
+
+func (r *memorySessionRepo) TouchLastSeen(_ context.Context, id string, at time.Time) error {
+	r.touches++
+	r.sessions[id].LastSeenAt = at
+	return nil
+}
+
+func (r *memorySessionRepo) Revoke(_ context.Context, id string, at time.Time) error {
+	r.sessions[id].RevokedAt = &at
+	return nil
+}
+
+func TestValidateSessionReturnsCurrentRole(t *testing.T) {
+	users := newMemoryUserRepo(models.NewUser("u_1", "a@example.com", "hash", models.RoleAdmin))
+	sessions := &memorySessionRepo{sessions: map[string]*models.Session{}}
+	s := NewSessionService(sessions, users, nopAudit{})
+	ctx := context.Background()
+
+	session, err := s.StartSession(ctx, users.users["u_1"], LocalProvider, ClientInfo{})
+	if err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+
+	role, active, err := s.ValidateSession(ctx, session.ID, "u_1")
+	if err != nil || !active || role != models.RoleAdmin {
+		t.Fatalf("ValidateSession = %q, %v, %v", role, active, err)
+	}
+
+	// Понижение роли действует на уже выданный токен.
+	users.users["u_1"].Role = models.RoleStorekeeper
+	role, active, err = s.ValidateSession(ctx, session.ID, "u_1")
+	if err != nil || !active || role != models.RoleStorekeeper {
+		t.Fatalf("after demotion ValidateSession = %q, %v, %v", role, active, err)
+	}
+
+	if _, active, _ := s.ValidateSession(ctx, session.ID, "u_2"); active {
+		t.Fatalf("session must not be valid for another user")
+	}
+
+	delete(users.users, "u_1")
+	if _, active, _ := s.ValidateSession(ctx, session.ID, "u_1"); active {
+		t.Fatalf("session of a deleted user must not be valid")
+	}
+}
+
+func TestValidateSessionRejectsRevoked(t *testing.T) {
+	users := newMemoryUserRepo(models.NewUser("u_1", "a@example.com", "hash", models.RoleManager))
+	sessions := &memorySessionRepo{sessions: map[string]*models.Session{}}
+	s := NewSessionService(sessions, users, nopAudit{})
+	ctx := context.Background()
+
+	session, err := s.StartSession(ctx, users.users["u_1"], LocalProvider, ClientInfo{})
+	if err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	if err := s.RevokeSession(ctx, "u_1", session.ID); err != nil {
+		t.Fatalf("RevokeSession: %v", err)
+	}
+	if _, active, err := s.ValidateSession(ctx, session.ID, "u_1"); err != nil || active {
+		t.Fatalf("revoked session: active=%v err=%v", active, err)
+	}
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"warehouse-management-system/src/models"
+)
+
+// SupplierCostRepository описывает поведение хранилища закупочных условий поставщиков.
+type SupplierCostRepository interface {
+	GetByProduct(ctx context.Context, productID string) ([]*models.SupplierCost, error)
+	Get(ctx context.Context, supplierID, productID string) (*models.SupplierCost, error)
+	Save(ctx context.Context, c *models.SupplierCost) error
+	Delete(ctx context.Context, supplierID, productID string) error
+}
+
+// SupplierCostService инкапсулирует бизнес-логику закупочных условий: у товара может быть
+// несколько поставщиков, каждый со своей ценой, сроком поставки и минимальной партией.
+type SupplierCostService struct {
+	repo         SupplierCostRepository
+	supplierRepo SupplierRepository
+	productRepo  ProductRepository
+	audit        AuditRecorder
+}
+
+// NewSupplierCostService — конструктор сервиса закупочных условий.
+func NewSupplierCostService(repo SupplierCostRepository, supplierRepo SupplierRepository, productRepo ProductRepository, audit AuditRecorder) *SupplierCostService {
+	return &SupplierCostService{repo: repo, supplierRepo: supplierRepo, productRepo: productRepo, audit: audit}
+}
+
+var (
+	ErrSupplierCostNotFound = errors.New("supplier has no terms for the product")
+	ErrInvalidSupplierCost  = errors.New("invalid supplier cost data")
+	ErrSupplierArchived     = errors.New("supplier is archived")
+)
+
+// ListProductSuppliers возвращает условия всех поставщиков товара.
+func (s *SupplierCostService) ListProductSuppliers(ctx context.Context, productID string) ([]*models.SupplierCost, error) {
+	product, err := s.getProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetByProduct(ctx, product.ID)
+}
+
+// SetSupplierCost задаёт (или заменяет) условия поставщика на товар. Цена — за базовую единицу товара,
+// пустая валюта — models.DefaultCurrency, минимальная партия — в базовых единицах (0 — без ограничения).
+func (s *SupplierCostService) SetSupplierCost(ctx context.Context, productID, supplierID, supplierSKU string, cost models.Decimal, currency string, leadTimeDays int, minOrderQuantity models.Decimal) (*models.SupplierCost, error) {
+	product, err := s.getProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if product.ArchivedAt != nil {
+		return nil, ErrProductArchived
+	}
+	supplier, err := s.getSupplier(ctx, supplierID)
+	if err != nil {
+		return nil, err
+	}
+	if supplier.ArchivedAt != nil {
+		return nil, ErrSupplierArchived
+	}
+
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" {
+		currency = models.DefaultCurrency
+	}
+	if cost < 0 || !models.ValidCurrency(currency) {
+		return nil, ErrInvalidPrice
+	}
+	if leadTimeDays < 0 || minOrderQuantity < 0 {
+		return nil, ErrInvalidSupplierCost
+	}
+	if !minOrderQuantity.HasPrecision(product.Unit.Precision()) {
+		return nil, ErrQuantityPrecision
+	}
+
+	before, err := s.repo.Get(ctx, supplier.ID, product.ID)
+	if err != nil {
+		return nil, err
+	}
+	c := &models.SupplierCost{
+		SupplierID:       supplier.ID,
+		ProductID:        product.ID,
+		SupplierSKU:      strings.TrimSpace(supplierSKU),
+		Cost:             cost,
+		Currency:         currency,
+		LeadTimeDays:     leadTimeDays,
+		MinOrderQuantity: minOrderQuantity,
+	}
+	if before != nil {
+		c.CreatedAt = before.CreatedAt
+	}
+	if err := s.repo.Save(ctx, c); err != nil {
+		return nil, err
+	}
+
+	action := models.AuditActionCreate
+	var beforeState any
+	if before != nil {
+		action = models.AuditActionUpdate
+		beforeState = before
+	}
+	s.audit.Record(ctx, action, models.AuditEntitySupplierCost, supplierCostAuditID(c), beforeState, c)
+	return c, nil
+}
+
+// DeleteSupplierCost удаляет условия поставщика на товар.
+func (s *SupplierCostService) DeleteSupplierCost(ctx context.Context, productID, supplierID string) error {
+	product, err := s.getProduct(ctx, productID)
+	if err != nil {
+		return err
+	}
+	target, err := s.repo.Get(ctx, strings.TrimSpace(supplierID), product.ID)
+	if err != nil {
+		return err
+	}
+	if target == nil {
+		return ErrSupplierCostNotFound
+	}
+
+	if err := s.repo.Delete(ctx, target.SupplierID, target.ProductID); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, models.AuditActionDelete, models.AuditEntitySupplierCost, supplierCostAuditID(target), target, nil)
+	return nil
+}
+
+func (s *SupplierCostService) getProduct(ctx context.Context, id string) (*models.Product, error) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return nil, ErrInvalidProduct
+	}
+	product, err := s.productRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, ErrProductNotFound
+	}
+	return product, nil
+}
+
+func (s *SupplierCostService) getSupplier(ctx context.Context, id string) (*models.Supplier, error) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return nil, ErrInvalidSupplier
+	}
+	supplier, err := s.supplierRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if supplier == nil {
+		return nil, ErrSupplierNotFound
+	}
+	return supplier, nil
+}
+
+// supplierCostAuditID — идентификатор условий поставщика в журнале аудита.
+func supplierCostAuditID(c *models.SupplierCost) string {
+	return c.SupplierID + "/" + c.ProductID
+}
@@ -1,48 +1,55 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"strings"
+	"time"
 	"warehouse-management-system/src/models"
 )
 
 // SupplierRepository описывает поведение хранилища поставщиков для слоя сервисов.
 type SupplierRepository interface {
-	GetAll() ([]*models.Supplier, error)
-	GetByID(id string) (*models.Supplier, error)
-	Create(supplier *models.Supplier) error
-	Update(supplier *models.Supplier) error
-	Delete(id string) error
+	GetAll(ctx context.Context, includeArchived bool) ([]*models.Supplier, error)
+	GetByID(ctx context.Context, id string) (*models.Supplier, error)
+	Create(ctx context.Context, supplier *models.Supplier) error
+	Update(ctx context.Context, supplier *models.Supplier) error
+	Archive(ctx context.Context, id string, version int64, at time.Time) error
+	Restore(ctx context.Context, id string, version int64, at time.Time) error
+	IsReferenced(ctx context.Context, id string) (bool, error)
+	Delete(ctx context.Context, id string, version int64) error
 }
 
 // SupplierService инкапсулирует бизнес-логику работы с поставщиками.
 type SupplierService struct {
-	repo SupplierRepository
+	repo  SupplierRepository
+	audit AuditRecorder
 }
 
 // NewSupplierService — конструктор сервиса поставщиков.
-func NewSupplierService(repo SupplierRepository) *SupplierService {
-	return &SupplierService{repo: repo}
+func NewSupplierService(repo SupplierRepository, audit AuditRecorder) *SupplierService {
+	return &SupplierService{repo: repo, audit: audit}
 }
 
 var (
 	ErrSupplierNotFound = errors.New("supplier not found")
 	ErrInvalidSupplier  = errors.New("invalid supplier data")
+	ErrSupplierInUse    = errors.New("supplier is referenced by products, receipts or attachments, archive it instead")
 )
 
-// ListSuppliers возвращает список всех поставщиков.
-func (s *SupplierService) ListSuppliers() ([]*models.Supplier, error) {
-	return s.repo.GetAll()
+// ListSuppliers возвращает список поставщиков; заархивированных — только если includeArchived.
+func (s *SupplierService) ListSuppliers(ctx context.Context, includeArchived bool) ([]*models.Supplier, error) {
+	return s.repo.GetAll(ctx, includeArchived)
 }
 
 // GetSupplier возвращает поставщика по ID.
-func (s *SupplierService) GetSupplier(id string) (*models.Supplier, error) {
+func (s *SupplierService) GetSupplier(ctx context.Context, id string) (*models.Supplier, error) {
 	id = strings.TrimSpace(id)
 	if id == "" {
 		return nil, ErrInvalidSupplier
 	}
 
-	supplier, err := s.repo.GetByID(id)
+	supplier, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -53,7 +60,7 @@ func (s *SupplierService) GetSupplier(id string) (*models.Supplier, error) {
 }
 
 // CreateSupplier создаёт нового поставщика.
-func (s *SupplierService) CreateSupplier(name, address, phone, email string) (*models.Supplier, error) {
+func (s *SupplierService) CreateSupplier(ctx context.Context, name, address, phone, email string) (*models.Supplier, error) {
 	name = strings.TrimSpace(name)
 	address = strings.TrimSpace(address)
 	phone = strings.TrimSpace(phone)
@@ -65,27 +72,32 @@ func (s *SupplierService) CreateSupplier(name, address, phone, email string) (*m
 
 	supplier := models.NewSupplier(name, address, phone, email)
 
-	if err := s.repo.Create(supplier); err != nil {
+	if err := s.repo.Create(ctx, supplier); err != nil {
 		return nil, err
 	}
 
+	s.audit.Record(ctx, models.AuditActionCreate, models.AuditEntitySupplier, supplier.ID, nil, supplier)
 	return supplier, nil
 }
 
 // UpdateSupplier обновляет данные поставщика.
-func (s *SupplierService) UpdateSupplier(id, name, address, phone, email string) (*models.Supplier, error) {
+// Если запись изменили после чтения клиентом (expectedVersion из If-Match), возвращается ErrVersionMismatch.
+func (s *SupplierService) UpdateSupplier(ctx context.Context, id string, expectedVersion int64, name, address, phone, email string) (*models.Supplier, error) {
 	id = strings.TrimSpace(id)
 	if id == "" {
 		return nil, ErrInvalidSupplier
 	}
 
-	supplier, err := s.repo.GetByID(id)
+	supplier, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 	if supplier == nil {
 		return nil, ErrSupplierNotFound
 	}
+	if err := checkVersion(supplier.Version, expectedVersion); err != nil {
+		return nil, err
+	}
 
 	name = strings.TrimSpace(name)
 	address = strings.TrimSpace(address)
@@ -96,24 +108,95 @@ func (s *SupplierService) UpdateSupplier(id, name, address, phone, email string)
 		return nil, ErrInvalidSupplier
 	}
 
+	before := *supplier
 	supplier.Name = name
 	supplier.Address = address
 	supplier.Phone = phone
 	supplier.Email = email
 
-	if err := s.repo.Update(supplier); err != nil {
+	if err := s.repo.Update(ctx, supplier); err != nil {
 		return nil, err
 	}
 
+	s.audit.Record(ctx, models.AuditActionUpdate, models.AuditEntitySupplier, supplier.ID, before, supplier)
 	return supplier, nil
 }
 
-// DeleteSupplier удаляет поставщика по ID.
-func (s *SupplierService) DeleteSupplier(id string) error {
-	id = strings.TrimSpace(id)
-	if id == "" {
-		return ErrInvalidSupplier
+// ArchiveSupplier архивирует поставщика (мягкое удаление). Повторная архивация ничего не меняет.
+func (s *SupplierService) ArchiveSupplier(ctx context.Context, id string, expectedVersion int64) error {
+	supplier, err := s.GetSupplier(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := checkVersion(supplier.Version, expectedVersion); err != nil {
+		return err
+	}
+	if supplier.ArchivedAt != nil {
+		return nil
+	}
+
+	before := *supplier
+	now := time.Now().UTC()
+	if err := s.repo.Archive(ctx, supplier.ID, supplier.Version, now); err != nil {
+		return err
+	}
+	supplier.ArchivedAt = &now
+	supplier.UpdatedAt = now
+	supplier.Version++
+
+	s.audit.Record(ctx, models.AuditActionArchive, models.AuditEntitySupplier, supplier.ID, before, supplier)
+	return nil
+}
+
+// RestoreSupplier возвращает заархивированного поставщика в работу.
+func (s *SupplierService) RestoreSupplier(ctx context.Context, id string, expectedVersion int64) (*models.Supplier, error) {
+	supplier, err := s.GetSupplier(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(supplier.Version, expectedVersion); err != nil {
+		return nil, err
+	}
+	if supplier.ArchivedAt == nil {
+		return supplier, nil
+	}
+
+	before := *supplier
+	now := time.Now().UTC()
+	if err := s.repo.Restore(ctx, supplier.ID, supplier.Version, now); err != nil {
+		return nil, err
+	}
+	supplier.ArchivedAt = nil
+	supplier.UpdatedAt = now
+	supplier.Version++
+
+	s.audit.Record(ctx, models.AuditActionRestore, models.AuditEntitySupplier, supplier.ID, before, supplier)
+	return supplier, nil
+}
+
+// DeleteSupplier физически удаляет поставщика по ID. Поставщика, на которого ссылаются товары,
+// приёмки или вложения, удалить нельзя (ErrSupplierInUse) — его можно только заархивировать.
+func (s *SupplierService) DeleteSupplier(ctx context.Context, id string, expectedVersion int64) error {
+	supplier, err := s.GetSupplier(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := checkVersion(supplier.Version, expectedVersion); err != nil {
+		return err
+	}
+
+	referenced, err := s.repo.IsReferenced(ctx, supplier.ID)
+	if err != nil {
+		return err
+	}
+	if referenced {
+		return ErrSupplierInUse
+	}
+
+	if err := s.repo.Delete(ctx, supplier.ID, supplier.Version); err != nil {
+		return err
 	}
 
-	return s.repo.Delete(id)
+	s.audit.Record(ctx, models.AuditActionDelete, models.AuditEntitySupplier, supplier.ID, supplier, nil)
+	return nil
 }
@@ -0,0 +1,271 @@
+package services
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Поддерживаемые алгоритмы подписи JWT.
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+// signingKey — один ключ подписи/проверки JWT. Для ключей, оставленных только для проверки
+// (после ротации, когда закрытая часть уничтожена), private == nil.
+type signingKey struct {
+	id      string
+	method  jwt.SigningMethod
+	private any // []byte для HMAC, *rsa.PrivateKey или ed25519.PrivateKey
+	public  any // []byte для HMAC, *rsa.PublicKey или ed25519.PublicKey
+}
+
+// TokenKeySet хранит ключи подписи JWT, идентифицируемые kid.
+// Токены подписываются активным ключом, проверяются любым ключом из набора —
+// так старые токены остаются валидными на время ротации.
+type TokenKeySet struct {
+	active *signingKey
+	keys   map[string]*signingKey
+}
+
+var ErrNoSigningKey = errors.New("no usable jwt signing key")
+
+// NewHMACKeySet создаёт набор из одного HMAC-секрета (HS256).
+func NewHMACKeySet(secret string) *TokenKeySet {
+	k := &signingKey{
+		id:      "",
+		method:  jwt.SigningMethodHS256,
+		private: []byte(secret),
+		public:  []byte(secret),
+	}
+	return &TokenKeySet{
+		active: k,
+		keys:   map[string]*signingKey{k.id: k},
+	}
+}
+
+// LoadTokenKeySet загружает асимметричные ключи из каталога dir: каждый *.pem файл — один ключ,
+// имя файла без расширения — его kid. Файлы с "PRIVATE KEY" используются для подписи и проверки,
+// с "PUBLIC KEY" — только для проверки. Активный ключ — activeKID либо последний по имени.
+func LoadTokenKeySet(alg, dir, activeKID string) (*TokenKeySet, error) {
+	method, err := signingMethod(alg)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	set := &TokenKeySet{keys: map[string]*signingKey{}}
+	for _, file := range files {
+		kid := strings.TrimSuffix(filepath.Base(file), ".pem")
+		key, err := loadPEMKey(file, kid, method)
+		if err != nil {
+			return nil, err
+		}
+		set.keys[kid] = key
+		if key.private != nil && (activeKID == "" || activeKID == kid) {
+			set.active = key
+		}
+	}
+
+	if set.active == nil {
+		return nil, fmt.Errorf("%w in %s (alg %s, active kid %q)", ErrNoSigningKey, dir, alg, activeKID)
+	}
+	return set, nil
+}
+
+// GenerateEphemeralKeySet создаёт набор из одного случайного ключа, живущего до перезапуска.
+// Подходит только для разработки: после рестарта все выданные токены станут недействительными.
+func GenerateEphemeralKeySet(alg string) (*TokenKeySet, error) {
+	method, err := signingMethod(alg)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &signingKey{id: "dev-ephemeral", method: method}
+	switch alg {
+	case AlgRS256:
+		priv, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return nil, err
+		}
+		key.private, key.public = priv, &priv.PublicKey
+	case AlgEdDSA:
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		key.private, key.public = priv, pub
+	default:
+		return nil, fmt.Errorf("cannot generate ephemeral key for %s", alg)
+	}
+
+	log.Printf("WARNING: using ephemeral %s signing key %q, tokens will not survive restart", alg, key.id)
+	return &TokenKeySet{
+		active: key,
+		keys:   map[string]*signingKey{key.id: key},
+	}, nil
+}
+
+// Sign подписывает claims активным ключом и проставляет kid в заголовок токена.
+func (s *TokenKeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(s.active.method, claims)
+	if s.active.id != "" {
+		token.Header["kid"] = s.active.id
+	}
+	return token.SignedString(s.active.private)
+}
+
+// Keyfunc возвращает ключ проверки по kid из заголовка токена.
+// Токены без kid (выданные до ротации) проверяются активным ключом.
+func (s *TokenKeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	key, ok := s.keys[kid]
+	if !ok && kid == "" {
+		key, ok = s.active, true
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown jwt key id %q", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, jwt.ErrTokenUnverifiable
+	}
+	return key.public, nil
+}
+
+// ValidMethods возвращает алгоритмы, которые допускается принимать при проверке.
+func (s *TokenKeySet) ValidMethods() []string {
+	seen := map[string]bool{}
+	var result []string
+	for _, k := range s.keys {
+		if alg := k.method.Alg(); !seen[alg] {
+			seen[alg] = true
+			result = append(result, alg)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// JWKS возвращает открытые ключи набора в формате JSON Web Key Set.
+// HMAC-секреты, разумеется, не публикуются.
+func (s *TokenKeySet) JWKS() JSONWebKeySet {
+	set := JSONWebKeySet{Keys: []JSONWebKey{}}
+
+	kids := make([]string, 0, len(s.keys))
+	for kid := range s.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	for _, kid := range kids {
+		k := s.keys[kid]
+		switch pub := k.public.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, JSONWebKey{
+				Kty: "RSA",
+				Kid: k.id,
+				Use: "sig",
+				Alg: k.method.Alg(),
+				N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, JSONWebKey{
+				Kty: "OKP",
+				Kid: k.id,
+				Use: "sig",
+				Alg: k.method.Alg(),
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(pub),
+			})
+		}
+	}
+	return set
+}
+
+func signingMethod(alg string) (jwt.SigningMethod, error) {
+	switch alg {
+	case AlgRS256:
+		return jwt.SigningMethodRS256, nil
+	case AlgEdDSA:
+		return jwt.SigningMethodEdDSA, nil
+	}
+	return nil, fmt.Errorf("unsupported jwt algorithm %q", alg)
+}
+
+// loadPEMKey читает ключ из PEM-файла и проверяет, что он подходит к алгоритму.
+func loadPEMKey(file, kid string, method jwt.SigningMethod) (*signingKey, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM block found", file)
+	}
+
+	key := &signingKey{id: kid, method: method}
+	switch block.Type {
+	case "PRIVATE KEY":
+		priv, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		signer, ok := priv.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("%s: unsupported private key", file)
+		}
+		key.private, key.public = priv, signer.Public()
+	case "RSA PRIVATE KEY":
+		priv, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		key.private, key.public = priv, &priv.PublicKey
+	case "PUBLIC KEY":
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		key.public = pub
+	default:
+		return nil, fmt.Errorf("%s: unsupported PEM block %q", file, block.Type)
+	}
+
+	switch key.public.(type) {
+	case *rsa.PublicKey:
+		if method != jwt.SigningMethodRS256 {
+			return nil, fmt.Errorf("%s: RSA key cannot be used with %s", file, method.Alg())
+		}
+	case ed25519.PublicKey:
+		if method != jwt.SigningMethodEdDSA {
+			return nil, fmt.Errorf("%s: Ed25519 key cannot be used with %s", file, method.Alg())
+		}
+	default:
+		return nil, fmt.Errorf("%s: unsupported key type", file)
+	}
+
+	return key, nil
+}
@@ -0,0 +1,308 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"warehouse-management-system/src/models"
+)
+
+// UnitRepository описывает поведение хранилища дополнительных единиц измерения товаров.
+type UnitRepository interface {
+	GetByProduct(ctx context.Context, productID string) ([]*models.UnitConversion, error)
+	GetByUnit(ctx context.Context, unit models.UnitOfMeasure) ([]*models.UnitConversion, error)
+	Save(ctx context.Context, c *models.UnitConversion) error
+	Delete(ctx context.Context, productID string, unit models.UnitOfMeasure) error
+}
+
+// UnitService инкапсулирует бизнес-логику единиц измерения: иерархию упаковок товара
+// и пересчёт количеств в базовую единицу.
+type UnitService struct {
+	repo        UnitRepository
+	productRepo ProductRepository
+	barcodes    BarcodeRepository
+	audit       AuditRecorder
+}
+
+// NewUnitService — конструктор сервиса единиц измерения.
+func NewUnitService(repo UnitRepository, productRepo ProductRepository, barcodes BarcodeRepository, audit AuditRecorder) *UnitService {
+	return &UnitService{repo: repo, productRepo: productRepo, barcodes: barcodes, audit: audit}
+}
+
+var (
+	ErrInvalidUnit         = errors.New("unknown unit of measure")
+	ErrInvalidUnitQuantity = errors.New("unit quantity must be positive")
+	ErrUnitInUse           = errors.New("unit is used by another unit of the product")
+	ErrUnitHasBarcodes     = errors.New("unit is used as packaging by product barcodes, remove them first")
+)
+
+// ListUnits возвращает дополнительные единицы товара с вычисленными коэффициентами пересчёта.
+func (s *UnitService) ListUnits(ctx context.Context, productID string) ([]*models.UnitConversion, error) {
+	product, err := s.getProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	conversions, err := s.repo.GetByProduct(ctx, product.ID)
+	if err != nil {
+		return nil, err
+	}
+	byUnit := unitMap(conversions)
+	for _, c := range conversions {
+		if c.Factor, err = models.UnitFactor(product.Unit, c.Unit, byUnit); err != nil {
+			return nil, err
+		}
+	}
+	return conversions, nil
+}
+
+// SetUnit задаёт (или заменяет) дополнительную единицу товара: 1 unit = quantity ofUnit.
+// Пустой ofUnit означает базовую единицу товара.
+func (s *UnitService) SetUnit(ctx context.Context, productID string, unit models.UnitOfMeasure, quantity models.Decimal, ofUnit models.UnitOfMeasure) (*models.UnitConversion, error) {
+	product, err := s.getProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if product.ArchivedAt != nil {
+		return nil, ErrProductArchived
+	}
+
+	unit = normalizeUnit(unit)
+	ofUnit = normalizeUnit(ofUnit)
+	if ofUnit == "" {
+		ofUnit = product.Unit
+	}
+	if !unit.Valid() || !ofUnit.Valid() {
+		return nil, ErrInvalidUnit
+	}
+	if unit == product.Unit {
+		return nil, models.ErrUnitCycle
+	}
+	if quantity <= 0 {
+		return nil, ErrInvalidUnitQuantity
+	}
+
+	conversions, err := s.repo.GetByProduct(ctx, product.ID)
+	if err != nil {
+		return nil, err
+	}
+	byUnit := unitMap(conversions)
+	before := byUnit[unit]
+	previous := unitMap(conversions)
+
+	c := &models.UnitConversion{
+		ProductID: product.ID,
+		Unit:      unit,
+		Quantity:  quantity,
+		OfUnit:    ofUnit,
+	}
+	if before != nil {
+		c.CreatedAt = before.CreatedAt
+	}
+	byUnit[unit] = c
+
+	// Новое соотношение не должно замыкать цепочку, а штучный товар — дробиться на части.
+	// Проверяем все единицы: изменённая может быть звеном чужой цепочки.
+	for u := range byUnit {
+		factor, err := models.UnitFactor(product.Unit, u, byUnit)
+		if err != nil {
+			return nil, unitError(err)
+		}
+		if !factor.HasPrecision(product.Unit.Precision()) {
+			return nil, ErrQuantityPrecision
+		}
+		if u == unit {
+			c.Factor = factor
+		}
+	}
+	if err := s.checkBarcodes(ctx, product, previous, byUnit); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Save(ctx, c); err != nil {
+		return nil, err
+	}
+
+	action := models.AuditActionCreate
+	var beforeState any
+	if before != nil {
+		action = models.AuditActionUpdate
+		beforeState = before
+	}
+	s.audit.Record(ctx, action, models.AuditEntityProductUnit, unitAuditID(c), beforeState, c)
+	return c, nil
+}
+
+// DeleteUnit удаляет дополнительную единицу товара. Единицу, через которую задана другая,
+// или упаковку штрихкода товара удалить нельзя.
+func (s *UnitService) DeleteUnit(ctx context.Context, productID string, unit models.UnitOfMeasure) error {
+	product, err := s.getProduct(ctx, productID)
+	if err != nil {
+		return err
+	}
+	conversions, err := s.repo.GetByProduct(ctx, product.ID)
+	if err != nil {
+		return err
+	}
+
+	unit = normalizeUnit(unit)
+	var target *models.UnitConversion
+	for _, c := range conversions {
+		if c.Unit == unit {
+			target = c
+		}
+		if c.OfUnit == unit {
+			return ErrUnitInUse
+		}
+	}
+	if target == nil {
+		return models.ErrUnitNotDefined
+	}
+	remaining := unitMap(conversions)
+	delete(remaining, unit)
+	if err := s.checkBarcodes(ctx, product, unitMap(conversions), remaining); err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, product.ID, unit); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, models.AuditActionDelete, models.AuditEntityProductUnit, unitAuditID(target), target, nil)
+	return nil
+}
+
+// toBase пересчитывает количество quantity в единице unit в базовую единицу товара и возвращает
+// его вместе с коэффициентом пересчёта. Пустая unit означает базовую единицу.
+func (s *UnitService) toBase(ctx context.Context, product *models.Product, quantity models.Decimal, unit models.UnitOfMeasure) (models.Decimal, models.Decimal, error) {
+	one := models.DecimalFromInt(1)
+	unit = normalizeUnit(unit)
+	if unit == "" || unit == product.Unit {
+		if !quantity.HasPrecision(product.Unit.Precision()) {
+			return 0, 0, ErrQuantityPrecision
+		}
+		return quantity, one, nil
+	}
+	if !unit.Valid() {
+		return 0, 0, ErrInvalidUnit
+	}
+
+	conversions, err := s.repo.GetByProduct(ctx, product.ID)
+	if err != nil {
+		return 0, 0, err
+	}
+	factor, err := models.UnitFactor(product.Unit, unit, unitMap(conversions))
+	if err != nil {
+		return 0, 0, unitError(err)
+	}
+	base, err := quantity.Mul(factor)
+	if err != nil || !base.HasPrecision(product.Unit.Precision()) {
+		return 0, 0, ErrQuantityPrecision
+	}
+	return base, factor, nil
+}
+
+// inUnit пересчитывает остатки items в единицу unit. Товары, для которых единица не задана,
+// остаются в своей базовой единице.
+func (s *UnitService) inUnit(ctx context.Context, items []*models.StockItem, unit models.UnitOfMeasure) error {
+	unit = normalizeUnit(unit)
+	if !unit.Valid() {
+		return ErrInvalidUnit
+	}
+	conversions, err := s.repo.GetByUnit(ctx, unit)
+	if err != nil {
+		return err
+	}
+	byProduct := make(map[string]map[models.UnitOfMeasure]*models.UnitConversion)
+	for _, c := range conversions {
+		if byProduct[c.ProductID] == nil {
+			byProduct[c.ProductID] = make(map[models.UnitOfMeasure]*models.UnitConversion)
+		}
+		byProduct[c.ProductID][c.Unit] = c
+	}
+
+	for _, it := range items {
+		product, err := s.productRepo.GetByID(ctx, it.ProductID)
+		if err != nil {
+			return err
+		}
+		if product == nil {
+			continue
+		}
+		base := it.Quantity
+		it.BaseQuantity = &base
+		it.BaseUnit = product.Unit
+		it.Unit = product.Unit
+
+		factor, err := models.UnitFactor(product.Unit, unit, byProduct[product.ID])
+		if err != nil {
+			continue // единица не задана для товара — оставляем базовую
+		}
+		if it.Quantity, err = base.Div(factor); err != nil {
+			return err
+		}
+		it.Unit = unit
+	}
+	return nil
+}
+
+// checkBarcodes проверяет, что смена единиц товара с before на after не меняет коэффициент упаковок
+// его штрихкодов: количество в штрихкоде — коэффициент его упаковки на момент добавления.
+func (s *UnitService) checkBarcodes(ctx context.Context, product *models.Product, before, after map[models.UnitOfMeasure]*models.UnitConversion) error {
+	barcodes, err := s.barcodes.GetByProduct(ctx, product.ID)
+	if err != nil {
+		return err
+	}
+	for _, b := range barcodes {
+		unit := models.UnitOfMeasure(b.Packaging)
+		if !unit.Valid() {
+			continue
+		}
+		was, errBefore := models.UnitFactor(product.Unit, unit, before)
+		now, errAfter := models.UnitFactor(product.Unit, unit, after)
+		if (errBefore == nil) != (errAfter == nil) || was != now {
+			return ErrUnitHasBarcodes
+		}
+	}
+	return nil
+}
+
+func (s *UnitService) getProduct(ctx context.Context, id string) (*models.Product, error) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return nil, ErrInvalidProduct
+	}
+	product, err := s.productRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, ErrProductNotFound
+	}
+	return product, nil
+}
+
+// unitError приводит ошибки пересчёта единиц к ошибкам сервиса.
+func unitError(err error) error {
+	switch {
+	case errors.Is(err, models.ErrInvalidDecimal), errors.Is(err, models.ErrDecimalOverflow):
+		return ErrQuantityPrecision
+	}
+	return err
+}
+
+func normalizeUnit(unit models.UnitOfMeasure) models.UnitOfMeasure {
+	return models.UnitOfMeasure(strings.ToLower(strings.TrimSpace(string(unit))))
+}
+
+func unitMap(conversions []*models.UnitConversion) map[models.UnitOfMeasure]*models.UnitConversion {
+	byUnit := make(map[models.UnitOfMeasure]*models.UnitConversion, len(conversions))
+	for _, c := range conversions {
+		byUnit[c.Unit] = c
+	}
+	return byUnit
+}
+
+// unitAuditID — идентификатор единицы товара в журнале аудита.
+func unitAuditID(c *models.UnitConversion) string {
+	return c.ProductID + "/" + string(c.Unit)
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"warehouse-management-system/src/models"
+)
+
+// VariantService инкапсулирует бизнес-логику вариантов товара: размеров, цветов и других
+// исполнений одного шаблона.
+type VariantService struct {
+	productRepo ProductRepository
+	audit       AuditRecorder
+}
+
+// NewVariantService — конструктор сервиса вариантов товара.
+func NewVariantService(productRepo ProductRepository, audit AuditRecorder) *VariantService {
+	return &VariantService{productRepo: productRepo, audit: audit}
+}
+
+var (
+	ErrVariantExists = errors.New("variant with these attributes already exists")
+	ErrNestedVariant = errors.New("a variant cannot have variants of its own")
+	ErrVariantUnit   = errors.New("variants must use the unit of measure of their template")
+)
+
+// ListVariants возвращает незаархивированные варианты шаблона, упорядоченные по SKU.
+func (s *VariantService) ListVariants(ctx context.Context, templateID string) ([]*models.Product, error) {
+	template, err := s.getProduct(ctx, templateID)
+	if err != nil {
+		return nil, err
+	}
+	return s.variants(ctx, template.ID, false)
+}
+
+// CreateVariant создаёт вариант шаблона templateID с атрибутами attrs. Категория, поставщик,
+// описание и единица измерения берутся из шаблона. Пустой SKU формируется из SKU шаблона и значений
+// атрибутов (models.VariantSKU), пустое название — из названия шаблона и значений атрибутов.
+func (s *VariantService) CreateVariant(ctx context.Context, templateID, sku, name string, attrs []models.ProductAttribute) (*models.Product, error) {
+	template, err := s.getProduct(ctx, templateID)
+	if err != nil {
+		return nil, err
+	}
+	if template.ArchivedAt != nil {
+		return nil, ErrProductArchived
+	}
+	if template.ParentID != "" {
+		return nil, ErrNestedVariant
+	}
+
+	attrs, err = models.NormalizeAttributes(attrs)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkSiblings(ctx, template.ID, "", attrs); err != nil {
+		return nil, err
+	}
+
+	sku = strings.TrimSpace(sku)
+	if sku == "" {
+		sku = models.VariantSKU(template.SKU, attrs)
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = models.VariantName(template.Name, attrs)
+	}
+	existing, err := s.productRepo.GetBySKU(ctx, sku)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrSKUAlreadyUsed
+	}
+
+	variant := models.NewProduct(sku, name, template.Description, template.CategoryID, template.SupplierID, template.Unit)
+	variant.ParentID = template.ID
+	variant.Attributes = attrs
+	if err := s.productRepo.Create(ctx, variant); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, models.AuditActionCreate, models.AuditEntityProduct, variant.ID, nil, variant)
+	return variant, nil
+}
+
+// SetAttributes заменяет атрибуты товара. Атрибуты варианта обязательны и не должны совпадать
+// с атрибутами другого варианта того же шаблона. expectedVersion — версия из If-Match.
+func (s *VariantService) SetAttributes(ctx context.Context, id string, expectedVersion int64, attrs []models.ProductAttribute) (*models.Product, error) {
+	product, err := s.getProduct(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(product.Version, expectedVersion); err != nil {
+		return nil, err
+	}
+
+	attrs, err = models.NormalizeAttributes(attrs)
+	if err != nil {
+		return nil, err
+	}
+	if product.ParentID != "" {
+		if err := s.checkSiblings(ctx, product.ParentID, product.ID, attrs); err != nil {
+			return nil, err
+		}
+	}
+
+	before := *product
+	product.Attributes = attrs
+	if err := s.productRepo.SetAttributes(ctx, product); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, models.AuditActionUpdate, models.AuditEntityProduct, product.ID, before, product)
+	return product, nil
+}
+
+// checkSiblings проверяет, что у варианта есть атрибуты и ни один другой вариант шаблона
+// (кроме exceptID, в том числе архивный) не имеет тех же значений.
+func (s *VariantService) checkSiblings(ctx context.Context, templateID, exceptID string, attrs []models.ProductAttribute) error {
+	if len(attrs) == 0 {
+		return models.ErrInvalidAttribute
+	}
+	siblings, err := s.variants(ctx, templateID, true)
+	if err != nil {
+		return err
+	}
+	key := models.AttributesKey(attrs)
+	for _, v := range siblings {
+		if v.ID != exceptID && models.AttributesKey(v.Attributes) == key {
+			return ErrVariantExists
+		}
+	}
+	return nil
+}
+
+// variants возвращает все варианты шаблона, постранично выбирая их из репозитория.
+func (s *VariantService) variants(ctx context.Context, templateID string, includeArchived bool) ([]*models.Product, error) {
+	filter := models.ProductFilter{
+		ParentID:        templateID,
+		IncludeArchived: includeArchived,
+		Sort:            models.ProductSortSKU,
+		Limit:           maxProductLimit,
+	}
+	result := []*models.Product{}
+	for {
+		page, err := s.productRepo.Find(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, page...)
+		if len(page) < filter.Limit {
+			return result, nil
+		}
+		last := page[len(page)-1]
+		cursor := models.NewProductCursor(last, filter.Sort, filter.Desc)
+		filter.After = &cursor
+	}
+}
+
+func (s *VariantService) getProduct(ctx context.Context, id string) (*models.Product, error) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return nil, ErrInvalidProduct
+	}
+	product, err := s.productRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, ErrProductNotFound
+	}
+	return product, nil
+}
@@ -0,0 +1,18 @@
+package services
+
+import "warehouse-management-system/src/models"
+
+// AnyVersion — ожидаемая версия для "If-Match: *": изменение применяется к любой текущей версии записи.
+const AnyVersion int64 = 0
+
+// ErrVersionMismatch — запись изменена после того, как клиент её прочитал (HTTP 412).
+// Совпадает с models.ErrVersionConflict, который возвращают репозитории при гонке двух изменений.
+var ErrVersionMismatch = models.ErrVersionConflict
+
+// checkVersion сверяет текущую версию записи с версией, которую прислал клиент.
+func checkVersion(current, expected int64) error {
+	if expected != AnyVersion && current != expected {
+		return ErrVersionMismatch
+	}
+	return nil
+}
@@ -1,45 +1,69 @@
 package services
 
 import (
+	"context"
 	"errors"
+	"sort"
+	"strings"
 	"time"
 	"warehouse-management-system/src/models"
 )
 
 // WarehouseRepository описывает поведение складского хранилища для слоя сервисов.
 type WarehouseRepository interface {
-	GetInventory() ([]*models.StockItem, error)
-	GetStockByProduct(productID string) (float64, error)
-	AddMovement(m *models.StockMovement) error
+	GetInventory(ctx context.Context) ([]*models.StockItem, error)
+	GetStockByProduct(ctx context.Context, productID string) (models.Decimal, error)
+	AddMovement(ctx context.Context, m *models.StockMovement) error
+	CheckBalances(ctx context.Context) ([]*models.StockDrift, error)
+	RebuildBalances(ctx context.Context) error
 }
 
 // WarehouseService инкапсулирует бизнес-логику складских операций.
 type WarehouseService struct {
 	warehouseRepo WarehouseRepository
 	productRepo   ProductRepository
+	units         *UnitService
+	audit         AuditRecorder
 }
 
 // NewWarehouseService — конструктор сервиса складских операций.
-func NewWarehouseService(warehouseRepo WarehouseRepository, productRepo ProductRepository) *WarehouseService {
+func NewWarehouseService(warehouseRepo WarehouseRepository, productRepo ProductRepository, units *UnitService, audit AuditRecorder) *WarehouseService {
 	return &WarehouseService{
 		warehouseRepo: warehouseRepo,
 		productRepo:   productRepo,
+		units:         units,
+		audit:         audit,
 	}
 }
 
 var (
-	ErrInsufficientStock = errors.New("insufficient stock")
+	ErrInsufficientStock = models.ErrInsufficientStock
 	ErrInvalidOperation  = errors.New("invalid warehouse operation data")
+	ErrQuantityPrecision = errors.New("quantity has more decimal places than the product unit allows")
+	ErrInvalidPrice      = errors.New("invalid price or currency")
 )
 
-// Receipt регистрирует приёмку товара на склад.
-func (s *WarehouseService) Receipt(productID, supplierID string, quantity, price float64, expiry *time.Time) error {
-	if productID == "" || quantity <= 0 {
-		return ErrInvalidOperation
+// Receipt регистрирует приёмку товара на склад. Принимать заархивированный товар нельзя.
+// Количество и цена указаны в единице unit (пустая — базовая единица товара); в журнал движений
+// они попадают пересчитанными в базовую единицу.
+// Пустая валюта при ненулевой цене означает models.DefaultCurrency.
+func (s *WarehouseService) Receipt(ctx context.Context, productID, supplierID string, quantity models.Decimal, unit models.UnitOfMeasure, price models.Decimal, currency string, expiry *time.Time) error {
+	product, err := checkQuantity(ctx, s.productRepo, productID, quantity)
+	if err != nil {
+		return err
 	}
-
-	// Убедимся, что товар существует.
-	if _, err := s.productRepo.GetByID(productID); err != nil {
+	if product.ArchivedAt != nil {
+		return ErrProductArchived
+	}
+	currency, err = normalizePrice(price, currency)
+	if err != nil {
+		return err
+	}
+	quantity, factor, err := s.units.toBase(ctx, product, quantity, unit)
+	if err != nil {
+		return err
+	}
+	if price, err = price.Div(factor); err != nil {
 		return err
 	}
 
@@ -50,25 +74,26 @@ func (s *WarehouseService) Receipt(productID, supplierID string, quantity, price
 		SupplierID: supplierID,
 		Quantity:   quantity,
 		Price:      price,
+		Currency:   currency,
 		ExpiryDate: expiry,
+		CreatedBy:  actorFromContext(ctx),
 		CreatedAt:  time.Now().UTC(),
 	}
 
-	return s.warehouseRepo.AddMovement(m)
+	return s.addMovement(ctx, models.AuditActionReceipt, m)
 }
 
 // WriteOff регистрирует списание товара со склада (метод FIFO/LIFO пока не учитывается, только проверка количества).
-func (s *WarehouseService) WriteOff(productID string, quantity float64) error {
-	if productID == "" || quantity <= 0 {
-		return ErrInvalidOperation
-	}
-
-	current, err := s.warehouseRepo.GetStockByProduct(productID)
+// Количество указано в единице unit (пустая — базовая единица товара). Остаток проверяется хранилищем
+// в транзакции записи движения: при нехватке возвращается ErrInsufficientStock.
+// Списание разрешено и для заархивированного товара: так выбывают его остатки.
+func (s *WarehouseService) WriteOff(ctx context.Context, productID string, quantity models.Decimal, unit models.UnitOfMeasure) error {
+	product, err := checkQuantity(ctx, s.productRepo, productID, quantity)
 	if err != nil {
 		return err
 	}
-	if current < quantity {
-		return ErrInsufficientStock
+	if quantity, _, err = s.units.toBase(ctx, product, quantity, unit); err != nil {
+		return err
 	}
 
 	m := &models.StockMovement{
@@ -76,24 +101,28 @@ func (s *WarehouseService) WriteOff(productID string, quantity float64) error {
 		Type:      models.MovementWriteOff,
 		ProductID: productID,
 		Quantity:  quantity,
+		CreatedBy: actorFromContext(ctx),
 		CreatedAt: time.Now().UTC(),
 	}
 
-	return s.warehouseRepo.AddMovement(m)
+	return s.addMovement(ctx, models.AuditActionWriteOff, m)
 }
 
-// Reserve резервирует товар под заказ (без создания самого заказа).
-func (s *WarehouseService) Reserve(productID, orderID string, quantity float64) error {
-	if productID == "" || orderID == "" || quantity <= 0 {
+// Reserve резервирует товар под заказ (без создания самого заказа). Заархивированный товар не резервируется.
+// Количество указано в единице unit (пустая — базовая единица товара); нехватка остатка — ErrInsufficientStock.
+func (s *WarehouseService) Reserve(ctx context.Context, productID, orderID string, quantity models.Decimal, unit models.UnitOfMeasure) error {
+	if orderID == "" {
 		return ErrInvalidOperation
 	}
-
-	current, err := s.warehouseRepo.GetStockByProduct(productID)
+	product, err := checkQuantity(ctx, s.productRepo, productID, quantity)
 	if err != nil {
 		return err
 	}
-	if current < quantity {
-		return ErrInsufficientStock
+	if product.ArchivedAt != nil {
+		return ErrProductArchived
+	}
+	if quantity, _, err = s.units.toBase(ctx, product, quantity, unit); err != nil {
+		return err
 	}
 
 	m := &models.StockMovement{
@@ -102,13 +131,130 @@ func (s *WarehouseService) Reserve(productID, orderID string, quantity float64)
 		ProductID: productID,
 		OrderID:   orderID,
 		Quantity:  quantity,
+		CreatedBy: actorFromContext(ctx),
 		CreatedAt: time.Now().UTC(),
 	}
 
-	return s.warehouseRepo.AddMovement(m)
+	return s.addMovement(ctx, models.AuditActionReserve, m)
+}
+
+// addMovement сохраняет движение и фиксирует его в журнале аудита.
+func (s *WarehouseService) addMovement(ctx context.Context, action string, m *models.StockMovement) error {
+	if err := s.warehouseRepo.AddMovement(ctx, m); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, action, models.AuditEntityStockMovement, m.ID, nil, m)
+	return nil
+}
+
+// GetInventory возвращает текущие остатки по складу в базовых единицах товаров или, если задана unit,
+// в этой единице (для товаров, у которых она определена). При rollup остатки вариантов сворачиваются
+// в строки их шаблонов.
+func (s *WarehouseService) GetInventory(ctx context.Context, unit models.UnitOfMeasure, rollup bool) ([]*models.StockItem, error) {
+	items, err := s.warehouseRepo.GetInventory(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if rollup {
+		items = rollUpVariants(items)
+	}
+	if unit != "" {
+		// Пересчитываем и шаблоны, и варианты: упаковки у каждого товара свои.
+		all := append([]*models.StockItem(nil), items...)
+		for _, it := range items {
+			all = append(all, it.Variants...)
+		}
+		if err := s.units.inUnit(ctx, all, unit); err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+// rollUpVariants сворачивает остатки вариантов в строки их шаблонов: остаток шаблона — его собственный
+// остаток плюс остатки вариантов. Шаблон без собственного остатка тоже попадает в результат.
+func rollUpVariants(items []*models.StockItem) []*models.StockItem {
+	byID := make(map[string]*models.StockItem, len(items))
+	var result []*models.StockItem
+	for _, it := range items {
+		if it.ParentID == "" {
+			byID[it.ProductID] = it
+			result = append(result, it)
+		}
+	}
+	for _, it := range items {
+		if it.ParentID == "" {
+			continue
+		}
+		parent, ok := byID[it.ParentID]
+		if !ok {
+			parent = &models.StockItem{ProductID: it.ParentID}
+			byID[it.ParentID] = parent
+			result = append(result, parent)
+		}
+		parent.Quantity += it.Quantity
+		parent.Variants = append(parent.Variants, it)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ProductID < result[j].ProductID })
+	return result
+}
+
+// CheckBalances сверяет материализованные остатки с журналом движений и возвращает расхождения.
+// Пустой результат означает, что остатки согласованы.
+func (s *WarehouseService) CheckBalances(ctx context.Context) ([]*models.StockDrift, error) {
+	return s.warehouseRepo.CheckBalances(ctx)
+}
+
+// RebuildBalances пересчитывает остатки по журналу движений и возвращает исправленные расхождения.
+func (s *WarehouseService) RebuildBalances(ctx context.Context) ([]*models.StockDrift, error) {
+	drift, err := s.warehouseRepo.CheckBalances(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(drift) == 0 {
+		return drift, nil
+	}
+
+	if err := s.warehouseRepo.RebuildBalances(ctx); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, models.AuditActionRebuild, models.AuditEntityStockBalance, "all", drift, nil)
+	return drift, nil
+}
+
+// checkQuantity проверяет, что товар существует, а количество положительно, и возвращает товар.
+// Точность количества проверяется после пересчёта в базовую единицу товара (UnitService.toBase).
+func checkQuantity(ctx context.Context, products ProductRepository, productID string, quantity models.Decimal) (*models.Product, error) {
+	if productID == "" || quantity <= 0 {
+		return nil, ErrInvalidOperation
+	}
+
+	product, err := products.GetByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, ErrProductNotFound
+	}
+	return product, nil
 }
 
-// GetInventory возвращает текущие остатки по складу.
-func (s *WarehouseService) GetInventory() ([]*models.StockItem, error) {
-	return s.warehouseRepo.GetInventory()
+// normalizePrice проверяет цену и возвращает её валюту: для ненулевой цены без валюты —
+// models.DefaultCurrency, для нулевой цены валюта не хранится.
+func normalizePrice(price models.Decimal, currency string) (string, error) {
+	if price < 0 {
+		return "", ErrInvalidPrice
+	}
+	if price == 0 {
+		return "", nil
+	}
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" {
+		currency = models.DefaultCurrency
+	}
+	if !models.ValidCurrency(currency) {
+		return "", ErrInvalidPrice
+	}
+	return currency, nil
 }
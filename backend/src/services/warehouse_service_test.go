@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"warehouse-management-system/src/config"
+	"warehouse-management-system/src/models"
+	"warehouse-management-system/src/repositories"
+)
+
+// Размер нагрузки TestWarehouseConcurrentLoad:
+// go test ./src/services -run ConcurrentLoad -args -loadtest.workers=64 -loadtest.operations=300
+var (
+	loadTestWorkers    = flag.Int("loadtest.workers", 16, "number of concurrent workers in TestWarehouseConcurrentLoad")
+	loadTestOperations = flag.Int("loadtest.operations", 200, "operations per worker in TestWarehouseConcurrentLoad")
+)
+
+const loadTestProducts = 10
+
+// loadTestCounters — итоги нагрузочного теста. Ошибки записи журнала аудита считаются отдельно:
+// сервис аудита их только логирует, и без счётчика SQLITE_BUSY в журнале прошёл бы незамеченным.
+type loadTestCounters struct {
+	ok, rejected, busy, auditBusy atomic.Int64
+}
+
+// busyCountingAuditRepo считает ошибки SQLITE_BUSY при записи журнала аудита.
+type busyCountingAuditRepo struct {
+	*repositories.AuditRepositorySQL
+	counters *loadTestCounters
+}
+
+func (r busyCountingAuditRepo) Create(ctx context.Context, entry *models.AuditEntry) error {
+	err := r.AuditRepositorySQL.Create(ctx, entry)
+	if isBusyError(err) {
+		r.counters.auditBusy.Add(1)
+	}
+	return err
+}
+
+// isBusyError сообщает, что SQLite отказал из-за занятой блокировки.
+func isBusyError(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "SQLITE_BUSY") || strings.Contains(msg, "database is locked")
+}
+
+// TestWarehouseConcurrentLoad гоняет параллельные приёмки, заказы, списания и чтения через сервисы
+// на временной БД SQLite с настройками SQLITE_* из окружения. Тест проверяет, что не было SQLITE_BUSY,
+// ни один остаток не ушёл в минус и остатки сходятся с журналом движений. С -short пропускается.
+func TestWarehouseConcurrentLoad(t *testing.T) {
+	if testing.Short() {
+		t.Skip("load test is skipped in -short mode")
+	}
+
+	sqliteCfg := config.LoadConfig().SQLite
+	db, err := config.OpenSQLite(filepath.Join(t.TempDir(), "loadtest.db"), sqliteCfg)
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := config.Migrate(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	var counters loadTestCounters
+	ids := repositories.NewUUIDv7Generator()
+	productRepo := repositories.NewProductRepository(db, ids)
+	auditService := NewAuditService(busyCountingAuditRepo{repositories.NewAuditRepository(db), &counters})
+	productSearchRepo, err := repositories.NewProductSearchRepository(context.Background(), db)
+	if err != nil {
+		t.Fatalf("search repository: %v", err)
+	}
+	unitRepo := repositories.NewProductUnitRepository(db)
+	categoryRepo := repositories.NewCategoryRepository(db, ids)
+	supplierRepo := repositories.NewSupplierRepository(db, ids)
+	unitService := NewUnitService(unitRepo, productRepo, repositories.NewBarcodeRepository(db), auditService)
+	productService := NewProductService(productRepo, productSearchRepo, unitRepo, categoryRepo, supplierRepo, auditService)
+	warehouseService := NewWarehouseService(repositories.NewWarehouseRepository(db, ids), productRepo, unitService, auditService)
+	priceListService := NewPriceListService(repositories.NewPriceListRepository(db, ids), productRepo, auditService)
+	orderService := NewOrderService(repositories.NewOrderRepository(db, ids), productRepo, unitService, priceListService, auditService)
+
+	ctx := context.Background()
+	category, err := NewCategoryService(categoryRepo, auditService).CreateCategory(ctx, "Load test", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	supplier, err := NewSupplierService(supplierRepo, auditService).CreateSupplier(ctx, "Load test supplier", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	products := make([]*models.Product, loadTestProducts)
+	for i := range products {
+		sku := fmt.Sprintf("LOAD-%03d", i)
+		if products[i], err = productService.CreateProduct(ctx, sku, sku, "", category.ID, supplier.ID, string(models.UnitPiece)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	workers, operations := *loadTestWorkers, *loadTestOperations
+	var (
+		wg        sync.WaitGroup
+		firstErr  error
+		firstOnce sync.Once
+	)
+	start := time.Now()
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			rnd := rand.New(rand.NewSource(seed))
+			one := models.DecimalFromInt(1)
+			for i := 0; i < operations; i++ {
+				product := products[rnd.Intn(len(products))]
+
+				var err error
+				switch rnd.Intn(5) {
+				case 0:
+					err = warehouseService.Receipt(ctx, product.ID, supplier.ID, models.DecimalFromInt(5), "", one, "", nil)
+				case 1:
+					_, err = orderService.CreateOrder(ctx, "Load test", []models.OrderItem{{ProductID: product.ID, Quantity: one, Price: one}})
+				case 2:
+					err = warehouseService.WriteOff(ctx, product.ID, one, "")
+				case 3:
+					_, err = warehouseService.GetInventory(ctx, "", false)
+				default:
+					_, err = productService.ListProducts(ctx, models.ProductFilter{})
+				}
+
+				switch {
+				case err == nil:
+					counters.ok.Add(1)
+				case errors.Is(err, ErrInsufficientStock):
+					// Отказ по бизнес-правилу — ожидаемый исход при случайной нагрузке.
+					counters.rejected.Add(1)
+				case isBusyError(err):
+					counters.busy.Add(1)
+				default:
+					firstOnce.Do(func() { firstErr = err })
+				}
+			}
+		}(int64(w) + 1)
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	t.Logf("SQLite: journal_mode=%s synchronous=%s busy_timeout=%s read_conns=%d",
+		sqliteCfg.JournalMode, sqliteCfg.Synchronous, sqliteCfg.BusyTimeout, sqliteCfg.ReadConns)
+	t.Logf("%d workers x %d operations in %s: ok %d, insufficient stock %d",
+		workers, operations, elapsed.Round(time.Millisecond), counters.ok.Load(), counters.rejected.Load())
+
+	if firstErr != nil {
+		t.Fatalf("unexpected error: %v", firstErr)
+	}
+	if busy, auditBusy := counters.busy.Load(), counters.auditBusy.Load(); busy > 0 || auditBusy > 0 {
+		t.Fatalf("database was busy: %d operation(s), %d audit write(s)", busy, auditBusy)
+	}
+
+	var negative int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM stock_balances WHERE quantity < 0`).Scan(&negative); err != nil {
+		t.Fatal(err)
+	}
+	if negative > 0 {
+		t.Fatalf("%d product(s) have a negative stock balance", negative)
+	}
+
+	drift, err := warehouseService.CheckBalances(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(drift) > 0 {
+		t.Fatalf("stock balances do not match the movement ledger: %+v", drift)
+	}
+}
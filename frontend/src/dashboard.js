@@ -18,9 +18,14 @@ import {fetchWithAuth} from "./utils.js";
     }
 
     logoutBtn.addEventListener('click', function () {
-        localStorage.removeItem('wms_token');
-        localStorage.removeItem('wms_user');
-        window.location.href = '/';
+        // Завершаем сессию на сервере; локальные данные очищаем в любом случае.
+        fetchWithAuth(API_BASE_URL + '/auth/logout', {method: 'POST'})
+            .catch(() => {})
+            .finally(() => {
+                localStorage.removeItem('wms_token');
+                localStorage.removeItem('wms_user');
+                window.location.href = '/';
+            });
     });
 
     // Загружаем данные пользователя
@@ -43,7 +48,7 @@ import {fetchWithAuth} from "./utils.js";
 
     // Простая загрузка метрик: считаем количество сущностей
     Promise.all([
-        fetchWithAuth(API_BASE_URL + '/products'),
+        fetchWithAuth(API_BASE_URL + '/products?limit=1'),
         fetchWithAuth(API_BASE_URL + '/categories'),
         fetchWithAuth(API_BASE_URL + '/suppliers'),
         fetchWithAuth(API_BASE_URL + '/orders')
@@ -52,7 +57,8 @@ import {fetchWithAuth} from "./utils.js";
         const cd = (c && c.ok && Array.isArray(c.data)) ? c.data : [];
         const sd = (s && s.ok && Array.isArray(s.data)) ? s.data : [];
         const od = (o && o.ok && Array.isArray(o.data)) ? o.data : [];
-        document.getElementById('metricProducts').textContent = pd.length;
+        // Список товаров постраничный: общее число — в заголовке X-Total-Count.
+        document.getElementById('metricProducts').textContent = (p && p.headers && p.headers.get('X-Total-Count')) || pd.length;
         document.getElementById('metricCategories').textContent = cd.length;
         document.getElementById('metricSuppliers').textContent = sd.length;
         document.getElementById('metricOrders').textContent = od.length;
@@ -18,6 +18,25 @@
         }
     }
 
+    // Вход через внешнего провайдера (OIDC): сервер возвращает токен во fragment URL.
+    const hashParams = new URLSearchParams(window.location.hash.slice(1));
+    const redirectToken = hashParams.get('token');
+    if (redirectToken) {
+        history.replaceState(null, '', window.location.pathname);
+        localStorage.setItem('wms_token', redirectToken);
+        fetch(API_BASE_URL + '/auth/me', {
+            headers: { 'Authorization': 'Bearer ' + redirectToken }
+        })
+            .then(resp => resp.ok ? resp.json() : null)
+            .then(user => {
+                if (user) {
+                    localStorage.setItem('wms_user', JSON.stringify(user));
+                }
+                window.location.href = '/dashboard';
+            })
+            .catch(() => setMessage(messageEl, 'Не удалось подключиться к серверу.', 'error'));
+    }
+
     form.addEventListener('submit', async function (e) {
         e.preventDefault();
         setMessage(messageEl, '', '');
@@ -100,11 +100,19 @@ import {fetchWithAuth} from "./utils.js";
             setMsg(statusMsg, 'Заполните ID и статус');
             return;
         }
-        fetch(API_BASE_URL + '/orders/' + id + '/status', {
-            method: 'PUT',
-            headers: authHeaders(),
-            body: JSON.stringify({ status })
-        }).then(r => r.json().then(data => ({ ok: r.ok, data })))
+        // Статус меняется с If-Match по текущей версии заказа, поэтому сначала читаем заказ.
+        fetch(API_BASE_URL + '/orders/' + id, { headers: authHeaders() })
+            .then(r => r.json().then(data => ({ ok: r.ok, data })))
+            .then(({ ok, data }) => {
+                if (!ok) return { ok, data };
+                const headers = authHeaders();
+                headers['If-Match'] = '"' + data.version + '"';
+                return fetch(API_BASE_URL + '/orders/' + id + '/status', {
+                    method: 'PUT',
+                    headers,
+                    body: JSON.stringify({ status })
+                }).then(r => r.json().then(data => ({ ok: r.ok, data })));
+            })
             .then(({ ok, data }) => {
                 if (!ok) {
                     setMsg(statusMsg, data && data.error ? data.error : 'Ошибка обновления статуса');
@@ -10,6 +10,8 @@ import {fetchWithAuth} from "./utils.js";
 
     const productsBody = document.getElementById('productsBody');
     const productsMessage = document.getElementById('productsMessage');
+    const searchInput = document.getElementById('productSearch');
+    const moreBtn = document.getElementById('moreBtn');
     const form = document.getElementById('productForm');
     const formMessage = document.getElementById('formMessage');
 
@@ -88,16 +90,30 @@ import {fetchWithAuth} from "./utils.js";
         return { 'Authorization': 'Bearer ' + token, 'Content-Type': 'application/json' };
     }
 
-    function loadProducts() {
+    // Версия редактируемого товара: отправляется в If-Match, чтобы не затереть чужие изменения.
+    let editingVersion = null;
+
+    // Курсор следующей страницы списка (заголовок X-Next-Cursor); пусто — страниц больше нет.
+    let nextCursor = '';
+
+    // loadProducts загружает первую страницу списка, а с append — следующую страницу по курсору.
+    function loadProducts(append) {
         setMessage(productsMessage, '');
-        fetch(API_BASE_URL + '/products', { headers: authHeaders() })
-            .then(r => r.json().then(data => ({ ok: r.ok, data })))
-            .then(({ ok, data }) => {
+        const params = new URLSearchParams();
+        const q = searchInput.value.trim();
+        if (q) params.set('q', q);
+        if (append && nextCursor) params.set('cursor', nextCursor);
+
+        fetch(API_BASE_URL + '/products?' + params.toString(), { headers: authHeaders() })
+            .then(r => r.json().then(data => ({ ok: r.ok, data, cursor: r.headers.get('X-Next-Cursor') || '' })))
+            .then(({ ok, data, cursor }) => {
                 if (!ok) {
                     setMessage(productsMessage, data && data.error ? data.error : 'Ошибка загрузки товаров');
                     return;
                 }
-                productsBody.innerHTML = '';
+                nextCursor = cursor;
+                moreBtn.style.display = nextCursor ? '' : 'none';
+                if (!append) productsBody.innerHTML = '';
                 (data || []).forEach(p => {
                     const tr = document.createElement('tr');
                     tr.innerHTML = `
@@ -107,7 +123,7 @@ import {fetchWithAuth} from "./utils.js";
                         <td>${p.unit || ''}</td>
                         <td>
                             <button data-id="${p.id}" class="editBtn" style="font-size:11px;margin-right:4px;">Ред.</button>
-                            <button data-id="${p.id}" class="delBtn" style="font-size:11px;background:#b91c1c;">X</button>
+                            <button data-id="${p.id}" data-version="${p.version}" class="delBtn" style="font-size:11px;background:#b91c1c;">X</button>
                         </td>
                     `;
                     productsBody.appendChild(tr);
@@ -128,6 +144,7 @@ import {fetchWithAuth} from "./utils.js";
                         setMessage(formMessage, data && data.error ? data.error : 'Ошибка загрузки товара');
                         return;
                     }
+                    editingVersion = data.version;
                     idInput.value = data.id || '';
                     skuInput.value = data.sku || '';
                     nameInput.value = data.name || '';
@@ -141,7 +158,7 @@ import {fetchWithAuth} from "./utils.js";
             if (!confirm('Удалить товар?')) return;
             fetch(API_BASE_URL + '/products/' + id, {
                 method: 'DELETE',
-                headers: { 'Authorization': 'Bearer ' + token }
+                headers: { 'Authorization': 'Bearer ' + token, 'If-Match': '"' + e.target.getAttribute('data-version') + '"' }
             })
                 .then(r => {
                     if (!r.ok) return r.json().then(d => { throw new Error(d && d.error || 'Ошибка удаления'); });
@@ -168,9 +185,12 @@ import {fetchWithAuth} from "./utils.js";
         const method = id ? 'PUT' : 'POST';
         const url = API_BASE_URL + '/products' + (id ? '/' + id : '');
 
+        const headers = authHeaders();
+        if (id) headers['If-Match'] = '"' + editingVersion + '"';
+
         fetch(url, {
             method,
-            headers: authHeaders(),
+            headers,
             body: JSON.stringify(dto)
         })
             .then(r => r.json().then(data => ({ ok: r.ok, data })))
@@ -181,12 +201,20 @@ import {fetchWithAuth} from "./utils.js";
                 }
                 form.reset();
                 idInput.value = '';
+                editingVersion = null;
                 unitInput.value = 'pcs';
                 loadProducts();
             })
             .catch(() => setMessage(formMessage, 'Не удалось сохранить товар'));
     });
 
+    let searchTimer = null;
+    searchInput.addEventListener('input', function () {
+        clearTimeout(searchTimer);
+        searchTimer = setTimeout(() => loadProducts(), 300);
+    });
+    moreBtn.addEventListener('click', () => loadProducts(true));
+
     loadProducts();
     loadCategories();
     loadSuppliers();
@@ -18,6 +18,6 @@ export function fetchWithAuth(url, options = {}) {
             }
 
             const data = await response.json().catch(() => null);
-            return { ok: response.ok, data };
+            return { ok: response.ok, data, headers: response.headers };
         });
 }